@@ -4,6 +4,13 @@ export type Board = {
   id: string
   name: string
   description: string
+  subscribed?: boolean
 }
 
 export const fetchBoards = (): Promise<Board[]> => apiRequest<Board[]>('/boards')
+
+export const subscribeBoard = (boardId: string): Promise<void> =>
+  apiRequest(`/boards/${boardId}/subscribe`, { method: 'POST' })
+
+export const unsubscribeBoard = (boardId: string): Promise<void> =>
+  apiRequest(`/boards/${boardId}/subscribe`, { method: 'DELETE' })
@@ -94,6 +94,10 @@ export const apiRequest = async <T>(
     throw apiError
   }
 
+  if (response.status === 204) {
+    return undefined as T
+  }
+
   return response.json() as Promise<T>
 }
 
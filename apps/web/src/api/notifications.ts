@@ -7,9 +7,11 @@ export type NotificationItem = {
   actor_avatar: string
   actor_level?: number
   actor_level_title?: string
-  type: 'comment' | 'reply' | 'follow' | 'like'
+  type: 'comment' | 'reply' | 'follow' | 'like' | 'mention' | 'content_removed'
   target_type: string
   target_id: string
+  // Why the content was removed; content_removed only.
+  deleted_reason?: string
   read: boolean
   created_at: string
 }
@@ -25,26 +27,56 @@ export type UnreadCountResponse = {
   count: number
 }
 
+export type BadgesResponse = {
+  notifications: number
+  messages: number
+}
+
 export const fetchNotifications = async (
   page = 1,
   pageSize = 20,
+  types: NotificationItem['type'][] = [],
 ): Promise<NotificationsResponse> => {
   const params = new URLSearchParams({
     page: String(page),
     page_size: String(pageSize),
   })
+  if (types.length > 0) {
+    params.set('type', types.join(','))
+  }
   return apiRequest<NotificationsResponse>(`/notifications?${params.toString()}`)
 }
 
 export const fetchUnreadCount = async (): Promise<UnreadCountResponse> =>
   apiRequest<UnreadCountResponse>('/notifications/unread-count')
 
+export const fetchBadges = async (): Promise<BadgesResponse> =>
+  apiRequest<BadgesResponse>('/me/badges')
+
 export const markNotificationRead = async (id: string): Promise<{ success: boolean }> =>
   apiRequest<{ success: boolean }>(`/notifications/${id}`, {
     method: 'PATCH',
   })
 
+export const markNotificationsRead = async (
+  ids: string[],
+): Promise<{ success: boolean; updated: number }> =>
+  apiRequest<{ success: boolean; updated: number }>('/notifications/read', {
+    method: 'POST',
+    body: JSON.stringify({ ids }),
+  })
+
 export const markAllNotificationsRead = async (): Promise<{ success: boolean }> =>
   apiRequest<{ success: boolean }>('/notifications/read-all', {
     method: 'POST',
   })
+
+export const markNotificationUnread = async (id: string): Promise<{ success: boolean }> =>
+  apiRequest<{ success: boolean }>(`/notifications/${id}/unread`, {
+    method: 'POST',
+  })
+
+export const deleteNotification = async (id: string): Promise<{ success: boolean }> =>
+  apiRequest<{ success: boolean }>(`/notifications/${id}`, {
+    method: 'DELETE',
+  })
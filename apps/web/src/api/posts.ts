@@ -53,8 +53,12 @@ export type PostDetail = {
   attachments?: AttachmentItem[]
   created_at: string
   deleted_at: string | null
+  deleted_reason?: string
   score?: number
+  upvotes?: number
+  downvotes?: number
   my_vote?: number
+  my_vote_at?: string | null
   comment_count?: number
   view_count?: number
 }
@@ -72,6 +76,7 @@ export type CreatePostResponse = {
   id: string
   board_id: string
   author_id: string
+  author?: PostAuthor
   title: string
   content: string
   content_json?: unknown
@@ -80,10 +85,6 @@ export type CreatePostResponse = {
   created_at: string
 }
 
-export type DeletePostResponse = {
-  status: string
-}
-
 export type CommentItem = {
   id: string
   author: PostAuthor
@@ -102,6 +103,7 @@ export type CreateCommentResponse = {
   id: string
   post_id: string
   author_id: string
+  author?: PostAuthor
   content: string
   content_json?: unknown
   tags?: string[]
@@ -113,10 +115,6 @@ export type CreateCommentResponse = {
   my_vote?: number
 }
 
-export type DeleteCommentResponse = {
-  status: string
-}
-
 export type VoteResponse = {
   post_id: string
   score: number
@@ -132,17 +130,18 @@ export type CommentVoteResponse = {
 export const fetchPosts = (
   page: number,
   pageSize: number,
-  boardId?: string,
+  boardId?: string | string[],
   authorId?: string,
-  sort?: 'latest' | 'hot',
+  sort?: 'latest' | 'hot' | 'controversial',
 ): Promise<PostListResponse> => {
   const params = new URLSearchParams({
     page: String(page),
     page_size: String(pageSize),
   })
 
-  if (boardId) {
-    params.set('board_id', boardId)
+  const boardIds = Array.isArray(boardId) ? boardId.join(',') : boardId
+  if (boardIds) {
+    params.set('board_id', boardIds)
   }
 
   if (authorId) {
@@ -176,14 +175,26 @@ export const createPost = (
     }),
   })
 
-export const deletePost = (postId: string): Promise<DeletePostResponse> =>
-  apiRequest<DeletePostResponse>(`/posts/${postId}`, {
+export const deletePost = (postId: string): Promise<void> =>
+  apiRequest<void>(`/posts/${postId}`, {
     method: 'DELETE',
   })
 
 export const fetchComments = (postId: string): Promise<CommentItem[]> =>
   apiRequest<CommentItem[]>(`/posts/${postId}/comments`)
 
+export type CommentLink = {
+  comment: CommentItem
+  post_id: string
+  post_title: string
+  offset: number
+  page: number
+  page_size: number
+}
+
+export const fetchCommentLink = (commentId: string): Promise<CommentLink> =>
+  apiRequest<CommentLink>(`/comments/${commentId}`)
+
 export type CreateCommentInput = {
   content: string
   content_json?: unknown
@@ -212,8 +223,8 @@ export const createComment = (
 export const deleteComment = (
   postId: string,
   commentId: string,
-): Promise<DeleteCommentResponse> =>
-  apiRequest<DeleteCommentResponse>(`/posts/${postId}/comments/${commentId}`, {
+): Promise<void> =>
+  apiRequest<void>(`/posts/${postId}/comments/${commentId}`, {
     method: 'DELETE',
   })
 
@@ -1,6 +1,6 @@
 import { apiRequest } from './client'
 
-export type ReportTargetType = 'post' | 'comment' | 'user'
+export type ReportTargetType = 'post' | 'comment' | 'user' | 'file'
 
 export type ReportRequest = {
   target_type: ReportTargetType
@@ -26,6 +26,7 @@ export type ReportItem = {
   id: string
   target_type: string
   target_id: string
+  target_snapshot: Record<string, string> | null
   reporter_id: string
   reason: string
   detail: string
@@ -7,6 +7,8 @@ export type CurrentUser = {
   cover: string
   bio: string
   created_at: string
+  email?: string
+  email_verified?: boolean
   exp?: number
   level?: number
   level_title?: string
@@ -32,9 +34,10 @@ export const updateCurrentUser = (data: UpdateUserInput): Promise<CurrentUser> =
     body: JSON.stringify(data),
   })
 
-export const deactivateCurrentUser = (): Promise<{ message: string }> =>
+export const deactivateCurrentUser = (password: string): Promise<{ message: string }> =>
   apiRequest<{ message: string }>('/users/me', {
     method: 'DELETE',
+    body: JSON.stringify({ password }),
   })
 
 export const followUser = (userId: string): Promise<void> =>
@@ -11,7 +11,7 @@ import {
   ReadOutlined,
   BellOutlined
 } from '@ant-design/icons'
-import { fetchUnreadCount } from '../api/notifications'
+import { fetchBadges } from '../api/notifications'
 import { useAuth } from '../context/useAuth'
 import type { MenuProps } from 'antd'
 import LevelBadge from './LevelBadge'
@@ -26,6 +26,7 @@ const SiteHeader = () => {
   const { token } = theme.useToken()
   
   const [unreadCount, setUnreadCount] = useState(0)
+  const [unreadMessages, setUnreadMessages] = useState(0)
 
   const current = useMemo(() => {
     if (location.pathname === '/') return '/'
@@ -48,7 +49,7 @@ const SiteHeader = () => {
     return `search:${searchDefaultValue}`
   }, [location.pathname, searchDefaultValue])
 
-  // Fetch unread notification count
+  // Fetch unread notification and chat message counts in one call
   useEffect(() => {
     if (!authToken) {
       return
@@ -58,9 +59,10 @@ const SiteHeader = () => {
 
     const loadUnreadCount = async () => {
       try {
-        const data = await fetchUnreadCount()
+        const data = await fetchBadges()
         if (active) {
-          setUnreadCount(data.count || 0)
+          setUnreadCount(data.notifications || 0)
+          setUnreadMessages(data.messages || 0)
         }
       } catch (err) {
         console.error('Failed to fetch unread count:', err)
@@ -77,6 +79,7 @@ const SiteHeader = () => {
   }, [authToken])
 
   const displayUnreadCount = authToken ? unreadCount : 0
+  const displayUnreadMessages = authToken ? unreadMessages : 0
 
   const handleLogout = () => {
     logout()
@@ -101,7 +104,11 @@ const SiteHeader = () => {
       icon: <CommentOutlined />,
     },
     {
-      label: '聊天室',
+      label: (
+        <Badge count={displayUnreadMessages} size="small" offset={[8, -2]}>
+          聊天室
+        </Badge>
+      ),
       key: '/chat',
       icon: <TeamOutlined />,
     },
@@ -34,6 +34,13 @@ type ChatMessage = {
   senderId?: string
   senderName?: string
   isHistory: boolean
+  editedAt?: string
+  deleted?: boolean
+  // Set on our own messages while they wait for the server's echo.
+  clientMsgId?: string
+  pending?: boolean
+  // Set when the echo never came: the socket dropped or the send timed out.
+  failed?: boolean
 }
 
 type Envelope = {
@@ -57,6 +64,17 @@ const buildWsUrl = (token: string) => {
 
 const makeRequestId = () => `${Date.now()}-${Math.random().toString(16).slice(2)}`
 
+// How long a sent message may wait for its echo before it is marked failed.
+const PENDING_TIMEOUT_MS = 10_000
+
+// Marks pending bubbles failed; with clientMsgId only that one, otherwise all.
+const failPending = (messages: ChatMessage[], clientMsgId?: string) =>
+  messages.map((msg) =>
+    msg.pending && (!clientMsgId || msg.clientMsgId === clientMsgId)
+      ? { ...msg, pending: false, failed: true }
+      : msg,
+  )
+
 const Chat = () => {
   const { user } = useAuth()
   const { token } = theme.useToken()
@@ -85,6 +103,8 @@ const Chat = () => {
     }
 
     if (socketRef.current) socketRef.current.close()
+    // Sends on the old socket will never echo on the new one.
+    setMessages((prev) => failPending(prev))
 
     setStatus('connecting')
     setError(null)
@@ -100,6 +120,13 @@ const Chat = () => {
 
         if (payload.type === 'error') {
           setError(payload.error?.message ?? '聊天室连接出现问题')
+          // A rejected send never echoes, so drop its pending bubble. Frame-level
+          // errors (3007/3008) carry an empty requestId, so fail every pending one.
+          if (payload.requestId) {
+            setMessages((prev) => prev.filter((msg) => msg.clientMsgId !== payload.requestId))
+          } else {
+            setMessages((prev) => failPending(prev))
+          }
           return
         }
 
@@ -109,6 +136,7 @@ const Chat = () => {
             id: entry.id,
             content: entry.content,
             createdAt: entry.created_at,
+            editedAt: entry.edited_at || undefined,
             isHistory: true,
           }))
           setMessages(history.reverse()) // History usually comes newest first
@@ -117,24 +145,51 @@ const Chat = () => {
 
         if (payload.type === 'chat.message') {
           const data = payload.data ?? {}
-          setMessages((prev) => [
-            ...prev,
-            {
-              id: data.id,
-              content: data.content,
-              createdAt: data.created_at,
-              senderId: data.sender?.id,
-              senderName: data.sender?.nickname,
-              isHistory: false,
-            },
-          ].slice(-200))
+          const message: ChatMessage = {
+            id: data.id,
+            content: data.content,
+            createdAt: data.created_at,
+            senderId: data.sender?.id,
+            senderName: data.sender?.nickname,
+            isHistory: false,
+          }
+          setMessages((prev) => {
+            // Our own echo replaces the pending bubble instead of adding a second one.
+            if (data.client_msg_id && prev.some((msg) => msg.clientMsgId === data.client_msg_id)) {
+              return prev.map((msg) => (msg.clientMsgId === data.client_msg_id ? message : msg))
+            }
+            return [...prev, message].slice(-200)
+          })
+          return
+        }
+
+        if (payload.type === 'chat.edit') {
+          const data = payload.data ?? {}
+          setMessages((prev) =>
+            prev.map((msg) =>
+              msg.id === data.id ? { ...msg, content: data.content, editedAt: data.edited_at } : msg,
+            ),
+          )
+          return
+        }
+
+        if (payload.type === 'chat.delete') {
+          const data = payload.data ?? {}
+          setMessages((prev) =>
+            prev.map((msg) => (msg.id === data.id ? { ...msg, content: '', deleted: true } : msg)),
+          )
         }
       } catch {
         setError('聊天消息解析失败')
       }
     })
 
-    socket.addEventListener('close', () => setStatus('error'))
+    socket.addEventListener('close', () => {
+      // A superseded socket closing must not touch sends on its replacement.
+      if (socketRef.current !== socket) return
+      setStatus('error')
+      setMessages((prev) => failPending(prev))
+    })
     socket.addEventListener('error', () => {
       setStatus('error')
       setError('聊天室连接失败，请稍后重试。')
@@ -176,15 +231,33 @@ const Chat = () => {
     const content = draft.trim()
     if (!content) return
 
+    const clientMsgId = makeRequestId()
     const sent = sendEnvelope({
       v: 1,
       type: 'chat.send',
-      requestId: makeRequestId(),
-      data: { roomId: activeRoom, content },
+      requestId: clientMsgId,
+      data: { roomId: activeRoom, content, client_msg_id: clientMsgId },
     })
 
-    if (sent) setDraft('')
-    else setError('尚未连接到聊天室，请稍后重试。')
+    if (sent) {
+      setDraft('')
+      setMessages((prev) => [
+        ...prev,
+        {
+          id: clientMsgId,
+          clientMsgId,
+          pending: true,
+          content,
+          createdAt: new Date().toISOString(),
+          senderId: user?.id,
+          senderName: user?.nickname,
+          isHistory: false,
+        },
+      ].slice(-200))
+      window.setTimeout(() => {
+        setMessages((prev) => failPending(prev, clientMsgId))
+      }, PENDING_TIMEOUT_MS)
+    } else setError('尚未连接到聊天室，请稍后重试。')
   }
 
   return (
@@ -276,6 +349,9 @@ const Chat = () => {
                         color: '#999' 
                       }}>
                         {msg.senderName || '匿名'} · {formatRelativeTimeUTC8(msg.createdAt)}
+                        {msg.editedAt && !msg.deleted ? ' · 已编辑' : ''}
+                        {msg.pending ? ' · 发送中' : ''}
+                        {msg.failed ? ' · 发送失败' : ''}
                       </div>
                       <div style={{
                         padding: '10px 14px',
@@ -287,7 +363,7 @@ const Chat = () => {
                         boxShadow: '0 1px 2px rgba(0,0,0,0.1)',
                         wordBreak: 'break-word'
                       }}>
-                        {msg.content}
+                        {msg.deleted ? <i style={{ opacity: 0.7 }}>该消息已删除</i> : msg.content}
                       </div>
                     </div>
                   </div>
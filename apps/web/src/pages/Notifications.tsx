@@ -6,7 +6,8 @@ import {
   CommentOutlined, 
   HeartOutlined, 
   UserAddOutlined,
-  CheckOutlined
+  CheckOutlined,
+  DeleteOutlined
 } from '@ant-design/icons'
 import { getErrorMessage } from '../api/client'
 import { fetchNotifications, markAllNotificationsRead, markNotificationRead, type NotificationItem } from '../api/notifications'
@@ -22,6 +23,8 @@ const typeConfig = {
   reply: { icon: <CommentOutlined />, label: '回复了你的评论', color: 'cyan' },
   follow: { icon: <UserAddOutlined />, label: '关注了你', color: 'green' },
   like: { icon: <HeartOutlined />, label: '赞了你的内容', color: 'red' },
+  mention: { icon: <UserOutlined />, label: '提到了你', color: 'purple' },
+  content_removed: { icon: <DeleteOutlined />, label: '移除了你的内容', color: 'orange' },
 }
 
 const pageSize = 20
@@ -139,6 +142,7 @@ const Notifications = () => {
           description={
             <Space>
               <Text type="secondary">{formatDate(notif.created_at)}</Text>
+              {notif.deleted_reason && <Text type="secondary">原因：{notif.deleted_reason}</Text>}
               {notif.target_id && (
                 <Link to={getTargetLink(notif)}>
                   <Button type="link" size="small" style={{ padding: 0 }}>
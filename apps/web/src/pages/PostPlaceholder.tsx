@@ -676,15 +676,33 @@ const PostPlaceholder = () => {
               <div style={{ marginBottom: 24 }}>
                 <Image.PreviewGroup>
                   <Space wrap size={8}>
-                    {postExtraMedia.map((media, i) => (
-                      <Image 
-                        key={i}
-                        src={media.url}
-                        width={120}
-                        height={120}
-                        style={{ objectFit: 'cover', borderRadius: 8 }}
-                      />
-                    ))}
+                    {postExtraMedia.map((media, i) =>
+                      media.deleted ? (
+                        <div
+                          key={i}
+                          style={{
+                            width: 120,
+                            height: 120,
+                            display: 'flex',
+                            alignItems: 'center',
+                            justifyContent: 'center',
+                            borderRadius: 8,
+                            background: token.colorFillTertiary,
+                            color: token.colorTextTertiary,
+                          }}
+                        >
+                          附件已删除
+                        </div>
+                      ) : (
+                        <Image 
+                          key={i}
+                          src={media.url}
+                          width={120}
+                          height={120}
+                          style={{ objectFit: 'cover', borderRadius: 8 }}
+                        />
+                      ),
+                    )}
                   </Space>
                 </Image.PreviewGroup>
               </div>
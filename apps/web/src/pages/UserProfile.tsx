@@ -18,6 +18,7 @@ import {
   theme,
   message,
   Modal,
+  Input,
   List
 } from 'antd'
 import { UserOutlined, ArrowLeftOutlined } from '@ant-design/icons'
@@ -348,15 +349,30 @@ const UserProfile = () => {
   const expProgress = getExpProgress(expValue)
 
   const handleDeactivate = () => {
+    let password = ''
     Modal.confirm({
       title: '确认注销账号？',
-      content: '注销后将无法登录，发布的内容会保留并显示为已注销用户。',
+      content: (
+        <Space direction="vertical" style={{ width: '100%' }}>
+          <span>注销后将无法登录，发布的内容会保留并显示为已注销用户。</span>
+          <Input.Password
+            placeholder="请输入当前密码"
+            onChange={(event) => {
+              password = event.target.value
+            }}
+          />
+        </Space>
+      ),
       okText: '确认注销',
       okType: 'danger',
       cancelText: '取消',
       onOk: async () => {
+        if (!password) {
+          message.error('请输入当前密码')
+          return Promise.reject()
+        }
         try {
-          await deactivateCurrentUser()
+          await deactivateCurrentUser(password)
           clearAuth()
           setUser(null)
           message.success('账号已注销')
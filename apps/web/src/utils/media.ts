@@ -6,6 +6,8 @@ export type MediaItem = {
   width?: number
   height?: number
   alt?: string
+  // Set on the placeholder for an attachment whose file was deleted; url is empty.
+  deleted?: boolean
 }
 
 type AttachmentLike = {
@@ -50,11 +52,13 @@ const normalizeFromAttachments = (attachments: AttachmentLike[] | undefined) =>
   if (!attachments || attachments.length === 0) {
     return []
   }
-  return attachments.map((item) => ({
-    url: item.url,
-    type: inferMediaKind(item),
-    alt: item.filename ?? 'media',
-  }))
+  // Deleted files come back as tombstones with an empty url; keep them so the
+  // UI can show a placeholder instead of silently dropping the attachment.
+  return attachments.map((item): MediaItem =>
+    item.url
+      ? { url: item.url, type: inferMediaKind(item), alt: item.filename ?? 'media' }
+      : { url: '', type: 'image', alt: item.filename, deleted: true },
+  )
 }
 
 const parseContentJSON = (value: unknown) => {
@@ -199,6 +203,10 @@ export const normalizeCommentMedia = (comment: {
   const seen = new Set<string>()
   const out: MediaItem[] = []
   for (const item of candidates) {
+    if (item.deleted) {
+      out.push(item)
+      continue
+    }
     // Skip invalid URLs (blob: URLs are temporary and won't work after page reload)
     if (!item.url || seen.has(item.url) || item.url.startsWith('blob:')) {
       continue
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+type changeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type emailResponse struct {
+	Email        string  `json:"email"`
+	PendingEmail *string `json:"pending_email"`
+}
+
+// maxChangeEmailBody bounds the change-email body: an address and a password.
+const maxChangeEmailBody = 1 << 10
+
+// GetMyEmail handles GET /api/v1/users/me/email. Addresses are masked; the
+// full value never leaves the server after registration.
+func (s *Service) GetMyEmail(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+	email, pending, err := s.Store.AccountEmail(user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+
+	resp := emailResponse{Email: maskEmail(email)}
+	if pending != "" {
+		masked := maskEmail(pending)
+		resp.PendingEmail = &masked
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ChangeEmail handles POST /api/v1/users/me/email. It mails a confirmation
+// link to the new address; the login email only changes once that link is
+// opened (see VerifyEmailHandler).
+func (s *Service) ChangeEmail(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+	var req changeEmailRequest
+	if !transport.BindJSON(c, &req, maxChangeEmailBody) {
+		return
+	}
+	if IsNilEmailSender(s.Mailer) {
+		writeError(c, http.StatusInternalServerError, 5000, "email service unavailable")
+		return
+	}
+
+	// A wrong password counts against the login lockout, same as login.
+	account := s.throttleAccount(user.ID)
+	if s.passwordLocked(c, account) {
+		return
+	}
+
+	// The link goes to an address the caller chose, so it shares the
+	// verification email budget, keyed by that address and the client IP.
+	if s.Signup != nil {
+		if wait := s.Signup.AllowEmail(req.Email, transport.ClientIP(c.Request)); wait > 0 {
+			transport.TooManyRequests(c, wait, "too many verification emails, try again later")
+			return
+		}
+	}
+
+	token, err := s.Store.RequestEmailChange(user.ID, req.Email, req.Password)
+	s.recordPassword(c, account, err)
+	if err != nil {
+		switch err {
+		case store.ErrInvalidInput:
+			writeError(c, http.StatusBadRequest, 2001, "missing fields")
+		case store.ErrInvalidEmail:
+			writeError(c, http.StatusBadRequest, 1006, "invalid email")
+		case store.ErrInvalidCredentials:
+			writeError(c, http.StatusUnauthorized, 1003, "invalid credentials")
+		case store.ErrAccountExists:
+			writeError(c, http.StatusConflict, 1004, "account already exists")
+		case store.ErrNotFound:
+			writeError(c, http.StatusNotFound, 2001, "not found")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
+	}
+	if err := s.Mailer.SendEmailChangeVerification(strings.TrimSpace(req.Email), token); err != nil {
+		log.Printf("failed to send email change verification: %v", err)
+		writeError(c, http.StatusInternalServerError, 5000, "failed to send verification email")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, registerResponse{Message: "verification email sent"})
+}
+
+// maskEmail keeps the first and last character of the local part, e.g.
+// alice@example.com becomes a***e@example.com. Local parts of two characters
+// or fewer keep only the first.
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	local, domain := email[:at], email[at:]
+	first, _ := utf8.DecodeRuneInString(local)
+	if utf8.RuneCountInString(local) <= 2 {
+		return string(first) + "***" + domain
+	}
+	last, _ := utf8.DecodeLastRuneInString(local)
+	return string(first) + "***" + string(last) + domain
+}
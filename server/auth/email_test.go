@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestChangeEmailSharesVerificationEmailBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	reg, err := s.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	dir := t.TempDir()
+	svc := &Service{
+		Store:  s,
+		Mailer: &LogMailer{From: "hub@localhost", Dir: dir},
+		Signup: NewSignupThrottler(
+			ratelimit.Rate{Limit: 10, Window: time.Hour},
+			ratelimit.Rate{Limit: 1, Window: time.Hour},
+		),
+	}
+	router := gin.New()
+	router.POST("/api/v1/users/me/email", svc.ChangeEmail)
+
+	change := func(email string) int {
+		body := `{"email":"` + email + `","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/email", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := change("target@example.com"); code != http.StatusAccepted {
+		t.Fatalf("first change: got %d, want 202", code)
+	}
+	if code := change("Target@example.com"); code != http.StatusTooManyRequests {
+		t.Fatalf("repeat to the same address: got %d, want 429", code)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(files) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(files))
+	}
+}
+
+func TestChangeEmailSharesLoginLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	reg, err := s.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	svc := &Service{
+		Store:     s,
+		Mailer:    &LogMailer{From: "hub@localhost", Dir: t.TempDir()},
+		Throttler: NewLoginThrottler(ratelimit.Rate{Limit: 2, Window: time.Hour}),
+	}
+	router := gin.New()
+	router.POST("/api/v1/users/me/email", svc.ChangeEmail)
+
+	change := func(password string) int {
+		body := `{"email":"target@example.com","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/email", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := change("wrong-password"); code != http.StatusUnauthorized {
+			t.Fatalf("wrong password %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := change("password123"); code != http.StatusTooManyRequests {
+		t.Fatalf("after lockout: got %d, want 429", code)
+	}
+	if wait := svc.Throttler.Locked("owner@example.com", ""); wait <= 0 {
+		t.Fatalf("login account is not locked out")
+	}
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// ExportMe handles GET /api/v1/users/me/export. The store hands over one
+// record at a time and each is written to the JSON attachment as it arrives,
+// so the export is never held in memory whole. Moderator notes on the user's
+// reports are left out.
+func (s *Service) ExportMe(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	var e *exportEncoder
+	err := s.Store.ExportUserData(user.ID, func(section string, item any) error {
+		if e == nil {
+			// The profile comes first; once it is here the user exists and
+			// the response can start.
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="campus-hub-export-`+user.ID+`.json"`)
+			c.Status(http.StatusOK)
+			e = &exportEncoder{w: bufio.NewWriter(c.Writer)}
+			e.raw("{")
+			e.field("exported_at", time.Now().UTC().Format(time.RFC3339))
+		}
+		e.item(section, exportItem(item))
+		return e.err
+	})
+	if e == nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+	if err == nil {
+		err = e.finish()
+	}
+	if err != nil {
+		log.Printf("failed to stream export for %s: %v", user.ID, err)
+	}
+}
+
+// exportSections lists the export's top-level fields in the order the store
+// emits them.
+var exportSections = []string{
+	store.ExportProfile,
+	store.ExportPosts,
+	store.ExportComments,
+	store.ExportFiles,
+	store.ExportFollowing,
+	store.ExportReports,
+}
+
+// exportItem shapes one store record for the export.
+func exportItem(item any) any {
+	switch v := item.(type) {
+	case store.ExportedProfile:
+		return map[string]any{
+			"id":         v.User.ID,
+			"nickname":   v.User.Nickname,
+			"email":      v.Email,
+			"avatar":     v.User.Avatar,
+			"cover":      v.User.Cover,
+			"bio":        v.User.Bio,
+			"exp":        v.User.Exp,
+			"created_at": v.User.CreatedAt,
+		}
+	case store.Post:
+		return map[string]any{
+			"id":              v.ID,
+			"board_id":        v.BoardID,
+			"title":           v.Title,
+			"content":         v.Content,
+			"content_json":    exportJSON(v.ContentJSON),
+			"tags":            v.Tags,
+			"attachments":     v.Attachments,
+			"status":          v.Status,
+			"view_count":      v.ViewCount,
+			"created_at":      v.CreatedAt,
+			"created_at_unix": transport.UnixMillis(v.CreatedAt),
+		}
+	case store.Comment:
+		return map[string]any{
+			"id":              v.ID,
+			"post_id":         v.PostID,
+			"parent_id":       v.ParentID,
+			"content":         v.Content,
+			"content_json":    exportJSON(v.ContentJSON),
+			"tags":            v.Tags,
+			"attachments":     v.Attachments,
+			"floor":           v.Floor,
+			"created_at":      v.CreatedAt,
+			"created_at_unix": transport.UnixMillis(v.CreatedAt),
+		}
+	case store.FileMeta:
+		return map[string]any{
+			"id":         v.ID,
+			"filename":   v.Filename,
+			"url":        "/files/" + v.ID,
+			"width":      v.Width,
+			"height":     v.Height,
+			"created_at": v.CreatedAt,
+		}
+	case store.User:
+		return map[string]any{
+			"id":       v.ID,
+			"nickname": v.Nickname,
+		}
+	case store.Report:
+		return map[string]any{
+			"id":          v.ID,
+			"target_type": v.TargetType,
+			"target_id":   v.TargetID,
+			"reason":      v.Reason,
+			"detail":      v.Detail,
+			"status":      v.Status,
+			"created_at":  v.CreatedAt,
+			"updated_at":  v.UpdatedAt,
+		}
+	}
+	return item
+}
+
+// exportEncoder writes one JSON object piecewise and remembers the first
+// write error, after which every call is a no-op. The profile is written as
+// an object and every later section as a list; sections the store skipped
+// for having no records come out as [].
+type exportEncoder struct {
+	w     *bufio.Writer
+	err   error
+	next  int // index in exportSections of the next section to open
+	items int // items written to the open list
+}
+
+func (e *exportEncoder) raw(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *exportEncoder) value(v any) {
+	if e.err != nil {
+		return
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return
+	}
+	_, e.err = e.w.Write(encoded)
+}
+
+func (e *exportEncoder) field(name string, v any) {
+	e.value(name)
+	e.raw(":")
+	e.value(v)
+}
+
+// item writes v into section, first opening that section and any empty ones
+// before it.
+func (e *exportEncoder) item(section string, v any) {
+	if section == store.ExportProfile && e.next == 0 {
+		e.raw(",")
+		e.field(section, v)
+		e.next = 1
+		return
+	}
+	for e.next == 0 || exportSections[e.next-1] != section {
+		if e.next == len(exportSections) {
+			e.err = fmt.Errorf("export section %q out of order", section)
+			return
+		}
+		e.open()
+	}
+	if e.items > 0 {
+		e.raw(",")
+	}
+	e.value(v)
+	e.items++
+}
+
+// open closes the current list and opens the next section's.
+func (e *exportEncoder) open() {
+	if e.next > 1 {
+		e.raw("]")
+	}
+	e.raw(",")
+	e.value(exportSections[e.next])
+	e.raw(":[")
+	e.next++
+	e.items = 0
+}
+
+// finish writes the remaining sections, closes the object and flushes.
+func (e *exportEncoder) finish() error {
+	for e.next < len(exportSections) {
+		e.open()
+	}
+	e.raw("]}\n")
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+// exportJSON passes stored rich-text JSON through, dropping values that are
+// empty or not valid JSON.
+func exportJSON(raw string) json.RawMessage {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || !json.Valid([]byte(trimmed)) {
+		return nil
+	}
+	return json.RawMessage(trimmed)
+}
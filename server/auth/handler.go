@@ -9,12 +9,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
 type Service struct {
 	Store  store.API
 	Mailer EmailSender
+	// Throttler locks out repeated failed logins; nil disables it.
+	Throttler *LoginThrottler
+	// Signup caps registrations and verification emails; nil disables it.
+	Signup *SignupThrottler
 }
 
 type loginRequest struct {
@@ -34,8 +39,38 @@ type resendVerificationRequest struct {
 }
 
 type loginResponse struct {
-	Token string       `json:"token"`
-	User  userResponse `json:"user"`
+	Token     string       `json:"token"`
+	ExpiresAt string       `json:"expires_at"`
+	User      userResponse `json:"user"`
+}
+
+type deactivateRequest struct {
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	Rotate bool `json:"rotate"`
+}
+
+type refreshResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+const (
+	// maxRefreshBody bounds the optional refresh body, which is at most {"rotate": true}.
+	maxRefreshBody = 1 << 10
+	// maxDeactivateBody bounds the deactivation body, which only carries the password.
+	maxDeactivateBody = 1 << 10
+)
+
+type sessionResponse struct {
+	ID        string `json:"id"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt string `json:"created_at"`
+	LastSeen  string `json:"last_seen"`
+	ExpiresAt string `json:"expires_at"`
+	Current   bool   `json:"current"`
 }
 
 type registerResponse struct {
@@ -50,10 +85,6 @@ type userResponse struct {
 	LevelTitle string `json:"level_title"`
 }
 
-type userStatsStore interface {
-	UserStats(userID string) (int, int, error)
-}
-
 // RegisterHandler handles POST /api/v1/auth/register.
 func (s *Service) RegisterHandler(c *gin.Context) {
 	var req registerRequest
@@ -66,21 +97,31 @@ func (s *Service) RegisterHandler(c *gin.Context) {
 		return
 	}
 	if strings.TrimSpace(req.Password) != strings.TrimSpace(req.ConfirmPassword) {
-		writeError(c, http.StatusBadRequest, 1011, "passwords do not match")
+		writeFieldError(c, 1011, "passwords do not match", "confirm_password", transport.FieldInvalid)
 		return
 	}
+	if s.Signup != nil {
+		if wait := s.Signup.AllowRegister(transport.ClientIP(c.Request)); wait > 0 {
+			transport.TooManyRequests(c, wait, "too many registrations, try again later")
+			return
+		}
+	}
 
 	result, err := s.Store.Register(req.Account, req.Password, req.Nickname)
 	if err != nil {
 		switch err {
 		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "missing fields")
+			transport.FieldError(c, http.StatusBadRequest, 2001, "missing fields", blankFields(map[string]string{
+				"account":  req.Account,
+				"password": req.Password,
+				"nickname": req.Nickname,
+			}))
 		case store.ErrInvalidEmail:
-			writeError(c, http.StatusBadRequest, 1006, "invalid email")
+			writeFieldError(c, 1006, "invalid email", "account", transport.FieldInvalid)
 		case store.ErrInvalidNickname:
-			writeError(c, http.StatusBadRequest, 1012, "invalid nickname")
+			writeFieldError(c, 1012, "invalid nickname", "nickname", transport.FieldInvalid)
 		case store.ErrWeakPassword:
-			writeError(c, http.StatusBadRequest, 1007, "weak password")
+			writeFieldError(c, 1007, "weak password", "password", transport.FieldInvalid)
 		case store.ErrAccountExists:
 			writeError(c, http.StatusConflict, 1004, "account already exists")
 		default:
@@ -88,6 +129,9 @@ func (s *Service) RegisterHandler(c *gin.Context) {
 		}
 		return
 	}
+	if s.Signup != nil {
+		s.Signup.RecordEmail(req.Account)
+	}
 	if err := s.Mailer.SendVerificationEmail(strings.TrimSpace(req.Account), result.VerificationToken); err != nil {
 		log.Printf("failed to send verification email: %v", err)
 		writeError(c, http.StatusInternalServerError, 5000, "failed to send verification email")
@@ -106,11 +150,25 @@ func (s *Service) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	token, user, err := s.Store.Login(req.Account, req.Password)
+	ip := transport.ClientIP(c.Request)
+	if s.Throttler != nil {
+		if wait := s.Throttler.Locked(req.Account, ip); wait > 0 {
+			transport.TooManyRequests(c, wait, "too many failed login attempts, try again later")
+			return
+		}
+	}
+
+	session, user, err := s.Store.Login(req.Account, req.Password, c.Request.UserAgent())
 	if err != nil {
+		if err == store.ErrInvalidCredentials && s.Throttler != nil {
+			s.Throttler.Fail(req.Account, ip)
+		}
 		switch err {
 		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "missing fields")
+			transport.FieldError(c, http.StatusBadRequest, 2001, "missing fields", blankFields(map[string]string{
+				"account":  req.Account,
+				"password": req.Password,
+			}))
 		case store.ErrInvalidCredentials:
 			writeError(c, http.StatusUnauthorized, 1003, "invalid credentials")
 		case store.ErrAccountUnverified:
@@ -120,9 +178,13 @@ func (s *Service) LoginHandler(c *gin.Context) {
 		}
 		return
 	}
+	if s.Throttler != nil {
+		s.Throttler.Succeed(req.Account)
+	}
 	level := store.LevelForExp(user.Exp)
 	resp := loginResponse{
-		Token: token,
+		Token:     session.Token,
+		ExpiresAt: session.ExpiresAt,
 		User: userResponse{
 			ID:         user.ID,
 			Nickname:   user.Nickname,
@@ -135,6 +197,32 @@ func (s *Service) LoginHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// RefreshHandler handles POST /api/v1/auth/refresh. The Bearer token must
+// still be valid; its expiry is pushed out, and with {"rotate": true} it is
+// replaced by a new token.
+func (s *Service) RefreshHandler(c *gin.Context) {
+	token := bearerToken(c)
+	if token == "" {
+		writeError(c, http.StatusUnauthorized, 1001, "missing token")
+		return
+	}
+	var req refreshRequest
+	if c.Request.ContentLength != 0 && !transport.BindJSON(c, &req, maxRefreshBody) {
+		return
+	}
+
+	session, err := s.Store.RefreshSession(token, req.Rotate)
+	if err != nil {
+		if err == store.ErrInvalidToken {
+			writeError(c, http.StatusUnauthorized, 1001, "invalid token")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+	c.JSON(http.StatusOK, refreshResponse{Token: session.Token, ExpiresAt: session.ExpiresAt})
+}
+
 // VerifyEmailHandler handles GET /api/v1/auth/verify-email.
 func (s *Service) VerifyEmailHandler(c *gin.Context) {
 	trimmedToken := strings.TrimSpace(c.Query("token"))
@@ -142,7 +230,14 @@ func (s *Service) VerifyEmailHandler(c *gin.Context) {
 		writeError(c, http.StatusBadRequest, 2001, "missing token")
 		return
 	}
-	if err := s.Store.VerifyEmail(trimmedToken); err != nil {
+	message := "email verified"
+	err := s.Store.VerifyEmail(trimmedToken)
+	if err == store.ErrVerificationTokenInvalid {
+		// Email change links share this endpoint with registration links.
+		message = "email changed"
+		err = s.Store.ConfirmEmailChange(trimmedToken)
+	}
+	if err != nil {
 		switch err {
 		case store.ErrInvalidInput:
 			writeError(c, http.StatusBadRequest, 2001, "missing token")
@@ -150,13 +245,15 @@ func (s *Service) VerifyEmailHandler(c *gin.Context) {
 			writeError(c, http.StatusBadRequest, 1009, "invalid verification token")
 		case store.ErrVerificationTokenExpired:
 			writeError(c, http.StatusGone, 1010, "verification token expired")
+		case store.ErrAccountExists:
+			writeError(c, http.StatusConflict, 1004, "account already exists")
 		default:
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, registerResponse{Message: "email verified"})
+	c.JSON(http.StatusOK, registerResponse{Message: message})
 }
 
 // ResendVerificationHandler handles POST /api/v1/auth/resend-verification.
@@ -171,13 +268,20 @@ func (s *Service) ResendVerificationHandler(c *gin.Context) {
 		return
 	}
 
+	if s.Signup != nil {
+		if wait := s.Signup.AllowEmail(req.Account, transport.ClientIP(c.Request)); wait > 0 {
+			transport.TooManyRequests(c, wait, "too many verification emails, try again later")
+			return
+		}
+	}
+
 	token, err := s.Store.ResendVerification(req.Account)
 	if err != nil {
 		switch err {
 		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "missing fields")
+			writeFieldError(c, 2001, "missing fields", "account", transport.FieldRequired)
 		case store.ErrInvalidEmail:
-			writeError(c, http.StatusBadRequest, 1006, "invalid email")
+			writeFieldError(c, 1006, "invalid email", "account", transport.FieldInvalid)
 		case store.ErrNotFound:
 			writeError(c, http.StatusNotFound, 1013, "account not found")
 		case store.ErrAccountVerified:
@@ -196,12 +300,34 @@ func (s *Service) ResendVerificationHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, registerResponse{Message: "verification email sent"})
 }
 
-// DeactivateMe handles DELETE /api/v1/users/me.
+// DeactivateMe handles DELETE /api/v1/users/me. The current password must be
+// supplied in the body, so a stolen token alone cannot delete the account.
 func (s *Service) DeactivateMe(c *gin.Context) {
 	user, ok := s.RequireUser(c)
 	if !ok {
 		return
 	}
+	var req deactivateRequest
+	if !transport.BindJSON(c, &req, maxDeactivateBody) {
+		return
+	}
+	account := s.throttleAccount(user.ID)
+	if s.passwordLocked(c, account) {
+		return
+	}
+	err := s.Store.CheckPassword(user.ID, req.Password)
+	s.recordPassword(c, account, err)
+	if err != nil {
+		switch err {
+		case store.ErrInvalidInput:
+			writeFieldError(c, 2001, "missing password", "password", transport.FieldRequired)
+		case store.ErrInvalidCredentials:
+			writeError(c, http.StatusUnauthorized, 1003, "invalid credentials")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
+	}
 	if err := s.Store.DeactivateAccount(user.ID); err != nil {
 		if err == store.ErrNotFound {
 			writeError(c, http.StatusNotFound, 2001, "not found")
@@ -214,6 +340,91 @@ func (s *Service) DeactivateMe(c *gin.Context) {
 	c.JSON(http.StatusOK, registerResponse{Message: "account deactivated"})
 }
 
+// throttleAccount returns the login account (the email) the login throttler
+// keys userID by, falling back to the user ID if it can't be read.
+func (s *Service) throttleAccount(userID string) string {
+	if email, _, err := s.Store.AccountEmail(userID); err == nil && email != "" {
+		return email
+	}
+	return userID
+}
+
+// passwordLocked writes a 429 and returns true when the login throttler has
+// locked out account or the client IP. Endpoints that re-check the password
+// share login's keys, so they can't be used to get around its lockout.
+func (s *Service) passwordLocked(c *gin.Context, account string) bool {
+	if s.Throttler == nil {
+		return false
+	}
+	if wait := s.Throttler.Locked(account, transport.ClientIP(c.Request)); wait > 0 {
+		transport.TooManyRequests(c, wait, "too many failed password attempts, try again later")
+		return true
+	}
+	return false
+}
+
+// recordPassword counts a wrong password against account and the client IP,
+// and clears the account's failures after a right one. Other errors say
+// nothing about the password and are ignored.
+func (s *Service) recordPassword(c *gin.Context, account string, err error) {
+	if s.Throttler == nil {
+		return
+	}
+	switch err {
+	case nil:
+		s.Throttler.Succeed(account)
+	case store.ErrInvalidCredentials:
+		s.Throttler.Fail(account, transport.ClientIP(c.Request))
+	}
+}
+
+// ListSessions handles GET /api/v1/users/me/sessions. Tokens are never
+// returned; current marks the session making this request.
+func (s *Service) ListSessions(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+	sessions, err := s.Store.Sessions(user.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+
+	token := bearerToken(c)
+	items := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, sessionResponse{
+			ID:        session.ID,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			LastSeen:  session.LastSeen,
+			ExpiresAt: session.ExpiresAt,
+			Current:   session.Token == token,
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// RevokeSession handles DELETE /api/v1/users/me/sessions/{id}. Revoking the
+// current session logs this client out.
+func (s *Service) RevokeSession(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+	if err := s.Store.RevokeSession(user.ID, c.Param("id")); err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]bool{"success": true})
+}
+
 // GetMe handles GET /api/v1/users/me.
 func (s *Service) GetMe(c *gin.Context) {
 	user, ok := s.RequireUser(c)
@@ -221,9 +432,17 @@ func (s *Service) GetMe(c *gin.Context) {
 		return
 	}
 
-	postsCount, commentsCount, _ := s.userStats(user.ID)
+	postsCount, commentsCount, _ := s.Store.UserStats(user.ID)
 	followers, following := s.Store.GetFollowCounts(user.ID)
 
+	// Clients show a verification banner from these; a lookup failure just
+	// leaves the email blank and the account unverified.
+	account, _ := s.Store.AccountStatus(user.ID)
+	email := ""
+	if account.Email != "" {
+		email = maskEmail(account.Email)
+	}
+
 	level := store.LevelForExp(user.Exp)
 	resp := struct {
 		ID             string `json:"id"`
@@ -232,6 +451,8 @@ func (s *Service) GetMe(c *gin.Context) {
 		Bio            string `json:"bio"`
 		Cover          string `json:"cover"`
 		CreatedAt      string `json:"created_at"`
+		Email          string `json:"email"`
+		EmailVerified  bool   `json:"email_verified"`
 		PostsCount     int    `json:"posts_count"`
 		CommentsCount  int    `json:"comments_count"`
 		FollowersCount int    `json:"followers_count"`
@@ -246,6 +467,8 @@ func (s *Service) GetMe(c *gin.Context) {
 		Bio:            user.Bio,
 		Cover:          user.Cover,
 		CreatedAt:      user.CreatedAt,
+		Email:          email,
+		EmailVerified:  account.Verified,
 		PostsCount:     postsCount,
 		CommentsCount:  commentsCount,
 		FollowersCount: followers,
@@ -258,63 +481,89 @@ func (s *Service) GetMe(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// GetFollowers handles GET /api/v1/users/{id}/followers.
-func (s *Service) GetFollowers(c *gin.Context) {
-	targetID := strings.TrimSpace(c.Param("id"))
-	if targetID == "" {
-		writeError(c, http.StatusNotFound, 2001, "not found")
+// CheckIn handles POST /api/v1/users/me/checkin.
+func (s *Service) CheckIn(c *gin.Context) {
+	user, ok := s.RequireUser(c)
+	if !ok {
 		return
 	}
 
-	page := parsePositiveInt(c.Query("page"), 1)
-	pageSize := parsePositiveInt(c.Query("page_size"), 20)
-	offset := (page - 1) * pageSize
+	exp, alreadyClaimed, err := s.Store.CheckIn(user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
 
-	items, total := s.Store.Followers(targetID, offset, pageSize)
-	respItems := make([]map[string]any, 0, len(items))
-	for _, u := range items {
-		level := store.LevelForExp(u.Exp)
-		respItems = append(respItems, map[string]any{
-			"id":          u.ID,
-			"nickname":    u.Nickname,
-			"avatar":      u.Avatar,
-			"bio":         u.Bio,
-			"created_at":  u.CreatedAt,
+	awarded := store.DailyCheckInExp
+	if alreadyClaimed {
+		awarded = 0
+	}
+	level := store.LevelForExp(exp)
+	resp := map[string]any{
+		"exp":             exp,
+		"level":           level.Level,
+		"level_title":     level.Title,
+		"awarded":         awarded,
+		"already_claimed": alreadyClaimed,
+	}
+	if store.LevelForExp(exp-awarded).Level < level.Level {
+		resp["level_up"] = map[string]any{
 			"level":       level.Level,
 			"level_title": level.Title,
-		})
+			"exp":         exp,
+		}
 	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	c.JSON(http.StatusOK, map[string]any{
-		"items": respItems,
-		"total": total,
-	})
+// GetFollowers handles GET /api/v1/users/{id}/followers.
+func (s *Service) GetFollowers(c *gin.Context) {
+	s.writeFollowList(c, s.Store.Followers)
 }
 
 // GetFollowing handles GET /api/v1/users/{id}/following.
 func (s *Service) GetFollowing(c *gin.Context) {
+	s.writeFollowList(c, s.Store.Following)
+}
+
+// writeFollowList pages through list for the {id} user. Each item carries
+// is_following for the authenticated viewer (always false for anonymous
+// requests) so the UI can offer follow-back.
+func (s *Service) writeFollowList(c *gin.Context, list func(userID string, offset, limit int) ([]store.User, int)) {
 	targetID := strings.TrimSpace(c.Param("id"))
 	if targetID == "" {
 		writeError(c, http.StatusNotFound, 2001, "not found")
 		return
 	}
 
-	page := parsePositiveInt(c.Query("page"), 1)
-	pageSize := parsePositiveInt(c.Query("page_size"), 20)
-	offset := (page - 1) * pageSize
+	offset, limit := listWindow(c)
+	items, total := list(targetID, offset, limit)
+
+	followed := map[string]bool{}
+	if me, ok := s.OptionalUser(c); ok {
+		ids := make([]string, 0, len(items))
+		for _, u := range items {
+			ids = append(ids, u.ID)
+		}
+		followed = s.Store.FollowingAmong(me.ID, ids)
+	}
 
-	items, total := s.Store.Following(targetID, offset, pageSize)
 	respItems := make([]map[string]any, 0, len(items))
 	for _, u := range items {
 		level := store.LevelForExp(u.Exp)
 		respItems = append(respItems, map[string]any{
-			"id":          u.ID,
-			"nickname":    u.Nickname,
-			"avatar":      u.Avatar,
-			"bio":         u.Bio,
-			"created_at":  u.CreatedAt,
-			"level":       level.Level,
-			"level_title": level.Title,
+			"id":           u.ID,
+			"nickname":     u.Nickname,
+			"avatar":       u.Avatar,
+			"bio":          u.Bio,
+			"created_at":   u.CreatedAt,
+			"level":        level.Level,
+			"level_title":  level.Title,
+			"is_following": followed[u.ID],
 		})
 	}
 
@@ -351,18 +600,19 @@ func (s *Service) GetUserComments(c *gin.Context) {
 		}
 		isReply := strings.TrimSpace(cmt.ParentID) != ""
 		respItems = append(respItems, map[string]any{
-			"id":           cmt.ID,
-			"post_id":      cmt.PostID,
-			"parent_id":    cmt.ParentID,
-			"author_id":    cmt.AuthorID,
-			"content":      cmt.Content,
-			"content_json": json.RawMessage(cmt.ContentJSON),
-			"created_at":   cmt.CreatedAt,
-			"floor":        cmt.Floor,
-			"post_title":   postTitle,
-			"board_id":     boardID,
-			"board_name":   boardName,
-			"is_reply":     isReply,
+			"id":              cmt.ID,
+			"post_id":         cmt.PostID,
+			"parent_id":       cmt.ParentID,
+			"author_id":       cmt.AuthorID,
+			"content":         cmt.Content,
+			"content_json":    json.RawMessage(cmt.ContentJSON),
+			"created_at":      cmt.CreatedAt,
+			"created_at_unix": transport.UnixMillis(cmt.CreatedAt),
+			"floor":           cmt.Floor,
+			"post_title":      postTitle,
+			"board_id":        boardID,
+			"board_name":      boardName,
+			"is_reply":        isReply,
 		})
 	}
 
@@ -372,6 +622,29 @@ func (s *Service) GetUserComments(c *gin.Context) {
 	})
 }
 
+// maxListLimit caps the page size of user lists.
+const maxListLimit = 100
+
+// listWindow reads offset/limit, falling back to page/page_size when offset
+// is absent. The limit defaults to 20 and is capped at maxListLimit.
+func listWindow(c *gin.Context) (offset, limit int) {
+	if raw, ok := c.GetQuery("limit"); ok {
+		limit = parsePositiveInt(raw, 20)
+	} else {
+		limit = parsePositiveInt(c.Query("page_size"), 20)
+	}
+	limit = min(limit, maxListLimit)
+
+	if raw, ok := c.GetQuery("offset"); ok {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil && parsed > 0 {
+			offset = parsed
+		}
+		return offset, limit
+	}
+	page := parsePositiveInt(c.Query("page"), 1)
+	return (page - 1) * limit, limit
+}
+
 func parsePositiveInt(value string, fallback int) int {
 	value = strings.TrimSpace(value)
 	if value == "" {
@@ -404,9 +677,7 @@ func (s *Service) UpdateMe(c *gin.Context) {
 
 	nickname := user.Nickname
 	if req.Nickname != nil {
-		if strings.TrimSpace(*req.Nickname) != "" {
-			nickname = *req.Nickname
-		}
+		nickname = *req.Nickname
 	}
 	bio := user.Bio
 	if req.Bio != nil {
@@ -414,15 +685,25 @@ func (s *Service) UpdateMe(c *gin.Context) {
 	}
 	avatar := user.Avatar
 	if req.Avatar != nil {
-		avatar = *req.Avatar
+		if avatar, ok = s.profileImage(*req.Avatar); !ok {
+			writeFieldError(c, 2001, "avatar must be an uploaded file", "avatar", transport.FieldInvalid)
+			return
+		}
 	}
 	cover := user.Cover
 	if req.Cover != nil {
-		cover = *req.Cover
+		if cover, ok = s.profileImage(*req.Cover); !ok {
+			writeFieldError(c, 2001, "cover must be an uploaded file", "cover", transport.FieldInvalid)
+			return
+		}
 	}
 
 	updated, err := s.Store.UpdateUser(user.ID, nickname, bio, avatar, cover)
 	if err != nil {
+		if err == store.ErrInvalidNickname {
+			writeFieldError(c, 1012, "invalid nickname", "nickname", transport.FieldInvalid)
+			return
+		}
 		writeError(c, http.StatusInternalServerError, 5000, "server error")
 		return
 	}
@@ -452,6 +733,24 @@ func (s *Service) UpdateMe(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// profileImage resolves an avatar or cover value, given as a file ID or its
+// /files/{id} URL, to the URL form. Blank clears the image; unknown files are
+// rejected.
+func (s *Service) profileImage(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return "", true
+	}
+	fileID := strings.TrimPrefix(trimmed, "/files/")
+	if fileID == "" || strings.Contains(fileID, "/") {
+		return "", false
+	}
+	if _, ok := s.Store.GetFile(fileID); !ok {
+		return "", false
+	}
+	return "/files/" + fileID, true
+}
+
 // GetUser handles GET /api/v1/users/{id}.
 func (s *Service) GetUser(c *gin.Context) {
 	trimmedID := strings.TrimSpace(c.Param("id"))
@@ -466,7 +765,7 @@ func (s *Service) GetUser(c *gin.Context) {
 		return
 	}
 
-	postsCount, commentsCount, err := s.userStats(trimmedID)
+	postsCount, commentsCount, err := s.Store.UserStats(trimmedID)
 	if err != nil {
 		writeError(c, http.StatusInternalServerError, 5000, "server error")
 		return
@@ -474,10 +773,8 @@ func (s *Service) GetUser(c *gin.Context) {
 
 	followers, following := s.Store.GetFollowCounts(trimmedID)
 	isFollowing := false
-	if token := bearerToken(c); token != "" {
-		if me, ok := s.Store.UserByToken(token); ok {
-			isFollowing = s.Store.IsFollowing(me.ID, trimmedID)
-		}
+	if me, ok := s.OptionalUser(c); ok {
+		isFollowing = s.Store.IsFollowing(me.ID, trimmedID)
 	}
 
 	level := store.LevelForExp(user.Exp)
@@ -534,6 +831,8 @@ func (s *Service) FollowUser(c *gin.Context) {
 			writeError(c, http.StatusNotFound, 2001, "user not found")
 		} else if err == store.ErrInvalidInput {
 			writeError(c, http.StatusBadRequest, 2001, "cannot follow yourself")
+		} else if err == store.ErrForbidden {
+			writeError(c, http.StatusForbidden, 1002, "forbidden")
 		} else {
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
@@ -567,6 +866,54 @@ func (s *Service) UnfollowUser(c *gin.Context) {
 	c.JSON(http.StatusOK, map[string]bool{"success": true})
 }
 
+// BlockUser handles POST /api/v1/users/{id}/block.
+func (s *Service) BlockUser(c *gin.Context) {
+	targetID := strings.TrimSpace(c.Param("id"))
+	if targetID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	me, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	if err := s.Store.BlockUser(me.ID, targetID); err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "user not found")
+		} else if err == store.ErrInvalidInput {
+			writeError(c, http.StatusBadRequest, 2001, "cannot block yourself")
+		} else {
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]bool{"success": true})
+}
+
+// UnblockUser handles DELETE /api/v1/users/{id}/block.
+func (s *Service) UnblockUser(c *gin.Context) {
+	targetID := strings.TrimSpace(c.Param("id"))
+	if targetID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	me, ok := s.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	if err := s.Store.UnblockUser(me.ID, targetID); err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]bool{"success": true})
+}
+
 // RequireUser extracts the Bearer token, loads the user, and writes a 401 error on failure.
 func (s *Service) RequireUser(c *gin.Context) (store.User, bool) {
 	token := bearerToken(c)
@@ -583,6 +930,16 @@ func (s *Service) RequireUser(c *gin.Context) (store.User, bool) {
 	return user, true
 }
 
+// OptionalUser resolves the Bearer token if one is present, without writing
+// an error; anonymous or invalid tokens yield false.
+func (s *Service) OptionalUser(c *gin.Context) (store.User, bool) {
+	token := bearerToken(c)
+	if token == "" {
+		return store.User{}, false
+	}
+	return s.Store.UserByToken(token)
+}
+
 // bearerToken parses Authorization: Bearer <token>.
 func bearerToken(c *gin.Context) string {
 	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
@@ -595,28 +952,22 @@ func bearerToken(c *gin.Context) string {
 	return ""
 }
 
-func (s *Service) userStats(userID string) (int, int, error) {
-	if statsStore, ok := s.Store.(userStatsStore); ok {
-		return statsStore.UserStats(userID)
-	}
+func writeError(c *gin.Context, status int, code int, message string) {
+	transport.Error(c, status, code, message)
+}
 
-	posts := s.Store.Posts("")
-	postsCount := 0
-	commentsCount := 0
-	for _, post := range posts {
-		if post.AuthorID == userID {
-			postsCount++
-		}
-		comments := s.Store.Comments(post.ID)
-		for _, comment := range comments {
-			if comment.AuthorID == userID {
-				commentsCount++
-			}
-		}
-	}
-	return postsCount, commentsCount, nil
+// writeFieldError writes a 400 with code naming the one request field at fault.
+func writeFieldError(c *gin.Context, code int, message, field, reason string) {
+	transport.FieldError(c, http.StatusBadRequest, code, message, map[string]string{field: reason})
 }
 
-func writeError(c *gin.Context, status int, code int, message string) {
-	c.JSON(status, gin.H{"code": code, "message": message})
+// blankFields marks each of values that is empty after trimming as required.
+func blankFields(values map[string]string) map[string]string {
+	fields := map[string]string{}
+	for name, value := range values {
+		if strings.TrimSpace(value) == "" {
+			fields[name] = transport.FieldRequired
+		}
+	}
+	return fields
 }
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestDeactivateMeSharesLoginLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	reg, err := s.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	svc := &Service{
+		Store:     s,
+		Throttler: NewLoginThrottler(ratelimit.Rate{Limit: 2, Window: time.Hour}),
+	}
+	router := gin.New()
+	router.DELETE("/api/v1/users/me", svc.DeactivateMe)
+
+	deactivate := func(password string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", strings.NewReader(`{"password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := deactivate("wrong-password"); code != http.StatusUnauthorized {
+			t.Fatalf("wrong password %d: got %d, want 401", i+1, code)
+		}
+	}
+	if code := deactivate("password123"); code != http.StatusTooManyRequests {
+		t.Fatalf("after lockout: got %d, want 429", code)
+	}
+	if wait := svc.Throttler.Locked("Owner@example.com", ""); wait <= 0 {
+		t.Fatalf("login account is not locked out")
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// LogMailer is an EmailSender for local development: nothing is sent, the
+// links a real mail would carry are logged instead, and each message is
+// optionally saved as an .eml file under Dir.
+type LogMailer struct {
+	From       string
+	AppBaseURL string
+	// Dir, when set, receives every message as <time>-<recipient>.eml.
+	Dir string
+}
+
+// NewLogMailerFromEnv builds a LogMailer from APP_BASE_URL and DEV_MAIL_DIR.
+func NewLogMailerFromEnv() (*LogMailer, error) {
+	appBaseURL := strings.TrimSpace(os.Getenv("APP_BASE_URL"))
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:5173"
+	}
+	dir := strings.TrimSpace(os.Getenv("DEV_MAIL_DIR"))
+	if dir != "" {
+		dir = filepath.Clean(dir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create DEV_MAIL_DIR: %w", err)
+		}
+	}
+	return &LogMailer{
+		From:       "campus-hub@localhost",
+		AppBaseURL: appBaseURL,
+		Dir:        dir,
+	}, nil
+}
+
+func (m *LogMailer) SendVerificationEmail(toEmail, token string) error {
+	link := verificationURL(m.AppBaseURL, token)
+	subject, plainBody, htmlBody := composeVerification(link)
+	return m.deliver(toEmail, subject, link, plainBody, htmlBody)
+}
+
+func (m *LogMailer) SendEmailChangeVerification(toEmail, token string) error {
+	link := verificationURL(m.AppBaseURL, token)
+	subject, plainBody, htmlBody := composeEmailChange(link)
+	return m.deliver(toEmail, subject, link, plainBody, htmlBody)
+}
+
+func (m *LogMailer) SendNotificationDigest(toEmail string, items []store.Notification) error {
+	if len(items) == 0 {
+		return nil
+	}
+	link := notificationsURL(m.AppBaseURL)
+	subject, plainBody, htmlBody := composeDigest(items, link)
+	return m.deliver(toEmail, subject, link, plainBody, htmlBody)
+}
+
+// unsafeFilenameChars matches what is replaced in a recipient before it is
+// used in an .eml file name.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+
+func (m *LogMailer) deliver(toEmail, subject, link, plainBody, htmlBody string) error {
+	log.Printf("dev mail to %s: %q %s", toEmail, subject, link)
+	if m.Dir == "" {
+		return nil
+	}
+	name := fmt.Sprintf("%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000000000"),
+		unsafeFilenameChars.ReplaceAllString(toEmail, "_"))
+	message := buildMessage(m.From, toEmail, subject, plainBody, htmlBody)
+	if err := os.WriteFile(filepath.Join(m.Dir, name), []byte(message), 0o644); err != nil {
+		return fmt.Errorf("write dev mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogMailerWritesEmlWithVerifyLink(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &LogMailer{From: "hub@localhost", AppBaseURL: "http://localhost:5173/", Dir: dir}
+	if IsNilEmailSender(mailer) {
+		t.Fatalf("LogMailer must count as a configured sender")
+	}
+
+	if err := mailer.SendVerificationEmail("alice@example.com", "v_abc"); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("eml files = %v, %v; want one", files, err)
+	}
+	if !strings.HasSuffix(files[0], "-alice@example.com.eml") {
+		t.Fatalf("file name = %s", filepath.Base(files[0]))
+	}
+	body, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !strings.Contains(string(body), "To: alice@example.com") ||
+		!strings.Contains(string(body), "http://localhost:5173/verify-email?token=v_abc") {
+		t.Fatalf("eml is missing recipient or link:\n%s", body)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"fmt"
+	"html"
 	"net"
 	"net/smtp"
 	"net/url"
@@ -12,10 +13,14 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
 type EmailSender interface {
 	SendVerificationEmail(toEmail, token string) error
+	SendEmailChangeVerification(toEmail, token string) error
+	SendNotificationDigest(toEmail string, items []store.Notification) error
 }
 
 func IsNilEmailSender(sender EmailSender) bool {
@@ -83,20 +88,97 @@ func NewSMTPMailerFromEnv() (*SMTPMailer, error) {
 }
 
 func (m *SMTPMailer) SendVerificationEmail(toEmail, token string) error {
-	verifyURL := m.verificationURL(token)
-	subject := "Verify your email"
-	plainBody := fmt.Sprintf("请通过下面的链接验证邮箱：\n\n%s\n\n该链接 24 小时内有效。\n如果不是你本人操作，请忽略此邮件。", verifyURL)
-	htmlBody := buildVerificationHTML(verifyURL)
+	subject, plainBody, htmlBody := composeVerification(verificationURL(m.AppBaseURL, token))
+	message := buildMessage(m.From, toEmail, subject, plainBody, htmlBody)
+	return m.sendMail(toEmail, []byte(message))
+}
+
+// SendEmailChangeVerification mails the confirmation link for a login email
+// change to the new address. The link opens the same verify-email page.
+func (m *SMTPMailer) SendEmailChangeVerification(toEmail, token string) error {
+	subject, plainBody, htmlBody := composeEmailChange(verificationURL(m.AppBaseURL, token))
 	message := buildMessage(m.From, toEmail, subject, plainBody, htmlBody)
 	return m.sendMail(toEmail, []byte(message))
 }
 
-func (m *SMTPMailer) verificationURL(token string) string {
-	base := strings.TrimRight(m.AppBaseURL, "/")
+func verificationURL(appBaseURL, token string) string {
+	base := strings.TrimRight(appBaseURL, "/")
 	encoded := url.QueryEscape(token)
 	return fmt.Sprintf("%s/verify-email?token=%s", base, encoded)
 }
 
+func notificationsURL(appBaseURL string) string {
+	return strings.TrimRight(appBaseURL, "/") + "/notifications"
+}
+
+// maxDigestItems caps how many notifications are listed in one digest email;
+// the rest are summarized as a count.
+const maxDigestItems = 10
+
+func (m *SMTPMailer) SendNotificationDigest(toEmail string, items []store.Notification) error {
+	if len(items) == 0 {
+		return nil
+	}
+	subject, plainBody, htmlBody := composeDigest(items, notificationsURL(m.AppBaseURL))
+	message := buildMessage(m.From, toEmail, subject, plainBody, htmlBody)
+	return m.sendMail(toEmail, []byte(message))
+}
+
+func composeVerification(verifyURL string) (subject, plainBody, htmlBody string) {
+	subject = "Verify your email"
+	plainBody = fmt.Sprintf("请通过下面的链接验证邮箱：\n\n%s\n\n该链接 24 小时内有效。\n如果不是你本人操作，请忽略此邮件。", verifyURL)
+	htmlBody = buildVerificationHTML(verifyURL, "验证你的邮箱", "感谢注册！请点击下方按钮完成邮箱验证。")
+	return subject, plainBody, htmlBody
+}
+
+func composeEmailChange(verifyURL string) (subject, plainBody, htmlBody string) {
+	subject = "Confirm your new email"
+	plainBody = fmt.Sprintf("你正在将 Campus Hub 账号的登录邮箱更换为此地址，请通过下面的链接确认：\n\n%s\n\n该链接 24 小时内有效，确认前仍使用原邮箱登录。\n如果不是你本人操作，请忽略此邮件。", verifyURL)
+	htmlBody = buildVerificationHTML(verifyURL, "确认新的登录邮箱", "你正在将账号的登录邮箱更换为此地址，请点击下方按钮确认。")
+	return subject, plainBody, htmlBody
+}
+
+func composeDigest(items []store.Notification, notificationsURL string) (subject, plainBody, htmlBody string) {
+	subject = fmt.Sprintf("You have %d unread notifications", len(items))
+
+	shown := items
+	if len(shown) > maxDigestItems {
+		shown = shown[:maxDigestItems]
+	}
+	lines := make([]string, 0, len(shown))
+	for _, item := range shown {
+		lines = append(lines, "- "+describeNotification(item))
+	}
+	plainBody = fmt.Sprintf("你在 Campus Hub 有 %d 条未读通知：\n\n%s\n", len(items), strings.Join(lines, "\n"))
+	if more := len(items) - len(shown); more > 0 {
+		plainBody += fmt.Sprintf("……以及另外 %d 条\n", more)
+	}
+	plainBody += fmt.Sprintf("\n查看全部：%s\n\n如需停止接收，请在设置中关闭通知邮件。", notificationsURL)
+
+	htmlBody = buildDigestHTML(shown, len(items)-len(shown), notificationsURL)
+	return subject, plainBody, htmlBody
+}
+
+// describeNotification renders one notification as a short Chinese sentence.
+func describeNotification(n store.Notification) string {
+	switch n.Type {
+	case "comment":
+		return "有人评论了你的帖子"
+	case "reply":
+		return "有人回复了你的评论"
+	case "follow":
+		return "有人关注了你"
+	case "like":
+		return "有人赞了你的内容"
+	case "mention":
+		return "有人提到了你"
+	case store.NotificationContentRemoved:
+		return "你的内容已被管理员移除"
+	default:
+		return "你有一条新通知"
+	}
+}
+
 func buildMessage(from, to, subject, plainBody, htmlBody string) string {
 	boundary := randomBoundary()
 	headers := []string{
@@ -135,7 +217,7 @@ func randomBoundary() string {
 	return "boundary_" + hex.EncodeToString(b[:])
 }
 
-func buildVerificationHTML(verifyURL string) string {
+func buildVerificationHTML(verifyURL, heading, intro string) string {
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html lang="zh-CN">
   <head>
@@ -151,8 +233,8 @@ func buildVerificationHTML(verifyURL string) string {
             <tr>
               <td style="padding:28px 32px 0;">
                 <div style="font-size:12px;letter-spacing:0.2em;color:#c55f24;font-weight:600;">CAMPUS HUB</div>
-                <h1 style="margin:16px 0 8px;font-size:24px;">验证你的邮箱</h1>
-                <p style="margin:0 0 20px;line-height:1.6;color:#4a4a4a;">感谢注册！请点击下方按钮完成邮箱验证。</p>
+                <h1 style="margin:16px 0 8px;font-size:24px;">%s</h1>
+                <p style="margin:0 0 20px;line-height:1.6;color:#4a4a4a;">%s</p>
               </td>
             </tr>
             <tr>
@@ -177,11 +259,65 @@ func buildVerificationHTML(verifyURL string) string {
       </tr>
     </table>
   </body>
-</html>`, verifyURL, verifyURL)
+</html>`, heading, intro, verifyURL, verifyURL)
+}
+
+func buildDigestHTML(items []store.Notification, more int, notificationsURL string) string {
+	var rows strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&rows, `
+                <div style="padding:10px 0;border-bottom:1px solid #f0ece6;line-height:1.6;">%s<span style="float:right;font-size:12px;color:#9a9a9a;">%s</span></div>`,
+			html.EscapeString(describeNotification(item)), html.EscapeString(item.CreatedAt))
+	}
+	if more > 0 {
+		fmt.Fprintf(&rows, `
+                <div style="padding:10px 0;color:#7a7a7a;font-size:13px;">……以及另外 %d 条</div>`, more)
+	}
+	escapedURL := html.EscapeString(notificationsURL)
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="zh-CN">
+  <head>
+    <meta charset="UTF-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+    <title>Notification Digest</title>
+  </head>
+  <body style="margin:0;padding:0;background-color:#f5f4f2;font-family:'Noto Sans SC','Segoe UI',Arial,sans-serif;color:#1f1f1f;">
+    <table role="presentation" width="100%%" cellpadding="0" cellspacing="0" style="padding:32px 16px;">
+      <tr>
+        <td align="center">
+          <table role="presentation" width="100%%" cellpadding="0" cellspacing="0" style="max-width:560px;background:#ffffff;border-radius:16px;box-shadow:0 10px 30px rgba(0,0,0,0.08);overflow:hidden;">
+            <tr>
+              <td style="padding:28px 32px 0;">
+                <div style="font-size:12px;letter-spacing:0.2em;color:#c55f24;font-weight:600;">CAMPUS HUB</div>
+                <h1 style="margin:16px 0 8px;font-size:24px;">你有 %d 条未读通知</h1>
+                <p style="margin:0 0 12px;line-height:1.6;color:#4a4a4a;">以下是你错过的动态：</p>
+              </td>
+            </tr>
+            <tr>
+              <td style="padding:0 32px 20px;font-size:14px;color:#1f1f1f;">%s
+              </td>
+            </tr>
+            <tr>
+              <td align="center" style="padding:0 32px 28px;">
+                <a href="%s" style="display:inline-block;padding:12px 24px;background:#c55f24;color:#ffffff;text-decoration:none;border-radius:999px;font-weight:600;">查看全部通知</a>
+              </td>
+            </tr>
+            <tr>
+              <td style="padding:18px 32px;background:#f8f6f3;color:#9a9a9a;font-size:12px;line-height:1.6;">
+                你收到这封邮件是因为开启了每日通知摘要，可在设置中关闭。
+              </td>
+            </tr>
+          </table>
+        </td>
+      </tr>
+    </table>
+  </body>
+</html>`, len(items)+more, rows.String(), escapedURL)
 }
 
 func (m *SMTPMailer) sendMail(to string, message []byte) error {
-	address := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	address := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
 	if m.UseImplicitTLS {
 		return m.sendMailImplicitTLS(address, to, message)
 	}
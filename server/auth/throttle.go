@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+)
+
+// loginIPFactor lets one address fail this many times more often than one
+// account, so users behind a shared NAT don't lock each other out.
+const loginIPFactor = 4
+
+// LoginThrottler counts failed logins per account and per client IP. Once
+// either key reaches its limit within the window, further attempts are refused
+// until the oldest failure leaves the window.
+type LoginThrottler struct {
+	accounts *ratelimit.SlidingWindow
+	ips      *ratelimit.SlidingWindow
+}
+
+// NewLoginThrottler allows rate.Limit failures per account and
+// rate.Limit*loginIPFactor per IP within rate.Window.
+func NewLoginThrottler(rate ratelimit.Rate) *LoginThrottler {
+	return &LoginThrottler{
+		accounts: ratelimit.NewSlidingWindow(rate.Window, rate.Limit),
+		ips:      ratelimit.NewSlidingWindow(rate.Window, rate.Limit*loginIPFactor),
+	}
+}
+
+// Locked returns how long account or ip must wait before trying again, or zero
+// if neither is locked out.
+func (t *LoginThrottler) Locked(account, ip string) time.Duration {
+	wait := t.accounts.RetryAfter(accountKey(account))
+	if ip != "" {
+		wait = max(wait, t.ips.RetryAfter(ip))
+	}
+	return wait
+}
+
+// Fail records a failed attempt against account and ip.
+func (t *LoginThrottler) Fail(account, ip string) {
+	t.accounts.Allow(accountKey(account))
+	if ip != "" {
+		t.ips.Allow(ip)
+	}
+}
+
+// Succeed clears the failure count for account.
+func (t *LoginThrottler) Succeed(account string) {
+	t.accounts.Reset(accountKey(account))
+}
+
+func accountKey(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// signupIPFactor plays the role of loginIPFactor for verification emails
+// requested from one address.
+const signupIPFactor = 4
+
+// SignupThrottler caps registrations per client IP and verification emails
+// per account, so the register, resend and change-email endpoints cannot be
+// used to flood inboxes through our SMTP relay.
+type SignupThrottler struct {
+	registrations *ratelimit.SlidingWindow
+	emails        *ratelimit.SlidingWindow
+	emailIPs      *ratelimit.SlidingWindow
+}
+
+// NewSignupThrottler allows register.Limit registrations per IP within
+// register.Window, and email.Limit verification emails per account (times
+// signupIPFactor per IP) within email.Window.
+func NewSignupThrottler(register, email ratelimit.Rate) *SignupThrottler {
+	return &SignupThrottler{
+		registrations: ratelimit.NewSlidingWindow(register.Window, register.Limit),
+		emails:        ratelimit.NewSlidingWindow(email.Window, email.Limit),
+		emailIPs:      ratelimit.NewSlidingWindow(email.Window, email.Limit*signupIPFactor),
+	}
+}
+
+// AllowRegister records a registration attempt from ip. It returns how long
+// the caller must wait instead, or zero if the attempt may proceed.
+func (t *SignupThrottler) AllowRegister(ip string) time.Duration {
+	if ip == "" || t.registrations.Allow(ip) {
+		return 0
+	}
+	return t.registrations.RetryAfter(ip)
+}
+
+// AllowEmail records a verification email to account requested from ip. It
+// returns how long the caller must wait instead, or zero if it may be sent.
+func (t *SignupThrottler) AllowEmail(account, ip string) time.Duration {
+	key := accountKey(account)
+	if !t.emails.Allow(key) {
+		return t.emails.RetryAfter(key)
+	}
+	if ip != "" && !t.emailIPs.Allow(ip) {
+		return t.emailIPs.RetryAfter(ip)
+	}
+	return 0
+}
+
+// RecordEmail counts the email sent on registration against account without
+// refusing it; registrations are already capped per IP.
+func (t *SignupThrottler) RecordEmail(account string) {
+	t.emails.Allow(accountKey(account))
+}
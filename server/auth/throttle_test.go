@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+)
+
+func TestSignupThrottlerCapsVerificationEmails(t *testing.T) {
+	throttler := NewSignupThrottler(
+		ratelimit.Rate{Limit: 2, Window: time.Hour},
+		ratelimit.Rate{Limit: 3, Window: time.Hour},
+	)
+
+	// The registration email counts toward the account's budget.
+	throttler.RecordEmail("Alice@Example.com")
+	for i := 0; i < 2; i++ {
+		if wait := throttler.AllowEmail("alice@example.com", "10.0.0.1"); wait != 0 {
+			t.Fatalf("resend %d refused, wait %v", i+1, wait)
+		}
+	}
+	if wait := throttler.AllowEmail(" alice@example.com ", "10.0.0.2"); wait <= 0 || wait > time.Hour {
+		t.Fatalf("fourth email: wait = %v, want up to an hour", wait)
+	}
+	if wait := throttler.AllowEmail("bob@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("other account refused, wait %v", wait)
+	}
+
+	for i := 0; i < 2; i++ {
+		if wait := throttler.AllowRegister("10.0.0.1"); wait != 0 {
+			t.Fatalf("registration %d refused, wait %v", i+1, wait)
+		}
+	}
+	if wait := throttler.AllowRegister("10.0.0.1"); wait <= 0 {
+		t.Fatalf("third registration from one IP allowed")
+	}
+	if wait := throttler.AllowRegister("10.0.0.3"); wait != 0 {
+		t.Fatalf("registration from another IP refused, wait %v", wait)
+	}
+}
@@ -0,0 +1,150 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// OpenDM handles GET /api/v1/chat/dm/{user_id}: it returns the direct-message
+// room shared with that user, creating it on first use.
+func (h *Handler) OpenDM(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	peerID := strings.TrimSpace(c.Param("id"))
+	peer, ok := h.Store.GetUser(peerID)
+	if !ok {
+		transport.Error(c, http.StatusNotFound, transport.CodeInvalidInput, "user not found")
+		return
+	}
+	if peer.ID == user.ID {
+		transport.Error(c, http.StatusBadRequest, transport.CodeInvalidInput, "cannot message yourself")
+		return
+	}
+	if h.blockedBetween(user.ID, peer.ID) {
+		transport.Error(c, http.StatusForbidden, transport.CodeForbidden, "blocked")
+		return
+	}
+
+	roomID, err := h.Store.OpenDMRoom(user.ID, peer.ID)
+	if err != nil {
+		transport.Error(c, http.StatusInternalServerError, transport.CodeServerError, "server error")
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"room_id": roomID,
+		"peer":    peerSummary(peer),
+	})
+}
+
+// ListConversations handles GET /api/v1/chat/conversations: the caller's
+// direct-message rooms, most recently active first, with a last-message
+// preview and unread count.
+func (h *Handler) ListConversations(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	conversations := h.Store.Conversations(user.ID)
+	peerIDs := make([]string, 0, len(conversations))
+	for _, conv := range conversations {
+		peerIDs = append(peerIDs, conv.PeerID)
+	}
+	peers := h.Store.GetUsers(peerIDs)
+
+	items := make([]map[string]any, 0, len(conversations))
+	for _, conv := range conversations {
+		var lastMessage map[string]any
+		if conv.LastMessage.ID != "" {
+			lastMessage = map[string]any{
+				"id":              conv.LastMessage.ID,
+				"sender_id":       conv.LastMessage.SenderID,
+				"content":         conv.LastMessage.Content,
+				"created_at":      conv.LastMessage.CreatedAt,
+				"created_at_unix": transport.UnixMillis(conv.LastMessage.CreatedAt),
+			}
+		}
+		peer := peers[conv.PeerID]
+		peer.ID = conv.PeerID
+		items = append(items, map[string]any{
+			"room_id":      conv.RoomID,
+			"peer":         peerSummary(peer),
+			"last_message": lastMessage,
+			"updated_at":   conv.UpdatedAt(),
+			"unread_count": conv.Unread,
+		})
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// maxReadBody caps the mark-read request body.
+const maxReadBody = 1 << 10
+
+// MarkRead handles POST /api/v1/chat/conversations/{room_id}/read: it moves
+// the caller's read position in the room up to message_id.
+func (h *Handler) MarkRead(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MessageID string `json:"message_id"`
+	}
+	if !transport.BindJSON(c, &req, maxReadBody) {
+		return
+	}
+
+	roomID := strings.TrimSpace(c.Param("id"))
+	if !h.canUseRoom(user, roomID) {
+		transport.Error(c, http.StatusForbidden, transport.CodeForbidden, "room forbidden")
+		return
+	}
+	if err := h.Store.MarkRoomRead(user.ID, roomID, req.MessageID); err != nil {
+		if errors.Is(err, store.ErrInvalidInput) {
+			transport.Error(c, http.StatusBadRequest, transport.CodeInvalidInput, "invalid message_id")
+			return
+		}
+		transport.Error(c, http.StatusInternalServerError, transport.CodeServerError, "server error")
+		return
+	}
+	c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func peerSummary(user store.User) map[string]any {
+	return map[string]any{
+		"id":       user.ID,
+		"nickname": user.Nickname,
+		"avatar":   user.Avatar,
+	}
+}
+
+// blockedBetween reports whether either user has blocked the other.
+func (h *Handler) blockedBetween(userA, userB string) bool {
+	return h.Store.IsBlocked(userA, userB) || h.Store.IsBlocked(userB, userA)
+}
+
+// canUseRoom reports whether user may join, read or write roomID. Public rooms
+// are open to everyone; a direct-message room only to its two members, and
+// not while one has blocked the other.
+func (h *Handler) canUseRoom(user store.User, roomID string) bool {
+	if !store.IsDMRoom(roomID) {
+		return true
+	}
+	userA, userB, ok := h.Store.DMRoomMembers(roomID)
+	if !ok || (user.ID != userA && user.ID != userB) {
+		return false
+	}
+	return !h.blockedBetween(userA, userB)
+}
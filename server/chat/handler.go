@@ -2,17 +2,23 @@ package chat
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
 type Handler struct {
 	Store store.API
 	Hub   *Hub
+	Auth  *auth.Service
 }
 
 // Client represents a single WebSocket connection to a specific user.
@@ -21,6 +27,13 @@ type Client struct {
 	User store.User
 	Room string
 	Send chan []byte
+
+	// sendMu guards closing Send against broadcasts still queueing to it, and
+	// the lagging state below.
+	sendMu       sync.Mutex
+	sendClosed   bool
+	laggingSince time.Time // zero while the client keeps up
+	evicted      bool
 }
 
 type envelope struct {
@@ -36,6 +49,31 @@ type wsError struct {
 	Message string `json:"message"`
 }
 
+// Heartbeat timing. The server pings every pingPeriod; a connection that
+// sends nothing (not even a pong) for pongWait is treated as dead, closed and
+// removed from its room.
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = 30 * time.Second
+
+	// sendBufferSize is how many outgoing messages a client may have queued.
+	sendBufferSize = 16
+	// slowClientWait is how long a client may stay with a full queue before
+	// a broadcast closes it.
+	slowClientWait = 2 * time.Second
+
+	// maxFrameSize caps an incoming frame. A larger one is discarded with an
+	// error event; one beyond maxFrameHardLimit closes the connection.
+	maxFrameSize      = 16 << 10
+	maxFrameHardLimit = 1 << 20
+	// maxContentLength caps message content, in characters, whether sent
+	// over the socket or edited later.
+	maxContentLength = 2000
+	// maxClientMsgIDLength caps the client_msg_id echoed back to the sender.
+	maxClientMsgIDLength = 64
+)
+
 var upgrader = websocket.Upgrader{
 	// Demo mode: allow all origins. Tighten this in production.
 	CheckOrigin: func(_ *http.Request) bool { return true },
@@ -45,13 +83,13 @@ var upgrader = websocket.Upgrader{
 func (h *Handler) ServeWS(c *gin.Context) {
 	token := c.Query("token")
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 1001, "message": "missing token"})
+		transport.Error(c, http.StatusUnauthorized, transport.CodeUnauthorized, "missing token")
 		return
 	}
 
 	user, ok := h.Store.UserByToken(token)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"code": 1001, "message": "invalid token"})
+		transport.Error(c, http.StatusUnauthorized, transport.CodeUnauthorized, "invalid token")
 		return
 	}
 
@@ -63,9 +101,22 @@ func (h *Handler) ServeWS(c *gin.Context) {
 	client := &Client{
 		Conn: conn,
 		User: user,
-		Send: make(chan []byte, 16),
+		Send: make(chan []byte, sendBufferSize),
+	}
+	if !h.Hub.Register(client) {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(time.Second))
+		_ = conn.Close()
+		return
 	}
 
+	conn.SetReadLimit(maxFrameHardLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	go client.writeLoop()
 
 	client.sendEnvelope("system.connected", "", map[string]any{
@@ -73,10 +124,15 @@ func (h *Handler) ServeWS(c *gin.Context) {
 	})
 
 	for {
-		var msg envelope
-		if err := conn.ReadJSON(&msg); err != nil {
+		msg, rejected, err := readEnvelope(conn)
+		if err != nil {
 			break
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		if rejected != nil {
+			client.sendError("", rejected.Code, rejected.Message)
+			continue
+		}
 
 		switch msg.Type {
 		case "chat.join":
@@ -93,10 +149,36 @@ func (h *Handler) ServeWS(c *gin.Context) {
 	}
 
 	h.Hub.Leave(client)
-	close(client.Send)
+	h.Hub.Unregister(client)
+	client.closeSend()
 	_ = conn.Close()
 }
 
+// readEnvelope reads the next frame and decodes it. A frame that is too
+// large or isn't a JSON envelope comes back as a wsError for the client;
+// err is reserved for a broken connection.
+func readEnvelope(conn *websocket.Conn) (envelope, *wsError, error) {
+	var msg envelope
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return msg, nil, err
+	}
+	raw, err := io.ReadAll(io.LimitReader(r, maxFrameSize+1))
+	if err != nil {
+		return msg, nil, err
+	}
+	if len(raw) > maxFrameSize {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return msg, nil, err
+		}
+		return msg, &wsError{Code: 3007, Message: "frame too large"}, nil
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.Type == "" {
+		return msg, &wsError{Code: 3008, Message: "invalid frame"}, nil
+	}
+	return msg, nil, nil
+}
+
 func (h *Handler) handleJoin(client *Client, msg envelope) {
 	var req struct {
 		RoomID string `json:"roomId"`
@@ -105,6 +187,10 @@ func (h *Handler) handleJoin(client *Client, msg envelope) {
 		client.sendError(msg.RequestID, 3002, "invalid join payload")
 		return
 	}
+	if !h.canUseRoom(client.User, req.RoomID) {
+		client.sendError(msg.RequestID, 3006, "room forbidden")
+		return
+	}
 
 	h.Hub.Leave(client)
 	h.Hub.Join(req.RoomID, client)
@@ -116,17 +202,28 @@ func (h *Handler) handleJoin(client *Client, msg envelope) {
 
 func (h *Handler) handleSend(client *Client, msg envelope) {
 	var req struct {
-		RoomID  string `json:"roomId"`
-		Content string `json:"content"`
+		RoomID      string `json:"roomId"`
+		Content     string `json:"content"`
+		ClientMsgID string `json:"client_msg_id"`
 	}
-	if err := json.Unmarshal(msg.Data, &req); err != nil || req.RoomID == "" || req.Content == "" {
+	if err := json.Unmarshal(msg.Data, &req); err != nil || req.RoomID == "" || req.Content == "" ||
+		len(req.ClientMsgID) > maxClientMsgIDLength {
 		client.sendError(msg.RequestID, 3003, "invalid send payload")
 		return
 	}
+	if contentTooLong(req.Content) {
+		client.sendError(msg.RequestID, 3007, "message too long")
+		return
+	}
 	if client.Room != req.RoomID {
 		client.sendError(msg.RequestID, 3004, "not joined")
 		return
 	}
+	// Re-checked on every send so a block takes effect in an open DM.
+	if !h.canUseRoom(client.User, req.RoomID) {
+		client.sendError(msg.RequestID, 3006, "room forbidden")
+		return
+	}
 
 	chatMsg := h.Store.AddMessage(req.RoomID, client.User.ID, req.Content)
 	level := store.LevelForExp(client.User.Exp)
@@ -139,15 +236,17 @@ func (h *Handler) handleSend(client *Client, msg envelope) {
 			"level":       level.Level,
 			"level_title": level.Title,
 		},
-		"content":    chatMsg.Content,
-		"created_at": chatMsg.CreatedAt,
+		"content":         chatMsg.Content,
+		"created_at":      chatMsg.CreatedAt,
+		"created_at_unix": transport.UnixMillis(chatMsg.CreatedAt),
 	}
 
-	encoded, err := marshalEnvelope(1, "chat.message", "", payload, nil)
-	if err != nil {
-		return
-	}
-	h.Hub.Broadcast(req.RoomID, encoded)
+	// The sender's copy answers its request and carries client_msg_id so an
+	// optimistic UI can swap its pending bubble for the stored message
+	// instead of rendering it twice.
+	h.broadcastExcept(req.RoomID, client, "chat.message", payload)
+	payload["client_msg_id"] = req.ClientMsgID
+	client.sendEnvelope("chat.message", msg.RequestID, payload)
 }
 
 func (h *Handler) handleHistory(client *Client, msg envelope) {
@@ -159,14 +258,20 @@ func (h *Handler) handleHistory(client *Client, msg envelope) {
 		client.sendError(msg.RequestID, 3005, "invalid history payload")
 		return
 	}
+	if !h.canUseRoom(client.User, req.RoomID) {
+		client.sendError(msg.RequestID, 3006, "room forbidden")
+		return
+	}
 
 	history := h.Store.Messages(req.RoomID, req.Limit)
 	items := make([]map[string]any, 0, len(history))
 	for _, entry := range history {
 		items = append(items, map[string]any{
-			"id":         entry.ID,
-			"content":    entry.Content,
-			"created_at": entry.CreatedAt,
+			"id":              entry.ID,
+			"content":         entry.Content,
+			"created_at":      entry.CreatedAt,
+			"created_at_unix": transport.UnixMillis(entry.CreatedAt),
+			"edited_at":       entry.EditedAt,
 		})
 	}
 
@@ -175,10 +280,94 @@ func (h *Handler) handleHistory(client *Client, msg envelope) {
 	})
 }
 
+// writeLoop delivers queued messages and pings the peer every pingPeriod.
+// A failed write closes the connection, which ends the read loop in ServeWS
+// and takes the client out of its room; the queue is then drained so senders
+// never block on a dead client.
 func (c *Client) writeLoop() {
-	for message := range c.Send {
-		_ = c.Conn.WriteMessage(websocket.TextMessage, message)
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			if !ok {
+				return
+			}
+			_ = c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.abort()
+				return
+			}
+		case <-ticker.C:
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.abort()
+				return
+			}
+		}
+	}
+}
+
+// abort closes a broken connection and discards anything still queued until
+// ServeWS closes Send.
+func (c *Client) abort() {
+	_ = c.Conn.Close()
+	for range c.Send {
+	}
+}
+
+// trySend queues message without blocking. It reports false only when the
+// queue is full; messages for a closed or evicted client are dropped.
+func (c *Client) trySend(message []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.sendClosed || c.evicted {
+		return true
+	}
+	select {
+	case c.Send <- message:
+		c.laggingSince = time.Time{}
+		return true
+	default:
+		return false
+	}
+}
+
+// waitSend marks the client as lagging and waits for room in its queue until
+// slowClientWait has passed since it first fell behind. It reports false
+// exactly once, when the deadline is missed; the caller then evicts the client.
+func (c *Client) waitSend(message []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.sendClosed || c.evicted {
+		return true
 	}
+	now := time.Now()
+	if c.laggingSince.IsZero() {
+		c.laggingSince = now
+	}
+	timer := time.NewTimer(c.laggingSince.Add(slowClientWait).Sub(now))
+	defer timer.Stop()
+
+	select {
+	case c.Send <- message:
+		c.laggingSince = time.Time{}
+		return true
+	case <-timer.C:
+		c.evicted = true
+		return false
+	}
+}
+
+// closeSend closes Send once no broadcast is queueing to it.
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.sendClosed = true
+	close(c.Send)
 }
 
 // sendEnvelope marshals and sends a success event to the client.
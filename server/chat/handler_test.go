@@ -0,0 +1,143 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestSendEchoesClientMsgIDOnlyToSender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Hub: NewHub()}
+	router := gin.New()
+	router.GET("/ws/chat", h.ServeWS)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	dial := func(account string) *websocket.Conn {
+		t.Helper()
+		reg, err := s.Register(account, "password123", strings.Split(account, "@")[0])
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+			t.Fatalf("VerifyEmail: %v", err)
+		}
+		session, _, err := s.Login(account, "password123", "")
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/chat?token="+session.Token, nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+	read := func(conn *websocket.Conn) (envelope, map[string]any) {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var msg envelope
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		var data map[string]any
+		_ = json.Unmarshal(msg.Data, &data)
+		return msg, data
+	}
+	send := func(conn *websocket.Conn, frame string) {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	sender, other := dial("sender@example.com"), dial("other@example.com")
+	for _, conn := range []*websocket.Conn{sender, other} {
+		read(conn) // system.connected
+		send(conn, `{"v":1,"type":"chat.join","data":{"roomId":"general"}}`)
+		if msg, _ := read(conn); msg.Type != "chat.joined" {
+			t.Fatalf("join reply = %s", msg.Type)
+		}
+	}
+
+	// Bad frames get an error event and leave the connection usable.
+	for frame, code := range map[string]int{
+		strings.Repeat("x", maxFrameSize+1): 3007,
+		`not json`:                          3008,
+		`{"v":1,"type":"chat.shout"}`:       3001,
+		`{"v":1,"type":"chat.send","data":{"roomId":"general","content":"` + strings.Repeat("長", maxContentLength+1) + `"}}`: 3007,
+	} {
+		send(sender, frame)
+		if msg, _ := read(sender); msg.Type != "error" || msg.Error.Code != code {
+			t.Fatalf("frame %.20q: got %s %+v, want error %d", frame, msg.Type, msg.Error, code)
+		}
+	}
+
+	send(sender, `{"v":1,"type":"chat.send","requestId":"r1","data":{"roomId":"general","content":"hi","client_msg_id":"tmp-1"}}`)
+	echo, echoData := read(sender)
+	if echo.Type != "chat.message" || echo.RequestID != "r1" || echoData["client_msg_id"] != "tmp-1" || echoData["id"] == "" {
+		t.Fatalf("sender echo = %+v %v", echo, echoData)
+	}
+	msg, data := read(other)
+	if msg.Type != "chat.message" || data["id"] != echoData["id"] || msg.RequestID != "" {
+		t.Fatalf("broadcast = %+v %v", msg, data)
+	}
+	if _, ok := data["client_msg_id"]; ok {
+		t.Fatalf("broadcast leaked client_msg_id: %v", data)
+	}
+
+	// The sender got exactly one copy: the next event it sees is the pong.
+	send(sender, `{"v":1,"type":"system.ping"}`)
+	if msg, _ := read(sender); msg.Type != "system.pong" {
+		t.Fatalf("after echo got %s, want system.pong", msg.Type)
+	}
+}
+
+func TestEditMessageSharesSendLengthCap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Hub: NewHub(), Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.PATCH("/api/v1/chat/messages/:id", h.EditMessage)
+
+	reg, err := s.Register("sender@example.com", "password123", "sender")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("sender@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	message := s.AddMessage("general", reg.User.ID, "hello")
+
+	edit := func(content string) int {
+		body, _ := json.Marshal(map[string]string{"content": content})
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/chat/messages/"+message.ID, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := edit(strings.Repeat("長", maxContentLength+1)); code != http.StatusBadRequest {
+		t.Fatalf("oversized edit: got %d, want 400", code)
+	}
+	if code := edit(strings.Repeat("長", maxContentLength)); code != http.StatusOK {
+		t.Fatalf("edit at the cap: got %d, want 200", code)
+	}
+}
@@ -1,16 +1,122 @@
 package chat
 
-import "sync"
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+)
 
 type Hub struct {
-	mu    sync.Mutex
-	rooms map[string]map[*Client]bool
+	mu      sync.Mutex
+	rooms   map[string]map[*Client]bool
+	clients map[*Client]bool
+	streams map[string]map[chan []byte]bool // user ID -> per-user event streams
+	closed  bool
 }
 
+// userStreamBufferSize is how many events a user stream may have queued.
+// Events published while it is full are dropped for that stream.
+const userStreamBufferSize = 16
+
 // NewHub creates an in-memory chat hub that manages rooms and connected clients.
 func NewHub() *Hub {
 	return &Hub{
-		rooms: map[string]map[*Client]bool{},
+		rooms:   map[string]map[*Client]bool{},
+		clients: map[*Client]bool{},
+		streams: map[string]map[chan []byte]bool{},
+	}
+}
+
+// Register tracks a connected client so Close can reach it. It returns false
+// once the hub is closed; the caller should then drop the connection.
+func (h *Hub) Register(client *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.clients[client] = true
+	return true
+}
+
+// Unregister stops tracking a client.
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, client)
+}
+
+// SubscribeUser opens an event stream for one user; PublishUser delivers to
+// every stream the user has open. The returned func unsubscribes. The channel
+// is closed when the hub closes, so readers can treat that as shutdown.
+func (h *Hub) SubscribeUser(userID string) (<-chan []byte, func()) {
+	ch := make(chan []byte, userStreamBufferSize)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.streams[userID] == nil {
+		h.streams[userID] = map[chan []byte]bool{}
+	}
+	h.streams[userID][ch] = true
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		streams := h.streams[userID]
+		delete(streams, ch)
+		if len(streams) == 0 {
+			delete(h.streams, userID)
+		}
+	}
+}
+
+// PublishUser sends message to the user's open streams without blocking; a
+// stream whose buffer is full misses it.
+func (h *Hub) PublishUser(userID string, message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.streams[userID] {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+}
+
+// Close sends a "going away" close frame to every connected client and closes
+// their connections. Each read loop then exits and cleans up after itself.
+// User streams are closed as well. Calling Close again is harmless.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	for userID, streams := range h.streams {
+		for ch := range streams {
+			close(ch)
+		}
+		delete(h.streams, userID)
+	}
+	h.mu.Unlock()
+
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	deadline := time.Now().Add(time.Second)
+	for _, client := range clients {
+		_ = client.Conn.WriteControl(websocket.CloseMessage, frame, deadline)
+		_ = client.Conn.Close()
 	}
 }
 
@@ -22,7 +128,10 @@ func (h *Hub) Join(room string, client *Client) {
 	if h.rooms[room] == nil {
 		h.rooms[room] = map[*Client]bool{}
 	}
-	h.rooms[room][client] = true
+	if !h.rooms[room][client] {
+		h.rooms[room][client] = true
+		metrics.WSConnections.WithLabelValues(room).Inc()
+	}
 	client.Room = room
 }
 
@@ -31,35 +140,86 @@ func (h *Hub) Leave(client *Client) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	room := client.Room
-	if room == "" {
+	if client.Room == "" {
 		return
 	}
+	h.removeLocked(client.Room, client)
+	client.Room = ""
+}
+
+// removeLocked takes client out of room without touching client.Room, which
+// only the client's own goroutine writes. Callers hold h.mu.
+func (h *Hub) removeLocked(room string, client *Client) {
 	clients := h.rooms[room]
 	if clients == nil {
 		return
 	}
-	delete(clients, client)
+	if clients[client] {
+		delete(clients, client)
+		metrics.WSConnections.WithLabelValues(room).Dec()
+	}
 	if len(clients) == 0 {
 		delete(h.rooms, room)
+		metrics.WSConnections.DeleteLabelValues(room)
 	}
-	client.Room = ""
 }
 
-// Broadcast sends a message to all clients currently in the room.
+// Broadcast sends a message to all clients currently in the room. A client
+// whose queue is full gets until slowClientWait after it first fell behind to
+// make room; one that is still full is evicted instead of silently missing
+// messages.
 func (h *Hub) Broadcast(room string, message []byte) {
+	h.BroadcastExcept(room, nil, message)
+}
+
+// BroadcastExcept is Broadcast skipping except, which may be nil.
+func (h *Hub) BroadcastExcept(room string, except *Client, message []byte) {
 	h.mu.Lock()
 	roomClients := h.rooms[room]
 	clients := make([]*Client, 0, len(roomClients))
 	for client := range roomClients {
-		clients = append(clients, client)
+		if client != except {
+			clients = append(clients, client)
+		}
 	}
 	h.mu.Unlock()
 
+	lagging := make([]*Client, 0)
 	for _, client := range clients {
-		select {
-		case client.Send <- message:
-		default:
+		if !client.trySend(message) {
+			lagging = append(lagging, client)
 		}
 	}
+	if len(lagging) == 0 {
+		return
+	}
+
+	// Wait on the laggards in parallel so one slow reader cannot hold up
+	// delivery to the others for more than a single deadline.
+	var wg sync.WaitGroup
+	for _, client := range lagging {
+		wg.Add(1)
+		go func(client *Client) {
+			defer wg.Done()
+			if !client.waitSend(message) {
+				h.evict(room, client)
+			}
+		}(client)
+	}
+	wg.Wait()
+}
+
+// evict closes a client that could not keep up. It is removed from the room
+// at once; closing the connection then ends its read loop, which finishes
+// the cleanup.
+func (h *Hub) evict(room string, client *Client) {
+	metrics.WSSlowClientsClosed.Inc()
+
+	h.mu.Lock()
+	h.removeLocked(room, client)
+	h.mu.Unlock()
+
+	frame := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow")
+	_ = client.Conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+	_ = client.Conn.Close()
 }
@@ -0,0 +1,132 @@
+package chat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialPair returns the server side of a WebSocket connection and the client
+// connection talking to it.
+func dialPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+
+	serverConns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = peer.Close() })
+	conn := <-serverConns
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, peer
+}
+
+func TestBroadcastEvictsClientThatStaysFull(t *testing.T) {
+	hub := NewHub()
+	conn, peer := dialPair(t)
+
+	// No write loop drains this client, so its queue fills up and stays full.
+	slow := &Client{Conn: conn, Send: make(chan []byte, sendBufferSize)}
+	fast := &Client{Send: make(chan []byte, sendBufferSize+2)}
+	hub.Join("lobby", slow)
+	hub.Join("lobby", fast)
+
+	for i := 0; i < sendBufferSize; i++ {
+		hub.Broadcast("lobby", []byte("hi"))
+	}
+	started := time.Now()
+	hub.Broadcast("lobby", []byte("one too many"))
+	if waited := time.Since(started); waited < slowClientWait {
+		t.Fatalf("broadcast returned after %v, want at least %v", waited, slowClientWait)
+	}
+
+	if len(fast.Send) != sendBufferSize+1 {
+		t.Fatalf("fast client got %d messages, want %d", len(fast.Send), sendBufferSize+1)
+	}
+	hub.mu.Lock()
+	_, stillJoined := hub.rooms["lobby"][slow]
+	hub.mu.Unlock()
+	if stillJoined {
+		t.Fatalf("slow client still in room")
+	}
+
+	_ = peer.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := peer.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Fatalf("peer read error = %v, want close 1013", err)
+	}
+
+	// Later broadcasts skip the evicted client instead of waiting again.
+	started = time.Now()
+	hub.Broadcast("lobby", []byte("after"))
+	if waited := time.Since(started); waited >= slowClientWait {
+		t.Fatalf("broadcast after eviction waited %v", waited)
+	}
+}
+
+func TestBroadcastKeepsClientThatCatchesUp(t *testing.T) {
+	hub := NewHub()
+	client := &Client{Send: make(chan []byte, 1)}
+	hub.Join("lobby", client)
+
+	hub.Broadcast("lobby", []byte("first"))
+	go func() {
+		time.Sleep(slowClientWait / 4)
+		<-client.Send
+	}()
+	hub.Broadcast("lobby", []byte("second"))
+
+	if got := string(<-client.Send); got != "second" {
+		t.Fatalf("queued message = %q, want second", got)
+	}
+	if !client.laggingSince.IsZero() || client.evicted {
+		t.Fatalf("client still marked lagging after catching up")
+	}
+}
+
+func TestPublishUserReachesOnlyThatUser(t *testing.T) {
+	hub := NewHub()
+	alice, unsubscribe := hub.SubscribeUser("u_alice")
+	bob, _ := hub.SubscribeUser("u_bob")
+
+	hub.PublishUser("u_alice", []byte("hello"))
+	if got := string(<-alice); got != "hello" {
+		t.Fatalf("alice got %q, want hello", got)
+	}
+	if len(bob) != 0 {
+		t.Fatalf("bob got %d messages, want none", len(bob))
+	}
+
+	// A full stream drops events instead of blocking the publisher.
+	for i := 0; i < userStreamBufferSize+1; i++ {
+		hub.PublishUser("u_alice", []byte("x"))
+	}
+	if len(alice) != userStreamBufferSize {
+		t.Fatalf("alice queued %d, want %d", len(alice), userStreamBufferSize)
+	}
+
+	unsubscribe()
+	hub.Close()
+	if _, open := <-bob; open {
+		t.Fatalf("bob's stream still open after Close")
+	}
+	late, _ := hub.SubscribeUser("u_carol")
+	if _, open := <-late; open {
+		t.Fatalf("subscribing after Close returned an open stream")
+	}
+}
@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// maxEditBody caps the edit-message request body.
+const maxEditBody = 16 << 10
+
+// contentTooLong reports whether content exceeds maxContentLength. chat.send
+// and edits share it so an edit can't grow a message past what sending allows.
+func contentTooLong(content string) bool {
+	return utf8.RuneCountInString(content) > maxContentLength
+}
+
+// EditMessage handles PATCH /api/v1/chat/messages/{message_id}. Only the
+// sender may edit, within store.MessageEditWindow of sending; the room is
+// sent a chat.edit event.
+func (h *Handler) EditMessage(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if !transport.BindJSON(c, &req, maxEditBody) {
+		return
+	}
+	if contentTooLong(req.Content) {
+		transport.Error(c, http.StatusBadRequest, transport.CodeInvalidInput, "message too long")
+		return
+	}
+
+	message, err := h.Store.EditMessage(strings.TrimSpace(c.Param("id")), user.ID, req.Content)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	payload := map[string]any{
+		"id":              message.ID,
+		"roomId":          message.RoomID,
+		"content":         message.Content,
+		"created_at":      message.CreatedAt,
+		"created_at_unix": transport.UnixMillis(message.CreatedAt),
+		"edited_at":       message.EditedAt,
+	}
+	h.broadcast(message.RoomID, "chat.edit", payload)
+	c.JSON(http.StatusOK, payload)
+}
+
+// DeleteMessage handles DELETE /api/v1/chat/messages/{message_id}. Only the
+// sender may delete; the message disappears from history and the room is
+// sent a chat.delete event so clients can show a tombstone.
+func (h *Handler) DeleteMessage(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.Store.DeleteMessage(strings.TrimSpace(c.Param("id")), user.ID)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	h.broadcast(message.RoomID, "chat.delete", map[string]any{
+		"id":         message.ID,
+		"roomId":     message.RoomID,
+		"deleted_at": message.DeletedAt,
+	})
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// broadcast sends a server-initiated event to everyone in roomID.
+func (h *Handler) broadcast(roomID, eventType string, data any) {
+	h.broadcastExcept(roomID, nil, eventType, data)
+}
+
+// broadcastExcept is broadcast skipping one client, which may be nil.
+func (h *Handler) broadcastExcept(roomID string, except *Client, eventType string, data any) {
+	encoded, err := marshalEnvelope(1, eventType, "", data, nil)
+	if err != nil {
+		return
+	}
+	h.Hub.BroadcastExcept(roomID, except, encoded)
+}
+
+func writeMessageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrInvalidInput):
+		transport.Error(c, http.StatusBadRequest, transport.CodeInvalidInput, "content is required")
+	case errors.Is(err, store.ErrNotFound):
+		transport.Error(c, http.StatusNotFound, transport.CodeInvalidInput, "message not found")
+	case errors.Is(err, store.ErrForbidden):
+		transport.Error(c, http.StatusForbidden, transport.CodeForbidden, "forbidden")
+	case errors.Is(err, store.ErrEditWindowExpired):
+		transport.Error(c, http.StatusForbidden, transport.CodeForbidden, "edit window expired")
+	default:
+		transport.Error(c, http.StatusInternalServerError, transport.CodeServerError, "server error")
+	}
+}
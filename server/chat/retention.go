@@ -0,0 +1,47 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// DefaultPruneInterval is how often RunRetention prunes when no interval is
+// configured.
+const DefaultPruneInterval = time.Hour
+
+// PruneHistory caps every room's history at its newest keepLast messages
+// and returns how many messages it removed in total.
+func PruneHistory(st store.API, keepLast int) int {
+	total := 0
+	for _, roomID := range st.MessageRooms() {
+		pruned, err := st.PruneMessages(roomID, keepLast)
+		if err != nil {
+			log.Printf("chat retention: room %s: %v", roomID, err)
+			continue
+		}
+		if pruned > 0 {
+			log.Printf("chat retention: pruned %d messages from room %s", pruned, roomID)
+			total += pruned
+		}
+	}
+	return total
+}
+
+// RunRetention calls PruneHistory every interval until ctx is cancelled.
+func RunRetention(ctx context.Context, st store.API, keepLast int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// PruneHistory logs each room it trims.
+			PruneHistory(st, keepLast)
+		}
+	}
+}
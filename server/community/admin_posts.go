@@ -0,0 +1,77 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+const maxAdminPostPageSize = 100
+
+type adminPostItem struct {
+	ID            string      `json:"id"`
+	BoardID       string      `json:"board_id"`
+	Title         string      `json:"title"`
+	Content       string      `json:"content"`
+	Tags          []string    `json:"tags"`
+	Author        userSummary `json:"author"`
+	CreatedAt     string      `json:"created_at"`
+	CreatedAtUnix int64       `json:"created_at_unix"`
+	DeletedAt     *string     `json:"deleted_at"`
+	DeletedReason string      `json:"deleted_reason,omitempty"`
+}
+
+// AdminListPosts handles GET /api/v1/admin/posts?deleted=true&board_id=&author_id=&page=1&page_size=20.
+// Unlike the public feed it lists removed posts too, so moderators can audit
+// an author's whole history; deleted=true narrows it to removed posts only.
+func (h *Handler) AdminListPosts(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	page := parsePositiveInt(c.Query("page"), 1)
+	pageSize := min(parsePositiveInt(c.Query("page_size"), 20), maxAdminPostPageSize)
+
+	posts, total := h.Store.AdminPosts(store.AdminPostFilter{
+		BoardID:     c.Query("board_id"),
+		AuthorID:    c.Query("author_id"),
+		DeletedOnly: strings.EqualFold(strings.TrimSpace(c.Query("deleted")), "true"),
+		Offset:      (page - 1) * pageSize,
+		Limit:       pageSize,
+	})
+
+	authorIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.AuthorID)
+	}
+	authors := h.Store.GetUsers(authorIDs)
+
+	items := make([]adminPostItem, 0, len(posts))
+	for _, post := range posts {
+		author, ok := authors[post.AuthorID]
+		var deletedAt *string
+		if post.DeletedAt != "" {
+			value := post.DeletedAt
+			deletedAt = &value
+		}
+		items = append(items, adminPostItem{
+			ID:            post.ID,
+			BoardID:       post.BoardID,
+			Title:         post.Title,
+			Content:       post.Content,
+			Tags:          post.Tags,
+			Author:        authorSummary(author, ok),
+			CreatedAt:     post.CreatedAt,
+			CreatedAtUnix: transport.UnixMillis(post.CreatedAt),
+			DeletedAt:     deletedAt,
+			DeletedReason: post.DeletedReason,
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"items": items,
+		"total": total,
+	})
+}
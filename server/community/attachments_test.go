@@ -0,0 +1,65 @@
+package community
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// loginTestUser registers, verifies and logs in a user, returning it and its token.
+func loginTestUser(t *testing.T, s *store.Store, account, nickname string) (store.User, string) {
+	t.Helper()
+	reg, err := s.Register(account, "password123", nickname)
+	if err != nil {
+		t.Fatalf("Register(%s): %v", account, err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail(%s): %v", account, err)
+	}
+	session, user, err := s.Login(account, "password123", "")
+	if err != nil {
+		t.Fatalf("Login(%s): %v", account, err)
+	}
+	return user, session.Token
+}
+
+func TestCreatePostRejectsForeignAttachment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+
+	owner, _ := loginTestUser(t, s, "owner@example.com", "owner")
+	_, token := loginTestUser(t, s, "other@example.com", "other")
+	admin, adminToken := loginTestUser(t, s, "admin@example.com", "admin")
+	if err := s.SetAdmin(admin.ID, true); err != nil {
+		t.Fatalf("SetAdmin: %v", err)
+	}
+	foreign := s.SaveFile(owner.ID, "private.png", "key", "path", 0, 0)
+
+	body := `{"board_id":"` + s.Boards()[0].ID + `","title":"hi","content":"x","attachments":["` + foreign.ID + `"]}`
+	post := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(token)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "attachment not owned") {
+		t.Fatalf("foreign attachment: got %d %s, want 400 attachment not owned", rec.Code, rec.Body.String())
+	}
+
+	if rec := post(adminToken); rec.Code != http.StatusOK {
+		t.Fatalf("admin attachment: got %d %s, want 200", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,106 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestMissingAuthorUsesDeletedPlaceholder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/posts", h.ListPosts)
+	router.GET("/api/v1/posts/:id", h.GetPost)
+	router.GET("/api/v1/posts/:id/comments", h.ListComments)
+	post := s.CreatePost(s.Boards()[0].ID, "u_gone", "title", "content", "", "", nil, nil)
+	s.CreateComment(post.ID, "u_gone", "reply", "", "", nil, nil)
+
+	get := func(path string, out any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: got %d %s", path, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+	}
+	want := userSummary{ID: store.DeletedUserID, Nickname: store.DeletedUserNickname, Level: deletedUserSummary.Level, LevelTitle: deletedUserSummary.LevelTitle}
+
+	var list struct {
+		Items []postItem `json:"items"`
+	}
+	get("/api/v1/posts", &list)
+	if len(list.Items) != 1 || list.Items[0].Author != want {
+		t.Fatalf("list author = %+v, want %+v", list.Items, want)
+	}
+
+	var detail struct {
+		Author userSummary `json:"author"`
+	}
+	get("/api/v1/posts/"+post.ID, &detail)
+	if detail.Author != want {
+		t.Fatalf("detail author = %+v, want %+v", detail.Author, want)
+	}
+
+	var comments []commentItem
+	get("/api/v1/posts/"+post.ID+"/comments", &comments)
+	if len(comments) != 1 || comments[0].Author != want {
+		t.Fatalf("comment author = %+v, want %+v", comments, want)
+	}
+}
+
+func TestCreateResponsesIncludeAuthor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	router.POST("/api/v1/posts/:id/comments", h.CreateComment)
+	user, token := loginTestUser(t, s, "writer@example.com", "writer")
+
+	create := func(path, body string) (string, userSummary) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: got %d %s", path, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			ID     string      `json:"id"`
+			Author userSummary `json:"author"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("POST %s: decode: %v", path, err)
+		}
+		return resp.ID, resp.Author
+	}
+	// The summary matches what the feed shows for the author afterwards,
+	// including exp the creation just earned.
+	wantAuthor := func() userSummary {
+		current, ok := s.GetUser(user.ID)
+		return authorSummary(current, ok)
+	}
+
+	postID, author := create("/api/v1/posts", `{"board_id":"`+s.Boards()[0].ID+`","title":"t","content":"hello"}`)
+	if want := wantAuthor(); author != want || author.Nickname != "writer" {
+		t.Fatalf("post author = %+v, want %+v", author, want)
+	}
+	_, author = create("/api/v1/posts/"+postID+"/comments", `{"content":"reply"}`)
+	if want := wantAuthor(); author != want {
+		t.Fatalf("comment author = %+v, want %+v", author, want)
+	}
+}
@@ -0,0 +1,116 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// maxBoardBody caps board create/update bodies: an ID, a name and a short description.
+const maxBoardBody = 4 << 10
+
+// requireAdmin resolves the caller and writes 401/403 unless they are an admin.
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return false
+	}
+	if !h.isAdmin(user) {
+		writeError(c, http.StatusForbidden, 1002, "forbidden")
+		return false
+	}
+	return true
+}
+
+// AdminCreateBoard handles POST /api/v1/admin/boards. New boards are listed
+// after the existing ones.
+func (h *Handler) AdminCreateBoard(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	var req struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if !transport.BindJSON(c, &req, maxBoardBody) {
+		return
+	}
+
+	board, err := h.Store.CreateBoard(req.ID, req.Name, req.Description)
+	if err != nil {
+		writeBoardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// AdminUpdateBoard handles PATCH /api/v1/admin/boards/{id}. Omitted fields
+// keep their current value.
+func (h *Handler) AdminUpdateBoard(c *gin.Context) {
+	boardID := strings.TrimSpace(c.Param("id"))
+	if !h.requireAdmin(c) {
+		return
+	}
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if !transport.BindJSON(c, &req, maxBoardBody) {
+		return
+	}
+
+	board, ok := h.Store.GetBoard(boardID)
+	if !ok {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+	if req.Name != nil {
+		board.Name = *req.Name
+	}
+	if req.Description != nil {
+		board.Description = *req.Description
+	}
+	updated, err := h.Store.UpdateBoard(board.ID, board.Name, board.Description)
+	if err != nil {
+		writeBoardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// AdminDeleteBoard handles DELETE /api/v1/admin/boards/{id}. A board that
+// still has posts is only removed with ?force=1; its posts stay reachable by
+// ID but the board disappears from listings and takes no new posts.
+func (h *Handler) AdminDeleteBoard(c *gin.Context) {
+	boardID := strings.TrimSpace(c.Param("id"))
+	if !h.requireAdmin(c) {
+		return
+	}
+	force := c.Query("force") == "1" || c.Query("force") == "true"
+
+	if err := h.Store.DeleteBoard(boardID, force); err != nil {
+		writeBoardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func writeBoardError(c *gin.Context, err error) {
+	switch err {
+	case store.ErrInvalidInput:
+		writeError(c, http.StatusBadRequest, 2001, "invalid board")
+	case store.ErrNotFound:
+		writeError(c, http.StatusNotFound, 2001, "not found")
+	case store.ErrBoardExists:
+		writeError(c, http.StatusConflict, 2001, "board already exists")
+	case store.ErrBoardNotEmpty:
+		writeError(c, http.StatusConflict, 2001, "board has posts")
+	default:
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+	}
+}
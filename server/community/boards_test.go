@@ -0,0 +1,76 @@
+package community
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestAdminBoardLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/admin/boards", h.AdminCreateBoard)
+	router.PATCH("/api/v1/admin/boards/:id", h.AdminUpdateBoard)
+	router.DELETE("/api/v1/admin/boards/:id", h.AdminDeleteBoard)
+	admin, adminToken := loginTestUser(t, s, "admin@example.com", "admin")
+	if err := s.SetAdmin(admin.ID, true); err != nil {
+		t.Fatalf("SetAdmin: %v", err)
+	}
+	_, userToken := loginTestUser(t, s, "user@example.com", "user")
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	create := `{"id":"lost-and-found","name":"失物招领","description":"找东西"}`
+	if rec := do(http.MethodPost, "/api/v1/admin/boards", userToken, create); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-admin create: got %d, want 403", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/v1/admin/boards", adminToken, `{"id":"Bad ID","name":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad id: got %d, want 400", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/v1/admin/boards", adminToken, create); rec.Code != http.StatusOK {
+		t.Fatalf("create: got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/v1/admin/boards", adminToken, create); rec.Code != http.StatusConflict {
+		t.Fatalf("duplicate create: got %d, want 409", rec.Code)
+	}
+	boards := s.Boards()
+	if last := boards[len(boards)-1]; last.ID != "lost-and-found" {
+		t.Fatalf("new board listed at %+v, want last", last)
+	}
+
+	if rec := do(http.MethodPatch, "/api/v1/admin/boards/lost-and-found", adminToken, `{"name":"招领"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update: got %d %s", rec.Code, rec.Body.String())
+	}
+	if board, _ := s.GetBoard("lost-and-found"); board.Name != "招领" || board.Description != "找东西" {
+		t.Fatalf("board after update = %+v", board)
+	}
+
+	s.CreatePost("lost-and-found", admin.ID, "title", "content", "", "", nil, nil)
+	if rec := do(http.MethodDelete, "/api/v1/admin/boards/lost-and-found", adminToken, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("delete non-empty: got %d, want 409", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/v1/admin/boards/lost-and-found?force=1", adminToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("forced delete: got %d %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := s.GetBoard("lost-and-found"); ok {
+		t.Fatalf("deleted board still resolvable")
+	}
+	if rec := do(http.MethodPost, "/api/v1/admin/boards", adminToken, create); rec.Code != http.StatusConflict {
+		t.Fatalf("reusing a deleted board id: got %d, want 409", rec.Code)
+	}
+}
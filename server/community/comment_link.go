@@ -0,0 +1,63 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commentLink is the GET /api/v1/comments/{id} response: the comment plus
+// where to find it.
+type commentLink struct {
+	Comment   commentItem `json:"comment"`
+	PostID    string      `json:"post_id"`
+	PostTitle string      `json:"post_title"`
+	// Offset is the comment's index in the post's comment list as the viewer
+	// sees it; Page is the 1-based page holding it at PageSize per page.
+	Offset   int `json:"offset"`
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// GetComment handles GET /api/v1/comments/{comment_id}?page_size=20, the
+// permalink lookup for clients that only know a comment ID, e.g. from a
+// notification. Deleted comments and comments on deleted posts are 404.
+func (h *Handler) GetComment(c *gin.Context) {
+	commentID := strings.TrimSpace(c.Param("id"))
+	comment, ok := h.Store.GetCommentByID(commentID)
+	if !ok {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+	post, ok := h.Store.GetPost(comment.PostID)
+	if !ok {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+	pageSize := parsePositiveInt(c.Query("page_size"), 20)
+
+	// Count the comments listed before this one, skipping the ones
+	// ListComments hides from this viewer.
+	viewerID := h.viewerID(c)
+	blocked := h.blockedSet(viewerID)
+	offset := 0
+	for _, other := range h.Store.Comments(post.ID) {
+		if other.ID == comment.ID {
+			break
+		}
+		if _, ok := blocked[other.AuthorID]; !ok {
+			offset++
+		}
+	}
+
+	author, found := h.Store.GetUser(comment.AuthorID)
+	c.JSON(http.StatusOK, commentLink{
+		Comment:   h.commentItem(comment, authorSummary(author, found), viewerID),
+		PostID:    post.ID,
+		PostTitle: post.Title,
+		Offset:    offset,
+		Page:      offset/pageSize + 1,
+		PageSize:  pageSize,
+	})
+}
@@ -0,0 +1,63 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestGetCommentResolvesPostAndPosition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/comments/:id", h.GetComment)
+
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, "u_author", "the post", "c", "", "", nil, nil)
+	first := s.CreateComment(post.ID, "u_a", "first", "", "", nil, nil)
+	removed := s.CreateComment(post.ID, "u_b", "removed", "", "", nil, nil)
+	s.CreateComment(post.ID, "u_c", "newest", "", "", nil, nil)
+	if err := s.SoftDeleteComment(post.ID, removed.ID, "u_b", false); err != nil {
+		t.Fatalf("SoftDeleteComment: %v", err)
+	}
+	other := s.CreatePost(boardID, "u_author", "gone", "c", "", "", nil, nil)
+	orphan := s.CreateComment(other.ID, "u_a", "orphan", "", "", nil, nil)
+	if err := s.SoftDeletePost(other.ID, "u_author", false); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Comments list newest first, so the oldest of the two live ones is second.
+	rec := get("/api/v1/comments/" + first.ID + "?page_size=1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var link commentLink
+	if err := json.Unmarshal(rec.Body.Bytes(), &link); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if link.Comment.ID != first.ID || link.PostID != post.ID || link.PostTitle != "the post" {
+		t.Fatalf("link = %+v", link)
+	}
+	if link.Offset != 1 || link.Page != 2 || link.PageSize != 1 {
+		t.Fatalf("offset/page/page_size = %d/%d/%d, want 1/2/1", link.Offset, link.Page, link.PageSize)
+	}
+
+	for _, id := range []string{removed.ID, orphan.ID, "c_missing"} {
+		if rec := get("/api/v1/comments/" + id); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status = %d, want 404", id, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package community
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// contentSchema is the rich-text editor schema accepted in content_json:
+// each allowed node type maps to the attributes kept on it. Nodes of any
+// other type are dropped together with their children, and unlisted
+// attributes are removed.
+var contentSchema = map[string][]string{
+	"doc":            nil,
+	"paragraph":      nil,
+	"text":           nil,
+	"heading":        {"level"},
+	"blockquote":     nil,
+	"bulletList":     nil,
+	"orderedList":    {"start"},
+	"listItem":       nil,
+	"codeBlock":      {"language"},
+	"hardBreak":      nil,
+	"horizontalRule": nil,
+	"image":          {"src", "alt", "title", "width", "height"},
+}
+
+// contentMarkSchema lists the allowed text marks and their attributes.
+var contentMarkSchema = map[string][]string{
+	"bold":   nil,
+	"italic": nil,
+	"strike": nil,
+	"code":   nil,
+	"link":   {"href"},
+}
+
+// allowedURLSchemes are the schemes a link href may use. Relative URLs are
+// also accepted; image sources are further limited to http(s) and relative.
+var allowedURLSchemes = []string{"http", "https", "mailto"}
+
+// maxContentDepth bounds node nesting in content_json.
+const maxContentDepth = 32
+
+var errInvalidContentJSON = errors.New("invalid content_json")
+
+// contentJSONFromRequest trims and sanitizes a request's content_json. An
+// absent or null value yields "".
+func contentJSONFromRequest(raw json.RawMessage) (string, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return "", nil
+	}
+	return sanitizeContentJSON(trimmed)
+}
+
+// sanitizeContentJSON parses raw as an editor document and returns it with
+// everything outside contentSchema removed: unknown nodes and marks, extra
+// attributes, links with a disallowed href and images with a disallowed src.
+// It fails when raw is not a JSON object of type "doc" or nests too deeply.
+func sanitizeContentJSON(raw string) (string, error) {
+	var root map[string]any
+	if err := json.Unmarshal([]byte(raw), &root); err != nil {
+		return "", errInvalidContentJSON
+	}
+	if root["type"] != "doc" {
+		return "", errInvalidContentJSON
+	}
+	doc, ok := sanitizeNode(root, 0)
+	if !ok {
+		return "", errInvalidContentJSON
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", errInvalidContentJSON
+	}
+	return string(out), nil
+}
+
+// sanitizeNode returns the cleaned node, or ok=false if it must be dropped.
+func sanitizeNode(node map[string]any, depth int) (map[string]any, bool) {
+	if depth > maxContentDepth {
+		return nil, false
+	}
+	nodeType, _ := node["type"].(string)
+	allowedAttrs, known := contentSchema[nodeType]
+	if !known {
+		return nil, false
+	}
+
+	out := map[string]any{"type": nodeType}
+	if nodeType == "text" {
+		text, ok := node["text"].(string)
+		if !ok || text == "" {
+			return nil, false
+		}
+		out["text"] = text
+		if marks := sanitizeMarks(node["marks"]); len(marks) > 0 {
+			out["marks"] = marks
+		}
+		return out, true
+	}
+
+	attrs := sanitizeAttrs(node["attrs"], allowedAttrs)
+	if nodeType == "image" {
+		src, _ := attrs["src"].(string)
+		if !safeURL(src, false) {
+			return nil, false
+		}
+	}
+	if len(attrs) > 0 {
+		out["attrs"] = attrs
+	}
+
+	if children, ok := node["content"].([]any); ok {
+		content := make([]any, 0, len(children))
+		for _, child := range children {
+			childNode, ok := child.(map[string]any)
+			if !ok {
+				continue
+			}
+			if cleaned, ok := sanitizeNode(childNode, depth+1); ok {
+				content = append(content, cleaned)
+			}
+		}
+		if len(content) > 0 {
+			out["content"] = content
+		}
+	}
+	return out, true
+}
+
+// sanitizeMarks keeps known marks; a link whose href is not safe is dropped,
+// leaving its text as plain text.
+func sanitizeMarks(raw any) []any {
+	marks, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]any, 0, len(marks))
+	for _, item := range marks {
+		mark, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		markType, _ := mark["type"].(string)
+		allowedAttrs, known := contentMarkSchema[markType]
+		if !known {
+			continue
+		}
+		cleaned := map[string]any{"type": markType}
+		attrs := sanitizeAttrs(mark["attrs"], allowedAttrs)
+		if markType == "link" {
+			href, _ := attrs["href"].(string)
+			if !safeURL(href, true) {
+				continue
+			}
+		}
+		if len(attrs) > 0 {
+			cleaned["attrs"] = attrs
+		}
+		out = append(out, cleaned)
+	}
+	return out
+}
+
+// sanitizeAttrs keeps the allowed attributes whose values are scalars.
+func sanitizeAttrs(raw any, allowed []string) map[string]any {
+	attrs, ok := raw.(map[string]any)
+	if !ok || len(allowed) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(allowed))
+	for _, name := range allowed {
+		switch value := attrs[name].(type) {
+		case string, float64, bool:
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// safeURL reports whether raw is a relative URL or uses an allowed scheme.
+// mailto is only accepted for links.
+func safeURL(raw string, isLink bool) bool {
+	if raw == "" || raw != strings.TrimSpace(raw) {
+		return false
+	}
+	for _, r := range raw {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme == "" {
+		return true
+	}
+	for _, scheme := range allowedURLSchemes {
+		if parsed.Scheme == scheme {
+			return isLink || scheme != "mailto"
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestSanitizeContentJSON(t *testing.T) {
+	raw := `{"type":"doc","content":[
+		{"type":"paragraph","attrs":{"onclick":"alert(1)"},"content":[
+			{"type":"text","text":"safe","marks":[{"type":"link","attrs":{"href":"https://example.com","onmouseover":"x"}}]},
+			{"type":"text","text":"js","marks":[{"type":"bold"},{"type":"link","attrs":{"href":"javascript:alert(1)"}}]},
+			{"type":"text","text":"tab","marks":[{"type":"link","attrs":{"href":"java\tscript:alert(1)"}}]}
+		]},
+		{"type":"script","content":[{"type":"text","text":"<script>alert(1)</script>"}]},
+		{"type":"image","attrs":{"src":"data:text/html,<script>alert(1)</script>"}},
+		{"type":"image","attrs":{"src":"/files/f_1","alt":"ok","uploadId":"u1"}},
+		{"type":"heading","attrs":{"level":2,"style":{"x":1}},"content":[{"type":"text","text":"<script>alert(1)</script>"}]}
+	]}`
+
+	got, err := sanitizeContentJSON(raw)
+	if err != nil {
+		t.Fatalf("sanitizeContentJSON: %v", err)
+	}
+	want := `{"content":[` +
+		`{"content":[` +
+		`{"marks":[{"attrs":{"href":"https://example.com"},"type":"link"}],"text":"safe","type":"text"},` +
+		`{"marks":[{"type":"bold"}],"text":"js","type":"text"},` +
+		`{"text":"tab","type":"text"}` +
+		`],"type":"paragraph"},` +
+		`{"attrs":{"alt":"ok","src":"/files/f_1"},"type":"image"},` +
+		`{"attrs":{"level":2},"content":[{"text":"\u003cscript\u003ealert(1)\u003c/script\u003e","type":"text"}],"type":"heading"}` +
+		`],"type":"doc"}`
+	if got != want {
+		t.Fatalf("sanitized content:\n got %s\nwant %s", got, want)
+	}
+
+	for _, node := range []string{"script", "iframe"} {
+		if _, ok := contentSchema[node]; ok {
+			t.Fatalf("contentSchema allows %q", node)
+		}
+	}
+	for _, bad := range []string{`[]`, `{"type":"paragraph"}`, `not json`} {
+		if _, err := sanitizeContentJSON(bad); err == nil {
+			t.Fatalf("sanitizeContentJSON(%s): want error", bad)
+		}
+	}
+}
+
+func TestCreatePostSanitizesContentJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	_, token := loginTestUser(t, s, "author@example.com", "author")
+
+	body := `{"board_id":"` + s.Boards()[0].ID + `","title":"hi","content":"x","content_json":` +
+		`{"type":"doc","content":[{"type":"script","content":[{"type":"text","text":"alert(1)"}]},` +
+		`{"type":"paragraph","content":[{"type":"text","text":"x","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}]}]}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create post: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	post, ok := s.GetPost(resp.ID)
+	if !ok {
+		t.Fatalf("post %s not stored", resp.ID)
+	}
+	want := `{"content":[{"content":[{"text":"x","type":"text"}],"type":"paragraph"}],"type":"doc"}`
+	if post.ContentJSON != want {
+		t.Fatalf("stored content_json = %s, want %s", post.ContentJSON, want)
+	}
+}
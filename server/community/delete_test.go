@@ -0,0 +1,51 @@
+package community
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestDeleteIsIdempotent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.DELETE("/api/v1/posts/:id", h.DeletePost)
+	router.DELETE("/api/v1/posts/:id/comments/:commentId", h.DeleteComment)
+
+	author, token := loginTestUser(t, s, "author@example.com", "author")
+	_, otherToken := loginTestUser(t, s, "other@example.com", "other")
+	post := s.CreatePost(s.Boards()[0].ID, author.ID, "t", "c", "", "", nil, nil)
+	comment := s.CreateComment(post.ID, author.ID, "reply", "", "", nil, nil)
+
+	del := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, path := range []string{
+		"/api/v1/posts/" + post.ID + "/comments/" + comment.ID,
+		"/api/v1/posts/" + post.ID,
+	} {
+		for i := 0; i < 2; i++ {
+			if code := del(path, token); code != http.StatusNoContent {
+				t.Fatalf("DELETE %s attempt %d: got %d, want 204", path, i+1, code)
+			}
+		}
+		if code := del(path, otherToken); code != http.StatusForbidden {
+			t.Fatalf("DELETE %s by another user: got %d, want 403", path, code)
+		}
+	}
+	if code := del("/api/v1/posts/p_missing", token); code != http.StatusNotFound {
+		t.Fatalf("DELETE missing post: got %d, want 404", code)
+	}
+}
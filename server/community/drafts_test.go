@@ -0,0 +1,69 @@
+package community
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestDraftVisibilityAndPublish(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/posts/:id", h.GetPost)
+	router.POST("/api/v1/posts/:id/publish", h.PublishPost)
+	router.GET("/api/v1/users/me/drafts", h.ListDrafts)
+	author, authorToken := loginTestUser(t, s, "author@example.com", "author")
+	_, otherToken := loginTestUser(t, s, "other@example.com", "other")
+	draft := s.CreatePost(s.Boards()[0].ID, author.ID, "draft", "wip", "", store.PostStatusDraft, nil, nil)
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	postPath := "/api/v1/posts/" + draft.ID
+
+	if posts, _, _ := s.Posts(store.PostListFilter{}); len(posts) != 0 {
+		t.Fatal("draft should not appear in the feed")
+	}
+	if rec := send(http.MethodGet, postPath, authorToken); rec.Code != http.StatusOK {
+		t.Fatalf("author GetPost: got %d, want 200", rec.Code)
+	}
+	if rec := send(http.MethodGet, postPath, otherToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("other GetPost: got %d, want 404", rec.Code)
+	}
+	if rec := send(http.MethodGet, postPath, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("anonymous GetPost: got %d, want 404", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/api/v1/users/me/drafts", authorToken); !strings.Contains(rec.Body.String(), draft.ID) {
+		t.Fatalf("drafts list: got %s, want %s", rec.Body.String(), draft.ID)
+	}
+
+	if rec := send(http.MethodPost, postPath+"/publish", otherToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("other publish: got %d, want 404", rec.Code)
+	}
+	if rec := send(http.MethodPost, postPath+"/publish", authorToken); rec.Code != http.StatusOK {
+		t.Fatalf("publish: got %d %s, want 200", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPost, postPath+"/publish", authorToken); rec.Code != http.StatusConflict {
+		t.Fatalf("republish: got %d, want 409", rec.Code)
+	}
+	if rec := send(http.MethodGet, postPath, otherToken); rec.Code != http.StatusOK {
+		t.Fatalf("other GetPost after publish: got %d, want 200", rec.Code)
+	}
+	if drafts := s.DraftPosts(author.ID); len(drafts) != 0 {
+		t.Fatalf("drafts after publish = %d, want 0", len(drafts))
+	}
+}
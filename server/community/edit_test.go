@@ -0,0 +1,55 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestUpdatePostRejectsStaleVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.PATCH("/api/v1/posts/:id", h.UpdatePost)
+	author, token := loginTestUser(t, s, "author@example.com", "author")
+	_, otherToken := loginTestUser(t, s, "other@example.com", "other")
+	post := s.CreatePost(s.Boards()[0].ID, author.ID, "title", "content", "", "", nil, nil)
+
+	patch := func(token string, body map[string]any) *httptest.ResponseRecorder {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/posts/"+post.ID, strings.NewReader(string(encoded)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := patch(token, map[string]any{"title": "no version"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing version: got %d, want 400", rec.Code)
+	}
+	if rec := patch(otherToken, map[string]any{"title": "hijack", "version": 1}); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-author edit: got %d, want 403", rec.Code)
+	}
+	rec := patch(token, map[string]any{"title": "first edit", "version": 1})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"version":2`) {
+		t.Fatalf("first edit: got %d %s, want 200 with version 2", rec.Code, rec.Body.String())
+	}
+	if rec := patch(token, map[string]any{"title": "stale edit", "version": 1}); rec.Code != http.StatusConflict {
+		t.Fatalf("stale edit: got %d, want 409", rec.Code)
+	}
+	if got, _ := s.GetPost(post.ID); got.Title != "first edit" || got.Content != "content" {
+		t.Fatalf("post after edits = %q / %q, want first edit / content", got.Title, got.Content)
+	}
+}
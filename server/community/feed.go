@@ -0,0 +1,69 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// SubscribeBoard handles POST /api/v1/boards/{id}/subscribe.
+func (h *Handler) SubscribeBoard(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	boardID := strings.TrimSpace(c.Param("id"))
+	if err := h.Store.SubscribeBoard(user.ID, boardID); err != nil {
+		writeBoardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]bool{"subscribed": true})
+}
+
+// UnsubscribeBoard handles DELETE /api/v1/boards/{id}/subscribe. Boards the
+// user never subscribed to are not an error.
+func (h *Handler) UnsubscribeBoard(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	boardID := strings.TrimSpace(c.Param("id"))
+	if err := h.Store.UnsubscribeBoard(user.ID, boardID); err != nil {
+		writeBoardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]bool{"subscribed": false})
+}
+
+// Feed handles GET /api/v1/feed?source=&page=1&page_size=20, the signed-in
+// user's feed of posts by people they follow and in boards they subscribe
+// to, newest first. source=following or source=boards narrows it to one of
+// the two.
+func (h *Handler) Feed(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	source := strings.ToLower(strings.TrimSpace(c.Query("source")))
+	switch source {
+	case store.FeedAll, store.FeedFollowing, store.FeedBoards:
+	default:
+		writeError(c, http.StatusBadRequest, 2001, "invalid source")
+		return
+	}
+	page := parsePositiveInt(c.Query("page"), 1)
+	pageSize := min(parsePositiveInt(c.Query("page_size"), 20), maxFeedLimit)
+
+	posts, total := h.Store.FeedPosts(user.ID, store.FeedFilter{
+		Source: source,
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	c.JSON(http.StatusOK, map[string]any{
+		"items": h.postItems(posts, user.ID, nil),
+		"total": total,
+	})
+}
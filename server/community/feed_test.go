@@ -0,0 +1,93 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestBoardSubscriptionFeedsPersonalizedFeed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/boards", h.GetBoards)
+	router.POST("/api/v1/boards/:id/subscribe", h.SubscribeBoard)
+	router.DELETE("/api/v1/boards/:id/subscribe", h.UnsubscribeBoard)
+	router.GET("/api/v1/feed", h.Feed)
+
+	_, token := loginTestUser(t, s, "viewer@example.com", "viewer")
+	boards := s.Boards()
+	post := s.CreatePost(boards[0].ID, "u_author", "hello", "c", "", "", nil, nil)
+	s.CreatePost(boards[1].ID, "u_author", "elsewhere", "c", "", "", nil, nil)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/v1/boards/"+boards[0].ID+"/subscribe", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous subscribe: got %d, want 401", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/v1/boards/b_missing/subscribe", token); rec.Code != http.StatusNotFound {
+		t.Fatalf("subscribe to missing board: got %d, want 404", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/v1/boards/"+boards[0].ID+"/subscribe", token); rec.Code != http.StatusOK {
+		t.Fatalf("subscribe: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var listed []boardItem
+	if err := json.Unmarshal(do(http.MethodGet, "/api/v1/boards", token).Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode boards: %v", err)
+	}
+	for _, board := range listed {
+		want := board.ID == boards[0].ID
+		if board.Subscribed == nil || *board.Subscribed != want {
+			t.Fatalf("board %s subscribed = %v, want %v", board.ID, board.Subscribed, want)
+		}
+	}
+	listed = nil
+	if err := json.Unmarshal(do(http.MethodGet, "/api/v1/boards", "").Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode boards: %v", err)
+	}
+	if listed[0].Subscribed != nil {
+		t.Fatalf("anonymous viewer got subscribed = %v, want it omitted", *listed[0].Subscribed)
+	}
+
+	var feed struct {
+		Items []postItem `json:"items"`
+		Total int        `json:"total"`
+	}
+	rec := do(http.MethodGet, "/api/v1/feed", token)
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("decode feed: %v (%s)", err, rec.Body.String())
+	}
+	if feed.Total != 1 || len(feed.Items) != 1 || feed.Items[0].ID != post.ID {
+		t.Fatalf("feed = %+v, want only %s", feed, post.ID)
+	}
+	if rec := do(http.MethodGet, "/api/v1/feed?source=nope", token); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid source: got %d, want 400", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/v1/boards/"+boards[0].ID+"/subscribe", token); rec.Code != http.StatusOK {
+		t.Fatalf("unsubscribe: got %d %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(do(http.MethodGet, "/api/v1/feed", token).Body.Bytes(), &feed); err != nil {
+		t.Fatalf("decode feed: %v", err)
+	}
+	if feed.Total != 0 {
+		t.Fatalf("feed after unsubscribe has %d posts, want 0", feed.Total)
+	}
+}
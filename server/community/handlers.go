@@ -5,8 +5,6 @@ import (
 	"log"
 	"math"
 	"net/http"
-	"net/netip"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,32 +14,63 @@ import (
 
 	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
 	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
 type Handler struct {
 	Store store.API
 	Auth  *auth.Service
+
+	// PostLimiter and CommentLimiter throttle writes; nil disables the limit.
+	PostLimiter    ratelimit.Limiter
+	CommentLimiter ratelimit.Limiter
+
+	// Limits caps post and comment field sizes, attachments and tags; zero
+	// fields use the defaults.
+	Limits Limits
+
+	trending trendingCache
 }
 
-var (
-	postLimiter    = ratelimit.NewFixedWindow(30*time.Second, 5)
-	commentLimiter = ratelimit.NewFixedWindow(30*time.Second, 10)
-)
+// NewHandler builds a Handler whose write limiters follow cfg.
+func NewHandler(s store.API, a *auth.Service, cfg ratelimit.Config) *Handler {
+	return &Handler{
+		Store:          s,
+		Auth:           a,
+		PostLimiter:    cfg.Post.NewLimiter(cfg.Sliding),
+		CommentLimiter: cfg.Comment.NewLimiter(cfg.Sliding),
+	}
+}
 
 const (
-	postSortLatest = "latest"
-	postSortHot    = "hot"
+	postSortLatest        = "latest"
+	postSortHot           = "hot"
+	postSortControversial = "controversial"
 )
 
 func normalizePostSort(value string) string {
 	value = strings.ToLower(strings.TrimSpace(value))
-	if value == postSortHot {
-		return postSortHot
+	if value == postSortHot || value == postSortControversial {
+		return value
 	}
 	return postSortLatest
 }
 
+// minControversialVotes is how many votes a post needs before the
+// controversial sort ranks it, so a lone up and down vote can't top the feed.
+const minControversialVotes = 4
+
+// controversyScore is min(up, down): only posts with many votes on both
+// sides rank high. Posts under minControversialVotes score -1, behind every
+// post that qualifies.
+func controversyScore(votes store.VoteBreakdown) int {
+	if votes.Up+votes.Down < minControversialVotes {
+		return -1
+	}
+	return min(votes.Up, votes.Down)
+}
+
 func hotScore(score int, commentCount int, createdAt string) float64 {
 	createdTime, err := time.Parse(time.RFC3339, createdAt)
 	if err != nil {
@@ -57,73 +86,218 @@ func hotScore(score int, commentCount int, createdAt string) float64 {
 
 // GetBoards handles GET /api/v1/boards.
 func (h *Handler) GetBoards(c *gin.Context) {
-	c.JSON(http.StatusOK, h.Store.Boards())
+	boards := h.Store.BoardsWithStats()
+	var subscribed map[string]bool
+	if viewerID := h.viewerID(c); viewerID != "" {
+		subscribed = map[string]bool{}
+		for _, id := range h.Store.SubscribedBoards(viewerID) {
+			subscribed[id] = true
+		}
+	}
+	items := make([]boardItem, 0, len(boards))
+	for _, board := range boards {
+		var lastPostAt *string
+		if board.LastPostAt != "" {
+			value := board.LastPostAt
+			lastPostAt = &value
+		}
+		item := boardItem{
+			ID:          board.ID,
+			Name:        board.Name,
+			Description: board.Description,
+			PostCount:   board.PostCount,
+			LastPostAt:  lastPostAt,
+		}
+		if subscribed != nil {
+			value := subscribed[board.ID]
+			item.Subscribed = &value
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, items)
 }
 
-// ListPosts handles GET /api/v1/posts.
+// maxListBoardIDs caps how many boards one combined post list may name.
+const maxListBoardIDs = 50
+
+// ListPosts handles GET /api/v1/posts. board_id may list several boards
+// separated by commas for a combined feed.
 func (h *Handler) ListPosts(c *gin.Context) {
-	boardID := c.Query("board_id")
+	boardIDs := parseBoardIDs(c.Query("board_id"))
+	if len(boardIDs) > maxListBoardIDs {
+		writeError(c, http.StatusBadRequest, 2001, "too many board ids")
+		return
+	}
 	authorID := c.Query("author_id")
 	sortBy := normalizePostSort(c.Query("sort"))
+	if cursor, ok := c.GetQuery("after"); ok {
+		if sortBy != postSortLatest || authorID != "" {
+			writeError(c, http.StatusBadRequest, 2001, "cursor paging supports only the latest feed")
+			return
+		}
+		if len(boardIDs) > 1 {
+			writeError(c, http.StatusBadRequest, 2001, "cursor paging supports a single board")
+			return
+		}
+		boardID := ""
+		if len(boardIDs) == 1 {
+			boardID = boardIDs[0]
+		}
+		h.listPostsAfter(c, boardID, cursor)
+		return
+	}
 	page := parsePositiveInt(c.Query("page"), 1)
 	pageSize := parsePositiveInt(c.Query("page_size"), 20)
 
 	viewerID := h.viewerID(c)
-	posts := h.Store.Posts(boardID)
-	if authorID != "" {
-		filtered := make([]store.Post, 0, len(posts))
-		for _, post := range posts {
-			if post.AuthorID == authorID {
-				filtered = append(filtered, post)
-			}
-		}
-		posts = filtered
+	filter := store.PostListFilter{
+		BoardIDs: boardIDs,
+		AuthorID: authorID,
+		ViewerID: viewerID,
+	}
+	// The latest feed pages in the store; the ranked sorts need every match
+	// in hand before they can pick a page.
+	if sortBy == postSortLatest {
+		filter.Offset = (page - 1) * pageSize
+		filter.Limit = pageSize
+	}
+	posts, total, err := h.Store.Posts(filter)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
 	}
 
-	postMeta := make(map[string]struct {
-		score        int
-		commentCount int
-		hotScore     float64
-	}, len(posts))
-	for _, post := range posts {
-		score := h.Store.PostScore(post.ID)
-		commentCount := h.Store.CommentCount(post.ID)
-		postMeta[post.ID] = struct {
-			score        int
-			commentCount int
-			hotScore     float64
-		}{
-			score:        score,
-			commentCount: commentCount,
-			hotScore:     hotScore(score, commentCount, post.CreatedAt),
-		}
-	}
-
-	sort.SliceStable(posts, func(i, j int) bool {
-		if sortBy == postSortHot {
-			left := postMeta[posts[i].ID].hotScore
-			right := postMeta[posts[j].ID].hotScore
+	// Hot sorting needs every post's stats up front; the latest feed only
+	// loads stats for the page it returns.
+	var stats *postStats
+	switch sortBy {
+	case postSortHot:
+		stats = h.loadPostStats(posts)
+		hot := make(map[string]float64, len(posts))
+		for _, post := range posts {
+			hot[post.ID] = hotScore(stats.scores[post.ID], stats.commentCounts[post.ID], post.CreatedAt)
+		}
+		sort.SliceStable(posts, func(i, j int) bool {
+			left, right := hot[posts[i].ID], hot[posts[j].ID]
 			if left == right {
 				return posts[i].CreatedAt > posts[j].CreatedAt
 			}
 			return left > right
+		})
+	case postSortControversial:
+		votes := h.Store.PostVoteBreakdowns(postIDs(posts))
+		sort.SliceStable(posts, func(i, j int) bool {
+			return controversyScore(votes[posts[i].ID]) > controversyScore(votes[posts[j].ID])
+		})
+	}
+	if sortBy != postSortLatest {
+		if len(boardIDs) == 1 {
+			sort.SliceStable(posts, func(i, j int) bool {
+				return store.PinnedBefore(posts[i], posts[j])
+			})
+		}
+		start := min((page-1)*pageSize, len(posts))
+		end := min(start+pageSize, len(posts))
+		posts = posts[start:end]
+	}
+
+	items := h.postItems(posts, viewerID, stats)
+
+	resp := struct {
+		Items []postItem `json:"items"`
+		Total int        `json:"total"`
+	}{
+		Items: items,
+		Total: total,
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseBoardIDs splits a comma-separated board_id query into distinct IDs,
+// dropping blank entries. Unknown IDs are kept; they simply match no posts.
+func parseBoardIDs(raw string) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		id := strings.TrimSpace(part)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// listPostsAfter serves GET /api/v1/posts?after={post_id}&limit=20, the
+// cursor variant of the latest feed. Posts created while the client scrolls
+// can't shift later pages, so nothing repeats or gets skipped.
+func (h *Handler) listPostsAfter(c *gin.Context, boardID, cursor string) {
+	limit := min(parsePositiveInt(c.Query("limit"), 20), maxFeedLimit)
+	posts, next := h.Store.PostsAfterCursor(boardID, cursor, limit)
+
+	viewerID := h.viewerID(c)
+	if blocked := h.blockedSet(viewerID); len(blocked) > 0 {
+		visible := make([]store.Post, 0, len(posts))
+		for _, post := range posts {
+			if _, ok := blocked[post.AuthorID]; !ok {
+				visible = append(visible, post)
+			}
 		}
-		return posts[i].CreatedAt > posts[j].CreatedAt
+		posts = visible
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"items":       h.postItems(posts, viewerID, nil),
+		"next_cursor": next,
 	})
-	total := len(posts)
+}
 
-	start := (page - 1) * pageSize
-	if start > total {
-		start = total
+// postStats holds batch-loaded scores and comment counts keyed by post ID.
+type postStats struct {
+	scores        map[string]int
+	commentCounts map[string]int
+}
+
+// loadPostStats fetches scores and comment counts for posts in two queries.
+func (h *Handler) loadPostStats(posts []store.Post) *postStats {
+	ids := postIDs(posts)
+	return &postStats{
+		scores:        h.Store.PostScores(ids),
+		commentCounts: h.Store.CommentCounts(ids),
 	}
-	end := start + pageSize
-	if end > total {
-		end = total
+}
+
+func postIDs(posts []store.Post) []string {
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+// postItems renders feed items for posts. stats may carry scores and comment
+// counts the caller already loaded for sorting; when nil they are loaded for
+// posts here. Either way the page costs a fixed number of store calls.
+func (h *Handler) postItems(posts []store.Post, viewerID string, stats *postStats) []postItem {
+	if stats == nil {
+		stats = h.loadPostStats(posts)
+	}
+	myVotes := h.Store.PostVotes(postIDs(posts), viewerID)
+
+	authorIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.AuthorID)
 	}
+	authors := h.Store.GetUsers(authorIDs)
 
-	items := make([]postItem, 0, end-start)
-	for _, post := range posts[start:end] {
-		author, _ := h.Store.GetUser(post.AuthorID)
+	items := make([]postItem, 0, len(posts))
+	for _, post := range posts {
+		author, ok := authors[post.AuthorID]
 		board, _ := h.Store.GetBoard(post.BoardID)
 		var boardInfo *boardSummary
 		if strings.TrimSpace(board.ID) != "" {
@@ -132,39 +306,24 @@ func (h *Handler) ListPosts(c *gin.Context) {
 				Name: board.Name,
 			}
 		}
-		meta := postMeta[post.ID]
-		score := meta.score
-		myVote := 0
-		if viewerID != "" {
-			myVote = h.Store.PostVote(post.ID, viewerID)
-		}
-		commentCount := meta.commentCount
-
 		items = append(items, postItem{
-			ID:           post.ID,
-			Title:        post.Title,
-			Content:      post.Content,
-			ContentJSON:  safeJSON(post.ContentJSON),
-			Tags:         post.Tags,
-			Attachments:  h.attachmentsFromIDs(post.Attachments),
-			Score:        score,
-			CommentCount: commentCount,
-			MyVote:       myVote,
-			Author:       userSummaryFromUser(author),
-			Board:        boardInfo,
-			CreatedAt:    post.CreatedAt,
+			ID:            post.ID,
+			Title:         post.Title,
+			Content:       post.Content,
+			ContentJSON:   safeJSON(post.ContentJSON),
+			Tags:          post.Tags,
+			Attachments:   h.attachmentsFromIDs(post.Attachments),
+			Score:         stats.scores[post.ID],
+			CommentCount:  stats.commentCounts[post.ID],
+			MyVote:        myVotes[post.ID],
+			Author:        authorSummary(author, ok),
+			Board:         boardInfo,
+			Pinned:        post.Pinned,
+			CreatedAt:     post.CreatedAt,
+			CreatedAtUnix: transport.UnixMillis(post.CreatedAt),
 		})
 	}
-
-	resp := struct {
-		Items []postItem `json:"items"`
-		Total int        `json:"total"`
-	}{
-		Items: items,
-		Total: total,
-	}
-
-	c.JSON(http.StatusOK, resp)
+	return items
 }
 
 // CreatePost handles POST /api/v1/posts.
@@ -173,8 +332,8 @@ func (h *Handler) CreatePost(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if !h.allowWrite(postLimiter, c, user.ID) {
-		writeError(c, http.StatusTooManyRequests, 1005, "rate limited")
+	if ok, retryAfter := h.allowWrite(h.PostLimiter, c, user.ID); !ok {
+		transport.RateLimited(c, retryAfter)
 		return
 	}
 
@@ -185,62 +344,93 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		ContentJSON json.RawMessage `json:"content_json"`
 		Tags        []string        `json:"tags"`
 		Attachments []string        `json:"attachments"`
+		Status      string          `json:"status"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
+	if !transport.BindJSON(c, &req, maxContentBody) {
 		return
 	}
 	if req.BoardID == "" || req.Title == "" {
-		writeError(c, http.StatusBadRequest, 2001, "missing fields")
+		missing := map[string]string{}
+		if req.BoardID == "" {
+			missing["board_id"] = transport.FieldRequired
+		}
+		if req.Title == "" {
+			missing["title"] = transport.FieldRequired
+		}
+		transport.FieldError(c, http.StatusBadRequest, 2001, "missing fields", missing)
+		return
+	}
+	switch req.Status {
+	case "", store.PostStatusPublished, store.PostStatusDraft:
+	default:
+		writeFieldError(c, "invalid status", "status", transport.FieldInvalid)
 		return
 	}
 	if _, ok := h.Store.GetBoard(req.BoardID); !ok {
-		writeError(c, http.StatusBadRequest, 2001, "invalid board_id")
+		writeFieldError(c, "invalid board_id", "board_id", transport.FieldInvalid)
+		return
+	}
+	if !h.checkLengths(c, req.Title, req.Content, strings.TrimSpace(string(req.ContentJSON))) {
 		return
 	}
 
-	contentJSON := strings.TrimSpace(string(req.ContentJSON))
+	contentJSON, err := contentJSONFromRequest(req.ContentJSON)
+	if err != nil {
+		writeFieldError(c, "invalid content_json", "content_json", transport.FieldInvalid)
+		return
+	}
 	attachments := normalizeAttachmentIDs(req.Attachments)
-	if len(attachments) > maxPostAttachments {
-		writeError(c, http.StatusBadRequest, 2001, "too many attachments")
+	if len(attachments) > h.limits().PostAttachments {
+		writeFieldError(c, "too many attachments", "attachments", transport.FieldTooMany)
 		return
 	}
-	for _, fileID := range attachments {
-		if _, ok := h.Store.GetFile(fileID); !ok {
-			writeError(c, http.StatusBadRequest, 2001, "invalid attachment_id")
-			return
-		}
+	if !h.checkAttachments(c, user, attachments) {
+		return
 	}
 
 	if strings.TrimSpace(req.Content) == "" && contentJSON == "" && len(attachments) == 0 {
-		writeError(c, http.StatusBadRequest, 2001, "missing content")
+		writeFieldError(c, "missing content", "content", transport.FieldRequired)
 		return
 	}
-	tags := normalizeTags(req.Tags, maxPostTags)
-	post := h.Store.CreatePost(req.BoardID, user.ID, req.Title, req.Content, contentJSON, tags, attachments)
-	if err := h.Store.AddUserExp(user.ID, 10); err != nil {
-		log.Printf("failed to add post exp for user %s: %v", user.ID, err)
+	tags := normalizeTags(req.Tags, h.limits().PostTags)
+	post := h.Store.CreatePost(req.BoardID, user.ID, req.Title, req.Content, contentJSON, req.Status, tags, attachments)
+	// Drafts earn exp and notify mentions only once published.
+	var levelUp *levelUpEvent
+	mentions := []mentionItem{}
+	if !post.IsDraft() {
+		levelUp = h.awardExp(user.ID, store.ExpReasonPost)
+		mentions = h.notifyMentions(post.Content, user.ID, "post", post.ID)
 	}
 	resp := struct {
-		ID          string           `json:"id"`
-		BoardID     string           `json:"board_id"`
-		AuthorID    string           `json:"author_id"`
-		Title       string           `json:"title"`
-		Content     string           `json:"content"`
-		ContentJSON json.RawMessage  `json:"content_json,omitempty"`
-		Tags        []string         `json:"tags"`
-		Attachments []attachmentItem `json:"attachments"`
-		CreatedAt   string           `json:"created_at"`
+		ID            string           `json:"id"`
+		BoardID       string           `json:"board_id"`
+		AuthorID      string           `json:"author_id"`
+		Author        userSummary      `json:"author"`
+		Title         string           `json:"title"`
+		Content       string           `json:"content"`
+		ContentJSON   json.RawMessage  `json:"content_json,omitempty"`
+		Tags          []string         `json:"tags"`
+		Attachments   []attachmentItem `json:"attachments"`
+		Status        string           `json:"status"`
+		CreatedAt     string           `json:"created_at"`
+		CreatedAtUnix int64            `json:"created_at_unix"`
+		Mentions      []mentionItem    `json:"mentions"`
+		LevelUp       *levelUpEvent    `json:"level_up,omitempty"`
 	}{
-		ID:          post.ID,
-		BoardID:     post.BoardID,
-		AuthorID:    post.AuthorID,
-		Title:       post.Title,
-		Content:     post.Content,
-		ContentJSON: safeJSON(post.ContentJSON),
-		Tags:        post.Tags,
-		Attachments: h.attachmentsFromIDs(post.Attachments),
-		CreatedAt:   post.CreatedAt,
+		ID:            post.ID,
+		BoardID:       post.BoardID,
+		AuthorID:      post.AuthorID,
+		Author:        creatorSummary(user, levelUp),
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentJSON:   safeJSON(post.ContentJSON),
+		Tags:          post.Tags,
+		Attachments:   h.attachmentsFromIDs(post.Attachments),
+		Status:        post.Status,
+		CreatedAt:     post.CreatedAt,
+		CreatedAtUnix: transport.UnixMillis(post.CreatedAt),
+		Mentions:      mentions,
+		LevelUp:       levelUp,
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -260,37 +450,58 @@ func (h *Handler) ListComments(c *gin.Context) {
 
 	viewerID := h.viewerID(c)
 	comments := h.Store.Comments(postID)
+	if blocked := h.blockedSet(viewerID); len(blocked) > 0 {
+		filtered := make([]store.Comment, 0, len(comments))
+		for _, comment := range comments {
+			if _, ok := blocked[comment.AuthorID]; !ok {
+				filtered = append(filtered, comment)
+			}
+		}
+		comments = filtered
+	}
+	authorIDs := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		authorIDs = append(authorIDs, comment.AuthorID)
+	}
+	authors := h.Store.GetUsers(authorIDs)
+
 	items := make([]commentItem, 0, len(comments))
 	for _, comment := range comments {
-		author, _ := h.Store.GetUser(comment.AuthorID)
-		var parentID *string
-		if strings.TrimSpace(comment.ParentID) != "" {
-			value := comment.ParentID
-			parentID = &value
-		}
-		score := h.Store.CommentScore(postID, comment.ID)
-		myVote := 0
-		if viewerID != "" {
-			myVote = h.Store.CommentVote(postID, comment.ID, viewerID)
-		}
-		items = append(items, commentItem{
-			ID:          comment.ID,
-			ParentID:    parentID,
-			Author:      userSummaryFromUser(author),
-			Floor:       comment.Floor,
-			Content:     comment.Content,
-			ContentJSON: safeJSON(comment.ContentJSON),
-			Tags:        comment.Tags,
-			Attachments: h.attachmentsFromIDs(comment.Attachments),
-			CreatedAt:   comment.CreatedAt,
-			Score:       score,
-			MyVote:      myVote,
-		})
+		author, ok := authors[comment.AuthorID]
+		items = append(items, h.commentItem(comment, authorSummary(author, ok), viewerID))
 	}
 
 	c.JSON(http.StatusOK, items)
 }
 
+// commentItem renders a comment with its score and, for a signed-in viewer,
+// their vote.
+func (h *Handler) commentItem(comment store.Comment, author userSummary, viewerID string) commentItem {
+	var parentID *string
+	if strings.TrimSpace(comment.ParentID) != "" {
+		value := comment.ParentID
+		parentID = &value
+	}
+	myVote := 0
+	if viewerID != "" {
+		myVote = h.Store.CommentVote(comment.PostID, comment.ID, viewerID)
+	}
+	return commentItem{
+		ID:            comment.ID,
+		ParentID:      parentID,
+		Author:        author,
+		Floor:         comment.Floor,
+		Content:       comment.Content,
+		ContentJSON:   safeJSON(comment.ContentJSON),
+		Tags:          comment.Tags,
+		Attachments:   h.attachmentsFromIDs(comment.Attachments),
+		CreatedAt:     comment.CreatedAt,
+		CreatedAtUnix: transport.UnixMillis(comment.CreatedAt),
+		Score:         h.Store.CommentScore(comment.PostID, comment.ID),
+		MyVote:        myVote,
+	}
+}
+
 // CreateComment handles POST /api/v1/posts/{post_id}/comments.
 func (h *Handler) CreateComment(c *gin.Context) {
 	postID := strings.TrimSpace(c.Param("id"))
@@ -303,8 +514,8 @@ func (h *Handler) CreateComment(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if !h.allowWrite(commentLimiter, c, user.ID) {
-		writeError(c, http.StatusTooManyRequests, 1005, "rate limited")
+	if ok, retryAfter := h.allowWrite(h.CommentLimiter, c, user.ID); !ok {
+		transport.RateLimited(c, retryAfter)
 		return
 	}
 	if _, ok := h.Store.GetPost(postID); !ok {
@@ -319,43 +530,46 @@ func (h *Handler) CreateComment(c *gin.Context) {
 		Tags        []string        `json:"tags"`
 		Attachments []string        `json:"attachments"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
+	if !transport.BindJSON(c, &req, maxContentBody) {
+		return
+	}
+	if !h.checkLengths(c, "", req.Content, strings.TrimSpace(string(req.ContentJSON))) {
 		return
 	}
+
 	parentIDValue := strings.TrimSpace(req.ParentID)
 	if parentIDValue != "" {
 		if _, ok := h.Store.GetComment(postID, parentIDValue); !ok {
-			writeError(c, http.StatusBadRequest, 2001, "invalid parent_id")
+			writeFieldError(c, "invalid parent_id", "parent_id", transport.FieldInvalid)
 			return
 		}
 	}
 
-	contentJSON := strings.TrimSpace(string(req.ContentJSON))
+	contentJSON, err := contentJSONFromRequest(req.ContentJSON)
+	if err != nil {
+		writeFieldError(c, "invalid content_json", "content_json", transport.FieldInvalid)
+		return
+	}
 	attachments := normalizeAttachmentIDs(req.Attachments)
-	if len(attachments) > maxCommentAttachments {
-		writeError(c, http.StatusBadRequest, 2001, "too many attachments")
+	if len(attachments) > h.limits().CommentAttachments {
+		writeFieldError(c, "too many attachments", "attachments", transport.FieldTooMany)
 		return
 	}
-	for _, fileID := range attachments {
-		if _, ok := h.Store.GetFile(fileID); !ok {
-			writeError(c, http.StatusBadRequest, 2001, "invalid attachment_id")
-			return
-		}
+	if !h.checkAttachments(c, user, attachments) {
+		return
 	}
 	if strings.TrimSpace(req.Content) == "" && contentJSON == "" && len(attachments) == 0 {
-		writeError(c, http.StatusBadRequest, 2001, "missing content")
+		writeFieldError(c, "missing content", "content", transport.FieldRequired)
 		return
 	}
 
-	tags := normalizeTags(req.Tags, maxCommentTags)
+	tags := normalizeTags(req.Tags, h.limits().CommentTags)
 	comment := h.Store.CreateComment(postID, user.ID, req.Content, contentJSON, parentIDValue, tags, attachments)
-	if err := h.Store.AddUserExp(user.ID, 2); err != nil {
-		log.Printf("failed to add comment exp for user %s: %v", user.ID, err)
-	}
+	levelUp := h.awardExp(user.ID, store.ExpReasonComment)
 
 	// Trigger notifications
 	h.triggerCommentNotifications(postID, comment, user.ID, parentIDValue)
+	mentions := h.notifyMentions(comment.Content, user.ID, "comment", comment.ID)
 
 	var parentID *string
 	if strings.TrimSpace(comment.ParentID) != "" {
@@ -363,31 +577,39 @@ func (h *Handler) CreateComment(c *gin.Context) {
 		parentID = &value
 	}
 	resp := struct {
-		ID          string           `json:"id"`
-		PostID      string           `json:"post_id"`
-		ParentID    *string          `json:"parent_id"`
-		AuthorID    string           `json:"author_id"`
-		Floor       int              `json:"floor"`
-		Content     string           `json:"content"`
-		ContentJSON json.RawMessage  `json:"content_json,omitempty"`
-		Tags        []string         `json:"tags"`
-		Attachments []attachmentItem `json:"attachments"`
-		CreatedAt   string           `json:"created_at"`
-		Score       int              `json:"score"`
-		MyVote      int              `json:"my_vote"`
+		ID            string           `json:"id"`
+		PostID        string           `json:"post_id"`
+		ParentID      *string          `json:"parent_id"`
+		AuthorID      string           `json:"author_id"`
+		Author        userSummary      `json:"author"`
+		Floor         int              `json:"floor"`
+		Content       string           `json:"content"`
+		ContentJSON   json.RawMessage  `json:"content_json,omitempty"`
+		Tags          []string         `json:"tags"`
+		Attachments   []attachmentItem `json:"attachments"`
+		CreatedAt     string           `json:"created_at"`
+		CreatedAtUnix int64            `json:"created_at_unix"`
+		Score         int              `json:"score"`
+		MyVote        int              `json:"my_vote"`
+		Mentions      []mentionItem    `json:"mentions"`
+		LevelUp       *levelUpEvent    `json:"level_up,omitempty"`
 	}{
-		ID:          comment.ID,
-		PostID:      comment.PostID,
-		ParentID:    parentID,
-		AuthorID:    comment.AuthorID,
-		Floor:       comment.Floor,
-		Content:     comment.Content,
-		ContentJSON: safeJSON(comment.ContentJSON),
-		Tags:        comment.Tags,
-		Attachments: h.attachmentsFromIDs(comment.Attachments),
-		CreatedAt:   comment.CreatedAt,
-		Score:       0,
-		MyVote:      0,
+		ID:            comment.ID,
+		PostID:        comment.PostID,
+		ParentID:      parentID,
+		AuthorID:      comment.AuthorID,
+		Author:        creatorSummary(user, levelUp),
+		Floor:         comment.Floor,
+		Content:       comment.Content,
+		ContentJSON:   safeJSON(comment.ContentJSON),
+		Tags:          comment.Tags,
+		Attachments:   h.attachmentsFromIDs(comment.Attachments),
+		CreatedAt:     comment.CreatedAt,
+		CreatedAtUnix: transport.UnixMillis(comment.CreatedAt),
+		Score:         0,
+		MyVote:        0,
+		Mentions:      mentions,
+		LevelUp:       levelUp,
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -402,23 +624,36 @@ func (h *Handler) GetPost(c *gin.Context) {
 	}
 
 	post, ok := h.Store.GetPost(postID)
+	if !ok {
+		post, ok = h.draftForViewer(c, postID)
+	}
+	if !ok && strings.EqualFold(strings.TrimSpace(c.Query("include_deleted")), "true") {
+		post, ok = h.deletedPostForViewer(c, postID)
+	}
 	if !ok {
 		writeError(c, http.StatusNotFound, 2001, "not found")
 		return
 	}
 
 	board, _ := h.Store.GetBoard(post.BoardID)
-	author, _ := h.Store.GetUser(post.AuthorID)
-	authorLevel := store.LevelForExp(author.Exp)
-	score := h.Store.PostScore(post.ID)
+	author := authorSummary(h.Store.GetUser(post.AuthorID))
+	// The score comes from the same counts as upvotes and downvotes, so the
+	// three always agree; the cached PostScore may lag behind a fresh vote.
+	upvotes, downvotes := h.Store.PostVoteBreakdown(post.ID)
 	commentCount := h.Store.CommentCount(post.ID)
 	myVote := 0
+	var myVoteAt *string
 	if viewerID := h.viewerID(c); viewerID != "" {
 		myVote = h.Store.PostVote(post.ID, viewerID)
+		if votedAt := h.Store.PostVoteTime(post.ID, viewerID); myVote != 0 && votedAt != "" {
+			myVoteAt = &votedAt
+		}
+	}
+	if post.DeletedAt == "" && !post.IsDraft() {
+		go func(postID string) {
+			_ = h.Store.IncrementPostViewCount(postID)
+		}(post.ID)
 	}
-	go func(postID string) {
-		_ = h.Store.IncrementPostViewCount(postID)
-	}(post.ID)
 
 	var deletedAt *string
 	if strings.TrimSpace(post.DeletedAt) != "" {
@@ -427,51 +662,100 @@ func (h *Handler) GetPost(c *gin.Context) {
 	}
 
 	resp := struct {
-		ID           string           `json:"id"`
-		Board        any              `json:"board"`
-		Author       any              `json:"author"`
-		Title        string           `json:"title"`
-		Content      string           `json:"content"`
-		ContentJSON  json.RawMessage  `json:"content_json,omitempty"`
-		Tags         []string         `json:"tags"`
-		Attachments  []attachmentItem `json:"attachments"`
-		Score        int              `json:"score"`
-		MyVote       int              `json:"my_vote"`
-		CommentCount int              `json:"comment_count"`
-		ViewCount    int              `json:"view_count"`
-		CreatedAt    string           `json:"created_at"`
-		DeletedAt    any              `json:"deleted_at"`
+		ID            string           `json:"id"`
+		Board         any              `json:"board"`
+		Author        any              `json:"author"`
+		Title         string           `json:"title"`
+		Content       string           `json:"content"`
+		ContentJSON   json.RawMessage  `json:"content_json,omitempty"`
+		Tags          []string         `json:"tags"`
+		Attachments   []attachmentItem `json:"attachments"`
+		Score         int              `json:"score"`
+		Upvotes       int              `json:"upvotes"`
+		Downvotes     int              `json:"downvotes"`
+		MyVote        int              `json:"my_vote"`
+		MyVoteAt      *string          `json:"my_vote_at"`
+		CommentCount  int              `json:"comment_count"`
+		ViewCount     int              `json:"view_count"`
+		Status        string           `json:"status"`
+		Pinned        bool             `json:"pinned"`
+		Version       int              `json:"version"`
+		CreatedAt     string           `json:"created_at"`
+		CreatedAtUnix int64            `json:"created_at_unix"`
+		DeletedAt     any              `json:"deleted_at"`
+		DeletedReason string           `json:"deleted_reason,omitempty"`
 	}{
 		ID: post.ID,
 		Board: map[string]any{
 			"id":   board.ID,
 			"name": board.Name,
 		},
-		Author: map[string]any{
-			"id":          author.ID,
-			"nickname":    author.Nickname,
-			"avatar":      author.Avatar,
-			"level":       authorLevel.Level,
-			"level_title": authorLevel.Title,
-		},
-		Title:        post.Title,
-		Content:      post.Content,
-		ContentJSON:  safeJSON(post.ContentJSON),
-		Tags:         post.Tags,
-		Attachments:  h.attachmentsFromIDs(post.Attachments),
-		Score:        score,
-		MyVote:       myVote,
-		CommentCount: commentCount,
-		ViewCount:    post.ViewCount,
-		CreatedAt:    post.CreatedAt,
-		DeletedAt:    deletedAt,
+		Author:        author,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentJSON:   safeJSON(post.ContentJSON),
+		Tags:          post.Tags,
+		Attachments:   h.attachmentsFromIDs(post.Attachments),
+		Score:         upvotes - downvotes,
+		Upvotes:       upvotes,
+		Downvotes:     downvotes,
+		MyVote:        myVote,
+		MyVoteAt:      myVoteAt,
+		CommentCount:  commentCount,
+		ViewCount:     post.ViewCount,
+		Status:        post.Status,
+		Pinned:        post.Pinned,
+		Version:       post.Version,
+		CreatedAt:     post.CreatedAt,
+		CreatedAtUnix: transport.UnixMillis(post.CreatedAt),
+		DeletedAt:     deletedAt,
+		DeletedReason: post.DeletedReason,
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
-// DeletePost handles DELETE /api/v1/posts/{post_id}.
-func (h *Handler) DeletePost(c *gin.Context) {
+// draftForViewer returns a live draft when the viewer is its author; to
+// everyone else a draft does not exist.
+func (h *Handler) draftForViewer(c *gin.Context, postID string) (store.Post, bool) {
+	viewer, ok := h.viewer(c)
+	if !ok {
+		return store.Post{}, false
+	}
+	post, ok := h.Store.GetPostIncludingDeleted(postID)
+	if !ok || !post.IsDraft() || post.DeletedAt != "" || post.AuthorID != viewer.ID {
+		return store.Post{}, false
+	}
+	return post, true
+}
+
+// deletedPostForViewer returns a soft-deleted post as a tombstone (content blanked)
+// when the viewer is its author or an admin. A moderator's reason for removing
+// it is kept.
+func (h *Handler) deletedPostForViewer(c *gin.Context, postID string) (store.Post, bool) {
+	viewer, ok := h.viewer(c)
+	if !ok {
+		return store.Post{}, false
+	}
+	post, ok := h.Store.GetPostIncludingDeleted(postID)
+	if !ok || post.DeletedAt == "" {
+		return store.Post{}, false
+	}
+	if post.AuthorID != viewer.ID && !h.isAdmin(viewer) {
+		return store.Post{}, false
+	}
+
+	post.Content = ""
+	post.ContentJSON = ""
+	post.Tags = []string{}
+	post.Attachments = nil
+	return post, true
+}
+
+// UpdatePost handles PATCH /api/v1/posts/{post_id}. Omitted fields keep
+// their value; version must be the one the client last read, otherwise the
+// edit is rejected with 409 instead of overwriting someone else's change.
+func (h *Handler) UpdatePost(c *gin.Context) {
 	postID := strings.TrimSpace(c.Param("id"))
 	if postID == "" {
 		writeError(c, http.StatusNotFound, 2001, "not found")
@@ -483,23 +767,91 @@ func (h *Handler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	if err := h.Store.SoftDeletePost(postID, user.ID, isAdmin(user)); err != nil {
+	var req struct {
+		Title       *string         `json:"title"`
+		Content     *string         `json:"content"`
+		ContentJSON json.RawMessage `json:"content_json"`
+		Tags        []string        `json:"tags"`
+		Version     *int            `json:"version"`
+	}
+	if !transport.BindJSON(c, &req, maxContentBody) {
+		return
+	}
+	if req.Version == nil {
+		writeFieldError(c, "missing version", "version", transport.FieldRequired)
+		return
+	}
+
+	post, ok := h.Store.GetPostIncludingDeleted(postID)
+	if !ok || post.DeletedAt != "" || (post.IsDraft() && post.AuthorID != user.ID) {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	title, content, contentJSON, tags := post.Title, post.Content, post.ContentJSON, post.Tags
+	if req.Title != nil {
+		title = *req.Title
+	}
+	if req.Content != nil {
+		content = *req.Content
+	}
+	if req.ContentJSON != nil {
+		sanitized, err := contentJSONFromRequest(req.ContentJSON)
+		if err != nil {
+			writeFieldError(c, "invalid content_json", "content_json", transport.FieldInvalid)
+			return
+		}
+		contentJSON = sanitized
+	}
+	if req.Tags != nil {
+		tags = normalizeTags(req.Tags, h.limits().PostTags)
+	}
+	if strings.TrimSpace(title) == "" {
+		writeFieldError(c, "missing fields", "title", transport.FieldRequired)
+		return
+	}
+	if !h.checkLengths(c, title, content, contentJSON) {
+		return
+	}
+	if strings.TrimSpace(content) == "" && contentJSON == "" && len(post.Attachments) == 0 {
+		writeFieldError(c, "missing content", "content", transport.FieldRequired)
+		return
+	}
+
+	updated, err := h.Store.UpdatePost(postID, user.ID, h.isAdmin(user), *req.Version, title, content, contentJSON, tags)
+	if err != nil {
 		switch err {
 		case store.ErrNotFound:
 			writeError(c, http.StatusNotFound, 2001, "not found")
 		case store.ErrForbidden:
 			writeError(c, http.StatusForbidden, 1002, "forbidden")
+		case store.ErrConflict:
+			writeError(c, http.StatusConflict, 2001, "post was modified by someone else")
 		default:
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+	c.JSON(http.StatusOK, struct {
+		ID          string          `json:"id"`
+		Title       string          `json:"title"`
+		Content     string          `json:"content"`
+		ContentJSON json.RawMessage `json:"content_json,omitempty"`
+		Tags        []string        `json:"tags"`
+		Version     int             `json:"version"`
+	}{
+		ID:          updated.ID,
+		Title:       updated.Title,
+		Content:     updated.Content,
+		ContentJSON: safeJSON(updated.ContentJSON),
+		Tags:        updated.Tags,
+		Version:     updated.Version,
+	})
 }
 
-// VotePost handles POST /api/v1/posts/{post_id}/votes.
-func (h *Handler) VotePost(c *gin.Context) {
+// DeletePost handles DELETE /api/v1/posts/{post_id}.
+func (h *Handler) DeletePost(c *gin.Context) {
 	postID := strings.TrimSpace(c.Param("id"))
 	if postID == "" {
 		writeError(c, http.StatusNotFound, 2001, "not found")
@@ -511,48 +863,93 @@ func (h *Handler) VotePost(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Value int `json:"value"`
+	// Deleting twice is not an error, so clients can retry safely.
+	if err := h.Store.SoftDeletePost(postID, user.ID, h.isAdmin(user)); err != nil && err != store.ErrAlreadyDeleted {
+		switch err {
+		case store.ErrNotFound:
+			writeError(c, http.StatusNotFound, 2001, "not found")
+		case store.ErrForbidden:
+			writeError(c, http.StatusForbidden, 1002, "forbidden")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDrafts handles GET /api/v1/users/me/drafts.
+func (h *Handler) ListDrafts(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
 		return
 	}
-	if req.Value != 1 && req.Value != -1 {
-		writeError(c, http.StatusBadRequest, 2001, "invalid vote value")
+
+	drafts := h.Store.DraftPosts(user.ID)
+	c.JSON(http.StatusOK, map[string]any{
+		"items": h.postItems(drafts, user.ID, nil),
+		"total": len(drafts),
+	})
+}
+
+// PublishPost handles POST /api/v1/posts/{post_id}/publish.
+func (h *Handler) PublishPost(c *gin.Context) {
+	postID := strings.TrimSpace(c.Param("id"))
+	if postID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
 		return
 	}
 
-	score, myVote, err := h.Store.VotePost(postID, user.ID, req.Value)
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	post, err := h.Store.PublishPost(postID, user.ID)
 	if err != nil {
 		switch err {
-		case store.ErrNotFound:
+		// Someone else's draft is as invisible here as it is to GetPost.
+		case store.ErrNotFound, store.ErrForbidden:
 			writeError(c, http.StatusNotFound, 2001, "not found")
-		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "invalid input")
+		case store.ErrInvalidTransition:
+			writeError(c, http.StatusConflict, 2001, "post already published")
 		default:
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
 		return
 	}
 
-	// Trigger like notification only for upvotes
-	if req.Value == 1 {
-		if post, ok := h.Store.GetPost(postID); ok && post.AuthorID != user.ID {
-			_, _ = h.Store.CreateNotification(post.AuthorID, user.ID, "like", "post", postID)
-		}
-	}
+	levelUp := h.awardExp(user.ID, store.ExpReasonPost)
+	mentions := h.notifyMentions(post.Content, user.ID, "post", post.ID)
+	c.JSON(http.StatusOK, struct {
+		ID            string        `json:"id"`
+		Status        string        `json:"status"`
+		CreatedAt     string        `json:"created_at"`
+		CreatedAtUnix int64         `json:"created_at_unix"`
+		Mentions      []mentionItem `json:"mentions"`
+		LevelUp       *levelUpEvent `json:"level_up,omitempty"`
+	}{
+		ID:            post.ID,
+		Status:        post.Status,
+		CreatedAt:     post.CreatedAt,
+		CreatedAtUnix: transport.UnixMillis(post.CreatedAt),
+		Mentions:      mentions,
+		LevelUp:       levelUp,
+	})
+}
 
-	resp := map[string]any{
-		"post_id": postID,
-		"score":   score,
-		"my_vote": myVote,
-	}
-	c.JSON(http.StatusOK, resp)
+// PinPost handles POST /api/v1/posts/{post_id}/pin.
+func (h *Handler) PinPost(c *gin.Context) {
+	h.setPostPinned(c, true)
 }
 
-// ClearPostVote handles DELETE /api/v1/posts/{post_id}/votes.
-func (h *Handler) ClearPostVote(c *gin.Context) {
+// UnpinPost handles DELETE /api/v1/posts/{post_id}/pin.
+func (h *Handler) UnpinPost(c *gin.Context) {
+	h.setPostPinned(c, false)
+}
+
+func (h *Handler) setPostPinned(c *gin.Context, pinned bool) {
 	postID := strings.TrimSpace(c.Param("id"))
 	if postID == "" {
 		writeError(c, http.StatusNotFound, 2001, "not found")
@@ -564,19 +961,72 @@ func (h *Handler) ClearPostVote(c *gin.Context) {
 		return
 	}
 
-	score, myVote, err := h.Store.ClearPostVote(postID, user.ID)
-	if err != nil {
+	if err := h.Store.SetPostPinned(postID, pinned, h.isAdmin(user)); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			writeError(c, http.StatusNotFound, 2001, "not found")
-		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "invalid input")
+		case store.ErrForbidden:
+			writeError(c, http.StatusForbidden, 1002, "forbidden")
 		default:
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
 		return
 	}
 
+	c.JSON(http.StatusOK, map[string]any{"post_id": postID, "pinned": pinned})
+}
+
+// VotePost handles POST /api/v1/posts/{post_id}/votes.
+func (h *Handler) VotePost(c *gin.Context) {
+	h.handlePostVote(c, false, false)
+}
+
+// ClearPostVote handles DELETE /api/v1/posts/{post_id}/votes.
+func (h *Handler) ClearPostVote(c *gin.Context) {
+	h.handlePostVote(c, true, false)
+}
+
+// SetPostVote handles PUT /api/v1/posts/{post_id}/votes. A value of 0 clears the vote.
+func (h *Handler) SetPostVote(c *gin.Context) {
+	h.handlePostVote(c, false, true)
+}
+
+// handlePostVote applies a vote on a post. clear ignores the body and removes
+// the vote; allowZero accepts {"value": 0} as a clear.
+func (h *Handler) handlePostVote(c *gin.Context, clear, allowZero bool) {
+	postID := strings.TrimSpace(c.Param("id"))
+	if postID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	value := 0
+	if !clear {
+		var valid bool
+		if value, valid = bindVoteValue(c, allowZero); !valid {
+			return
+		}
+	}
+
+	// SetPostVote also grants the author exp for a voter's first upvote.
+	score, myVote, err := h.Store.SetPostVote(postID, user.ID, value)
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+
+	// Trigger like notification only for upvotes
+	if value == 1 {
+		if post, ok := h.Store.GetPost(postID); ok && post.AuthorID != user.ID {
+			_, _ = h.Store.CreateNotification(post.AuthorID, user.ID, "like", "post", postID)
+		}
+	}
+
 	resp := map[string]any{
 		"post_id": postID,
 		"score":   score,
@@ -600,7 +1050,8 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	if err := h.Store.SoftDeleteComment(postID, commentID, user.ID, isAdmin(user)); err != nil {
+	// Deleting twice is not an error, so clients can retry safely.
+	if err := h.Store.SoftDeleteComment(postID, commentID, user.ID, h.isAdmin(user)); err != nil && err != store.ErrAlreadyDeleted {
 		switch err {
 		case store.ErrNotFound:
 			writeError(c, http.StatusNotFound, 2001, "not found")
@@ -612,11 +1063,27 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+	c.Status(http.StatusNoContent)
 }
 
 // VoteComment handles POST /api/v1/posts/{post_id}/comments/{comment_id}/votes.
 func (h *Handler) VoteComment(c *gin.Context) {
+	h.handleCommentVote(c, false, false)
+}
+
+// ClearCommentVote handles DELETE /api/v1/posts/{post_id}/comments/{comment_id}/votes.
+func (h *Handler) ClearCommentVote(c *gin.Context) {
+	h.handleCommentVote(c, true, false)
+}
+
+// SetCommentVote handles PUT /api/v1/posts/{post_id}/comments/{comment_id}/votes.
+// A value of 0 clears the vote.
+func (h *Handler) SetCommentVote(c *gin.Context) {
+	h.handleCommentVote(c, false, true)
+}
+
+// handleCommentVote is the comment counterpart of handlePostVote.
+func (h *Handler) handleCommentVote(c *gin.Context, clear, allowZero bool) {
 	postID := strings.TrimSpace(c.Param("id"))
 	commentID := strings.TrimSpace(c.Param("commentId"))
 	if postID == "" || commentID == "" {
@@ -629,33 +1096,22 @@ func (h *Handler) VoteComment(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Value int `json:"value"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
-		return
-	}
-	if req.Value != 1 && req.Value != -1 {
-		writeError(c, http.StatusBadRequest, 2001, "invalid vote value")
-		return
+	value := 0
+	if !clear {
+		var valid bool
+		if value, valid = bindVoteValue(c, allowZero); !valid {
+			return
+		}
 	}
 
-	score, myVote, err := h.Store.VoteComment(postID, commentID, user.ID, req.Value)
+	score, myVote, err := h.Store.SetCommentVote(postID, commentID, user.ID, value)
 	if err != nil {
-		switch err {
-		case store.ErrNotFound:
-			writeError(c, http.StatusNotFound, 2001, "not found")
-		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "invalid input")
-		default:
-			writeError(c, http.StatusInternalServerError, 5000, "server error")
-		}
+		writeVoteError(c, err)
 		return
 	}
 
 	// Trigger like notification only for upvotes
-	if req.Value == 1 {
+	if value == 1 {
 		if comment, ok := h.Store.GetComment(postID, commentID); ok && comment.AuthorID != user.ID {
 			_, _ = h.Store.CreateNotification(comment.AuthorID, user.ID, "like", "comment", commentID)
 		}
@@ -669,96 +1125,124 @@ func (h *Handler) VoteComment(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// ClearCommentVote handles DELETE /api/v1/posts/{post_id}/comments/{comment_id}/votes.
-func (h *Handler) ClearCommentVote(c *gin.Context) {
-	postID := strings.TrimSpace(c.Param("id"))
-	commentID := strings.TrimSpace(c.Param("commentId"))
-	if postID == "" || commentID == "" {
-		writeError(c, http.StatusNotFound, 2001, "not found")
-		return
+// bindVoteValue decodes {"value": n} from the request body. The value must be
+// 1 or -1, or also 0 when allowZero is set; a missing value is rejected.
+func bindVoteValue(c *gin.Context, allowZero bool) (int, bool) {
+	var req struct {
+		Value *int `json:"value"`
 	}
-
-	user, ok := h.Auth.RequireUser(c)
-	if !ok {
-		return
+	if !transport.BindJSON(c, &req, maxVoteBody) {
+		return 0, false
 	}
-
-	score, myVote, err := h.Store.ClearCommentVote(postID, commentID, user.ID)
-	if err != nil {
-		switch err {
-		case store.ErrNotFound:
-			writeError(c, http.StatusNotFound, 2001, "not found")
-		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "invalid input")
-		default:
-			writeError(c, http.StatusInternalServerError, 5000, "server error")
-		}
-		return
+	if req.Value == nil {
+		writeError(c, http.StatusBadRequest, 2001, "invalid vote value")
+		return 0, false
 	}
-
-	resp := map[string]any{
-		"comment_id": commentID,
-		"score":      score,
-		"my_vote":    myVote,
+	switch value := *req.Value; {
+	case value == 1 || value == -1, value == 0 && allowZero:
+		return value, true
+	default:
+		writeError(c, http.StatusBadRequest, 2001, "invalid vote value")
+		return 0, false
 	}
-	c.JSON(http.StatusOK, resp)
 }
 
-func (h *Handler) allowWrite(limiter *ratelimit.FixedWindow, c *gin.Context, userID string) bool {
-	ip := clientIP(c.Request)
-	if ip != "" && !limiter.Allow("ip:"+ip) {
-		return false
+func writeVoteError(c *gin.Context, err error) {
+	switch err {
+	case store.ErrNotFound:
+		writeError(c, http.StatusNotFound, 2001, "not found")
+	case store.ErrInvalidInput:
+		writeError(c, http.StatusBadRequest, 2001, "invalid input")
+	default:
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
 	}
-	if userID != "" && !limiter.Allow("user:"+userID) {
-		return false
+}
+
+// checkAttachments verifies every attachment exists and was uploaded by user,
+// so nobody can attach someone else's files. Admins may attach any file. On
+// failure it writes a 400 and returns false.
+func (h *Handler) checkAttachments(c *gin.Context, user store.User, fileIDs []string) bool {
+	admin := h.isAdmin(user)
+	for _, fileID := range fileIDs {
+		meta, ok := h.Store.GetFile(fileID)
+		if !ok {
+			writeFieldError(c, "invalid attachment_id", "attachments", transport.FieldInvalid)
+			return false
+		}
+		if meta.UploaderID != user.ID && !admin {
+			writeFieldError(c, "attachment not owned", "attachments", transport.FieldInvalid)
+			return false
+		}
 	}
 	return true
 }
 
-func isAdmin(user store.User) bool {
-	raw := strings.TrimSpace(os.Getenv("ADMIN_ACCOUNTS"))
-	if raw == "" {
-		return false
+// allowWrite checks the client IP and the user against limiter. When either is
+// throttled it returns false and how long that key must wait. Either way the
+// response carries the X-RateLimit-* headers for the tighter of the two.
+func (h *Handler) allowWrite(limiter ratelimit.Limiter, c *gin.Context, userID string) (bool, time.Duration) {
+	if limiter == nil {
+		return true, 0
 	}
-	parts := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' })
-	for _, part := range parts {
-		if strings.TrimSpace(part) == "" {
-			continue
-		}
-		if strings.EqualFold(strings.TrimSpace(part), user.Nickname) {
-			return true
+	var keys []string
+	if ip := transport.ClientIP(c.Request); ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	if userID != "" {
+		keys = append(keys, "user:"+userID)
+	}
+	defer transport.RateLimitHeaders(c, limiter, keys...)
+	for _, key := range keys {
+		if !limiter.Allow(key) {
+			return false, limiter.RetryAfter(key)
 		}
 	}
-	return false
+	return true, 0
 }
 
-func clientIP(r *http.Request) string {
-	forwarded := strings.TrimSpace(r.Header.Get("X-Forwarded-For"))
-	if forwarded != "" {
-		first := strings.TrimSpace(strings.Split(forwarded, ",")[0])
-		if addr, err := netip.ParseAddr(first); err == nil {
-			return addr.String()
-		}
+// blockedSet returns the authors hidden from viewerID; empty for anonymous viewers.
+func (h *Handler) blockedSet(viewerID string) map[string]struct{} {
+	if viewerID == "" {
+		return nil
+	}
+	ids := h.Store.BlockedIDs(viewerID)
+	set := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
 	}
+	return set
+}
 
-	hostport := strings.TrimSpace(r.RemoteAddr)
-	if hostport == "" {
-		return ""
+// awardExp grants exp for reason and returns a level-up event when a threshold is crossed.
+// Failures are logged: exp is a side effect and must not fail the main request.
+func (h *Handler) awardExp(userID, reason string) *levelUpEvent {
+	award, err := h.Store.AwardExp(userID, reason)
+	if err != nil {
+		log.Printf("failed to award %s exp for user %s: %v", reason, userID, err)
+		return nil
 	}
-	if addrPort, err := netip.ParseAddrPort(hostport); err == nil {
-		return addrPort.Addr().String()
+	if !award.LeveledUp() {
+		return nil
 	}
-	if addr, err := netip.ParseAddr(hostport); err == nil {
-		return addr.String()
+	return &levelUpEvent{
+		Level:      award.After.Level,
+		LevelTitle: award.After.Title,
+		Exp:        award.Exp,
 	}
-	return ""
+}
+
+func (h *Handler) isAdmin(user store.User) bool {
+	return h.Store.IsAdmin(user.ID)
 }
 
 const (
-	maxPostAttachments    = 6
-	maxCommentAttachments = 3
-	maxPostTags           = 8
-	maxCommentTags        = 6
+	// Request body limits; content_json from the rich-text editor dominates posts and comments.
+	maxContentBody = 1 << 20
+	maxVoteBody    = 1 << 10
+
+	// maxFeedLimit caps limit for cursor-paged feeds and page_size for the
+	// personalized feed.
+	maxFeedLimit = 100
 )
 
 type attachmentItem struct {
@@ -770,18 +1254,35 @@ type attachmentItem struct {
 }
 
 type postItem struct {
-	ID           string           `json:"id"`
-	Title        string           `json:"title"`
-	Content      string           `json:"content"`
-	ContentJSON  json.RawMessage  `json:"content_json,omitempty"`
-	Tags         []string         `json:"tags"`
-	Attachments  []attachmentItem `json:"attachments"`
-	Score        int              `json:"score"`
-	CommentCount int              `json:"comment_count"`
-	MyVote       int              `json:"my_vote"`
-	Author       userSummary      `json:"author"`
-	Board        *boardSummary    `json:"board,omitempty"`
-	CreatedAt    string           `json:"created_at"`
+	ID            string           `json:"id"`
+	Title         string           `json:"title"`
+	Content       string           `json:"content"`
+	ContentJSON   json.RawMessage  `json:"content_json,omitempty"`
+	Tags          []string         `json:"tags"`
+	Attachments   []attachmentItem `json:"attachments"`
+	Score         int              `json:"score"`
+	CommentCount  int              `json:"comment_count"`
+	MyVote        int              `json:"my_vote"`
+	Author        userSummary      `json:"author"`
+	Board         *boardSummary    `json:"board,omitempty"`
+	Pinned        bool             `json:"pinned"`
+	CreatedAt     string           `json:"created_at"`
+	CreatedAtUnix int64            `json:"created_at_unix"`
+}
+
+type levelUpEvent struct {
+	Level      int    `json:"level"`
+	LevelTitle string `json:"level_title"`
+	Exp        int    `json:"exp"`
+}
+
+type boardItem struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	PostCount   int     `json:"post_count"`
+	LastPostAt  *string `json:"last_post_at"`
+	Subscribed  *bool   `json:"subscribed,omitempty"` // only for signed-in viewers
 }
 
 type boardSummary struct {
@@ -790,17 +1291,18 @@ type boardSummary struct {
 }
 
 type commentItem struct {
-	ID          string           `json:"id"`
-	ParentID    *string          `json:"parent_id"`
-	Author      userSummary      `json:"author"`
-	Floor       int              `json:"floor"`
-	Content     string           `json:"content"`
-	ContentJSON json.RawMessage  `json:"content_json,omitempty"`
-	Tags        []string         `json:"tags"`
-	Attachments []attachmentItem `json:"attachments"`
-	CreatedAt   string           `json:"created_at"`
-	Score       int              `json:"score"`
-	MyVote      int              `json:"my_vote"`
+	ID            string           `json:"id"`
+	ParentID      *string          `json:"parent_id"`
+	Author        userSummary      `json:"author"`
+	Floor         int              `json:"floor"`
+	Content       string           `json:"content"`
+	ContentJSON   json.RawMessage  `json:"content_json,omitempty"`
+	Tags          []string         `json:"tags"`
+	Attachments   []attachmentItem `json:"attachments"`
+	CreatedAt     string           `json:"created_at"`
+	CreatedAtUnix int64            `json:"created_at_unix"`
+	Score         int              `json:"score"`
+	MyVote        int              `json:"my_vote"`
 }
 
 type userSummary struct {
@@ -811,6 +1313,29 @@ type userSummary struct {
 	LevelTitle string `json:"level_title"`
 }
 
+// deletedUserSummary is shown in place of an author whose user row is gone.
+var deletedUserSummary = userSummaryFromUser(store.DeletedUser())
+
+// authorSummary summarizes a looked-up author, falling back to
+// deletedUserSummary when the lookup failed.
+func authorSummary(user store.User, ok bool) userSummary {
+	if !ok {
+		return deletedUserSummary
+	}
+	return userSummaryFromUser(user)
+}
+
+// creatorSummary summarizes the signed-in user who just created a post or
+// comment, at the level the creation may have raised them to.
+func creatorSummary(user store.User, levelUp *levelUpEvent) userSummary {
+	summary := userSummaryFromUser(user)
+	if levelUp != nil {
+		summary.Level = levelUp.Level
+		summary.LevelTitle = levelUp.LevelTitle
+	}
+	return summary
+}
+
 func userSummaryFromUser(user store.User) userSummary {
 	level := store.LevelForExp(user.Exp)
 	return userSummary{
@@ -876,6 +1401,9 @@ func safeJSON(raw string) json.RawMessage {
 	return json.RawMessage(trimmed)
 }
 
+// deletedFilename stands in for the name of a soft-deleted attachment.
+const deletedFilename = "[deleted]"
+
 func (h *Handler) attachmentsFromIDs(ids []string) []attachmentItem {
 	if len(ids) == 0 {
 		return []attachmentItem{}
@@ -884,6 +1412,11 @@ func (h *Handler) attachmentsFromIDs(ids []string) []attachmentItem {
 	for _, id := range ids {
 		meta, ok := h.Store.GetFile(id)
 		if !ok {
+			// Keep a tombstone for deleted files so the UI can say so instead
+			// of silently dropping the attachment.
+			if h.Store.IsFileDeleted(id) {
+				out = append(out, attachmentItem{ID: id, Filename: deletedFilename})
+			}
 			continue
 		}
 		out = append(out, attachmentItem{
@@ -910,17 +1443,22 @@ func parsePositiveInt(value string, fallback int) int {
 }
 
 func (h *Handler) viewerID(c *gin.Context) string {
-	token := bearerToken(c)
-	if token == "" {
-		return ""
-	}
-	user, ok := h.Store.UserByToken(token)
+	user, ok := h.viewer(c)
 	if !ok {
 		return ""
 	}
 	return user.ID
 }
 
+// viewer resolves the optional Bearer token to a user without failing the request.
+func (h *Handler) viewer(c *gin.Context) (store.User, bool) {
+	token := bearerToken(c)
+	if token == "" {
+		return store.User{}, false
+	}
+	return h.Store.UserByToken(token)
+}
+
 func bearerToken(c *gin.Context) string {
 	authHeader := strings.TrimSpace(c.GetHeader("Authorization"))
 	if authHeader == "" {
@@ -933,7 +1471,12 @@ func bearerToken(c *gin.Context) string {
 }
 
 func writeError(c *gin.Context, status int, code int, message string) {
-	c.JSON(status, gin.H{"code": code, "message": message})
+	transport.Error(c, status, code, message)
+}
+
+// writeFieldError writes a 400 validation error naming the one field at fault.
+func writeFieldError(c *gin.Context, message, field, reason string) {
+	transport.FieldError(c, http.StatusBadRequest, 2001, message, map[string]string{field: reason})
 }
 
 // triggerCommentNotifications sends notifications when a comment is created.
@@ -0,0 +1,81 @@
+package community
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+)
+
+// Limits caps the size of post and comment fields and how many attachments
+// and tags they carry. Text is measured in runes so CJK and Latin text get
+// the same allowance; content_json in bytes. A zero field uses the
+// DefaultLimits value.
+type Limits struct {
+	TitleRunes       int
+	ContentRunes     int
+	ContentJSONBytes int
+
+	PostAttachments    int
+	CommentAttachments int
+	// PostTags and CommentTags cap how many tags are kept; extra tags are
+	// dropped rather than rejected.
+	PostTags    int
+	CommentTags int
+}
+
+// DefaultLimits applies when Handler.Limits leaves a field unset.
+var DefaultLimits = Limits{
+	TitleRunes:         200,
+	ContentRunes:       20000,
+	ContentJSONBytes:   100 << 10,
+	PostAttachments:    6,
+	CommentAttachments: 3,
+	PostTags:           8,
+	CommentTags:        6,
+}
+
+func (h *Handler) limits() Limits {
+	limits := h.Limits
+	if limits.TitleRunes <= 0 {
+		limits.TitleRunes = DefaultLimits.TitleRunes
+	}
+	if limits.ContentRunes <= 0 {
+		limits.ContentRunes = DefaultLimits.ContentRunes
+	}
+	if limits.ContentJSONBytes <= 0 {
+		limits.ContentJSONBytes = DefaultLimits.ContentJSONBytes
+	}
+	if limits.PostAttachments <= 0 {
+		limits.PostAttachments = DefaultLimits.PostAttachments
+	}
+	if limits.CommentAttachments <= 0 {
+		limits.CommentAttachments = DefaultLimits.CommentAttachments
+	}
+	if limits.PostTags <= 0 {
+		limits.PostTags = DefaultLimits.PostTags
+	}
+	if limits.CommentTags <= 0 {
+		limits.CommentTags = DefaultLimits.CommentTags
+	}
+	return limits
+}
+
+// checkLengths writes a 400 naming the first field over its limit and
+// returns false. Comments pass an empty title.
+func (h *Handler) checkLengths(c *gin.Context, title, content, contentJSON string) bool {
+	limits := h.limits()
+	switch {
+	case utf8.RuneCountInString(title) > limits.TitleRunes:
+		writeFieldError(c, fmt.Sprintf("title too long (max %d characters)", limits.TitleRunes), "title", transport.FieldTooLong)
+	case utf8.RuneCountInString(content) > limits.ContentRunes:
+		writeFieldError(c, fmt.Sprintf("content too long (max %d characters)", limits.ContentRunes), "content", transport.FieldTooLong)
+	case len(contentJSON) > limits.ContentJSONBytes:
+		writeFieldError(c, fmt.Sprintf("content_json too large (max %d bytes)", limits.ContentJSONBytes), "content_json", transport.FieldTooLong)
+	default:
+		return true
+	}
+	return false
+}
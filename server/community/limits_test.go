@@ -0,0 +1,139 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// docOfSize returns a valid content_json document exactly size bytes long.
+func docOfSize(t *testing.T, size int) string {
+	t.Helper()
+	const prefix = `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"`
+	const suffix = `"}]}]}`
+	doc := prefix + strings.Repeat("a", size-len(prefix)-len(suffix)) + suffix
+	if len(doc) != size {
+		t.Fatalf("docOfSize(%d) built %d bytes", size, len(doc))
+	}
+	return doc
+}
+
+func TestContentLengthLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	router.POST("/api/v1/posts/:id/comments", h.CreateComment)
+	_, token := loginTestUser(t, s, "author@example.com", "author")
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, "u_x", "t", "c", "", "", nil, nil)
+
+	send := func(path string, body map[string]any) *httptest.ResponseRecorder {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(encoded)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	postBody := func(title, content string, contentJSON any) map[string]any {
+		return map[string]any{"board_id": boardID, "title": title, "content": content, "content_json": contentJSON}
+	}
+	commentBody := func(content string, contentJSON any) map[string]any {
+		return map[string]any{"content": content, "content_json": contentJSON}
+	}
+
+	limits := DefaultLimits
+	title := strings.Repeat("标", limits.TitleRunes)
+	content := strings.Repeat("字", limits.ContentRunes)
+	doc := json.RawMessage(docOfSize(t, limits.ContentJSONBytes))
+	bigDoc := json.RawMessage(docOfSize(t, limits.ContentJSONBytes+1))
+	commentsPath := "/api/v1/posts/" + post.ID + "/comments"
+
+	cases := []struct {
+		name    string
+		path    string
+		body    map[string]any
+		wantErr string
+	}{
+		{"post at limits", "/api/v1/posts", postBody(title, content, doc), ""},
+		{"post title over", "/api/v1/posts", postBody(title+"标", "x", nil), "title too long (max 200 characters)"},
+		{"post content over", "/api/v1/posts", postBody("t", content+"字", nil), "content too long (max 20000 characters)"},
+		{"post content_json over", "/api/v1/posts", postBody("t", "x", bigDoc), "content_json too large (max 102400 bytes)"},
+		{"comment at limits", commentsPath, commentBody(content, doc), ""},
+		{"comment content over", commentsPath, commentBody(content+"字", nil), "content too long (max 20000 characters)"},
+		{"comment content_json over", commentsPath, commentBody("x", bigDoc), "content_json too large (max 102400 bytes)"},
+	}
+	for _, tc := range cases {
+		rec := send(tc.path, tc.body)
+		if tc.wantErr == "" {
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: got %d %s, want 200", tc.name, rec.Code, rec.Body.String())
+			}
+			continue
+		}
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tc.wantErr) {
+			t.Errorf("%s: got %d %s, want 400 %q", tc.name, rec.Code, rec.Body.String(), tc.wantErr)
+		}
+	}
+}
+
+func TestAttachmentAndTagLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}, Limits: Limits{PostAttachments: 1, CommentAttachments: 1, PostTags: 2}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	router.POST("/api/v1/posts/:id/comments", h.CreateComment)
+	user, token := loginTestUser(t, s, "author@example.com", "author")
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, "u_x", "t", "c", "", "", nil, nil)
+	first := s.SaveFile(user.ID, "a.png", "a", "a", 0, 0)
+	second := s.SaveFile(user.ID, "b.png", "b", "b", 0, 0)
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	two := `["` + first.ID + `","` + second.ID + `"]`
+
+	for path, body := range map[string]string{
+		"/api/v1/posts":                          `{"board_id":"` + boardID + `","title":"t","content":"c","attachments":` + two + `}`,
+		"/api/v1/posts/" + post.ID + "/comments": `{"content":"c","attachments":` + two + `}`,
+	} {
+		rec := send(path, body)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"attachments":"too_many"`) {
+			t.Fatalf("%s with two attachments: got %d %s, want 400 too_many", path, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := send("/api/v1/posts", `{"board_id":"`+boardID+`","title":"t","content":"c","attachments":["`+first.ID+`"],"tags":["a","b","c"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("post within limits: got %d %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(created.Tags) != 2 {
+		t.Fatalf("tags = %v, want the first two", created.Tags)
+	}
+}
@@ -0,0 +1,68 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestListPostsAcrossSeveralBoards(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/posts", h.ListPosts)
+
+	boards := s.Boards()
+	first := s.CreatePost(boards[0].ID, "u_author", "first", "c", "", "", nil, nil)
+	second := s.CreatePost(boards[1].ID, "u_author", "second", "c", "", "", nil, nil)
+	s.CreatePost(boards[2].ID, "u_author", "elsewhere", "c", "", "", nil, nil)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/posts?"+query, nil))
+		return rec
+	}
+
+	// Blank and repeated entries are dropped and the unknown board matches
+	// nothing; paging walks both remaining boards without repeats.
+	query := "board_id=" + boards[0].ID + ",%20," + boards[1].ID + ",b_missing," + boards[0].ID + "&page_size=1"
+	seen := map[string]bool{}
+	for page := 1; page <= 2; page++ {
+		var resp struct {
+			Items []postItem `json:"items"`
+			Total int        `json:"total"`
+		}
+		rec := get(query + "&page=" + strconv.Itoa(page))
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+		}
+		if resp.Total != 2 || len(resp.Items) != 1 {
+			t.Fatalf("page %d = %+v, want 1 item of 2", page, resp)
+		}
+		seen[resp.Items[0].ID] = true
+	}
+	if !seen[first.ID] || !seen[second.ID] {
+		t.Fatalf("pages returned %v, want %s and %s", seen, first.ID, second.ID)
+	}
+
+	if rec := get("board_id=" + boards[0].ID + "," + boards[1].ID + "&after="); rec.Code != http.StatusBadRequest {
+		t.Fatalf("multi-board cursor: got %d, want 400", rec.Code)
+	}
+
+	tooMany := make([]string, maxListBoardIDs+1)
+	for i := range tooMany {
+		tooMany[i] = "b_" + strconv.Itoa(i)
+	}
+	if rec := get("board_id=" + strings.Join(tooMany, ",")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%d board ids: got %d, want 400", len(tooMany), rec.Code)
+	}
+}
@@ -0,0 +1,33 @@
+package community
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRecount handles POST /api/v1/admin/recount. It first raises any ID
+// counter that has fallen behind its table, then reports the dangling
+// references VerifyIntegrity finds; those are listed, never changed.
+func (h *Handler) AdminRecount(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	repaired, err := h.Store.RepairCounters()
+	if err != nil {
+		log.Printf("recount: repair counters: %v", err)
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+	problems, err := h.Store.VerifyIntegrity()
+	if err != nil {
+		log.Printf("recount: verify integrity: %v", err)
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"repaired": repaired,
+		"problems": problems,
+	})
+}
@@ -0,0 +1,82 @@
+package community
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxMentions caps how many distinct @nicknames one post or comment resolves,
+	// bounding both lookups and the notifications a single write can fan out.
+	maxMentions = 10
+	// maxMentionLength matches the store's nickname limit.
+	maxMentionLength = 32
+)
+
+type mentionItem struct {
+	UserID   string `json:"user_id"`
+	Nickname string `json:"nickname"`
+}
+
+// extractMentions returns the distinct nicknames written as @nickname in
+// content, in order of first appearance. A mention runs over letters, digits,
+// '_', '-' and '.', and must not follow a letter or digit, so e-mail
+// addresses are not mistaken for mentions. Trailing dots are dropped.
+func extractMentions(content string) []string {
+	var out []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		if r == '@' && !isMentionRune(prev) {
+			start := i + size
+			end := start
+			for end < len(content) {
+				next, n := utf8.DecodeRuneInString(content[end:])
+				if !isMentionRune(next) {
+					break
+				}
+				end += n
+			}
+			for end > start && content[end-1] == '.' {
+				end--
+			}
+			if end > start {
+				nickname := content[start:end]
+				if utf8.RuneCountInString(nickname) <= maxMentionLength && !seen[nickname] {
+					seen[nickname] = true
+					out = append(out, nickname)
+					if len(out) >= maxMentions {
+						return out
+					}
+				}
+				prev, _ = utf8.DecodeLastRuneInString(nickname)
+				i = end
+				continue
+			}
+		}
+		prev = r
+		i += size
+	}
+	return out
+}
+
+func isMentionRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+// notifyMentions resolves the @nicknames in content and sends each mentioned
+// user a "mention" notification for the target. Unknown or ambiguous
+// nicknames and the author are skipped. It returns the resolved mentions.
+func (h *Handler) notifyMentions(content, authorID, targetType, targetID string) []mentionItem {
+	mentions := []mentionItem{}
+	for _, nickname := range extractMentions(content) {
+		user, ok := h.Store.UserByNickname(nickname)
+		if !ok || user.ID == authorID {
+			continue
+		}
+		mentions = append(mentions, mentionItem{UserID: user.ID, Nickname: user.Nickname})
+		_, _ = h.Store.CreateNotification(user.ID, authorID, "mention", targetType, targetID)
+	}
+	return mentions
+}
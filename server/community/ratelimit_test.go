@@ -0,0 +1,55 @@
+package community
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestWritesReportRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}, PostLimiter: ratelimit.NewFixedWindow(time.Minute, 2)}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	_, token := loginTestUser(t, s, "author@example.com", "author")
+	body := `{"board_id":"` + s.Boards()[0].ID + `","title":"t","content":"c"}`
+
+	for i, want := range []struct {
+		code      int
+		remaining string
+	}{
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != want.code {
+			t.Fatalf("write %d: got %d %s, want %d", i, rec.Code, rec.Body.String(), want.code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Fatalf("write %d: X-RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != want.remaining {
+			t.Fatalf("write %d: X-RateLimit-Remaining = %q, want %s", i, got, want.remaining)
+		}
+		reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if until := time.Until(time.Unix(reset, 0)); err != nil || until <= 0 || until > time.Minute+time.Second {
+			t.Fatalf("write %d: X-RateLimit-Reset = %q, want about a minute away", i, rec.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+}
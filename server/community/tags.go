@@ -0,0 +1,64 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+const (
+	defaultTagLimit = 30
+	maxTagLimit     = 100
+	maxTagPageSize  = 100
+)
+
+type tagItem struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ListTags handles GET /api/v1/tags?limit=30, the most used tags for a tag cloud.
+func (h *Handler) ListTags(c *gin.Context) {
+	limit := min(parsePositiveInt(c.Query("limit"), defaultTagLimit), maxTagLimit)
+
+	tags := h.Store.PopularTags(limit)
+	items := make([]tagItem, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, tagItem{Tag: tag.Tag, Count: tag.Count})
+	}
+	c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// ListTagPosts handles GET /api/v1/tags/{tag}/posts?page=1&page_size=20.
+// Tags match case-insensitively; posts are newest first.
+func (h *Handler) ListTagPosts(c *gin.Context) {
+	tag := strings.TrimSpace(c.Param("tag"))
+	if tag == "" {
+		writeError(c, http.StatusBadRequest, 2001, "missing tag")
+		return
+	}
+	page := parsePositiveInt(c.Query("page"), 1)
+	pageSize := min(parsePositiveInt(c.Query("page_size"), 20), maxTagPageSize)
+
+	posts, total := h.Store.PostsByTag(tag, (page-1)*pageSize, pageSize)
+
+	viewerID := h.viewerID(c)
+	if blocked := h.blockedSet(viewerID); len(blocked) > 0 {
+		visible := make([]store.Post, 0, len(posts))
+		for _, post := range posts {
+			if _, ok := blocked[post.AuthorID]; !ok {
+				visible = append(visible, post)
+			}
+		}
+		posts = visible
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"tag":   tag,
+		"items": h.postItems(posts, viewerID, nil),
+		"total": total,
+	})
+}
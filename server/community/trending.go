@@ -0,0 +1,86 @@
+package community
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+const (
+	defaultTrendingHours = 24
+	minTrendingHours     = 24
+	maxTrendingHours     = 72
+	trendingPostLimit    = 10
+	trendingTagLimit     = 10
+	// trendingTTL is how long a ranking is reused before it is recomputed.
+	trendingTTL = time.Minute
+)
+
+// trendingCache keeps the last ranking per window so the homepage panel does
+// not rescan votes and comments on every request. The zero value is ready.
+type trendingCache struct {
+	mu      sync.Mutex
+	entries map[int]trendingEntry
+}
+
+type trendingEntry struct {
+	posts     []store.Post
+	tags      []store.TagCount
+	expiresAt time.Time
+}
+
+func (tc *trendingCache) get(s store.API, hours int, now time.Time) trendingEntry {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if entry, ok := tc.entries[hours]; ok && now.Before(entry.expiresAt) {
+		return entry
+	}
+	since := now.Add(-time.Duration(hours) * time.Hour)
+	entry := trendingEntry{
+		posts:     s.TrendingPosts(since, trendingPostLimit),
+		tags:      s.TrendingTags(since, trendingTagLimit),
+		expiresAt: now.Add(trendingTTL),
+	}
+	if tc.entries == nil {
+		tc.entries = map[int]trendingEntry{}
+	}
+	tc.entries[hours] = entry
+	return entry
+}
+
+// Trending handles GET /api/v1/trending?hours=24, the hottest posts and tags
+// created within the last 24 to 72 hours. Rankings are cached for a minute;
+// block filtering and the viewer's votes are applied per request.
+func (h *Handler) Trending(c *gin.Context) {
+	hours := parsePositiveInt(c.Query("hours"), defaultTrendingHours)
+	hours = max(minTrendingHours, min(hours, maxTrendingHours))
+
+	entry := h.trending.get(h.Store, hours, time.Now())
+
+	viewerID := h.viewerID(c)
+	posts := entry.posts
+	if blocked := h.blockedSet(viewerID); len(blocked) > 0 {
+		visible := make([]store.Post, 0, len(posts))
+		for _, post := range posts {
+			if _, ok := blocked[post.AuthorID]; !ok {
+				visible = append(visible, post)
+			}
+		}
+		posts = visible
+	}
+	tags := make([]tagItem, 0, len(entry.tags))
+	for _, tag := range entry.tags {
+		tags = append(tags, tagItem{Tag: tag.Tag, Count: tag.Count})
+	}
+
+	c.JSON(http.StatusOK, map[string]any{
+		"hours": hours,
+		"posts": h.postItems(posts, viewerID, nil),
+		"tags":  tags,
+	})
+}
@@ -0,0 +1,69 @@
+package community
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestValidationErrorsNameFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.POST("/api/v1/posts", h.CreatePost)
+	router.POST("/api/v1/posts/:id/comments", h.CreateComment)
+	_, token := loginTestUser(t, s, "author@example.com", "author")
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, "u_x", "t", "c", "", "", nil, nil)
+
+	cases := []struct {
+		name       string
+		path       string
+		body       string
+		wantMsg    string
+		wantFields map[string]string
+	}{
+		{"post missing title and board", "/api/v1/posts", `{"content":"x"}`,
+			"missing fields", map[string]string{"title": "required", "board_id": "required"}},
+		{"post unknown board", "/api/v1/posts", `{"board_id":"nope","title":"t","content":"x"}`,
+			"invalid board_id", map[string]string{"board_id": "invalid"}},
+		{"post without content", "/api/v1/posts", `{"board_id":"` + boardID + `","title":"t"}`,
+			"missing content", map[string]string{"content": "required"}},
+		{"comment unknown parent", "/api/v1/posts/" + post.ID + "/comments", `{"content":"x","parent_id":"c_nope"}`,
+			"invalid parent_id", map[string]string{"parent_id": "invalid"}},
+		{"comment unknown attachment", "/api/v1/posts/" + post.ID + "/comments", `{"content":"x","attachments":["f_nope"]}`,
+			"invalid attachment_id", map[string]string{"attachments": "invalid"}},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d %s, want 400", tc.name, rec.Code, rec.Body.String())
+			continue
+		}
+		var resp transport.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if resp.Code != 2001 || resp.Message != tc.wantMsg {
+			t.Errorf("%s: got code %d message %q, want 2001 %q", tc.name, resp.Code, resp.Message, tc.wantMsg)
+		}
+		if !reflect.DeepEqual(resp.Fields, tc.wantFields) {
+			t.Errorf("%s: got fields %v, want %v", tc.name, resp.Fields, tc.wantFields)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package community
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxVoterLimit = 100
+
+// ListPostVoters handles GET /api/v1/posts/{post_id}/votes?value=1&offset=0&limit=20.
+// Upvoters are public; downvoters (value=-1) are visible only to the post
+// author and admins so a downvote can't be turned into a grudge.
+func (h *Handler) ListPostVoters(c *gin.Context) {
+	postID := strings.TrimSpace(c.Param("id"))
+	if postID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	value := 1
+	switch strings.TrimSpace(c.Query("value")) {
+	case "", "1":
+	case "-1":
+		value = -1
+	default:
+		writeError(c, http.StatusBadRequest, 2001, "invalid vote value")
+		return
+	}
+
+	post, ok := h.Store.GetPost(postID)
+	if !ok {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+	if value == -1 {
+		user, ok := h.Auth.RequireUser(c)
+		if !ok {
+			return
+		}
+		if user.ID != post.AuthorID && !h.isAdmin(user) {
+			writeError(c, http.StatusForbidden, 1002, "forbidden")
+			return
+		}
+	}
+
+	offset := parsePositiveInt(c.Query("offset"), 0)
+	limit := min(parsePositiveInt(c.Query("limit"), 20), maxVoterLimit)
+
+	voters, total := h.Store.PostVoters(postID, value, offset, limit)
+	items := make([]userSummary, 0, len(voters))
+	for _, voter := range voters {
+		items = append(items, userSummaryFromUser(voter))
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"post_id": postID,
+		"value":   value,
+		"items":   items,
+		"total":   total,
+	})
+}
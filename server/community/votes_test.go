@@ -0,0 +1,109 @@
+package community
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func TestControversialSortAndVoteBreakdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}}
+	router := gin.New()
+	router.GET("/api/v1/posts", h.ListPosts)
+	router.GET("/api/v1/posts/:id", h.GetPost)
+	boardID := s.Boards()[0].ID
+
+	// up/down votes per post, oldest post first.
+	votes := []struct{ up, down int }{
+		{3, 3},  // split, the most contested
+		{10, 0}, // popular, not controversial
+		{1, 1},  // split, but too few votes to count
+		{4, 2},
+	}
+	posts := make([]store.Post, len(votes))
+	for i, v := range votes {
+		posts[i] = s.CreatePost(boardID, "u_author", fmt.Sprintf("post %d", i), "c", "", "", nil, nil)
+		for n := 0; n < v.up+v.down; n++ {
+			value := 1
+			if n >= v.up {
+				value = -1
+			}
+			if _, _, err := s.VotePost(posts[i].ID, fmt.Sprintf("u_voter%d", n), value); err != nil {
+				t.Fatalf("VotePost: %v", err)
+			}
+		}
+	}
+
+	get := func(path string, out any) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: got %d %s", path, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+	}
+
+	var list struct {
+		Items []postItem `json:"items"`
+	}
+	get("/api/v1/posts?sort=controversial", &list)
+	got := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		got = append(got, item.ID)
+	}
+	// Qualifying posts by min(up, down), then the rest newest first.
+	want := []string{posts[0].ID, posts[3].ID, posts[1].ID, posts[2].ID}
+	if !slices.Equal(got, want) {
+		t.Fatalf("controversial order = %v, want %v", got, want)
+	}
+
+	var detail struct {
+		Score     int `json:"score"`
+		Upvotes   int `json:"upvotes"`
+		Downvotes int `json:"downvotes"`
+	}
+	get("/api/v1/posts/"+posts[3].ID, &detail)
+	if detail.Upvotes != 4 || detail.Downvotes != 2 || detail.Score != 2 {
+		t.Fatalf("detail votes = %+v, want 4 up, 2 down, score 2", detail)
+	}
+
+	// The viewer's own vote comes back with when it was cast; without a vote
+	// my_vote_at is null.
+	viewer, token := loginTestUser(t, s, "viewer@example.com", "viewer")
+	myVoteAt := func() *string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/posts/"+posts[2].ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var resp struct {
+			MyVoteAt *string `json:"my_vote_at"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+		}
+		return resp.MyVoteAt
+	}
+	if got := myVoteAt(); got != nil {
+		t.Fatalf("my_vote_at before voting = %q, want null", *got)
+	}
+	if _, _, err := s.VotePost(posts[2].ID, viewer.ID, -1); err != nil {
+		t.Fatalf("VotePost: %v", err)
+	}
+	if got := myVoteAt(); got == nil || *got != s.PostVoteTime(posts[2].ID, viewer.ID) {
+		t.Fatalf("my_vote_at = %v, want the vote's created_at", got)
+	}
+}
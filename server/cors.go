@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/requestlog"
+)
+
+const (
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Authorization, Content-Type, X-Request-ID"
+	corsMaxAge       = "600"
+)
+
+// corsMiddleware 根据 CORS_ORIGINS（逗号分隔的白名单，如 http://localhost:5173）
+// 为匹配的来源设置 CORS 响应头，并直接应答预检请求。
+// 未在白名单中的来源不设置任何 CORS 头，由浏览器拦截；"*" 表示允许任意来源（此时不带凭据）。
+func corsMiddleware() gin.HandlerFunc {
+	allowed := map[string]bool{}
+	allowAny := false
+	for _, origin := range strings.Split(os.Getenv("CORS_ORIGINS"), ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+		case "*":
+			allowAny = true
+		default:
+			allowed[origin] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		switch {
+		case allowed[origin]:
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		case allowAny:
+			c.Header("Access-Control-Allow-Origin", "*")
+		default:
+			origin = ""
+		}
+		if origin != "" {
+			c.Header("Access-Control-Expose-Headers", requestlog.Header)
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			if origin != "" {
+				c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+				c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+				c.Header("Access-Control-Max-Age", corsMaxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
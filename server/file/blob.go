@@ -0,0 +1,64 @@
+package file
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Blob stores uploaded file contents by storage key.
+type Blob interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalBlob keeps files on the local disk under Dir.
+type LocalBlob struct {
+	Dir string
+}
+
+func (b *LocalBlob) Put(key string, r io.Reader) error {
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+		return err
+	}
+	dst, err := os.Create(b.Path(key))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Move stores the file at path under key by renaming it, which avoids a copy
+// when path is on the same filesystem as Dir. The file is made world-readable
+// first, like one written by Put; spooled uploads are created 0600.
+func (b *LocalBlob) Move(key, path string) error {
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path, b.Path(key))
+}
+
+func (b *LocalBlob) Open(key string) (io.ReadCloser, error) {
+	return os.Open(b.Path(key))
+}
+
+func (b *LocalBlob) Delete(key string) error {
+	err := os.Remove(b.Path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Path returns the on-disk location for key.
+func (b *LocalBlob) Path(key string) string {
+	return filepath.Join(b.Dir, key)
+}
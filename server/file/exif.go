@@ -0,0 +1,224 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+// jpegQuality is used when re-encoding uploaded JPEGs.
+const jpegQuality = 90
+
+// maxImagePixels caps the size of a JPEG we will decode. Decoding holds the
+// whole image in memory (4 bytes a pixel once oriented), and a small file can
+// declare huge dimensions, so larger images are rejected from the header.
+const maxImagePixels = 40_000_000
+
+// storedContent returns what to store for an uploaded file along with its
+// image size and byte length. Unless KeepImageMetadata is set, a JPEG
+// carrying metadata is re-encoded upright without it; if that fails, or the
+// file isn't such a JPEG, the original is stored unchanged.
+func (h *Handler) storedContent(file io.ReadSeeker, size int64) (io.Reader, int, int, int64, error) {
+	if !h.KeepImageMetadata {
+		data, width, height, err := cleanJPEG(file)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+		if data != nil {
+			return bytes.NewReader(data), width, height, int64(len(data)), nil
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, 0, 0, err
+		}
+	}
+
+	width, height, _ := readImageSize(file)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return file, width, height, size, nil
+}
+
+// cleanJPEG re-encodes a JPEG that has APP1 metadata (EXIF, XMP), applying
+// its EXIF orientation. The encoder writes no metadata, so GPS and camera
+// details are dropped. data is nil for non-JPEGs, JPEGs without metadata and
+// decode or encode failures; err is errImageTooLarge for a JPEG whose header
+// declares more than maxImagePixels, which is never decoded. r is read from
+// the start several times, and only the decoded image is held in memory.
+func cleanJPEG(r io.ReadSeeker) (data []byte, width, height int, err error) {
+	orientation, hasMetadata := jpegMetadata(r)
+	if !hasMetadata {
+		return nil, 0, 0, nil
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0, err
+	}
+	config, err := jpeg.DecodeConfig(r)
+	if err != nil {
+		return nil, 0, 0, nil
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, 0, 0, errImageTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0, err
+	}
+	img, err := jpeg.Decode(r)
+	if err != nil {
+		return nil, 0, 0, nil
+	}
+	img = applyOrientation(img, orientation)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, 0, 0, nil
+	}
+	bounds := img.Bounds()
+	return buf.Bytes(), bounds.Dx(), bounds.Dy(), nil
+}
+
+// jpegMetadata reads a JPEG's segments up to the image data and reports the
+// EXIF orientation (1 when absent) and whether any APP1 segment is present.
+// Only APP1 payloads, at most 64 KiB each, are read into memory; a reader
+// that doesn't start with the SOI marker has no metadata.
+func jpegMetadata(r io.Reader) (orientation int, hasMetadata bool) {
+	orientation = 1
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return orientation, false
+	}
+	for {
+		if b, err := br.ReadByte(); err != nil || b != 0xFF {
+			return orientation, hasMetadata
+		}
+		marker, err := br.ReadByte()
+		if err != nil {
+			return orientation, hasMetadata
+		}
+		switch {
+		case marker == 0xFF: // fill byte
+			_ = br.UnreadByte()
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // no payload
+			continue
+		case marker == 0xDA || marker == 0xD9: // start of scan / end of image
+			return orientation, hasMetadata
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return orientation, hasMetadata
+		}
+		length := int(binary.BigEndian.Uint16(size[:]))
+		if length < 2 {
+			return orientation, hasMetadata
+		}
+		if marker != 0xE1 {
+			if _, err := br.Discard(length - 2); err != nil {
+				return orientation, hasMetadata
+			}
+			continue
+		}
+		payload := make([]byte, length-2)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return orientation, hasMetadata
+		}
+		hasMetadata = true
+		if bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			if o := exifOrientation(payload[6:]); o != 0 {
+				orientation = o
+			}
+		}
+	}
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF-structured EXIF
+// block. It returns 0 when the tag is missing or malformed.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		value := int(order.Uint16(tiff[entry+8:]))
+		if value < 1 || value > 8 {
+			return 0
+		}
+		return value
+	}
+	return 0
+}
+
+// applyOrientation returns img transformed so that EXIF orientation o
+// displays upright.
+func applyOrientation(img image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch o {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise to display
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise to display
+				dx, dy = y, w-1-x
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
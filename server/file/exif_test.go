@@ -0,0 +1,121 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"testing"
+)
+
+// jpegWithEXIF encodes img and inserts an APP1 EXIF segment carrying the
+// given orientation plus a marker string standing in for GPS data.
+func jpegWithEXIF(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	// Little-endian TIFF with a single IFD0 entry: tag 0x0112, SHORT, count 1.
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS-SECRET"...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	raw := encoded.Bytes()
+	out := append([]byte{}, raw[:2]...)
+	out = append(out, segment...)
+	return append(out, raw[2:]...)
+}
+
+// halves returns a w×h image whose left half is red and right half blue.
+func halves(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCleanJPEGAppliesOrientationAndStripsEXIF(t *testing.T) {
+	data, width, height, err := cleanJPEG(bytes.NewReader(jpegWithEXIF(t, halves(32, 16), 6)))
+	if err != nil || data == nil {
+		t.Fatalf("cleanJPEG = %v, %v; want re-encoded data", data == nil, err)
+	}
+	// Orientation 6 turns the 32×16 image upright as 16×32, red on top.
+	if width != 16 || height != 32 {
+		t.Fatalf("size = %dx%d, want 16x32", width, height)
+	}
+	if _, hasMetadata := jpegMetadata(bytes.NewReader(data)); hasMetadata {
+		t.Fatal("cleaned JPEG still has an APP1 segment")
+	}
+	if bytes.Contains(data, []byte("GPS-SECRET")) {
+		t.Fatal("cleaned JPEG still carries the EXIF payload")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode cleaned: %v", err)
+	}
+	top, bottom := color.RGBAModel.Convert(img.At(8, 4)).(color.RGBA), color.RGBAModel.Convert(img.At(8, 28)).(color.RGBA)
+	if top.R < 200 || top.B > 60 || bottom.B < 200 || bottom.R > 60 {
+		t.Fatalf("top = %v, bottom = %v; want red over blue", top, bottom)
+	}
+
+	// Without metadata there is nothing to strip, so the file is kept as is.
+	var plain bytes.Buffer
+	_ = jpeg.Encode(&plain, halves(8, 8), nil)
+	if data, _, _, err := cleanJPEG(bytes.NewReader(plain.Bytes())); data != nil || err != nil {
+		t.Fatalf("plain JPEG: data = %v, err = %v; want left alone", data != nil, err)
+	}
+}
+
+func TestUploadRejectsJPEGDeclaringHugeDimensions(t *testing.T) {
+	bomb := jpegWithEXIF(t, halves(8, 8), 1)
+	// Rewrite the SOF0 frame header to claim 20000×20000 pixels.
+	sof := bytes.Index(bomb, []byte{0xFF, 0xC0})
+	if sof < 0 {
+		t.Fatal("no SOF0 marker")
+	}
+	binary.BigEndian.PutUint16(bomb[sof+5:], 20000)
+	binary.BigEndian.PutUint16(bomb[sof+7:], 20000)
+
+	if _, _, _, err := cleanJPEG(bytes.NewReader(bomb)); !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("cleanJPEG = %v, want errImageTooLarge", err)
+	}
+
+	router, token, dir := newUploadTest(t, 1<<20)
+	rec := postMultipart(router, token, map[string][]string{"file": {string(bomb)}})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("upload: got %d %s, want 400", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Message != errImageTooLarge.Error() {
+		t.Fatalf("body = %s", rec.Body.String())
+	}
+	if files := storedFiles(t, dir); len(files) != 0 {
+		t.Fatalf("stored %v, want nothing", files)
+	}
+}
@@ -1,22 +1,28 @@
 package file
 
 import (
-	"bytes"
+	"bufio"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
 	"io"
+	"io/fs"
+	"mime"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
@@ -24,72 +30,208 @@ type Handler struct {
 	Store     store.API
 	Auth      *auth.Service
 	UploadDir string
+	// Blob is where file contents are stored. Nil means local disk under UploadDir.
+	Blob Blob
+	// UploadLimiter throttles uploads per user; nil disables the limit.
+	UploadLimiter ratelimit.Limiter
+	// MaxUploadBytes caps the multipart request body; zero means DefaultMaxUploadBytes.
+	MaxUploadBytes int64
+	// KeepImageMetadata stores JPEGs as uploaded instead of re-encoding them
+	// upright without EXIF (which can carry GPS location).
+	KeepImageMetadata bool
 }
 
-// Upload handles POST /api/v1/files (multipart/form-data, field name: file).
+// DefaultMaxUploadBytes is the upload size limit when none is configured.
+const DefaultMaxUploadBytes = 100 << 20
+
+// maxUploadFiles caps how many files one multipart request may carry in "files".
+const maxUploadFiles = 9
+
+var (
+	errInvalidFilename = errors.New("invalid filename")
+	errSaveFailed      = errors.New("failed to save file")
+	errWriteFailed     = errors.New("failed to write file")
+	errImageTooLarge   = errors.New("image too large")
+)
+
+// uploadedFile is one upload result. In a batch, a file that failed carries
+// only Filename and Error.
+type uploadedFile struct {
+	ID       string `json:"id,omitempty"`
+	Filename string `json:"filename"`
+	URL      string `json:"url,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	// SHA256 is the hex digest of the bytes received, before any metadata stripping.
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Upload handles POST /api/v1/files (multipart/form-data). A single file goes
+// in the "file" field; up to maxUploadFiles files may instead be sent as
+// repeated "files" fields, in which case each gets its own result and one bad
+// file doesn't fail the rest. Parts are streamed to temporary files as they
+// arrive, so the body is never held in memory and the size cap is enforced
+// mid-stream.
 func (h *Handler) Upload(c *gin.Context) {
 	user, ok := h.Auth.RequireUser(c)
 	if !ok {
 		return
 	}
-
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 100<<20)
-	if err := c.Request.ParseMultipartForm(100 << 20); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid multipart form")
+	if !h.allowUpload(c, user.ID) {
 		return
 	}
 
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "missing file")
+	single, batch, ok := h.spoolMultipart(c)
+	if !ok {
 		return
 	}
-	defer file.Close()
-
-	filename := sanitizeFilename(header.Filename)
-	if filename == "" {
-		writeError(c, http.StatusBadRequest, 2001, "invalid filename")
+	defer func() {
+		if single != nil {
+			single.discard()
+		}
+		for _, part := range batch {
+			part.discard()
+		}
+	}()
+
+	if len(batch) > 0 {
+		items := make([]uploadedFile, 0, len(batch))
+		for _, part := range batch {
+			result, err := h.saveUpload(user.ID, part)
+			if err != nil {
+				result = uploadedFile{Filename: part.Filename, Error: err.Error()}
+			}
+			items = append(items, result)
+		}
+		c.JSON(http.StatusOK, map[string]any{"items": items})
+		return
+	}
+	if single == nil {
+		writeError(c, http.StatusBadRequest, 2001, "missing file")
 		return
 	}
 
-	if err := os.MkdirAll(h.UploadDir, 0o755); err != nil {
-		writeError(c, http.StatusInternalServerError, 5000, "failed to prepare storage")
+	result, err := h.saveUpload(user.ID, single)
+	switch {
+	case errors.Is(err, errInvalidFilename), errors.Is(err, errImageTooLarge):
+		writeError(c, http.StatusBadRequest, 2001, err.Error())
+		return
+	case err != nil:
+		writeError(c, http.StatusInternalServerError, 5000, err.Error())
 		return
 	}
+	c.JSON(http.StatusOK, result)
+}
 
-	storageKey := fmt.Sprintf("%d_%s", time.Now().UTC().UnixNano(), filename)
-	storagePath := filepath.Join(h.UploadDir, storageKey)
+// spoolMultipart reads the request body part by part, spooling the first
+// "file" part and every "files" part to disk and skipping anything else. The
+// body is capped at MaxUploadBytes: going over it mid-stream gets a 413,
+// more than maxUploadFiles "files" parts or a malformed body a 400. On
+// failure the response is written, spooled files are removed and ok is false.
+func (h *Handler) spoolMultipart(c *gin.Context) (single *spooledPart, batch []*spooledPart, ok bool) {
+	fail := func(status int, message string) (*spooledPart, []*spooledPart, bool) {
+		if single != nil {
+			single.discard()
+		}
+		for _, part := range batch {
+			part.discard()
+		}
+		writeError(c, status, 2001, message)
+		return nil, nil, false
+	}
+	failRead := func(err error) (*spooledPart, []*spooledPart, bool) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fail(http.StatusRequestEntityTooLarge, fmt.Sprintf("file too large (limit %d bytes)", tooLarge.Limit))
+		}
+		return fail(http.StatusBadRequest, "invalid multipart form")
+	}
 
-	dst, err := os.Create(storagePath)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadBytes())
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, 5000, "failed to save file")
-		return
+		return fail(http.StatusBadRequest, "invalid multipart form")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return single, batch, true
+		}
+		if err != nil {
+			return failRead(err)
+		}
+
+		field := part.FormName()
+		wanted := part.FileName() != "" && (field == "files" || (field == "file" && single == nil))
+		if !wanted {
+			_, err = io.Copy(io.Discard, part)
+			_ = part.Close()
+			if err != nil {
+				return failRead(err)
+			}
+			continue
+		}
+		if field == "files" && len(batch) == maxUploadFiles {
+			_ = part.Close()
+			return fail(http.StatusBadRequest, fmt.Sprintf("too many files (max %d)", maxUploadFiles))
+		}
+
+		spooled, err := spoolPart(h.spoolDir(), part)
+		_ = part.Close()
+		var diskErr *fs.PathError
+		if errors.As(err, &diskErr) {
+			return fail(http.StatusInternalServerError, errSaveFailed.Error())
+		}
+		if err != nil {
+			return failRead(err)
+		}
+		if field == "files" {
+			batch = append(batch, spooled)
+		} else {
+			single = spooled
+		}
 	}
-	defer dst.Close()
+}
 
-	if _, err := io.Copy(dst, file); err != nil {
-		writeError(c, http.StatusInternalServerError, 5000, "failed to write file")
-		return
+// saveUpload stores one spooled file and records its metadata.
+func (h *Handler) saveUpload(userID string, part *spooledPart) (uploadedFile, error) {
+	filename := sanitizeFilename(part.Filename)
+	if filename == "" {
+		return uploadedFile{}, errInvalidFilename
+	}
+
+	content, width, height, size, err := h.storedContent(part.File, part.Size)
+	if errors.Is(err, errImageTooLarge) {
+		return uploadedFile{}, err
+	}
+	if err != nil {
+		return uploadedFile{}, errSaveFailed
 	}
 
-	width, height, _ := readImageSize(storagePath)
-	meta := h.Store.SaveFile(user.ID, filename, storageKey, storagePath, width, height)
+	storageKey := fmt.Sprintf("%d_%s", time.Now().UTC().UnixNano(), filename)
+	local, isLocal := h.blob().(*LocalBlob)
+	if isLocal && content == io.Reader(part.File) {
+		// Stored unchanged: move the spooled file into place instead of copying it.
+		_ = part.File.Close()
+		err = local.Move(storageKey, part.File.Name())
+	} else {
+		err = h.blob().Put(storageKey, content)
+	}
+	if err != nil {
+		return uploadedFile{}, errWriteFailed
+	}
+	metrics.UploadBytes.Add(float64(size))
 
-	resp := struct {
-		ID       string `json:"id"`
-		Filename string `json:"filename"`
-		URL      string `json:"url"`
-		Width    int    `json:"width,omitempty"`
-		Height   int    `json:"height,omitempty"`
-	}{
+	meta := h.Store.SaveFile(userID, filename, storageKey, h.storagePath(storageKey), width, height)
+	return uploadedFile{
 		ID:       meta.ID,
 		Filename: meta.Filename,
 		URL:      "/files/" + meta.ID,
 		Width:    meta.Width,
 		Height:   meta.Height,
-	}
-
-	c.JSON(http.StatusOK, resp)
+		SHA256:   part.SHA256,
+	}, nil
 }
 
 // UploadImage handles POST /api/uploads/images (multipart/form-data, field name: file).
@@ -98,11 +240,11 @@ func (h *Handler) UploadImage(c *gin.Context) {
 	if !ok {
 		return
 	}
+	if !h.allowUpload(c, user.ID) {
+		return
+	}
 
-	const maxInlineImageSize = 100 << 20
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInlineImageSize)
-	if err := c.Request.ParseMultipartForm(maxInlineImageSize); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid multipart form")
+	if !h.parseMultipart(c) {
 		return
 	}
 
@@ -121,34 +263,33 @@ func (h *Handler) UploadImage(c *gin.Context) {
 
 	sniff := make([]byte, 512)
 	n, _ := file.Read(sniff)
-	contentType := http.DetectContentType(sniff[:n])
-	if !strings.HasPrefix(contentType, "image/") {
+	if _, ok := sniffImageType(sniff[:n]); !ok {
 		writeError(c, http.StatusBadRequest, 2001, "invalid image type")
 		return
 	}
 
-	if err := os.MkdirAll(h.UploadDir, 0o755); err != nil {
-		writeError(c, http.StatusInternalServerError, 5000, "failed to prepare storage")
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "failed to save file")
+		return
+	}
+	content, width, height, size, err := h.storedContent(file, header.Size)
+	if errors.Is(err, errImageTooLarge) {
+		writeError(c, http.StatusBadRequest, 2001, err.Error())
 		return
 	}
-
-	storageKey := fmt.Sprintf("%d_%s", time.Now().UTC().UnixNano(), filename)
-	storagePath := filepath.Join(h.UploadDir, storageKey)
-
-	dst, err := os.Create(storagePath)
 	if err != nil {
 		writeError(c, http.StatusInternalServerError, 5000, "failed to save file")
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, io.MultiReader(bytes.NewReader(sniff[:n]), file)); err != nil {
+	storageKey := fmt.Sprintf("%d_%s", time.Now().UTC().UnixNano(), filename)
+	if err := h.blob().Put(storageKey, content); err != nil {
 		writeError(c, http.StatusInternalServerError, 5000, "failed to write file")
 		return
 	}
+	metrics.UploadBytes.Add(float64(size))
 
-	width, height, _ := readImageSize(storagePath)
-	meta := h.Store.SaveFile(user.ID, filename, storageKey, storagePath, width, height)
+	meta := h.Store.SaveFile(user.ID, filename, storageKey, h.storagePath(storageKey), width, height)
 
 	resp := struct {
 		URL    string `json:"url"`
@@ -163,7 +304,8 @@ func (h *Handler) UploadImage(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// Download handles GET /files/{file_id}.
+// Download handles GET /files/{file_id}. The response names the original
+// filename inline; ?download=1 asks the browser to save it instead.
 func (h *Handler) Download(c *gin.Context) {
 	fileID := strings.TrimSpace(c.Param("id"))
 	if fileID == "" {
@@ -177,7 +319,132 @@ func (h *Handler) Download(c *gin.Context) {
 		return
 	}
 
-	c.File(meta.StoragePath)
+	disposition := "inline"
+	if c.Query("download") == "1" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", contentDisposition(disposition, meta.Filename))
+
+	if _, ok := h.blob().(*LocalBlob); ok {
+		// http.ServeFile picks the type from the extension, sniffing when it's unknown.
+		c.File(meta.StoragePath)
+		return
+	}
+
+	reader, err := h.blob().Open(meta.StorageKey)
+	if err != nil {
+		writeError(c, http.StatusNotFound, 2001, "file not found")
+		return
+	}
+	defer reader.Close()
+
+	body := bufio.NewReaderSize(reader, sniffLen)
+	contentType := mime.TypeByExtension(filepath.Ext(meta.Filename))
+	if contentType == "" {
+		head, _ := body.Peek(sniffLen)
+		contentType = http.DetectContentType(head)
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+}
+
+// Delete handles DELETE /api/v1/files/{file_id}. Only the uploader or an
+// admin may delete; the file stops being served and attachments referencing
+// it render as deleted.
+func (h *Handler) Delete(c *gin.Context) {
+	fileID := strings.TrimSpace(c.Param("id"))
+	if fileID == "" {
+		writeError(c, http.StatusNotFound, 2001, "file not found")
+		return
+	}
+
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Store.SoftDeleteFile(fileID, user.ID, h.Store.IsAdmin(user.ID)); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(c, http.StatusNotFound, 2001, "file not found")
+		case store.ErrForbidden:
+			writeError(c, http.StatusForbidden, 1002, "forbidden")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// sniffLen is how many leading bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// contentDisposition builds a Content-Disposition value for filename. Control
+// characters are dropped so the name can't inject headers, and non-ASCII
+// names are emitted as an RFC 5987 filename* parameter.
+func contentDisposition(disposition, filename string) string {
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename)
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); value != "" {
+		return value
+	}
+	return disposition
+}
+
+// parseMultipart parses the request as a multipart form of at most
+// MaxUploadBytes. Oversized bodies get a 413 naming the limit; other parse
+// failures a 400.
+func (h *Handler) parseMultipart(c *gin.Context) bool {
+	limit := h.maxUploadBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	err := c.Request.ParseMultipartForm(limit)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(c, http.StatusRequestEntityTooLarge, 2001, fmt.Sprintf("file too large (limit %d bytes)", tooLarge.Limit))
+		return false
+	}
+	writeError(c, http.StatusBadRequest, 2001, "invalid multipart form")
+	return false
+}
+
+func (h *Handler) maxUploadBytes() int64 {
+	if h.MaxUploadBytes > 0 {
+		return h.MaxUploadBytes
+	}
+	return DefaultMaxUploadBytes
+}
+
+// spoolDir is where uploads are streamed before being stored: the upload
+// directory itself for local storage, so a finished file can be renamed into
+// place, otherwise the system temp dir.
+func (h *Handler) spoolDir() string {
+	if local, ok := h.blob().(*LocalBlob); ok {
+		return local.Dir
+	}
+	return ""
+}
+
+func (h *Handler) blob() Blob {
+	if h.Blob != nil {
+		return h.Blob
+	}
+	return &LocalBlob{Dir: h.UploadDir}
+}
+
+// storagePath records where a stored key lives; only the local backend has a disk path.
+func (h *Handler) storagePath(storageKey string) string {
+	if local, ok := h.blob().(*LocalBlob); ok {
+		return local.Path(storageKey)
+	}
+	return storageKey
 }
 
 // sanitizeFilename strips directory components and trims whitespace to prevent path traversal.
@@ -188,14 +455,8 @@ func sanitizeFilename(name string) string {
 	return cleaned
 }
 
-func readImageSize(path string) (int, int, bool) {
-	file, err := os.Open(path)
-	if err != nil {
-		return 0, 0, false
-	}
-	defer file.Close()
-
-	cfg, _, err := image.DecodeConfig(file)
+func readImageSize(r io.Reader) (int, int, bool) {
+	cfg, _, err := image.DecodeConfig(r)
 	if err != nil {
 		return 0, 0, false
 	}
@@ -205,6 +466,20 @@ func readImageSize(path string) (int, int, bool) {
 	return cfg.Width, cfg.Height, true
 }
 
+// allowUpload applies UploadLimiter to userID, setting the X-RateLimit-* headers
+// and writing a 429 when it is exhausted.
+func (h *Handler) allowUpload(c *gin.Context, userID string) bool {
+	if h.UploadLimiter == nil {
+		return true
+	}
+	allowed := h.UploadLimiter.Allow("user:" + userID)
+	transport.RateLimitHeaders(c, h.UploadLimiter, "user:"+userID)
+	if !allowed {
+		transport.RateLimited(c, h.UploadLimiter.RetryAfter("user:"+userID))
+	}
+	return allowed
+}
+
 func writeError(c *gin.Context, status int, code int, message string) {
-	c.JSON(status, gin.H{"code": code, "message": message})
+	transport.Error(c, status, code, message)
 }
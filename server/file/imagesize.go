@@ -0,0 +1,255 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebP and AVIF are registered with the image package for DecodeConfig only,
+// so readImageSize can size them; there is no pixel decoder for either, and
+// image.Decode on them fails with errNoPixelDecoder.
+func init() {
+	image.RegisterFormat("webp", "RIFF????WEBPVP8", decodeNoPixels, decodeWebPConfig)
+	image.RegisterFormat("avif", "????ftypavif", decodeNoPixels, decodeAVIFConfig)
+	image.RegisterFormat("avif", "????ftypavis", decodeNoPixels, decodeAVIFConfig)
+}
+
+var (
+	errNoPixelDecoder = errors.New("image: only the header of this format can be read")
+	errBadImageHeader = errors.New("image: malformed header")
+)
+
+// sniffImageType returns the content type of an uploaded image from its
+// first bytes, and false when they are not an image. It is
+// http.DetectContentType plus AVIF, which the standard sniffer reports as
+// application/octet-stream.
+func sniffImageType(head []byte) (string, bool) {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if brand := string(head[8:12]); brand == "avif" || brand == "avis" {
+			return "image/avif", true
+		}
+	}
+	contentType := http.DetectContentType(head)
+	return contentType, strings.HasPrefix(contentType, "image/")
+}
+
+func decodeNoPixels(io.Reader) (image.Image, error) {
+	return nil, errNoPixelDecoder
+}
+
+// decodeWebPConfig reads the canvas size from the first chunk of a WebP
+// file: the frame header of a lossy (VP8) or lossless (VP8L) image, or the
+// extended (VP8X) header used for alpha and animation.
+func decodeWebPConfig(r io.Reader) (image.Config, error) {
+	var head [30]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return image.Config{}, err
+	}
+	if string(head[:4]) != "RIFF" || string(head[8:12]) != "WEBP" {
+		return image.Config{}, errBadImageHeader
+	}
+	var width, height int
+	switch chunk := head[20:]; string(head[12:16]) {
+	case "VP8 ":
+		// 3-byte frame tag, start code, then 14-bit width and height.
+		if !bytes.Equal(chunk[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return image.Config{}, errBadImageHeader
+		}
+		width = int(binary.LittleEndian.Uint16(chunk[6:]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(chunk[8:]) & 0x3fff)
+	case "VP8L":
+		// Signature byte, then width-1 and height-1 in 14 bits each.
+		if chunk[0] != 0x2f {
+			return image.Config{}, errBadImageHeader
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:])
+		width = int(bits&0x3fff) + 1
+		height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		// Flags and reserved bytes, then 24-bit canvas width-1 and height-1.
+		width = int(uint24(chunk[4:])) + 1
+		height = int(uint24(chunk[7:])) + 1
+	default:
+		return image.Config{}, errBadImageHeader
+	}
+	return image.Config{Width: width, Height: height}, nil
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// avifHeaderLimit caps how much of an AVIF file is read looking for its meta
+// box, which encoders write ahead of the image data.
+const avifHeaderLimit = 64 << 10
+
+// decodeAVIFConfig reads the size of an AVIF's primary item from the image
+// spatial extents ("ispe") property the meta box associates with it, swapping
+// the sides when an "irot" property turns it a quarter.
+func decodeAVIFConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(io.LimitReader(r, avifHeaderLimit))
+	if err != nil {
+		return image.Config{}, err
+	}
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return image.Config{}, errBadImageHeader
+	}
+	meta = meta[4:] // version and flags
+
+	var primary uint32
+	hasPrimary := false
+	if pitm, ok := findBox(meta, "pitm"); ok && len(pitm) >= 6 {
+		if pitm[0] == 0 {
+			primary = uint32(binary.BigEndian.Uint16(pitm[4:]))
+		} else if len(pitm) >= 8 {
+			primary = binary.BigEndian.Uint32(pitm[4:])
+		}
+		hasPrimary = true
+	}
+	iprp, ok := findBox(meta, "iprp")
+	if !ok {
+		return image.Config{}, errBadImageHeader
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return image.Config{}, errBadImageHeader
+	}
+	properties := boxes(ipco)
+
+	// Without an association table, fall back to the first ispe.
+	indexes := make([]int, len(properties))
+	for i := range indexes {
+		indexes[i] = i + 1
+	}
+	if ipma, ok := findBox(iprp, "ipma"); ok && hasPrimary {
+		if associated, ok := itemProperties(ipma, primary); ok {
+			indexes = associated
+		}
+	}
+
+	var width, height uint32
+	quarterTurn := false
+	for _, index := range indexes {
+		if index < 1 || index > len(properties) {
+			continue
+		}
+		property := properties[index-1]
+		switch property.typ {
+		case "ispe":
+			if width == 0 && len(property.body) >= 12 {
+				width = binary.BigEndian.Uint32(property.body[4:])
+				height = binary.BigEndian.Uint32(property.body[8:])
+			}
+		case "irot":
+			if len(property.body) >= 1 {
+				quarterTurn = property.body[0]&1 == 1
+			}
+		}
+	}
+	if width == 0 || height == 0 {
+		return image.Config{}, errBadImageHeader
+	}
+	if quarterTurn {
+		width, height = height, width
+	}
+	return image.Config{Width: int(width), Height: int(height)}, nil
+}
+
+// itemProperties returns the 1-based ipco indexes an "ipma" box associates
+// with itemID.
+func itemProperties(ipma []byte, itemID uint32) ([]int, bool) {
+	if len(ipma) < 8 {
+		return nil, false
+	}
+	version, wideIndex := ipma[0], ipma[3]&1 == 1
+	count := binary.BigEndian.Uint32(ipma[4:])
+	pos := 8
+	for i := uint32(0); i < count; i++ {
+		var id uint32
+		if version < 1 {
+			if pos+2 > len(ipma) {
+				return nil, false
+			}
+			id = uint32(binary.BigEndian.Uint16(ipma[pos:]))
+			pos += 2
+		} else {
+			if pos+4 > len(ipma) {
+				return nil, false
+			}
+			id = binary.BigEndian.Uint32(ipma[pos:])
+			pos += 4
+		}
+		if pos >= len(ipma) {
+			return nil, false
+		}
+		n := int(ipma[pos])
+		pos++
+		indexes := make([]int, 0, n)
+		for j := 0; j < n; j++ {
+			if wideIndex {
+				if pos+2 > len(ipma) {
+					return nil, false
+				}
+				indexes = append(indexes, int(binary.BigEndian.Uint16(ipma[pos:])&0x7fff))
+				pos += 2
+			} else {
+				if pos+1 > len(ipma) {
+					return nil, false
+				}
+				indexes = append(indexes, int(ipma[pos]&0x7f))
+				pos++
+			}
+		}
+		if id == itemID {
+			return indexes, true
+		}
+	}
+	return nil, false
+}
+
+type isoBox struct {
+	typ  string
+	body []byte
+}
+
+// boxes splits data into ISO base media boxes, stopping at the first one
+// that is malformed or runs past the end of data.
+func boxes(data []byte) []isoBox {
+	var out []isoBox
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0: // extends to the end
+			size = uint64(len(data))
+		case 1: // 64-bit size follows the type
+			if len(data) < 16 {
+				return out
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return out
+		}
+		out = append(out, isoBox{typ: typ, body: data[header:size]})
+		data = data[size:]
+	}
+	return out
+}
+
+func findBox(data []byte, typ string) ([]byte, bool) {
+	for _, box := range boxes(data) {
+		if box.typ == typ {
+			return box.body, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,134 @@
+package file
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// 1x1 WebP files in each of the three bitstream layouts.
+var webpFixtures = map[string]string{
+	"lossy.webp":    "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA",
+	"lossless.webp": "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==",
+	"alpha.webp":    "UklGRkoAAABXRUJQVlA4WAoAAAAQAAAAAAAAAAAAQUxQSAwAAAARBxAR/Q9ERP8DAABWUDggGAAAABQBAJ0BKgEAAQAAAP4AAA3AAP7mtQAAAA==",
+}
+
+func TestUploadImageStoresWebPSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	reg, err := s.Register("images@example.com", "password123", "images")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("images@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}, UploadDir: t.TempDir()}
+	router := gin.New()
+	router.POST("/api/uploads/images", h.UploadImage)
+
+	for name, encoded := range webpFixtures {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, _ := w.CreateFormFile("file", name)
+		_, _ = part.Write(data)
+		_ = w.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/uploads/images", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", name, rec.Code, rec.Body.String())
+		}
+
+		var resp struct {
+			URL    string `json:"url"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if resp.Width != 1 || resp.Height != 1 {
+			t.Errorf("%s: response size = %dx%d, want 1x1", name, resp.Width, resp.Height)
+		}
+		meta, ok := s.GetFile(strings.TrimPrefix(resp.URL, "/files/"))
+		if !ok || meta.Width != 1 || meta.Height != 1 {
+			t.Errorf("%s: stored size = %dx%d (found %v), want 1x1", name, meta.Width, meta.Height, ok)
+		}
+	}
+}
+
+func TestReadImageSizeWebPAndAVIF(t *testing.T) {
+	// Headers alone are enough for DecodeConfig; the sizes differ per side so
+	// a swapped width and height shows.
+	vp8 := webpHeader("VP8 ", []byte{0x30, 0x01, 0x00, 0x9d, 0x01, 0x2a, 0x40, 0x01, 0xf0, 0x00})
+	vp8l := webpHeader("VP8L", []byte{0x2f, 0x3f, 0xc1, 0x3b, 0x00, 0, 0, 0, 0, 0})
+	vp8x := webpHeader("VP8X", []byte{0x10, 0, 0, 0, 0x3f, 0x01, 0x00, 0xef, 0x00, 0x00})
+	ftyp := isoBoxBytes("ftyp", []byte("avif\x00\x00\x00\x00avifmif1"))
+	pitm := isoBoxBytes("pitm", []byte{0, 0, 0, 0, 0, 2})
+	ispe := func(width, height uint32) []byte {
+		body := make([]byte, 12)
+		binary.BigEndian.PutUint32(body[4:], width)
+		binary.BigEndian.PutUint32(body[8:], height)
+		return isoBoxBytes("ispe", body)
+	}
+	// Item 1 is a 64x64 thumbnail listed first; item 2, the primary, is
+	// 320x240 and turned a quarter by property 3.
+	ipco := isoBoxBytes("ipco", ispe(64, 64), ispe(320, 240), isoBoxBytes("irot", []byte{1}))
+	ipma := isoBoxBytes("ipma", []byte{0, 0, 0, 0, 0, 0, 0, 2, 0, 1, 1, 0x81, 0, 2, 2, 0x82, 0x03})
+	avif := append(ftyp, isoBoxBytes("meta", []byte{0, 0, 0, 0}, pitm, isoBoxBytes("iprp", ipco, ipma))...)
+
+	cases := []struct {
+		name          string
+		data          []byte
+		width, height int
+	}{
+		{"webp lossy", vp8, 320, 240},
+		{"webp lossless", vp8l, 320, 240},
+		{"webp extended", vp8x, 320, 240},
+		{"avif rotated primary", avif, 240, 320},
+	}
+	for _, tc := range cases {
+		width, height, ok := readImageSize(bytes.NewReader(tc.data))
+		if !ok || width != tc.width || height != tc.height {
+			t.Errorf("%s: got %dx%d ok=%v, want %dx%d", tc.name, width, height, ok, tc.width, tc.height)
+		}
+	}
+	if contentType, ok := sniffImageType(avif); !ok || contentType != "image/avif" {
+		t.Errorf("sniff avif = %q, %v", contentType, ok)
+	}
+}
+
+func webpHeader(chunk string, payload []byte) []byte {
+	out := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(payload)))
+	return append(out, payload...)
+}
+
+func isoBoxBytes(typ string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	out = append(out, typ...)
+	return append(out, body...)
+}
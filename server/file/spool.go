@@ -0,0 +1,56 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"os"
+)
+
+// spooledPart is one uploaded multipart file streamed to a temporary file,
+// with its size and SHA-256 taken while it was written.
+type spooledPart struct {
+	Filename string
+	File     *os.File
+	Size     int64
+	SHA256   string
+}
+
+// spoolPart streams part into a new temporary file in dir ("" for the system
+// temp dir). Errors from the request body, such as *http.MaxBytesError, are
+// returned as is.
+func spoolPart(dir string, part *multipart.Part) (*spooledPart, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), part)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &spooledPart{
+		Filename: part.FileName(),
+		File:     tmp,
+		Size:     size,
+		SHA256:   hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// discard closes and removes the temporary file. It is safe to call after
+// the file has been moved into storage.
+func (p *spooledPart) discard() {
+	_ = p.File.Close()
+	_ = os.Remove(p.File.Name())
+}
@@ -0,0 +1,137 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+func newUploadTest(t *testing.T, maxBytes int64) (*gin.Engine, string, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := store.NewStore()
+	reg, err := s.Register("uploader@example.com", "password123", "uploader")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, _, err := s.Login("uploader@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	dir := t.TempDir()
+	h := &Handler{Store: s, Auth: &auth.Service{Store: s}, UploadDir: dir, MaxUploadBytes: maxBytes}
+	router := gin.New()
+	router.POST("/api/v1/files", h.Upload)
+	return router, session.Token, dir
+}
+
+func postMultipart(router *gin.Engine, token string, fields map[string][]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("note", "ignored")
+	for field, contents := range fields {
+		for i, content := range contents {
+			part, _ := w.CreateFormFile(field, field+string(rune('a'+i))+".txt")
+			_, _ = part.Write([]byte(content))
+		}
+	}
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// storedFiles lists the upload dir, failing if a spooled temp file was left behind.
+func storedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".upload-") {
+			t.Fatalf("spooled file %s left behind", entry.Name())
+		}
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestUploadStreamsSingleFile(t *testing.T) {
+	router, token, dir := newUploadTest(t, 0)
+
+	rec := postMultipart(router, token, map[string][]string{"file": {"hello world"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var resp uploadedFile
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// sha256("hello world")
+	if resp.SHA256 != "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9" {
+		t.Fatalf("sha256 = %s", resp.SHA256)
+	}
+	names := storedFiles(t, dir)
+	if len(names) != 1 {
+		t.Fatalf("stored files = %v, want one", names)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, names[0]))
+	if err != nil || string(data) != "hello world" {
+		t.Fatalf("stored content = %q, %v", data, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, names[0]))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Fatalf("stored file mode = %v, want 0644", perm)
+	}
+}
+
+func TestUploadBatchAndLimits(t *testing.T) {
+	router, token, dir := newUploadTest(t, 4<<10)
+
+	rec := postMultipart(router, token, map[string][]string{"files": {"one", "two"}})
+	if rec.Code != http.StatusOK || strings.Count(rec.Body.String(), `"id"`) != 2 {
+		t.Fatalf("batch: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	tooMany := make([]string, maxUploadFiles+1)
+	for i := range tooMany {
+		tooMany[i] = "x"
+	}
+	if rec := postMultipart(router, token, map[string][]string{"files": tooMany}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("too many files: status = %d", rec.Code)
+	}
+	if rec := postMultipart(router, token, map[string][]string{"file": {strings.Repeat("x", 8<<10)}}); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := postMultipart(router, token, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("no file: status = %d", rec.Code)
+	}
+
+	if names := storedFiles(t, dir); len(names) != 2 {
+		t.Fatalf("stored files = %v, want only the two batch files", names)
+	}
+}
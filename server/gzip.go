@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipMinSize 是开始压缩的最小响应体积；更小的响应压缩收益不抵开销。
+const gzipMinSize = 1024
+
+// gzipMiddleware 对 application/json 与 text/html 响应做 gzip 压缩。
+// 以下请求原样放行：不接受 gzip、WebSocket 升级、Range 请求、HEAD，以及 /files/ 下载。
+// 可压缩类型的响应无论是否压缩都带 Vary: Accept-Encoding，以免缓存把一种编码的内容给了另一种客户端。
+func gzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := c.Request
+		if req.Header.Get("Upgrade") != "" ||
+			req.Header.Get("Range") != "" ||
+			strings.HasPrefix(req.URL.Path, "/files/") {
+			c.Next()
+			return
+		}
+		if req.Method == http.MethodHead || !acceptsGzip(req.Header.Get("Accept-Encoding")) {
+			original := c.Writer
+			c.Writer = &varyResponseWriter{ResponseWriter: original}
+			defer func() {
+				c.Writer = original
+			}()
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &gzipResponseWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = original
+		}()
+
+		c.Next()
+	}
+}
+
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+func compressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return mediaType == "application/json" || mediaType == "text/html"
+}
+
+// gzipResponseWriter 先缓冲响应，直到达到 gzipMinSize 或处理结束，
+// 再根据 Content-Type 和体积决定是否压缩；在此之前不向下游写出状态码。
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if code > 0 && !w.decided {
+		w.status = code
+	}
+}
+
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+}
+
+func (w *gzipResponseWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipResponseWriter) Written() bool {
+	return w.decided || w.buf.Len() > 0
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() >= gzipMinSize {
+			if err := w.decide(); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 写出状态码，并把已缓冲的内容按是否压缩交给下游。
+func (w *gzipResponseWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Encoding") == "" && compressibleType(header.Get("Content-Type")) {
+		// 小于 gzipMinSize 不压缩，但同一地址内容变大后会压缩，Vary 照样要带。
+		header.Add("Vary", "Accept-Encoding")
+		if w.buf.Len() >= gzipMinSize {
+			header.Del("Content-Length")
+			header.Set("Content-Encoding", "gzip")
+			w.gz = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+// varyResponseWriter 用于不压缩的请求：在写出响应头前为可压缩类型补上 Vary: Accept-Encoding，
+// 与 gzipResponseWriter 给同一地址的响应保持一致。
+type varyResponseWriter struct {
+	gin.ResponseWriter
+	checked bool
+}
+
+func (w *varyResponseWriter) vary() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	header := w.Header()
+	if header.Get("Content-Encoding") == "" && compressibleType(header.Get("Content-Type")) {
+		header.Add("Vary", "Accept-Encoding")
+	}
+}
+
+func (w *varyResponseWriter) WriteHeaderNow() {
+	w.vary()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *varyResponseWriter) Write(p []byte) (int, error) {
+	w.vary()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *varyResponseWriter) WriteString(s string) (int, error) {
+	w.vary()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *varyResponseWriter) Flush() {
+	w.vary()
+	w.ResponseWriter.Flush()
+}
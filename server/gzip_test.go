@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGzipMiddlewareVaries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gzipMiddleware())
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"body": strings.Repeat("a", 2*gzipMinSize)})
+	})
+	router.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("a", 2*gzipMinSize))
+	})
+
+	cases := []struct {
+		path, acceptEncoding string
+		gzipped, vary        bool
+	}{
+		{"/large", "gzip", true, true},
+		{"/small", "gzip", false, true},
+		{"/large", "", false, true},
+		{"/small", "identity", false, true},
+		{"/text", "gzip", false, false},
+		{"/text", "", false, false},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if gzipped := rec.Header().Get("Content-Encoding") == "gzip"; gzipped != tc.gzipped {
+			t.Errorf("%s with %q: gzipped = %v, want %v", tc.path, tc.acceptEncoding, gzipped, tc.gzipped)
+		}
+		if vary := rec.Header().Get("Vary") == "Accept-Encoding"; vary != tc.vary {
+			t.Errorf("%s with %q: Vary = %q, want set = %v", tc.path, tc.acceptEncoding, rec.Header().Get("Vary"), tc.vary)
+		}
+	}
+}
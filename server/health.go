@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/file"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// healthHandler 处理 /healthz（存活探针）：数据库可达时返回 {"status":"ok"}，
+// 否则返回 503 {"status":"degraded","db":"down"}。
+func healthHandler(dataStore store.API) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := dataStore.Ping(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "degraded", "db": "down"})
+			return
+		}
+		c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// readyHandler 处理 /readyz（就绪探针）：在 /healthz 的基础上，
+// 本地文件存储时还要求上传目录可写；使用 S3 时不检查本地目录。
+func readyHandler(dataStore store.API, blob file.Blob) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := map[string]string{"db": "up"}
+		ready := true
+		if err := dataStore.Ping(c.Request.Context()); err != nil {
+			checks["db"] = "down"
+			ready = false
+		}
+		if local, ok := blob.(*file.LocalBlob); ok {
+			checks["uploads"] = "writable"
+			if err := checkWritableDir(local.Dir); err != nil {
+				checks["uploads"] = "unwritable"
+				ready = false
+			}
+		}
+
+		if !ready {
+			checks["status"] = "not_ready"
+			c.JSON(http.StatusServiceUnavailable, checks)
+			return
+		}
+		checks["status"] = "ok"
+		c.JSON(http.StatusOK, checks)
+	}
+}
+
+// checkWritableDir 通过创建并删除一个临时文件确认目录可写（目录不存在时先创建）。
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	closeErr := f.Close()
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	return closeErr
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "campus_hub"
+
+var (
+	// HTTPRequests counts finished requests by method, matched route and status.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes request latency by method and matched route.
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// WSConnections tracks WebSocket clients currently joined to each chat room.
+	WSConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_connections",
+		Help:      "WebSocket clients currently joined to a chat room.",
+	}, []string{"room"})
+
+	// WSSlowClientsClosed counts WebSocket clients closed because their send
+	// queue stayed full past the slow-client deadline.
+	WSSlowClientsClosed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_slow_clients_closed_total",
+		Help:      "WebSocket clients closed for falling behind on broadcasts.",
+	})
+
+	// UploadBytes sums the size of successfully stored uploads.
+	UploadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_bytes_total",
+		Help:      "Bytes of uploaded files that were stored.",
+	})
+
+	// DBErrors counts failed database calls by operation (exec, query, begin).
+	DBErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_errors_total",
+		Help:      "Database calls that returned an error.",
+	}, []string{"op"})
+
+	// StoreCacheLookups counts CachedStore reads by cache (boards, users,
+	// post_scores) and result (hit, miss); hit ratio = hit / (hit + miss).
+	StoreCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "store_cache_lookups_total",
+		Help:      "Store cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+)
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
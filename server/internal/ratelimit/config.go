@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a budget of Limit calls per Window, written as "5/30s".
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+// ParseRate parses "<limit>/<duration>", e.g. "10/30s" or "100/1h".
+func ParseRate(value string) (Rate, error) {
+	limitPart, windowPart, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q: want <limit>/<duration>", value)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("rate %q: limit must be a positive integer", value)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowPart))
+	if err != nil || window <= 0 {
+		return Rate{}, fmt.Errorf("rate %q: window must be a positive duration", value)
+	}
+	return Rate{Limit: limit, Window: window}, nil
+}
+
+// NewLimiter builds a limiter enforcing r.
+func (r Rate) NewLimiter(sliding bool) Limiter {
+	if sliding {
+		return NewSlidingWindow(r.Window, r.Limit)
+	}
+	return NewFixedWindow(r.Window, r.Limit)
+}
+
+// Config holds the per-endpoint write limits.
+type Config struct {
+	Post    Rate
+	Comment Rate
+	Upload  Rate
+	// Login bounds failed logins per account; it always uses SlidingWindow.
+	Login Rate
+	// Register bounds registration attempts per client IP, and VerifyEmail the
+	// verification emails sent to one account. Both always use SlidingWindow.
+	Register    Rate
+	VerifyEmail Rate
+	// Sliding selects SlidingWindow over FixedWindow for every endpoint.
+	Sliding bool
+}
+
+// DefaultConfig returns the built-in limits.
+func DefaultConfig() Config {
+	return Config{
+		Post:        Rate{Limit: 5, Window: 30 * time.Second},
+		Comment:     Rate{Limit: 10, Window: 30 * time.Second},
+		Upload:      Rate{Limit: 10, Window: time.Minute},
+		Login:       Rate{Limit: 5, Window: 15 * time.Minute},
+		Register:    Rate{Limit: 5, Window: time.Hour},
+		VerifyEmail: Rate{Limit: 3, Window: time.Hour},
+	}
+}
+
+// LoadConfig reads RATE_POST, RATE_COMMENT, RATE_UPLOAD, RATE_LOGIN,
+// RATE_REGISTER, RATE_VERIFY_EMAIL and RATE_LIMITER ("fixed" or "sliding").
+// Missing or unparseable values keep the defaults.
+func LoadConfig() Config {
+	cfg := DefaultConfig()
+	loadRate("RATE_POST", &cfg.Post)
+	loadRate("RATE_COMMENT", &cfg.Comment)
+	loadRate("RATE_UPLOAD", &cfg.Upload)
+	loadRate("RATE_LOGIN", &cfg.Login)
+	loadRate("RATE_REGISTER", &cfg.Register)
+	loadRate("RATE_VERIFY_EMAIL", &cfg.VerifyEmail)
+	cfg.Sliding = strings.EqualFold(strings.TrimSpace(os.Getenv("RATE_LIMITER")), "sliding")
+	return cfg
+}
+
+func loadRate(name string, rate *Rate) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return
+	}
+	parsed, err := ParseRate(raw)
+	if err != nil {
+		log.Printf("ratelimit: ignoring %s: %v; using %s", name, err, rate)
+		return
+	}
+	*rate = parsed
+}
@@ -5,6 +5,26 @@ import (
 	"time"
 )
 
+// Limiter decides whether the caller identified by key may proceed.
+type Limiter interface {
+	Allow(key string) bool
+	// RetryAfter reports how long key must wait before Allow can succeed again;
+	// zero when it would succeed now.
+	RetryAfter(key string) time.Duration
+	// Limit is how many calls a key may make per window.
+	Limit() int
+	// Remaining reports how many more calls key may make right now.
+	Remaining(key string) int
+	// ResetAt reports when key's full quota is restored; now when nothing
+	// counts against it.
+	ResetAt(key string) time.Time
+}
+
+var (
+	_ Limiter = (*FixedWindow)(nil)
+	_ Limiter = (*SlidingWindow)(nil)
+)
+
 type entry struct {
 	count     int
 	resetTime time.Time
@@ -44,3 +64,46 @@ func (l *FixedWindow) Allow(key string) bool {
 	l.items[key] = item
 	return true
 }
+
+// RetryAfter returns the time until key's current window resets, or zero if
+// key still has quota left.
+func (l *FixedWindow) RetryAfter(key string) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	item, ok := l.items[key]
+	if !ok || now.After(item.resetTime) || item.count < l.limit {
+		return 0
+	}
+	return item.resetTime.Sub(now)
+}
+
+func (l *FixedWindow) Limit() int { return l.limit }
+
+// Remaining returns the calls left in key's current window.
+func (l *FixedWindow) Remaining(key string) int {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	item, ok := l.items[key]
+	if !ok || now.After(item.resetTime) {
+		return l.limit
+	}
+	return max(l.limit-item.count, 0)
+}
+
+// ResetAt returns when key's current window ends.
+func (l *FixedWindow) ResetAt(key string) time.Time {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	item, ok := l.items[key]
+	if !ok || now.After(item.resetTime) {
+		return now
+	}
+	return item.resetTime
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	const window = time.Minute
+	for name, l := range map[string]Limiter{
+		"fixed":   NewFixedWindow(window, 1),
+		"sliding": NewSlidingWindow(window, 1),
+	} {
+		if got := l.RetryAfter("k"); got != 0 {
+			t.Fatalf("%s: RetryAfter before any hit = %v, want 0", name, got)
+		}
+		l.Allow("k")
+		if l.Allow("k") {
+			t.Fatalf("%s: second call allowed", name)
+		}
+		if got := l.RetryAfter("k"); got <= window-time.Second || got > window {
+			t.Fatalf("%s: RetryAfter = %v, want just under %v", name, got, window)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	got, err := ParseRate(" 10 / 30s ")
+	if err != nil || got != (Rate{Limit: 10, Window: 30 * time.Second}) {
+		t.Fatalf("ParseRate = %v, %v; want 10/30s", got, err)
+	}
+	for _, bad := range []string{"", "10", "0/30s", "-1/30s", "x/30s", "10/", "10/abc", "10/-5s"} {
+		if _, err := ParseRate(bad); err == nil {
+			t.Fatalf("ParseRate(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestRemainingAndResetAt(t *testing.T) {
+	const window = time.Minute
+	for name, l := range map[string]Limiter{
+		"fixed":   NewFixedWindow(window, 3),
+		"sliding": NewSlidingWindow(window, 3),
+	} {
+		before := time.Now()
+		if got := l.Remaining("k"); got != 3 {
+			t.Fatalf("%s: Remaining before any hit = %d, want 3", name, got)
+		}
+		if got := l.ResetAt("k"); got.Before(before) || got.After(time.Now()) {
+			t.Fatalf("%s: ResetAt before any hit = %v, want now", name, got)
+		}
+		for i := 0; i < 4; i++ {
+			l.Allow("k")
+		}
+		if got := l.Remaining("k"); got != 0 {
+			t.Fatalf("%s: Remaining after exhausting = %d, want 0", name, got)
+		}
+		if got := time.Until(l.ResetAt("k")); got <= window-time.Second || got > window {
+			t.Fatalf("%s: ResetAt in %v, want just under %v", name, got, window)
+		}
+		if l.Limit() != 3 || l.Remaining("other") != 3 {
+			t.Fatalf("%s: Limit = %d, Remaining(other) = %d", name, l.Limit(), l.Remaining("other"))
+		}
+	}
+}
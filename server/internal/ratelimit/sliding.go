@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingWindow allows at most limit calls per key within any trailing window.
+// Unlike FixedWindow it has no boundary at which a second burst is admitted.
+//
+// Each key keeps at most limit timestamps; keys that have been idle for a full
+// window are evicted by a sweep that runs at most once per window.
+type SlidingWindow struct {
+	mu        sync.Mutex
+	window    time.Duration
+	limit     int
+	items     map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewSlidingWindow(window time.Duration, limit int) *SlidingWindow {
+	return &SlidingWindow{
+		window: window,
+		limit:  limit,
+		items:  map[string][]time.Time{},
+	}
+}
+
+func (l *SlidingWindow) Allow(key string) bool {
+	if l.limit <= 0 {
+		return false
+	}
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now, cutoff)
+
+	hits := pruneBefore(l.items[key], cutoff)
+	if len(hits) >= l.limit {
+		l.items[key] = hits
+		return false
+	}
+	l.items[key] = append(hits, now)
+	return true
+}
+
+// RetryAfter returns the time until key's oldest hit in the window expires,
+// or zero if key still has quota left.
+func (l *SlidingWindow) RetryAfter(key string) time.Duration {
+	if l.limit <= 0 {
+		return l.window
+	}
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hits := pruneBefore(l.items[key], now.Add(-l.window))
+	l.items[key] = hits
+	if len(hits) < l.limit {
+		return 0
+	}
+	return hits[len(hits)-l.limit].Add(l.window).Sub(now)
+}
+
+func (l *SlidingWindow) Limit() int { return l.limit }
+
+// Remaining returns the calls key may still make within the trailing window.
+func (l *SlidingWindow) Remaining(key string) int {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hits := pruneBefore(l.items[key], now.Add(-l.window))
+	l.items[key] = hits
+	return max(l.limit-len(hits), 0)
+}
+
+// ResetAt returns when key's newest hit leaves the window, restoring its
+// full quota.
+func (l *SlidingWindow) ResetAt(key string) time.Time {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	hits := pruneBefore(l.items[key], now.Add(-l.window))
+	l.items[key] = hits
+	if len(hits) == 0 {
+		return now
+	}
+	return hits[len(hits)-1].Add(l.window)
+}
+
+// sweep drops keys whose newest hit has left the window.
+func (l *SlidingWindow) sweep(now, cutoff time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, hits := range l.items {
+		if len(hits) == 0 || !hits[len(hits)-1].After(cutoff) {
+			delete(l.items, key)
+		}
+	}
+}
+
+// pruneBefore removes timestamps at or before cutoff in place. hits is
+// ascending, so the expired ones form a prefix.
+func pruneBefore(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	if i == 0 {
+		return hits
+	}
+	n := copy(hits, hits[i:])
+	return hits[:n]
+}
+
+// Reset forgets every hit recorded for key.
+func (l *SlidingWindow) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.items, key)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowConcurrentAllow(t *testing.T) {
+	const (
+		limit      = 50
+		goroutines = 64
+		perWorker  = 200
+	)
+	l := NewSlidingWindow(time.Minute, limit)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if l.Allow("shared") {
+					allowed.Add(1)
+				}
+				// Spread some load over distinct keys to exercise the map too.
+				l.Allow(fmt.Sprintf("key-%d", g))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != limit {
+		t.Fatalf("allowed %d calls on shared key, want %d", got, limit)
+	}
+}
+
+func TestSlidingWindowNoBoundaryBurst(t *testing.T) {
+	const window = 100 * time.Millisecond
+	l := NewSlidingWindow(window, 2)
+
+	if !l.Allow("k") {
+		t.Fatal("first call denied")
+	}
+	time.Sleep(window / 2)
+	if !l.Allow("k") {
+		t.Fatal("second call denied")
+	}
+	if l.Allow("k") {
+		t.Fatal("third call within the window allowed")
+	}
+
+	// Past the first hit but not the second: exactly one slot frees up.
+	time.Sleep(window/2 + 10*time.Millisecond)
+	if !l.Allow("k") {
+		t.Fatal("call after the oldest hit expired denied")
+	}
+	if l.Allow("k") {
+		t.Fatal("second hit is still in the window; call should be denied")
+	}
+}
+
+func TestSlidingWindowEvictsIdleKeys(t *testing.T) {
+	const window = 20 * time.Millisecond
+	l := NewSlidingWindow(window, 1)
+
+	for i := 0; i < 100; i++ {
+		l.Allow(fmt.Sprintf("idle-%d", i))
+	}
+	time.Sleep(2 * window)
+	l.Allow("fresh")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.items) != 1 {
+		t.Fatalf("tracked %d keys after sweep, want 1", len(l.items))
+	}
+}
+
+func TestSlidingWindowReset(t *testing.T) {
+	l := NewSlidingWindow(time.Minute, 1)
+	if !l.Allow("k") || l.Allow("k") {
+		t.Fatal("expected one hit then a denial")
+	}
+	l.Reset("k")
+	if !l.Allow("k") {
+		t.Fatal("Allow after Reset = false, want true")
+	}
+}
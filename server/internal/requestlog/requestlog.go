@@ -0,0 +1,102 @@
+package requestlog
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+)
+
+// Header carries the request ID in both directions.
+const Header = "X-Request-ID"
+
+const contextKey = "request_id"
+
+// maxIDLength bounds client-supplied IDs so they can't bloat log lines.
+const maxIDLength = 128
+
+type entry struct {
+	Time      string  `json:"time"`
+	RequestID string  `json:"request_id"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	Bytes     int     `json:"bytes"`
+	LatencyMS float64 `json:"latency_ms"`
+	ClientIP  string  `json:"client_ip"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Middleware assigns every request an X-Request-ID (reusing a well-formed one
+// from the client), echoes it in the response, and writes one JSON line per
+// request to w once the handler chain has finished. It also records the
+// per-route request count and latency metrics.
+func Middleware(w io.Writer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(Header)
+		if !validID(id) {
+			id = newID()
+		}
+		c.Set(contextKey, id)
+		c.Header(Header, id)
+
+		c.Next()
+
+		latency := time.Since(start)
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(latency.Seconds())
+
+		line, err := json.Marshal(entry{
+			Time:      start.UTC().Format(time.RFC3339),
+			RequestID: id,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			Bytes:     max(c.Writer.Size(), 0),
+			LatencyMS: float64(latency.Microseconds()) / 1000,
+			ClientIP:  c.ClientIP(),
+			Error:     c.Errors.ByType(gin.ErrorTypePrivate).String(),
+		})
+		if err != nil {
+			return
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+// ID returns the request ID assigned by Middleware, or "" outside of it.
+func ID(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		ch := id[i]
+		if ch < 0x21 || ch > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultTrustedProxies is used when TRUSTED_PROXIES is unset: only a reverse
+// proxy on the same host may set X-Forwarded-For.
+var DefaultTrustedProxies = []netip.Prefix{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("::1/128"),
+}
+
+var (
+	trustedProxiesMu sync.RWMutex
+	trustedProxies   = DefaultTrustedProxies
+)
+
+// ParseTrustedProxies parses a comma-separated list of CIDRs or bare IPs.
+func ParseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXIES. Unset means
+// DefaultTrustedProxies; "none" trusts no proxy at all.
+func TrustedProxiesFromEnv() ([]netip.Prefix, error) {
+	raw := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	switch strings.ToLower(raw) {
+	case "":
+		return DefaultTrustedProxies, nil
+	case "none":
+		return []netip.Prefix{}, nil
+	}
+	return ParseTrustedProxies(raw)
+}
+
+// SetTrustedProxies replaces the proxies whose X-Forwarded-For ClientIP
+// honors. Call it once at startup, before serving requests.
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxiesMu.Lock()
+	defer trustedProxiesMu.Unlock()
+	trustedProxies = prefixes
+}
+
+func isTrustedProxy(addr netip.Addr) bool {
+	trustedProxiesMu.RLock()
+	defer trustedProxiesMu.RUnlock()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the caller's address. X-Forwarded-For is only honored when
+// the connection comes from a trusted proxy; its chain is then walked right to
+// left, skipping trusted hops, and the first untrusted address is the client.
+// Entries left of that point are client-supplied and ignored, so a spoofed
+// header cannot dodge per-IP limits. It returns "" when RemoteAddr is unusable.
+func ClientIP(r *http.Request) string {
+	remote, ok := remoteAddr(r.RemoteAddr)
+	if !ok {
+		return ""
+	}
+	if !isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A hop we cannot read ends the trustworthy part of the chain.
+			break
+		}
+		client = addr.Unmap()
+		if !isTrustedProxy(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func remoteAddr(hostport string) (netip.Addr, bool) {
+	hostport = strings.TrimSpace(hostport)
+	if addrPort, err := netip.ParseAddrPort(hostport); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	if addr, err := netip.ParseAddr(hostport); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.7")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+	SetTrustedProxies(proxies)
+	defer SetTrustedProxies(DefaultTrustedProxies)
+
+	cases := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct client", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"spoofed header from untrusted peer", "203.0.113.5:4000", []string{"1.2.3.4"}, "203.0.113.5"},
+		{"one trusted proxy", "10.1.1.1:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"spoofed entry left of the real client", "10.1.1.1:80", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of trusted hops", "10.1.1.1:80", []string{"198.51.100.9, 192.0.2.7", "10.2.2.2"}, "198.51.100.9"},
+		{"every hop trusted", "10.1.1.1:80", []string{"10.3.3.3"}, "10.3.3.3"},
+		{"garbage hop stops the walk", "10.1.1.1:80", []string{"198.51.100.9, nonsense"}, "10.1.1.1"},
+		{"trusted proxy without header", "10.1.1.1:80", nil, "10.1.1.1"},
+		{"ipv4-mapped peer", "[::ffff:10.1.1.1]:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"unusable remote", "", []string{"198.51.100.9"}, ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		for _, value := range tc.xff {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if got := ClientIP(req); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseTrustedProxiesRejectsGarbage(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8,proxy.local"); err == nil {
+		t.Fatal("want error for a host name")
+	}
+}
@@ -1,21 +1,104 @@
+// Package transport holds the HTTP error contract shared by all handlers.
+//
+// Every error response body is an ErrorResponse: {"code": 2001, "message": "..."}.
+// Clients branch on code, not on message. Validation errors may add "fields",
+// mapping each offending request field to a reason such as "required".
+// Code ranges are stable:
+//
+//	1xxx  authentication and account state (1001 missing/invalid token,
+//	      1002 forbidden, 1003 bad credentials, 1005 rate limited, ...)
+//	2xxx  validation and lookup (2001 invalid input or not found)
+//	3xxx  WebSocket protocol errors (see docs/ws-protocol.md)
+//	5xxx  server-side failures (5000 internal error)
 package transport
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+)
+
+const (
+	CodeUnauthorized = 1001
+	CodeForbidden    = 1002
+	CodeRateLimited  = 1005
+	CodeInvalidInput = 2001
+	CodeServerError  = 5000
 )
 
+// ErrorResponse is the body of every non-2xx API response.
 type ErrorResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// RetryAfter accompanies 429 responses: seconds until the request may be retried.
+	RetryAfter int `json:"retry_after,omitempty"`
+	// Fields accompanies validation errors: request field name to reason.
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
+// Reasons used as values in ErrorResponse.Fields.
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+	FieldTooLong  = "too_long"
+	FieldTooMany  = "too_many"
+)
+
 // ReadJSON decodes the request body into v.
 func ReadJSON(r *http.Request, v any) error {
 	decoder := json.NewDecoder(r.Body)
 	return decoder.Decode(v)
 }
 
+var (
+	ErrBodyTooLarge  = errors.New("request body too large")
+	ErrUnknownField  = errors.New("unknown field")
+	ErrMalformedJSON = errors.New("invalid json")
+)
+
+// ReadJSONStrict decodes a single JSON value from the request body into v,
+// reading at most maxBytes and rejecting fields v does not declare. The error
+// wraps ErrBodyTooLarge, ErrUnknownField or ErrMalformedJSON and its message is
+// safe to return to the client.
+func ReadJSONStrict(r *http.Request, v any, maxBytes int64) error {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return classifyJSONError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if err == nil {
+			return fmt.Errorf("%w: body must contain a single JSON value", ErrMalformedJSON)
+		}
+		return classifyJSONError(err)
+	}
+	return nil
+}
+
+func classifyJSONError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w (limit %d bytes)", ErrBodyTooLarge, tooLarge.Limit)
+	}
+	// encoding/json has no typed error for this case, only the message.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("%w %s", ErrUnknownField, field)
+	}
+	return ErrMalformedJSON
+}
+
 // WriteJSON writes v as JSON with a status code.
 func WriteJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -28,3 +111,82 @@ func WriteJSON(w http.ResponseWriter, status int, v any) {
 func WriteError(w http.ResponseWriter, status int, code int, message string) {
 	WriteJSON(w, status, ErrorResponse{Code: code, Message: message})
 }
+
+// Error is the gin adapter for WriteError.
+func Error(c *gin.Context, status int, code int, message string) {
+	c.JSON(status, ErrorResponse{Code: code, Message: message})
+}
+
+// FieldError is Error for validation failures: fields names the offending
+// request fields, each with one of the Field* reasons.
+func FieldError(c *gin.Context, status int, code int, message string, fields map[string]string) {
+	c.JSON(status, ErrorResponse{Code: code, Message: message, Fields: fields})
+}
+
+// RateLimitHeaders tells the client how much of limiter's quota is left,
+// allowed or not: X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix seconds). When a request counts against several
+// keys, the one with the fewest calls left is reported.
+func RateLimitHeaders(c *gin.Context, limiter ratelimit.Limiter, keys ...string) {
+	remaining := limiter.Limit()
+	var reset time.Time
+	for _, key := range keys {
+		keyRemaining, keyReset := limiter.Remaining(key), limiter.ResetAt(key)
+		if keyRemaining < remaining || (keyRemaining == remaining && keyReset.After(reset)) {
+			remaining, reset = keyRemaining, keyReset
+		}
+	}
+	if reset.IsZero() {
+		reset = time.Now()
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limiter.Limit()))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	// Round up so a client waiting until Reset finds the quota restored.
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Add(time.Second-1).Unix(), 10))
+}
+
+// RateLimited writes a 429 with a Retry-After header and the same delay, in
+// whole seconds (at least one), in the body.
+func RateLimited(c *gin.Context, retryAfter time.Duration) {
+	TooManyRequests(c, retryAfter, "rate limited")
+}
+
+// TooManyRequests is RateLimited with a caller-chosen message.
+func TooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, ErrorResponse{
+		Code:       CodeRateLimited,
+		Message:    message,
+		RetryAfter: seconds,
+	})
+}
+
+// BindJSON is the gin adapter for ReadJSONStrict. On failure it writes a 400
+// (or 413 for oversized bodies) with code 2001 and returns false.
+func BindJSON(c *gin.Context, v any, maxBytes int64) bool {
+	err := ReadJSONStrict(c.Request, v, maxBytes)
+	if err == nil {
+		return true
+	}
+	status := http.StatusBadRequest
+	if errors.Is(err, ErrBodyTooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	Error(c, status, CodeInvalidInput, err.Error())
+	return false
+}
+
+// UnixMillis converts a stored RFC3339 timestamp to epoch milliseconds, the
+// created_at_unix value sent alongside created_at so clients can sort and
+// localize without parsing strings. Empty or malformed input gives 0.
+func UnixMillis(rfc3339 string) int64 {
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(rfc3339))
+	if err != nil {
+		return 0
+	}
+	return parsed.UnixMilli()
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +22,10 @@ import (
 	"github.com/Versifine/Cumt-cumpus-hub/server/chat"
 	"github.com/Versifine/Cumt-cumpus-hub/server/community"
 	"github.com/Versifine/Cumt-cumpus-hub/server/file"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/ratelimit"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/requestlog"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/notification"
 	"github.com/Versifine/Cumt-cumpus-hub/server/report"
 	"github.com/Versifine/Cumt-cumpus-hub/server/search"
@@ -47,61 +56,129 @@ func main() {
 	// -----------------------------
 	// 2) 依赖初始化 / “手动注入”
 	// -----------------------------
+	// 密码哈希：PASSWORD_HASH 选择 bcrypt（默认）或 argon2（argon2id），
+	// PASSWORD_COST 调整 bcrypt 代价（默认 10）。旧哈希仍可验证，并在下次登录时按新配置重新哈希。
+	passwordPolicy, err := store.PasswordPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("invalid password hashing config: %v", err)
+	}
+	if err := store.SetPasswordPolicy(passwordPolicy); err != nil {
+		log.Fatalf("invalid password hashing config: %v", err)
+	}
+	// VERIFY_TOKEN_TTL 调整注册与更换邮箱验证链接的有效期（如 "48h"，默认 24h），只影响新发出的链接。
+	if ttl := positiveDurationEnv("VERIFY_TOKEN_TTL"); ttl > 0 {
+		_ = store.SetVerificationTokenTTL(ttl)
+	}
+
 	// 初始化数据存储层：支持内存 / SQLite（通过环境变量切换）。
 	dataStore := mustCreateStore(uploadDir)
-	if closer, ok := dataStore.(interface{ Close() error }); ok {
-		defer func() { _ = closer.Close() }()
+	// STORE_CACHE=1 时为版块列表、用户信息和帖子得分加一层进程内缓存，
+	// TTL 可用 STORE_CACHE_BOARDS_TTL / STORE_CACHE_USER_TTL / STORE_CACHE_SCORE_TTL（如 "30s"）调整。
+	if os.Getenv("STORE_CACHE") == "1" {
+		log.Printf("storage: in-process read cache enabled")
+		dataStore = store.NewCachedStore(dataStore, store.CachedStoreConfig{
+			BoardsTTL:    positiveDurationEnv("STORE_CACHE_BOARDS_TTL"),
+			UserTTL:      positiveDurationEnv("STORE_CACHE_USER_TTL"),
+			PostScoreTTL: positiveDurationEnv("STORE_CACHE_SCORE_TTL"),
+		})
 	}
+	seedAdmins(dataStore)
+
+	// 聊天 Hub：用于管理 WebSocket 连接、广播消息等（典型的 hub-and-spoke 结构），
+	// 同时按用户分发通知 SSE 流：经 store 新建的通知会推送给接收者已打开的流。
+	chatHub := chat.NewHub()
+	dataStore = &notification.PushingStore{API: dataStore, Streams: chatHub}
 
 	// 认证服务：依赖 store，用于登录、获取当前用户等。
+	// 未配置 SMTP_HOST 且 DEV_MAIL=log 时改用 LogMailer：不发邮件，只把验证链接打到日志，
+	// 设置 DEV_MAIL_DIR（如 "./dev-mails"）时另存为 .eml 文件，便于本地开发注册账号。
 	var mailer auth.EmailSender
-	smtpMailer, err := auth.NewSMTPMailerFromEnv()
-	if err != nil {
+	if strings.TrimSpace(os.Getenv("SMTP_HOST")) == "" && strings.EqualFold(strings.TrimSpace(os.Getenv("DEV_MAIL")), "log") {
+		logMailer, err := auth.NewLogMailerFromEnv()
+		if err != nil {
+			log.Fatalf("failed to set up dev mailer: %v", err)
+		}
+		log.Printf("email: DEV_MAIL=log, verification links are logged instead of sent")
+		mailer = logMailer
+	} else if smtpMailer, err := auth.NewSMTPMailerFromEnv(); err != nil {
 		log.Printf("email disabled: %v", err)
 	} else {
 		mailer = smtpMailer
 	}
-	authService := &auth.Service{Store: dataStore, Mailer: mailer}
-
-	// 聊天 Hub：用于管理 WebSocket 连接、广播消息等（典型的 hub-and-spoke 结构）。
-	chatHub := chat.NewHub()
+	// 限流配置：RATE_POST / RATE_COMMENT / RATE_UPLOAD / RATE_LOGIN（如 "5/30s"），
+	// RATE_LIMITER=sliding 时改用滑动窗口（默认固定窗口），便于对比两种策略。
+	rateConfig := ratelimit.LoadConfig()
+	// 登录失败锁定：同一账号（及同一 IP）短时间内多次密码错误后返回 429。
+	// 注册与重发验证邮件：RATE_REGISTER 限制同一 IP 的注册次数，
+	// RATE_VERIFY_EMAIL 限制同一账号收到的验证邮件数，超出后返回 429。
+	authService := &auth.Service{
+		Store:     dataStore,
+		Mailer:    mailer,
+		Throttler: auth.NewLoginThrottler(rateConfig.Login),
+		Signup:    auth.NewSignupThrottler(rateConfig.Register, rateConfig.VerifyEmail),
+	}
 
 	// -----------------------------
 	// 3) 初始化各业务 Handler
 	// -----------------------------
 	// 社区模块 Handler：依赖 store（数据读写）和 Auth（鉴权/当前用户信息）。
-	communityHandler := &community.Handler{Store: dataStore, Auth: authService}
+	communityHandler := community.NewHandler(dataStore, authService, rateConfig)
+	communityHandler.Limits = contentLimits()
 
 	// 聊天模块 Handler：依赖 store（消息/会话数据等）和 Hub（WS 连接管理）。
-	chatHandler := &chat.Handler{Store: dataStore, Hub: chatHub}
+	chatHandler := &chat.Handler{Store: dataStore, Hub: chatHub, Auth: authService}
 
 	reportHandler := &report.Handler{Store: dataStore, Auth: authService}
 
 	// 搜索模块 Handler：依赖 store（数据检索）。
 	searchHandler := &search.Handler{Store: dataStore}
 
-	// 通知模块 Handler：依赖 store 和 auth。
-	notificationHandler := &notification.Handler{Store: dataStore, Auth: authService}
+	// 通知模块 Handler：依赖 store 和 auth，SSE 通知流订阅 Hub。
+	notificationHandler := &notification.Handler{Store: dataStore, Auth: authService, Streams: chatHub}
 
 	// 文件模块 Handler：依赖 store、鉴权服务，以及上传目录配置。
 	fileHandler := &file.Handler{
 		Store:     dataStore,
 		Auth:      authService,
 		UploadDir: uploadDir,
+		Blob:      mustCreateBlob(uploadDir),
+
+		UploadLimiter:  rateConfig.Upload.NewLimiter(rateConfig.Sliding),
+		MaxUploadBytes: maxUploadBytes(),
+		// KEEP_IMAGE_METADATA=1 时保留 JPEG 原图；默认去除 EXIF（含 GPS 位置）并按拍摄方向摆正。
+		KeepImageMetadata: os.Getenv("KEEP_IMAGE_METADATA") == "1",
 	}
 
 	// -----------------------------
 	// 4) 路由注册（Gin）
 	// -----------------------------
 	router := gin.New()
-	router.Use(gin.LoggerWithWriter(loggerWriter))
+	// 只有来自可信代理（TRUSTED_PROXIES，默认仅本机）的连接才采信 X-Forwarded-For，
+	// 防止客户端伪造 IP 绕过限流；gin 的 c.ClientIP()（请求日志）使用同一名单。
+	proxies, err := transport.TrustedProxiesFromEnv()
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	transport.SetTrustedProxies(proxies)
+	proxyCIDRs := make([]string, 0, len(proxies))
+	for _, prefix := range proxies {
+		proxyCIDRs = append(proxyCIDRs, prefix.String())
+	}
+	if err := router.SetTrustedProxies(proxyCIDRs); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	// 每个请求一行 JSON 日志，并通过 X-Request-ID 关联上下游。
+	router.Use(requestlog.Middleware(loggerWriter))
 	router.Use(gin.RecoveryWithWriter(loggerWriter))
+	// 跨域：允许 CORS_ORIGINS 中的前端（如 Vite 开发服务器）直接调用 API。
+	router.Use(corsMiddleware())
+	// 压缩：较大的 JSON/HTML 响应按 Accept-Encoding 使用 gzip。
+	router.Use(gzipMiddleware())
 
-	// 健康检查接口：用于容器探活/负载均衡健康检查。
-	// 返回 JSON：{"status":"ok"}。
-	router.GET("/healthz", func(c *gin.Context) {
-		c.JSON(http.StatusOK, map[string]string{"status": "ok"})
-	})
+	// 健康检查接口：/healthz 用于存活探针（检查数据库），
+	// /readyz 用于就绪探针（另外检查上传目录可写）。
+	router.GET("/healthz", healthHandler(dataStore))
+	router.GET("/readyz", readyHandler(dataStore, fileHandler.Blob))
 
 	// -----------------------------
 	// 5) REST API：认证相关
@@ -112,15 +189,30 @@ func main() {
 
 	// 登录接口：由 authService 提供处理函数。
 	router.POST("/api/v1/auth/login", authService.LoginHandler)
+	// 续期接口：用仍有效的 token 延长有效期，可选轮换为新 token。
+	router.POST("/api/v1/auth/refresh", authService.RefreshHandler)
 
 	// 获取当前登录用户信息（通常依赖鉴权 token/cookie 等）。
 	router.GET("/api/v1/users/me", authService.GetMe)
 	router.PATCH("/api/v1/users/me", authService.UpdateMe)
 	router.DELETE("/api/v1/users/me", authService.DeactivateMe)
+	router.POST("/api/v1/users/me/checkin", authService.CheckIn)
+	// 登录设备管理：列出当前账号的所有会话，并可单独下线某一个。
+	router.GET("/api/v1/users/me/sessions", authService.ListSessions)
+	router.DELETE("/api/v1/users/me/sessions/:id", authService.RevokeSession)
+	// 登录邮箱：查看（脱敏）与更换，新邮箱验证通过后才生效。
+	router.GET("/api/v1/users/me/email", authService.GetMyEmail)
+	router.POST("/api/v1/users/me/email", authService.ChangeEmail)
+	// 草稿箱：仅本人可见，发布后进入信息流。
+	router.GET("/api/v1/users/me/drafts", communityHandler.ListDrafts)
+	// 个人数据导出：以 JSON 附件流式返回本人的全部数据。
+	router.GET("/api/v1/users/me/export", authService.ExportMe)
 
 	router.GET("/api/v1/users/:id", authService.GetUser)
 	router.POST("/api/v1/users/:id/follow", authService.FollowUser)
 	router.DELETE("/api/v1/users/:id/follow", authService.UnfollowUser)
+	router.POST("/api/v1/users/:id/block", authService.BlockUser)
+	router.DELETE("/api/v1/users/:id/block", authService.UnblockUser)
 	router.GET("/api/v1/users/:id/followers", authService.GetFollowers)
 	router.GET("/api/v1/users/:id/following", authService.GetFollowing)
 	router.GET("/api/v1/users/:id/comments", authService.GetUserComments)
@@ -130,30 +222,62 @@ func main() {
 	// -----------------------------
 	// boards 列表/创建等操作（具体取决于 communityHandler 的实现）。
 	router.GET("/api/v1/boards", communityHandler.GetBoards)
+	// 订阅版块：订阅后该版块的帖子进入个人动态；登录时版块列表带 subscribed 字段。
+	router.POST("/api/v1/boards/:id/subscribe", communityHandler.SubscribeBoard)
+	router.DELETE("/api/v1/boards/:id/subscribe", communityHandler.UnsubscribeBoard)
+	// 个人动态：关注用户的帖子与订阅版块的帖子合并去重，按时间倒序；?source=following|boards 只看其一。
+	router.GET("/api/v1/feed", communityHandler.Feed)
+
+	// 标签：热门标签（标签云）与按标签浏览帖子，标签匹配不区分大小写。
+	router.GET("/api/v1/tags", communityHandler.ListTags)
+	router.GET("/api/v1/tags/:tag/posts", communityHandler.ListTagPosts)
+	// 热门：近 24–72 小时内的热门帖子与标签，结果缓存一分钟。
+	router.GET("/api/v1/trending", communityHandler.Trending)
 
 	// posts 列表/创建等操作。
 	router.GET("/api/v1/posts", communityHandler.ListPosts)
 	router.POST("/api/v1/posts", communityHandler.CreatePost)
 
 	router.GET("/api/v1/posts/:id", communityHandler.GetPost)
+	router.PATCH("/api/v1/posts/:id", communityHandler.UpdatePost)
 	router.DELETE("/api/v1/posts/:id", communityHandler.DeletePost)
+	router.POST("/api/v1/posts/:id/publish", communityHandler.PublishPost)
+	// 置顶仅管理员可用，只影响版块内的列表顺序。
+	router.POST("/api/v1/posts/:id/pin", communityHandler.PinPost)
+	router.DELETE("/api/v1/posts/:id/pin", communityHandler.UnpinPost)
 
 	router.POST("/api/v1/posts/:id/votes", communityHandler.VotePost)
 	router.DELETE("/api/v1/posts/:id/votes", communityHandler.ClearPostVote)
+	router.PUT("/api/v1/posts/:id/votes", communityHandler.SetPostVote)
+	router.GET("/api/v1/posts/:id/votes", communityHandler.ListPostVoters)
 
 	router.GET("/api/v1/posts/:id/comments", communityHandler.ListComments)
 	router.POST("/api/v1/posts/:id/comments", communityHandler.CreateComment)
 	router.DELETE("/api/v1/posts/:id/comments/:commentId", communityHandler.DeleteComment)
+	// 评论永久链接：只有评论 ID（如来自通知）时查所属帖子及其在评论列表中的位置。
+	router.GET("/api/v1/comments/:id", communityHandler.GetComment)
 
 	router.POST("/api/v1/posts/:id/comments/:commentId/votes", communityHandler.VoteComment)
 	router.DELETE("/api/v1/posts/:id/comments/:commentId/votes", communityHandler.ClearCommentVote)
+	router.PUT("/api/v1/posts/:id/comments/:commentId/votes", communityHandler.SetCommentVote)
 
 	// -----------------------------
 	// 7) REST API：举报与管理（P0）
 	// -----------------------------
 	router.POST("/api/v1/reports", reportHandler.Create)
 	router.GET("/api/v1/admin/reports", reportHandler.AdminList)
+	router.GET("/api/v1/admin/reports/stats", reportHandler.AdminStats)
+	router.POST("/api/v1/admin/reports/bulk", reportHandler.AdminBulkUpdate)
 	router.PATCH("/api/v1/admin/reports/:id", reportHandler.AdminUpdate)
+	router.GET("/api/v1/admin/reports/:id/events", reportHandler.AdminEvents)
+	// 版块管理：新建、修改、删除（软删除，有帖子时需 ?force=1）。
+	router.POST("/api/v1/admin/boards", communityHandler.AdminCreateBoard)
+	router.PATCH("/api/v1/admin/boards/:id", communityHandler.AdminUpdateBoard)
+	router.DELETE("/api/v1/admin/boards/:id", communityHandler.AdminDeleteBoard)
+	// 数据一致性：修正落后的 ID 计数器，并列出悬空引用（只报告，不修改）。
+	router.POST("/api/v1/admin/recount", communityHandler.AdminRecount)
+	// 帖子审计：含已删除帖子（附删除时间与原因），可按版块、作者筛选。
+	router.GET("/api/v1/admin/posts", communityHandler.AdminListPosts)
 
 	// -----------------------------
 	// 8) REST API：搜索
@@ -166,13 +290,23 @@ func main() {
 	// -----------------------------
 	router.GET("/api/v1/notifications", notificationHandler.List)
 	router.GET("/api/v1/notifications/unread-count", notificationHandler.UnreadCount)
+	// SSE 通知流：无法使用 WebSocket 的客户端可用 EventSource 订阅（?token= 鉴权），
+	// 先推送未读数，之后每条新通知一个 notification 事件，每 15s 一次保活注释。
+	router.GET("/api/v1/notifications/stream", notificationHandler.Stream)
+	// 导航栏角标：未读通知与未读私信合并为一次请求，未登录返回 0。
+	router.GET("/api/v1/me/badges", notificationHandler.Badges)
 	router.PATCH("/api/v1/notifications/:id", notificationHandler.MarkRead)
+	router.POST("/api/v1/notifications/:id/unread", notificationHandler.MarkUnread)
+	router.DELETE("/api/v1/notifications/:id", notificationHandler.Delete)
 	router.POST("/api/v1/notifications/read-all", notificationHandler.MarkAllRead)
+	router.POST("/api/v1/notifications/read", notificationHandler.MarkListRead)
+	router.PATCH("/api/v1/users/me/notification-prefs", notificationHandler.UpdatePrefs)
 
 	// -----------------------------
 	// 10) REST API：文件上传/下载
 	// -----------------------------
 	router.POST("/api/v1/files", fileHandler.Upload)
+	router.DELETE("/api/v1/files/:id", fileHandler.Delete)
 	router.POST("/api/uploads/images", fileHandler.UploadImage)
 	router.GET("/files/:id", fileHandler.Download)
 
@@ -180,14 +314,25 @@ func main() {
 	// 11) WebSocket：聊天
 	// -----------------------------
 	router.GET("/ws/chat", chatHandler.ServeWS)
+	// 私信：获取（首次自动创建）与某用户的私信房间、当前用户的私信会话列表及标记已读。
+	router.GET("/api/v1/chat/dm/:id", chatHandler.OpenDM)
+	router.GET("/api/v1/chat/conversations", chatHandler.ListConversations)
+	router.POST("/api/v1/chat/conversations/:id/read", chatHandler.MarkRead)
+	// 聊天消息：发送者可删除自己的消息，或在发送后 15 分钟内编辑。
+	router.PATCH("/api/v1/chat/messages/:id", chatHandler.EditMessage)
+	router.DELETE("/api/v1/chat/messages/:id", chatHandler.DeleteMessage)
 
 	// -----------------------------
 	// 12) 静态资源：前端页面
 	// -----------------------------
-	fileServer := http.FileServer(http.Dir("apps/web"))
-	router.NoRoute(func(c *gin.Context) {
-		fileServer.ServeHTTP(c.Writer, c.Request)
-	})
+	// WEB_DIR 指定前端静态资源目录，默认为相对工作目录的 apps/web；
+	// 从其他目录启动二进制时需显式设置。
+	webDir := strings.TrimSpace(os.Getenv("WEB_DIR"))
+	if webDir == "" {
+		webDir = "apps/web"
+	}
+	// 未匹配的页面路径回退到 index.html，支持前端路由的深链接刷新。
+	router.NoRoute(spaHandler(filepath.Clean(webDir)))
 
 	// -----------------------------
 	// 13) 服务监听地址配置
@@ -204,28 +349,169 @@ func main() {
 	// -----------------------------
 	server := &http.Server{
 		Addr: addr,
-		// 外层套一层 logging 中间件，用于打印请求日志。
+		// 请求日志由 router 上的 requestlog 中间件负责。
 		Handler: router,
 
 		// 读取请求头的超时时间，避免慢速请求头攻击（Slowloris）。
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	// SSE 通知流不是被劫持的连接，Shutdown 会一直等它们结束；开始退出时先关闭 Hub 让它们返回。
+	server.RegisterOnShutdown(chatHub.Close)
+
+	// 指标：仅当设置 METRICS_ADDR（如 "127.0.0.1:9090"）时，在独立端口暴露 /metrics，
+	// 避免把内部指标暴露到公网端口。
+	metricsServer := newMetricsServer(strings.TrimSpace(os.Getenv("METRICS_ADDR")))
 
-	log.Printf("server listening on %s", addr)
-	log.Fatal(server.ListenAndServe())
+	// -----------------------------
+	// 15) 优雅退出
+	// -----------------------------
+	// 收到 SIGINT/SIGTERM 后停止接收新连接，等待进行中的请求（最长 30s），
+	// 再关闭聊天连接和存储，避免上传中断、WAL 未落盘。
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("server listening on %s", addr)
+		serveErr <- server.ListenAndServe()
+	}()
+	// 每日通知摘要：给开启了邮件摘要的用户发送未读通知汇总（需配置 SMTP），启动时先发一轮。
+	if mailer != nil {
+		go notification.RunDigests(ctx, dataStore, mailer, notification.DigestInterval)
+	}
+	// 聊天记录保留：设置 CHAT_HISTORY_LIMIT 后，定期（CHAT_PRUNE_INTERVAL，默认 1h）
+	// 把每个房间的记录裁剪到最近的 N 条，避免高频房间让数据库无限增长。
+	if keepLast := positiveIntEnv("CHAT_HISTORY_LIMIT"); keepLast > 0 {
+		interval := positiveDurationEnv("CHAT_PRUNE_INTERVAL")
+		if interval <= 0 {
+			interval = chat.DefaultPruneInterval
+		}
+		go chat.RunRetention(ctx, dataStore, keepLast, interval)
+	}
+	if metricsServer != nil {
+		go func() {
+			log.Printf("metrics listening on %s", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("metrics server stopped: %v", err)
+			}
+		}()
+	}
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		log.Printf("server stopped: %v", err)
+		exitCode = 1
+	case <-ctx.Done():
+		stop()
+		log.Printf("shutdown: signal received, draining in-flight requests")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutdown: http server: %v", err)
+			exitCode = 1
+		} else {
+			log.Printf("shutdown: http server stopped")
+		}
+		cancel()
+	}
+
+	if metricsServer != nil {
+		_ = metricsServer.Close()
+	}
+
+	log.Printf("shutdown: closing chat connections")
+	chatHub.Close()
+
+	if closer, ok := dataStore.(interface{ Close() error }); ok {
+		log.Printf("shutdown: closing store")
+		if err := closer.Close(); err != nil {
+			log.Printf("shutdown: store: %v", err)
+			exitCode = 1
+		}
+	}
+	log.Printf("shutdown: complete")
+
+	if exitCode != 0 {
+		closeLogger()
+		os.Exit(exitCode)
+	}
+}
+
+// newMetricsServer 构造只暴露 /metrics 的 HTTP Server；addr 为空时返回 nil。
+func newMetricsServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// shutdownTimeout 是优雅退出时等待进行中请求完成的最长时间。
+const shutdownTimeout = 30 * time.Second
+
+// seedAdmins 用 ADMIN_ACCOUNTS（逗号分隔的注册邮箱）初始化管理员。
+// 仅在库中尚无管理员时生效，之后以 users.is_admin 为准。
+func seedAdmins(dataStore store.API) {
+	raw := strings.TrimSpace(os.Getenv("ADMIN_ACCOUNTS"))
+	if raw == "" {
+		return
+	}
+	accounts := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' })
+	promoted, err := dataStore.SeedAdmins(accounts)
+	if err != nil {
+		log.Printf("admin seed failed: %v", err)
+		return
+	}
+	if promoted > 0 {
+		log.Printf("admin seed: promoted %d account(s) from ADMIN_ACCOUNTS", promoted)
+	}
 }
 
 func mustCreateStore(uploadDir string) store.API {
-	path := strings.TrimSpace(os.Getenv("SQLITE_PATH"))
+	// DB_DRIVER 选择存储后端：sqlite（默认）、postgres 或 memory（纯内存，重启即丢失）。
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER")))
+	switch driver {
+	case "", "sqlite":
+	case "memory":
+		log.Printf("storage: using in-memory store (data is lost on restart)")
+		return store.NewStore()
+	case "postgres", "postgresql", "pgx":
+		dsn := strings.TrimSpace(os.Getenv("POSTGRES_DSN"))
+		if dsn == "" {
+			log.Fatalf("POSTGRES_DSN is required when DB_DRIVER=%s", driver)
+		}
+		log.Printf("storage: using postgres database")
+		pgStore, err := store.OpenPostgres(dsn)
+		if err != nil {
+			log.Fatalf("failed to open postgres store: %v", err)
+		}
+		return pgStore
+	default:
+		log.Fatalf("unsupported DB_DRIVER: %s", driver)
+	}
+
+	// DB_PATH 指定 SQLite 文件路径（兼容旧的 SQLITE_PATH）；":memory:" 使用进程内数据库，便于测试。
+	path := strings.TrimSpace(os.Getenv("DB_PATH"))
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv("SQLITE_PATH"))
+	}
 	if path == "" {
 		path = filepath.Join("server", "storage", "dev.db")
 	}
-	path = filepath.Clean(path)
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		log.Fatalf("failed to create sqlite directory: %v", err)
+	if path == store.SQLiteMemoryPath {
+		log.Printf("storage: using in-memory sqlite database")
+	} else {
+		path = filepath.Clean(path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			log.Fatalf("failed to create sqlite directory: %v", err)
+		}
+		log.Printf("storage: using sqlite database at %s", path)
 	}
-
-	log.Printf("storage: using sqlite database at %s", path)
 	dbStore, err := store.OpenSQLite(path)
 	if err != nil {
 		log.Fatalf("failed to open sqlite store: %v", err)
@@ -233,6 +519,78 @@ func mustCreateStore(uploadDir string) store.API {
 	return dbStore
 }
 
+// maxUploadBytes 读取 MAX_UPLOAD_BYTES（单次上传的最大字节数），未设置或非法时使用默认的 100MB。
+func maxUploadBytes() int64 {
+	raw := strings.TrimSpace(os.Getenv("MAX_UPLOAD_BYTES"))
+	if raw == "" {
+		return file.DefaultMaxUploadBytes
+	}
+	limit, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || limit <= 0 {
+		log.Printf("invalid MAX_UPLOAD_BYTES %q, using default %d", raw, file.DefaultMaxUploadBytes)
+		return file.DefaultMaxUploadBytes
+	}
+	return limit
+}
+
+// contentLimits 读取帖子/评论的上限：MAX_TITLE_LENGTH、MAX_CONTENT_LENGTH（字符数）、
+// MAX_CONTENT_JSON_BYTES（字节数），以及附件数 MAX_POST_ATTACHMENTS、MAX_COMMENT_ATTACHMENTS
+// 和标签数 MAX_POST_TAGS、MAX_COMMENT_TAGS。未设置或非法的项使用 community.DefaultLimits。
+func contentLimits() community.Limits {
+	return community.Limits{
+		TitleRunes:         positiveIntEnv("MAX_TITLE_LENGTH"),
+		ContentRunes:       positiveIntEnv("MAX_CONTENT_LENGTH"),
+		ContentJSONBytes:   positiveIntEnv("MAX_CONTENT_JSON_BYTES"),
+		PostAttachments:    positiveIntEnv("MAX_POST_ATTACHMENTS"),
+		CommentAttachments: positiveIntEnv("MAX_COMMENT_ATTACHMENTS"),
+		PostTags:           positiveIntEnv("MAX_POST_TAGS"),
+		CommentTags:        positiveIntEnv("MAX_COMMENT_TAGS"),
+	}
+}
+
+// positiveIntEnv 读取正整数环境变量，未设置或非法时返回 0（由调用方回退到默认值）。
+func positiveIntEnv(name string) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		log.Printf("invalid %s %q, using default", name, raw)
+		return 0
+	}
+	return value
+}
+
+// positiveDurationEnv 读取时长环境变量（如 "30s"），未设置或非法时返回 0（由调用方回退到默认值）。
+func positiveDurationEnv(name string) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		log.Printf("invalid %s %q, using default", name, raw)
+		return 0
+	}
+	return value
+}
+
+// mustCreateBlob 选择文件存储后端：配置了 S3_ENDPOINT 时使用 S3 兼容存储，否则落盘到 uploadDir。
+func mustCreateBlob(uploadDir string) file.Blob {
+	if strings.TrimSpace(os.Getenv("S3_ENDPOINT")) == "" {
+		log.Printf("file storage: using local directory %s", uploadDir)
+		return &file.LocalBlob{Dir: uploadDir}
+	}
+
+	blob, err := file.NewS3BlobFromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure s3 storage: %v", err)
+	}
+	log.Printf("file storage: using s3 bucket %s at %s", blob.Bucket, blob.Endpoint)
+	return blob
+}
+
 type dailyLogWriter struct {
 	dir         string
 	prefix      string
@@ -0,0 +1,58 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// DigestInterval is how often RunDigests emails opted-in users.
+const DigestInterval = 24 * time.Hour
+
+// SendDigests emails each opted-in user a summary of the unread notifications
+// that arrived since their last digest and returns how many emails were
+// sent. A failed send is logged and retried on the next run.
+func SendDigests(st store.API, mailer auth.EmailSender) int {
+	sent := 0
+	for _, recipient := range st.DigestRecipients() {
+		if err := mailer.SendNotificationDigest(recipient.Email, recipient.Items); err != nil {
+			log.Printf("failed to send notification digest to user %s: %v", recipient.UserID, err)
+			continue
+		}
+		// Anything newer than the newest item belongs to the next digest.
+		if err := st.MarkDigestSent(recipient.UserID, recipient.Seq); err != nil {
+			log.Printf("failed to record notification digest for user %s: %v", recipient.UserID, err)
+		}
+		sent++
+	}
+	return sent
+}
+
+// RunDigests calls SendDigests once at startup and then every interval until
+// ctx is cancelled, so a restart can't push the next digest a full interval
+// out. Each digest only covers notifications newer than the user's last one,
+// so the startup run never repeats items.
+func RunDigests(ctx context.Context, st store.API, mailer auth.EmailSender, interval time.Duration) {
+	sendDigests(st, mailer)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sendDigests(st, mailer)
+		}
+	}
+}
+
+func sendDigests(st store.API, mailer auth.EmailSender) {
+	if sent := SendDigests(st, mailer); sent > 0 {
+		log.Printf("notification digest: sent %d emails", sent)
+	}
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// digestMailer records digest recipients on a channel.
+type digestMailer struct {
+	sent chan string
+}
+
+func (m *digestMailer) SendVerificationEmail(string, string) error       { return nil }
+func (m *digestMailer) SendEmailChangeVerification(string, string) error { return nil }
+
+func (m *digestMailer) SendNotificationDigest(toEmail string, _ []store.Notification) error {
+	m.sent <- toEmail
+	return nil
+}
+
+func TestRunDigestsSendsAtStartup(t *testing.T) {
+	s := store.NewStore()
+	reg, err := s.Register("reader@example.com", "password123", "reader")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if err := s.SetEmailDigest(reg.User.ID, true); err != nil {
+		t.Fatalf("SetEmailDigest: %v", err)
+	}
+	if _, err := s.CreateNotification(reg.User.ID, "u_actor", "follow", "user", reg.User.ID); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mailer := &digestMailer{sent: make(chan string, 1)}
+	go RunDigests(ctx, s, mailer, time.Hour)
+
+	select {
+	case to := <-mailer.sent:
+		if to != "reader@example.com" {
+			t.Fatalf("digest sent to %s, want reader@example.com", to)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no digest sent at startup")
+	}
+}
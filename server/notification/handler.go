@@ -8,13 +8,15 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
 // Handler provides notification API endpoints.
 type Handler struct {
-	Store store.API
-	Auth  *auth.Service
+	Store   store.API
+	Auth    *auth.Service
+	Streams Streams // optional; feeds Stream with new notifications
 }
 
 // NotificationResponse is a single notification in API responses.
@@ -28,8 +30,10 @@ type NotificationResponse struct {
 	Type            string `json:"type"`
 	TargetType      string `json:"target_type,omitempty"`
 	TargetID        string `json:"target_id,omitempty"`
+	DeletedReason   string `json:"deleted_reason,omitempty"` // content_removed only
 	Read            bool   `json:"read"`
 	CreatedAt       string `json:"created_at"`
+	CreatedAtUnix   int64  `json:"created_at_unix"`
 }
 
 // ListResponse is the response for listing notifications.
@@ -40,7 +44,7 @@ type ListResponse struct {
 	PageSize int                    `json:"page_size"`
 }
 
-// List handles GET /api/v1/notifications
+// List handles GET /api/v1/notifications (optionally ?type=comment,follow)
 func (h *Handler) List(c *gin.Context) {
 	user, ok := h.Auth.RequireUser(c)
 	if !ok {
@@ -57,35 +61,25 @@ func (h *Handler) List(c *gin.Context) {
 	}
 
 	offset := (page - 1) * pageSize
-	notifications, total := h.Store.Notifications(user.ID, offset, pageSize)
+	notifications, total := h.Store.NotificationsByType(user.ID, parseTypes(c.Query("type")), offset, pageSize)
+
+	actorIDs := make([]string, 0, len(notifications))
+	for _, n := range notifications {
+		actorIDs = append(actorIDs, n.ActorID)
+	}
+	actors := h.Store.GetUsers(actorIDs)
 
 	results := make([]NotificationResponse, 0, len(notifications))
 	for _, n := range notifications {
-		actorName := ""
-		actorAvatar := ""
-		actorLevel := 0
-		actorLevelTitle := ""
-		if actor, ok := h.Store.GetUser(n.ActorID); ok {
-			actorName = actor.Nickname
-			actorAvatar = actor.Avatar
-			level := store.LevelForExp(actor.Exp)
-			actorLevel = level.Level
-			actorLevelTitle = level.Title
+		actor, ok := actors[n.ActorID]
+		if !ok {
+			actor = store.DeletedUser()
 		}
-
-		results = append(results, NotificationResponse{
-			ID:              n.ID,
-			ActorID:         n.ActorID,
-			ActorName:       actorName,
-			ActorAvatar:     actorAvatar,
-			ActorLevel:      actorLevel,
-			ActorLevelTitle: actorLevelTitle,
-			Type:            n.Type,
-			TargetType:      n.TargetType,
-			TargetID:        n.TargetID,
-			Read:            strings.TrimSpace(n.ReadAt) != "",
-			CreatedAt:       n.CreatedAt,
-		})
+		resp := notificationResponse(n, actor)
+		if n.Type == store.NotificationContentRemoved {
+			resp.DeletedReason = h.removalReason(n)
+		}
+		results = append(results, resp)
 	}
 
 	c.JSON(http.StatusOK, ListResponse{
@@ -96,6 +90,22 @@ func (h *Handler) List(c *gin.Context) {
 	})
 }
 
+// removalReason reads the deleted_reason recorded on a content_removed
+// notification's target post or comment.
+func (h *Handler) removalReason(n store.Notification) string {
+	switch n.TargetType {
+	case store.ReportTargetPost:
+		if post, ok := h.Store.GetPostIncludingDeleted(n.TargetID); ok {
+			return post.DeletedReason
+		}
+	case store.ReportTargetComment:
+		if comment, ok := h.Store.GetCommentIncludingDeleted(n.TargetID); ok {
+			return comment.DeletedReason
+		}
+	}
+	return ""
+}
+
 // UnreadCount handles GET /api/v1/notifications/unread-count
 func (h *Handler) UnreadCount(c *gin.Context) {
 	user, ok := h.Auth.RequireUser(c)
@@ -107,6 +117,18 @@ func (h *Handler) UnreadCount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"count": count})
 }
 
+// Badges handles GET /api/v1/me/badges, the unread notification and chat
+// message counts the nav bar polls. Anonymous or invalid tokens get zeros
+// instead of a 401 so clients can call it before knowing the login state.
+func (h *Handler) Badges(c *gin.Context) {
+	notifications, messages := 0, 0
+	if user, ok := h.Auth.OptionalUser(c); ok {
+		notifications = h.Store.UnreadNotificationCount(user.ID)
+		messages = h.Store.UnreadMessageCount(user.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications, "messages": messages})
+}
+
 // MarkRead handles PATCH /api/v1/notifications/:id
 func (h *Handler) MarkRead(c *gin.Context) {
 	user, ok := h.Auth.RequireUser(c)
@@ -117,10 +139,84 @@ func (h *Handler) MarkRead(c *gin.Context) {
 	notificationID := c.Param("id")
 	if err := h.Store.MarkNotificationRead(notificationID, user.ID); err != nil {
 		if err == store.ErrNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+			writeError(c, http.StatusNotFound, 2001, "notification not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark as read"})
+		writeError(c, http.StatusInternalServerError, 5000, "failed to mark as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// maxReadIDs caps how many notifications one batch mark-read may name.
+const maxReadIDs = 100
+
+// MarkListRead handles POST /api/v1/notifications/read
+func (h *Handler) MarkListRead(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if !transport.BindJSON(c, &req, maxReadListBody) {
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(c, http.StatusBadRequest, 2001, "ids is required")
+		return
+	}
+	if len(req.IDs) > maxReadIDs {
+		writeError(c, http.StatusBadRequest, 2001, "too many ids")
+		return
+	}
+
+	updated, err := h.Store.MarkNotificationsRead(user.ID, req.IDs)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "failed to mark as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "updated": updated})
+}
+
+// MarkUnread handles POST /api/v1/notifications/:id/unread
+func (h *Handler) MarkUnread(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	notificationID := c.Param("id")
+	if err := h.Store.MarkNotificationUnread(notificationID, user.ID); err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "notification not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "failed to mark as unread")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// Delete handles DELETE /api/v1/notifications/:id
+func (h *Handler) Delete(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	notificationID := c.Param("id")
+	if err := h.Store.DeleteNotification(notificationID, user.ID); err != nil {
+		if err == store.ErrNotFound {
+			writeError(c, http.StatusNotFound, 2001, "notification not found")
+			return
+		}
+		writeError(c, http.StatusInternalServerError, 5000, "failed to delete notification")
 		return
 	}
 
@@ -135,9 +231,61 @@ func (h *Handler) MarkAllRead(c *gin.Context) {
 	}
 
 	if err := h.Store.MarkAllNotificationsRead(user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark all as read"})
+		writeError(c, http.StatusInternalServerError, 5000, "failed to mark all as read")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// Request body caps.
+const (
+	maxPrefsBody    = 1 << 10
+	maxReadListBody = 8 << 10
+)
+
+// UpdatePrefs handles PATCH /api/v1/users/me/notification-prefs
+func (h *Handler) UpdatePrefs(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		EmailDigest *bool `json:"email_digest"`
+	}
+	if !transport.BindJSON(c, &req, maxPrefsBody) {
+		return
+	}
+	if req.EmailDigest == nil {
+		writeError(c, http.StatusBadRequest, 2001, "email_digest is required")
+		return
+	}
+	if err := h.Store.SetEmailDigest(user.ID, *req.EmailDigest); err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "failed to update notification prefs")
+		return
+	}
+
+	prefs := h.Store.NotificationPrefs(user.ID)
+	c.JSON(http.StatusOK, gin.H{"email_digest": prefs.EmailDigest})
+}
+
+// parseTypes splits a comma-separated type filter, dropping blanks and
+// duplicates. An empty result means no filtering.
+func parseTypes(raw string) []string {
+	var types []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		t := strings.TrimSpace(part)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	return types
+}
+
+func writeError(c *gin.Context, status int, code int, message string) {
+	transport.Error(c, status, code, message)
+}
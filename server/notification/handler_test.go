@@ -0,0 +1,198 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// countingStore counts user lookups so tests can assert how many round trips
+// a handler makes.
+type countingStore struct {
+	store.API
+	getUser  int
+	getUsers int
+}
+
+func (s *countingStore) GetUser(userID string) (store.User, bool) {
+	s.getUser++
+	return s.API.GetUser(userID)
+}
+
+func (s *countingStore) GetUsers(userIDs []string) map[string]store.User {
+	s.getUsers++
+	return s.API.GetUsers(userIDs)
+}
+
+func TestListResolvesActorsInOneLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := store.NewStore()
+	reg, err := mem.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := mem.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, owner, err := mem.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	const actors = 5
+	for i := 0; i < actors; i++ {
+		actor, err := mem.Register(fmt.Sprintf("actor%d@example.com", i), "password123", fmt.Sprintf("actor%d", i))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if _, err := mem.CreateNotification(owner.ID, actor.User.ID, "follow", "user", owner.ID); err != nil {
+			t.Fatalf("CreateNotification: %v", err)
+		}
+	}
+	if _, err := mem.CreateNotification(owner.ID, "u_gone", "follow", "user", owner.ID); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	counting := &countingStore{API: mem}
+	h := &Handler{Store: counting, Auth: &auth.Service{Store: counting}}
+	router := gin.New()
+	router.GET("/api/v1/notifications", h.List)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var resp ListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Data) != actors+1 {
+		t.Fatalf("got %d notifications, want %d", len(resp.Data), actors+1)
+	}
+	if counting.getUsers != 1 || counting.getUser != 0 {
+		t.Fatalf("GetUsers called %d times and GetUser %d times, want 1 and 0", counting.getUsers, counting.getUser)
+	}
+	for _, n := range resp.Data {
+		if n.ActorName == "" {
+			t.Fatalf("notification %s has no actor name", n.ID)
+		}
+	}
+	if gone := resp.Data[0]; gone.ActorID != store.DeletedUserID || gone.ActorName != store.DeletedUserNickname {
+		t.Fatalf("missing actor = %+v, want deleted-user placeholder", gone)
+	}
+}
+
+func TestBadgesCombinesUnreadCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := store.NewStore()
+	reg, err := mem.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := mem.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, owner, err := mem.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if _, err := mem.CreateNotification(owner.ID, "u_peer", "follow", "user", owner.ID); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+	roomID, err := mem.OpenDMRoom(owner.ID, "u_peer")
+	if err != nil {
+		t.Fatalf("OpenDMRoom: %v", err)
+	}
+	first := mem.AddMessage(roomID, "u_peer", "hi")
+	mem.AddMessage(roomID, "u_peer", "are you there?")
+	mem.AddMessage(roomID, owner.ID, "yes")
+	if err := mem.MarkRoomRead(owner.ID, roomID, first.ID); err != nil {
+		t.Fatalf("MarkRoomRead: %v", err)
+	}
+
+	h := &Handler{Store: mem, Auth: &auth.Service{Store: mem}}
+	router := gin.New()
+	router.GET("/api/v1/me/badges", h.Badges)
+
+	for _, tc := range []struct {
+		name                    string
+		token                   string
+		notifications, messages int
+	}{
+		{"signed in", session.Token, 1, 1},
+		{"anonymous", "", 0, 0},
+		{"invalid token", "nope", 0, 0},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me/badges", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", tc.name, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Notifications int `json:"notifications"`
+			Messages      int `json:"messages"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if resp.Notifications != tc.notifications || resp.Messages != tc.messages {
+			t.Fatalf("%s: got %+v, want %d notifications and %d messages", tc.name, resp, tc.notifications, tc.messages)
+		}
+	}
+}
+
+func TestListCarriesRemovalReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := store.NewStore()
+	reg, err := mem.Register("author@example.com", "password123", "author")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := mem.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, author, err := mem.Login("author@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	post := mem.CreatePost(mem.Boards()[0].ID, "u_other", "t", "c", "", "", nil, nil)
+	comment := mem.CreateComment(post.ID, author.ID, "reply", "", "", nil, nil)
+	if err := mem.SoftDeleteComment(post.ID, comment.ID, "u_admin", true); err != nil {
+		t.Fatalf("SoftDeleteComment: %v", err)
+	}
+
+	h := &Handler{Store: mem, Auth: &auth.Service{Store: mem}}
+	router := gin.New()
+	router.GET("/api/v1/notifications", h.List)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp ListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v (%s)", err, rec.Body.String())
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Type != store.NotificationContentRemoved {
+		t.Fatalf("notifications = %+v, want one content_removed", resp.Data)
+	}
+	if got := resp.Data[0].DeletedReason; got != store.ModeratorRemovalReason {
+		t.Fatalf("deleted_reason = %q, want %q", got, store.ModeratorRemovalReason)
+	}
+}
@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// Streams fans events out to each user's open notification streams.
+// *chat.Hub implements it.
+type Streams interface {
+	SubscribeUser(userID string) (<-chan []byte, func())
+	PublishUser(userID string, message []byte)
+}
+
+// streamKeepAlive is how often an idle stream gets a comment line, so proxies
+// do not time it out. Each tick also re-checks the unread count.
+const streamKeepAlive = 15 * time.Second
+
+// Stream handles GET /api/v1/notifications/stream, a Server-Sent Events
+// fallback for clients that cannot hold a WebSocket. It sends the unread
+// count first, then each new notification as an "event: notification" frame.
+// EventSource cannot set headers, so ?token= is accepted as well.
+func (h *Handler) Stream(c *gin.Context) {
+	user, ok := h.streamUser(c)
+	if !ok {
+		return
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		writeError(c, http.StatusInternalServerError, 5000, "streaming unsupported")
+		return
+	}
+
+	// Without a hub the stream still reports unread counts on each tick.
+	var events <-chan []byte
+	if h.Streams != nil {
+		ch, unsubscribe := h.Streams.SubscribeUser(user.ID)
+		defer unsubscribe()
+		events = ch
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	unread := h.Store.UnreadNotificationCount(user.ID)
+	if writeUnreadCount(w, unread) != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+	done := c.Request.Context().Done()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case message, open := <-events:
+			if !open {
+				return
+			}
+			unread++
+			err = writeEvent(w, "notification", message)
+		case <-ticker.C:
+			if count := h.Store.UnreadNotificationCount(user.ID); count != unread {
+				unread = count
+				err = writeUnreadCount(w, unread)
+			} else {
+				_, err = io.WriteString(w, ": keep-alive\n\n")
+			}
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// streamUser authenticates like RequireUser, falling back to ?token= when no
+// Authorization header is sent.
+func (h *Handler) streamUser(c *gin.Context) (store.User, bool) {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" || c.GetHeader("Authorization") != "" {
+		return h.Auth.RequireUser(c)
+	}
+	user, ok := h.Store.UserByToken(token)
+	if !ok {
+		writeError(c, http.StatusUnauthorized, 1001, "invalid token")
+	}
+	return user, ok
+}
+
+func writeUnreadCount(w io.Writer, count int) error {
+	data, _ := json.Marshal(gin.H{"count": count})
+	return writeEvent(w, "unread_count", data)
+}
+
+func writeEvent(w io.Writer, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// PushingStore wraps a store so every notification created through it is
+// also published to the recipient's open streams. Notifications the store
+// creates internally (e.g. content_removed) are not pushed; streams pick
+// those up through the periodic unread count instead.
+type PushingStore struct {
+	store.API
+	Streams Streams
+}
+
+// CreateNotification creates the notification, then pushes it. A skipped
+// self-notification comes back with an empty ID and is not pushed.
+func (s *PushingStore) CreateNotification(recipientID, actorID, notifType, targetType, targetID string) (store.Notification, error) {
+	n, err := s.API.CreateNotification(recipientID, actorID, notifType, targetType, targetID)
+	if err != nil || n.ID == "" {
+		return n, err
+	}
+	actor, ok := s.API.GetUser(n.ActorID)
+	if !ok {
+		actor = store.DeletedUser()
+	}
+	if data, err := json.Marshal(notificationResponse(n, actor)); err == nil {
+		s.Streams.PublishUser(n.RecipientID, data)
+	}
+	return n, nil
+}
+
+// Close closes the wrapped store if it can be closed.
+func (s *PushingStore) Close() error {
+	if closer, ok := s.API.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func notificationResponse(n store.Notification, actor store.User) NotificationResponse {
+	level := store.LevelForExp(actor.Exp)
+	return NotificationResponse{
+		ID:              n.ID,
+		ActorID:         actor.ID,
+		ActorName:       actor.Nickname,
+		ActorAvatar:     actor.Avatar,
+		ActorLevel:      level.Level,
+		ActorLevelTitle: level.Title,
+		Type:            n.Type,
+		TargetType:      n.TargetType,
+		TargetID:        n.TargetID,
+		Read:            strings.TrimSpace(n.ReadAt) != "",
+		CreatedAt:       n.CreatedAt,
+		CreatedAtUnix:   transport.UnixMillis(n.CreatedAt),
+	}
+}
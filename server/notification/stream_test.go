@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/chat"
+	"github.com/Versifine/Cumt-cumpus-hub/server/store"
+)
+
+// readEvent reads one SSE frame, skipping comment lines.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamPushesNewNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mem := store.NewStore()
+	reg, err := mem.Register("owner@example.com", "password123", "owner")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := mem.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	session, owner, err := mem.Login("owner@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	actor, err := mem.Register("actor@example.com", "password123", "actor")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := mem.CreateNotification(owner.ID, actor.User.ID, "follow", "", ""); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+
+	hub := chat.NewHub()
+	pushing := &PushingStore{API: mem, Streams: hub}
+	h := &Handler{Store: pushing, Auth: &auth.Service{Store: pushing}, Streams: hub}
+	router := gin.New()
+	router.GET("/api/v1/notifications/stream", h.Stream)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/api/v1/notifications/stream?token=nope")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("invalid token status = %d, want 401", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/v1/notifications/stream?token=" + session.Token)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	body := bufio.NewReader(resp.Body)
+
+	event, data := readEvent(t, body)
+	if event != "unread_count" || data != `{"count":1}` {
+		t.Fatalf("first event = %s %s, want unread_count {\"count\":1}", event, data)
+	}
+
+	if _, err := pushing.CreateNotification(owner.ID, actor.User.ID, "like", "post", "p_1"); err != nil {
+		t.Fatalf("CreateNotification: %v", err)
+	}
+	event, data = readEvent(t, body)
+	if event != "notification" {
+		t.Fatalf("event = %q, want notification", event)
+	}
+	var pushed NotificationResponse
+	if err := json.Unmarshal([]byte(data), &pushed); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if pushed.Type != "like" || pushed.TargetID != "p_1" || pushed.ActorName != "actor" || pushed.Read {
+		t.Fatalf("pushed = %+v", pushed)
+	}
+
+	// Closing the hub ends the stream, as it does on server shutdown.
+	hub.Close()
+	if _, err := body.ReadString('\n'); err == nil {
+		t.Fatalf("stream still open after hub closed")
+	}
+}
+
+func TestPushingStoreCloseReachesSQLiteThroughCache(t *testing.T) {
+	sqlite, err := store.OpenSQLite(filepath.Join(t.TempDir(), "close.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	chain := &PushingStore{API: store.NewCachedStore(sqlite, store.CachedStoreConfig{})}
+	if err := chain.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := sqlite.Ping(context.Background()); err == nil {
+		t.Fatal("database still open after closing the store chain")
+	}
+}
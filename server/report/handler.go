@@ -1,14 +1,15 @@
 package report
 
 import (
+	"encoding/json"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Versifine/Cumt-cumpus-hub/server/auth"
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
@@ -17,6 +18,9 @@ type Handler struct {
 	Auth  *auth.Service
 }
 
+// maxReportBody caps report and moderation request bodies.
+const maxReportBody = 16 << 10
+
 func (h *Handler) Create(c *gin.Context) {
 	user, ok := h.Auth.RequireUser(c)
 	if !ok {
@@ -29,8 +33,7 @@ func (h *Handler) Create(c *gin.Context) {
 		Reason     string `json:"reason"`
 		Detail     string `json:"detail"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
+	if !transport.BindJSON(c, &req, maxReportBody) {
 		return
 	}
 
@@ -58,7 +61,7 @@ func (h *Handler) AdminList(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if !isAdmin(user) {
+	if !h.isAdmin(user) {
 		writeError(c, http.StatusForbidden, 1002, "forbidden")
 		return
 	}
@@ -73,8 +76,12 @@ func (h *Handler) AdminList(c *gin.Context) {
 		return
 	}
 
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		out = append(out, reportItem(item))
+	}
 	resp := map[string]any{
-		"items": items,
+		"items": out,
 		"total": total,
 	}
 	c.JSON(http.StatusOK, resp)
@@ -91,7 +98,7 @@ func (h *Handler) AdminUpdate(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if !isAdmin(user) {
+	if !h.isAdmin(user) {
 		writeError(c, http.StatusForbidden, 1002, "forbidden")
 		return
 	}
@@ -101,8 +108,7 @@ func (h *Handler) AdminUpdate(c *gin.Context) {
 		Action string `json:"action"`
 		Note   string `json:"note"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		writeError(c, http.StatusBadRequest, 2001, "invalid json")
+	if !transport.BindJSON(c, &req, maxReportBody) {
 		return
 	}
 
@@ -110,32 +116,171 @@ func (h *Handler) AdminUpdate(c *gin.Context) {
 	if err != nil {
 		switch err {
 		case store.ErrInvalidInput:
-			writeError(c, http.StatusBadRequest, 2001, "missing fields")
+			writeError(c, http.StatusBadRequest, 2001, "invalid status")
 		case store.ErrNotFound:
 			writeError(c, http.StatusNotFound, 2001, "not found")
+		case store.ErrInvalidTransition:
+			writeError(c, http.StatusConflict, 2001, "invalid status transition")
 		default:
 			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
 		return
 	}
-	c.JSON(http.StatusOK, updated)
+	c.JSON(http.StatusOK, reportItem(updated))
+}
+
+// reportItem is the admin view of a report. target_snapshot is the reported
+// content as it was at report time, or null when none was captured.
+func reportItem(r store.Report) map[string]any {
+	var snapshot json.RawMessage
+	if r.TargetSnapshot != "" {
+		snapshot = json.RawMessage(r.TargetSnapshot)
+	}
+	return map[string]any{
+		"id":              r.ID,
+		"target_type":     r.TargetType,
+		"target_id":       r.TargetID,
+		"target_snapshot": snapshot,
+		"reporter_id":     r.ReporterID,
+		"reason":          r.Reason,
+		"detail":          r.Detail,
+		"status":          r.Status,
+		"action":          r.Action,
+		"note":            r.Note,
+		"handled_by":      r.HandledBy,
+		"created_at":      r.CreatedAt,
+		"updated_at":      r.UpdatedAt,
+	}
 }
 
-func isAdmin(user store.User) bool {
-	raw := strings.TrimSpace(os.Getenv("ADMIN_ACCOUNTS"))
-	if raw == "" {
-		return false
+// maxBulkReports caps how many reports one bulk update may touch.
+const maxBulkReports = 100
+
+// AdminBulkUpdate handles POST /api/v1/admin/reports/bulk. Every report gets
+// the same status, action and note; IDs that do not exist or cannot make the
+// transition are skipped and left out of the count.
+func (h *Handler) AdminBulkUpdate(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	if !h.isAdmin(user) {
+		writeError(c, http.StatusForbidden, 1002, "forbidden")
+		return
+	}
+
+	var req struct {
+		IDs    []string `json:"ids"`
+		Status string   `json:"status"`
+		Action string   `json:"action"`
+		Note   string   `json:"note"`
+	}
+	if !transport.BindJSON(c, &req, maxReportBody) {
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(c, http.StatusBadRequest, 2001, "missing ids")
+		return
+	}
+	if len(req.IDs) > maxBulkReports {
+		writeError(c, http.StatusBadRequest, 2001, "too many ids")
+		return
 	}
-	parts := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' })
-	for _, part := range parts {
-		if strings.TrimSpace(part) == "" {
-			continue
+
+	updated, err := h.Store.UpdateReports(req.IDs, req.Status, req.Action, req.Note, user.ID)
+	if err != nil {
+		switch err {
+		case store.ErrInvalidInput:
+			writeError(c, http.StatusBadRequest, 2001, "invalid status")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
 		}
-		if strings.EqualFold(strings.TrimSpace(part), user.Nickname) {
-			return true
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"updated": updated})
+}
+
+// AdminStats handles GET /api/v1/admin/reports/stats.
+func (h *Handler) AdminStats(c *gin.Context) {
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	if !h.isAdmin(user) {
+		writeError(c, http.StatusForbidden, 1002, "forbidden")
+		return
+	}
+
+	stats, err := h.Store.ReportStats()
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, 5000, "server error")
+		return
+	}
+
+	byStatus := map[string]int{}
+	byReason := map[string]int{}
+	for key, count := range stats {
+		switch {
+		case strings.HasPrefix(key, store.ReportStatsStatusPrefix):
+			byStatus[strings.TrimPrefix(key, store.ReportStatsStatusPrefix)] = count
+		case strings.HasPrefix(key, store.ReportStatsReasonPrefix):
+			byReason[strings.TrimPrefix(key, store.ReportStatsReasonPrefix)] = count
 		}
 	}
-	return false
+
+	resp := map[string]any{
+		"total":               stats[store.ReportStatsTotal],
+		"open_older_than_24h": stats[store.ReportStatsOpenStale],
+		"by_status":           byStatus,
+		"by_reason":           byReason,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminEvents handles GET /api/v1/admin/reports/{id}/events.
+func (h *Handler) AdminEvents(c *gin.Context) {
+	reportID := strings.TrimSpace(c.Param("id"))
+	if reportID == "" {
+		writeError(c, http.StatusNotFound, 2001, "not found")
+		return
+	}
+
+	user, ok := h.Auth.RequireUser(c)
+	if !ok {
+		return
+	}
+	if !h.isAdmin(user) {
+		writeError(c, http.StatusForbidden, 1002, "forbidden")
+		return
+	}
+
+	events, err := h.Store.ReportEvents(reportID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(c, http.StatusNotFound, 2001, "not found")
+		default:
+			writeError(c, http.StatusInternalServerError, 5000, "server error")
+		}
+		return
+	}
+
+	items := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		items = append(items, map[string]any{
+			"from_status": event.FromStatus,
+			"to_status":   event.ToStatus,
+			"action":      event.Action,
+			"note":        event.Note,
+			"handled_by":  event.HandledBy,
+			"created_at":  event.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+func (h *Handler) isAdmin(user store.User) bool {
+	return h.Store.IsAdmin(user.ID)
 }
 
 func parsePositiveInt(value string, fallback int) int {
@@ -151,5 +296,5 @@ func parsePositiveInt(value string, fallback int) int {
 }
 
 func writeError(c *gin.Context, status int, code int, message string) {
-	c.JSON(status, gin.H{"code": code, "message": message})
+	transport.Error(c, status, code, message)
 }
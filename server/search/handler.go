@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
 	"github.com/Versifine/Cumt-cumpus-hub/server/store"
 )
 
@@ -35,6 +36,7 @@ type PostResult struct {
 	Content          string   `json:"content"`
 	Tags             []string `json:"tags"`
 	CreatedAt        string   `json:"created_at"`
+	CreatedAtUnix    int64    `json:"created_at_unix"`
 	Score            int      `json:"score"`
 	CommentCount     int      `json:"comment_count"`
 }
@@ -85,15 +87,8 @@ func (h *Handler) SearchPosts(c *gin.Context) {
 
 	results := make([]PostResult, 0, len(posts))
 	for _, post := range posts {
-		authorName := ""
-		authorLevel := 0
-		authorLevelTitle := ""
-		if user, ok := h.Store.GetUser(post.AuthorID); ok {
-			authorName = user.Nickname
-			level := store.LevelForExp(user.Exp)
-			authorLevel = level.Level
-			authorLevelTitle = level.Title
-		}
+		author := post.Author
+		level := store.LevelForExp(author.Exp)
 
 		// Truncate content for search results
 		content := post.Content
@@ -104,16 +99,17 @@ func (h *Handler) SearchPosts(c *gin.Context) {
 		results = append(results, PostResult{
 			ID:               post.ID,
 			BoardID:          post.BoardID,
-			AuthorID:         post.AuthorID,
-			AuthorName:       authorName,
-			AuthorLevel:      authorLevel,
-			AuthorLevelTitle: authorLevelTitle,
+			AuthorID:         author.ID,
+			AuthorName:       author.Nickname,
+			AuthorLevel:      level.Level,
+			AuthorLevelTitle: level.Title,
 			Title:            post.Title,
 			Content:          content,
 			Tags:             post.Tags,
 			CreatedAt:        post.CreatedAt,
-			Score:            h.Store.PostScore(post.ID),
-			CommentCount:     h.Store.CommentCount(post.ID),
+			CreatedAtUnix:    transport.UnixMillis(post.CreatedAt),
+			Score:            post.Score,
+			CommentCount:     post.CommentCount,
 		})
 	}
 
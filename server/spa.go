@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/transport"
+)
+
+// hashedAssetPattern 匹配 Vite 构建产物的文件名，如 index-B3kD9xQa.js：
+// 文件名末段带 8 位以上的内容哈希（hex 或 base64url 字符），内容变化时文件名随之变化，
+// 因此可以长期缓存。第 1 个分组是哈希段。
+var hashedAssetPattern = regexp.MustCompile(`^/assets/.+[-.]([A-Za-z0-9_-]{8,})\.(?:js|mjs|css|woff2?|ttf|svg|png|jpe?g|gif|webp|avif)$`)
+
+// isHashedAsset 判断清理后的请求路径是否是带内容哈希的构建产物。
+// 哈希段还必须含有数字，避免把 app-settings.js 这类普通单词当成哈希。
+func isHashedAsset(cleanPath string) bool {
+	m := hashedAssetPattern.FindStringSubmatch(cleanPath)
+	return m != nil && strings.ContainsAny(m[1], "0123456789")
+}
+
+// spaHandler 作为 NoRoute 处理器托管前端静态资源：root 下存在的文件原样返回，
+// 其余 GET/HEAD 请求一律返回 index.html（200），交给前端路由处理，
+// 这样刷新 /posts/p_1 之类的深链接不会 404。/api/ 与 /files/ 下未匹配的路径仍返回真正的 404。
+func spaHandler(root string) gin.HandlerFunc {
+	fileServer := http.FileServer(http.Dir(root))
+	index := filepath.Join(root, "index.html")
+
+	return func(c *gin.Context) {
+		req := c.Request
+		// path.Clean 作用于以 "/" 开头的路径，会吃掉所有 ".."，结果不会跳出 root。
+		clean := path.Clean("/" + req.URL.Path)
+		if strings.HasPrefix(clean, "/api/") || strings.HasPrefix(clean, "/files/") ||
+			(req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			transport.Error(c, http.StatusNotFound, transport.CodeInvalidInput, "not found")
+			return
+		}
+
+		if clean != "/" {
+			info, err := os.Stat(filepath.Join(root, filepath.FromSlash(clean)))
+			if err == nil && info.Mode().IsRegular() {
+				if isHashedAsset(clean) {
+					c.Header("Cache-Control", "public, max-age=31536000, immutable")
+				}
+				fileServer.ServeHTTP(c.Writer, req)
+				return
+			}
+		}
+
+		f, err := os.Open(index)
+		if err != nil {
+			transport.Error(c, http.StatusNotFound, transport.CodeInvalidInput, "not found")
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			transport.Error(c, http.StatusInternalServerError, transport.CodeServerError, "server error")
+			return
+		}
+		// index.html 不做缓存，确保发布新版本后立即生效。
+		c.Header("Cache-Control", "no-cache")
+		http.ServeContent(c.Writer, req, "index.html", info.ModTime(), f)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsHashedAsset(t *testing.T) {
+	cases := map[string]bool{
+		"/assets/index-B3kD9xQa.js":        true,
+		"/assets/index.4f9a0c1e.css":       true,
+		"/assets/vendor-a1b2_c3d4-e5.mjs":  true,
+		"/assets/logo-0123abcd.svg":        true,
+		"/assets/app-settings.js":          false,
+		"/assets/user-profile.css":         false,
+		"/assets/index-B3kD9x.js":          false,
+		"/assets/index-B3kD9xQa.map":       false,
+		"/index-B3kD9xQa.js":               false,
+		"/assets/notifications-panel.js":   false,
+		"/assets/nested/chunk-9f8e7d6c.js": true,
+	}
+	for path, want := range cases {
+		if got := isHashedAsset(path); got != want {
+			t.Errorf("isHashedAsset(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestSPAHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.html"), []byte("<!doctype html>app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"index-B3kD9xQa.js", "app-settings.js"} {
+		if err := os.WriteFile(filepath.Join(root, "assets", name), []byte("console.log(1)"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.GET("/api/v1/ping", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"pong": true}) })
+	router.NoRoute(spaHandler(root))
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	// Deep links fall back to index.html, which is never cached.
+	for _, target := range []string{"/", "/posts/p_1", "/assets/missing-1234abcd.js"} {
+		rec := do(http.MethodGet, target)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "app") {
+			t.Fatalf("GET %s = %d %q, want index.html", target, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+			t.Fatalf("GET %s Cache-Control = %q, want no-cache", target, got)
+		}
+	}
+
+	if rec := do(http.MethodGet, "/assets/index-B3kD9xQa.js"); rec.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Fatalf("hashed asset Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+	if rec := do(http.MethodGet, "/assets/app-settings.js"); rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "" {
+		t.Fatalf("unhashed asset = %d, Cache-Control %q; want 200 without caching", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+
+	// API routes pass through; unknown API and file paths, and non-GET
+	// requests, get a real 404 instead of the app shell.
+	if rec := do(http.MethodGet, "/api/v1/ping"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "pong") {
+		t.Fatalf("GET /api/v1/ping = %d %q", rec.Code, rec.Body.String())
+	}
+	for _, tc := range []struct{ method, target string }{
+		{http.MethodGet, "/api/v1/missing"},
+		{http.MethodGet, "/files/f_missing"},
+		{http.MethodPost, "/posts/p_1"},
+	} {
+		rec := do(tc.method, tc.target)
+		if rec.Code != http.StatusNotFound || strings.Contains(rec.Body.String(), "<!doctype") {
+			t.Fatalf("%s %s = %d %q, want JSON 404", tc.method, tc.target, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"sort"
+	"strings"
+)
+
+// AdminPostFilter selects posts for AdminPosts. Blank IDs match any board or
+// author.
+type AdminPostFilter struct {
+	BoardID  string
+	AuthorID string
+	// DeletedOnly keeps only soft-deleted posts; otherwise live and deleted
+	// posts are listed together.
+	DeletedOnly bool
+	Offset      int
+	Limit       int // <= 0 means 20
+}
+
+func (f AdminPostFilter) normalized() AdminPostFilter {
+	f.BoardID = strings.TrimSpace(f.BoardID)
+	f.AuthorID = strings.TrimSpace(f.AuthorID)
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	return f
+}
+
+// AdminPosts pages through published posts for moderators, deleted ones
+// included with their DeletedAt and DeletedReason, newest first. Drafts stay
+// private to their authors and are never listed. It also returns how many
+// posts match in total.
+func (s *Store) AdminPosts(filter AdminPostFilter) ([]Post, int) {
+	filter = filter.normalized()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched := make([]Post, 0)
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		post := s.posts[i]
+		if post.IsDraft() ||
+			(filter.BoardID != "" && post.BoardID != filter.BoardID) ||
+			(filter.AuthorID != "" && post.AuthorID != filter.AuthorID) ||
+			(filter.DeletedOnly && post.DeletedAt == "") {
+			continue
+		}
+		matched = append(matched, post)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt > matched[j].CreatedAt
+	})
+
+	total := len(matched)
+	start := min(filter.Offset, total)
+	end := min(start+filter.Limit, total)
+	return matched[start:end], total
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAdminPostsIncludesDeleted(t *testing.T) {
+	s := NewStore()
+	boards := s.Boards()
+	first := s.CreatePost(boards[0].ID, "u_alice", "first", "c", "", "", nil, nil)
+	removed := s.CreatePost(boards[0].ID, "u_alice", "removed", "c", "", "", nil, nil)
+	s.CreatePost(boards[0].ID, "u_alice", "draft", "c", "", PostStatusDraft, nil, nil)
+	other := s.CreatePost(boards[1].ID, "u_bob", "other", "c", "", "", nil, nil)
+	if err := s.SoftDeletePost(removed.ID, "u_admin", true); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+
+	ids := func(posts []Post) []string {
+		out := make([]string, 0, len(posts))
+		for _, post := range posts {
+			out = append(out, post.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		name      string
+		filter    AdminPostFilter
+		want      []string
+		wantTotal int
+	}{
+		{"all, newest first", AdminPostFilter{}, []string{other.ID, removed.ID, first.ID}, 3},
+		{"by author", AdminPostFilter{AuthorID: "u_alice"}, []string{removed.ID, first.ID}, 2},
+		{"by board", AdminPostFilter{BoardID: boards[1].ID}, []string{other.ID}, 1},
+		{"deleted only", AdminPostFilter{DeletedOnly: true}, []string{removed.ID}, 1},
+		{"second page", AdminPostFilter{Offset: 1, Limit: 1}, []string{removed.ID}, 3},
+	}
+	for _, tc := range cases {
+		posts, total := s.AdminPosts(tc.filter)
+		if got := ids(posts); total != tc.wantTotal || !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v (total %d), want %v (total %d)", tc.name, got, total, tc.want, tc.wantTotal)
+		}
+	}
+
+	posts, _ := s.AdminPosts(AdminPostFilter{DeletedOnly: true})
+	if len(posts) != 1 || posts[0].DeletedAt == "" || posts[0].DeletedReason != ModeratorRemovalReason {
+		t.Fatalf("deleted post = %+v, want DeletedAt and the moderator reason", posts)
+	}
+}
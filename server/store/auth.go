@@ -7,11 +7,10 @@ import (
 	"errors"
 	"net/mail"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -26,28 +25,63 @@ var (
 	ErrVerificationTokenInvalid = errors.New("invalid verification token")
 	ErrVerificationTokenExpired = errors.New("verification token expired")
 	ErrNotFound                 = errors.New("not found")
+	ErrAlreadyDeleted           = errors.New("already deleted")
 	ErrForbidden                = errors.New("forbidden")
+	ErrInvalidTransition        = errors.New("invalid status transition")
+	ErrInvalidToken             = errors.New("invalid or expired session token")
+	ErrEditWindowExpired        = errors.New("edit window expired")
+	ErrConflict                 = errors.New("version conflict")
+	ErrBoardExists              = errors.New("board already exists")
+	ErrBoardNotEmpty            = errors.New("board has posts")
 )
 
 const (
-	minPasswordLength    = 8
-	maxNicknameLength    = 32
-	verificationTokenTTL = 24 * time.Hour
+	minPasswordLength = 8
+	maxNicknameLength = 32
+	// defaultVerificationTokenTTL is how long verification links stay valid
+	// unless SetVerificationTokenTTL says otherwise.
+	defaultVerificationTokenTTL = 24 * time.Hour
+	sessionTokenTTL             = 7 * 24 * time.Hour
+	// sessionTouchInterval limits how often last_seen is rewritten for a busy session.
+	sessionTouchInterval = time.Minute
+	maxUserAgentLength   = 256
 )
 
-func hashPassword(password string) (string, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+var (
+	verificationTokenTTLMu sync.RWMutex
+	verificationTokenTTL   = defaultVerificationTokenTTL
+)
+
+// SetVerificationTokenTTL changes how long newly issued registration and
+// email change links stay valid; links already sent keep their expiry. Call
+// it once at startup, before serving requests.
+func SetVerificationTokenTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidInput
 	}
-	return string(hashed), nil
+	verificationTokenTTLMu.Lock()
+	defer verificationTokenTTLMu.Unlock()
+	verificationTokenTTL = ttl
+	return nil
 }
 
-func verifyPassword(passwordHash string, password string) bool {
-	if passwordHash == "" || password == "" {
-		return false
-	}
-	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+// AccountStatus is the login email of a user's account and whether it has
+// been verified.
+type AccountStatus struct {
+	Email    string
+	Verified bool
+}
+
+// Session is one logged-in device. A user may hold several at once; each has
+// its own bearer token. Timestamps are RFC3339 UTC.
+type Session struct {
+	ID        string
+	UserID    string
+	Token     string
+	UserAgent string
+	CreatedAt string
+	LastSeen  string
+	ExpiresAt string
 }
 
 func normalizeEmail(email string) string {
@@ -121,5 +155,36 @@ func hashVerificationToken(token string) string {
 }
 
 func verificationTokenExpiry() time.Time {
+	verificationTokenTTLMu.RLock()
+	defer verificationTokenTTLMu.RUnlock()
 	return time.Now().UTC().Add(verificationTokenTTL)
 }
+
+func sessionTokenExpiry() time.Time {
+	return time.Now().UTC().Add(sessionTokenTTL)
+}
+
+// sessionExpired reports whether an RFC3339 expiry has passed. Unparseable
+// values count as expired.
+func sessionExpired(expiresAt string) bool {
+	parsed, err := time.Parse(time.RFC3339, expiresAt)
+	return err != nil || !time.Now().Before(parsed)
+}
+
+// sessionStale reports whether last_seen is old enough to be worth rewriting.
+func sessionStale(lastSeen string) bool {
+	parsed, err := time.Parse(time.RFC3339, lastSeen)
+	return err != nil || time.Since(parsed) >= sessionTouchInterval
+}
+
+func normalizeUserAgent(userAgent string) string {
+	trimmed := strings.TrimSpace(userAgent)
+	if len(trimmed) <= maxUserAgentLength {
+		return trimmed
+	}
+	cut := maxUserAgentLength
+	for cut > 0 && !utf8.RuneStart(trimmed[cut]) {
+		cut--
+	}
+	return trimmed[:cut]
+}
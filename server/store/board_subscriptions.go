@@ -0,0 +1,119 @@
+package store
+
+import "sort"
+
+// Feed sources for FeedPosts.
+const (
+	FeedAll       = ""          // followed users and subscribed boards
+	FeedFollowing = "following" // followed users only
+	FeedBoards    = "boards"    // subscribed boards only
+)
+
+// FeedFilter selects a page of a user's personalized feed.
+type FeedFilter struct {
+	Source string // one of the Feed* constants
+	Offset int
+	Limit  int // <= 0 means 20
+}
+
+func (f FeedFilter) normalized() FeedFilter {
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	return f
+}
+
+// SubscribeBoard adds a live board to the user's feed. Subscribing twice is
+// not an error.
+func (s *Store) SubscribeBoard(userID, boardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return ErrNotFound
+	}
+	if !s.boardLiveLocked(boardID) {
+		return ErrNotFound
+	}
+	if s.boardSubs[userID] == nil {
+		s.boardSubs[userID] = make(map[string]bool)
+	}
+	s.boardSubs[userID][boardID] = true
+	return nil
+}
+
+// UnsubscribeBoard removes a board from the user's feed.
+func (s *Store) UnsubscribeBoard(userID, boardID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.boardSubs[userID], boardID)
+	return nil
+}
+
+// SubscribedBoards returns the IDs of the live boards the user subscribes to,
+// in board order.
+func (s *Store) SubscribedBoards(userID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.boardSubs[userID]))
+	for _, board := range s.boards {
+		if board.DeletedAt == "" && s.boardSubs[userID][board.ID] {
+			ids = append(ids, board.ID)
+		}
+	}
+	return ids
+}
+
+// FeedPosts pages through published posts by users the viewer follows and
+// in boards they subscribe to, newest first. A post matching both appears
+// once. Posts by users the viewer blocked are left out. It also returns how
+// many posts match in total.
+func (s *Store) FeedPosts(userID string, filter FeedFilter) ([]Post, int) {
+	filter = filter.normalized()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	followees := s.follows[userID]
+	boards := s.boardSubs[userID]
+	if filter.Source == FeedBoards {
+		followees = nil
+	}
+	if filter.Source == FeedFollowing {
+		boards = nil
+	}
+
+	matched := make([]Post, 0)
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		post := s.posts[i]
+		if post.DeletedAt != "" || post.IsDraft() || s.blocks[userID][post.AuthorID] {
+			continue
+		}
+		if followees[post.AuthorID] || boards[post.BoardID] {
+			matched = append(matched, post)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt > matched[j].CreatedAt
+	})
+
+	total := len(matched)
+	start := min(filter.Offset, total)
+	end := min(start+filter.Limit, total)
+	return matched[start:end], total
+}
+
+// boardLiveLocked reports whether boardID names a board that is not deleted.
+// Callers hold s.mu.
+func (s *Store) boardLiveLocked(boardID string) bool {
+	for _, board := range s.boards {
+		if board.ID == boardID && board.DeletedAt == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFeedPostsMergesFollowsAndBoards(t *testing.T) {
+	s := NewStore()
+	var users []User
+	for _, name := range []string{"viewer", "followed", "stranger", "blocked"} {
+		reg, err := s.Register(name+"@example.com", "password123", name)
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		users = append(users, reg.User)
+	}
+	viewer, followed, stranger, blocked := users[0], users[1], users[2], users[3]
+	boards := s.Boards()
+	subscribedBoard, otherBoard := boards[0].ID, boards[1].ID
+
+	if err := s.FollowUser(viewer.ID, followed.ID); err != nil {
+		t.Fatalf("FollowUser: %v", err)
+	}
+	if err := s.SubscribeBoard(viewer.ID, subscribedBoard); err != nil {
+		t.Fatalf("SubscribeBoard: %v", err)
+	}
+	if err := s.SubscribeBoard(viewer.ID, subscribedBoard); err != nil {
+		t.Fatalf("SubscribeBoard twice: %v", err)
+	}
+	if err := s.SubscribeBoard(viewer.ID, "b_missing"); err != ErrNotFound {
+		t.Fatalf("SubscribeBoard(missing) = %v, want ErrNotFound", err)
+	}
+	if err := s.BlockUser(viewer.ID, blocked.ID); err != nil {
+		t.Fatalf("BlockUser: %v", err)
+	}
+
+	byFollowed := s.CreatePost(otherBoard, followed.ID, "followed", "c", "", "", nil, nil)
+	inBoard := s.CreatePost(subscribedBoard, stranger.ID, "in board", "c", "", "", nil, nil)
+	both := s.CreatePost(subscribedBoard, followed.ID, "both", "c", "", "", nil, nil)
+	s.CreatePost(otherBoard, stranger.ID, "elsewhere", "c", "", "", nil, nil)
+	s.CreatePost(subscribedBoard, blocked.ID, "blocked", "c", "", "", nil, nil)
+	s.CreatePost(subscribedBoard, followed.ID, "draft", "c", "", PostStatusDraft, nil, nil)
+
+	ids := func(posts []Post) []string {
+		out := make([]string, 0, len(posts))
+		for _, post := range posts {
+			out = append(out, post.ID)
+		}
+		return out
+	}
+	cases := []struct {
+		name      string
+		filter    FeedFilter
+		want      []string
+		wantTotal int
+	}{
+		{"all, deduplicated", FeedFilter{}, []string{both.ID, inBoard.ID, byFollowed.ID}, 3},
+		{"following", FeedFilter{Source: FeedFollowing}, []string{both.ID, byFollowed.ID}, 2},
+		{"boards", FeedFilter{Source: FeedBoards}, []string{both.ID, inBoard.ID}, 2},
+		{"second page", FeedFilter{Offset: 1, Limit: 1}, []string{inBoard.ID}, 3},
+	}
+	for _, tc := range cases {
+		posts, total := s.FeedPosts(viewer.ID, tc.filter)
+		if got := ids(posts); total != tc.wantTotal || !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v (total %d), want %v (total %d)", tc.name, got, total, tc.want, tc.wantTotal)
+		}
+	}
+
+	if got := s.SubscribedBoards(viewer.ID); !slices.Equal(got, []string{subscribedBoard}) {
+		t.Fatalf("SubscribedBoards = %v, want [%s]", got, subscribedBoard)
+	}
+	if err := s.UnsubscribeBoard(viewer.ID, subscribedBoard); err != nil {
+		t.Fatalf("UnsubscribeBoard: %v", err)
+	}
+	if got := s.SubscribedBoards(viewer.ID); len(got) != 0 {
+		t.Fatalf("SubscribedBoards after unsubscribe = %v, want none", got)
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxBoardIDLength          = 32
+	maxBoardNameLength        = 20
+	maxBoardDescriptionLength = 200
+)
+
+// boardIDPattern accepts the seeded b_<n> style as well as lowercase slugs
+// such as "lost-and-found".
+var boardIDPattern = regexp.MustCompile(`^(b_[a-z0-9_]+|[a-z0-9]+(-[a-z0-9]+)*)$`)
+
+// normalizeBoard trims a board's fields and checks them; it returns
+// ErrInvalidInput when the ID, name or description is not acceptable.
+func normalizeBoard(boardID, name, description string) (Board, error) {
+	board := Board{
+		ID:          strings.TrimSpace(boardID),
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+	}
+	if len(board.ID) > maxBoardIDLength || !boardIDPattern.MatchString(board.ID) {
+		return Board{}, ErrInvalidInput
+	}
+	if board.Name == "" || utf8.RuneCountInString(board.Name) > maxBoardNameLength {
+		return Board{}, ErrInvalidInput
+	}
+	if utf8.RuneCountInString(board.Description) > maxBoardDescriptionLength {
+		return Board{}, ErrInvalidInput
+	}
+	return board, nil
+}
+
+// CreateBoard appends a board after the existing ones. IDs of deleted boards
+// stay taken, since their posts still point at them.
+func (s *Store) CreateBoard(boardID, name, description string) (Board, error) {
+	board, err := normalizeBoard(boardID, name, description)
+	if err != nil {
+		return Board{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.boards {
+		if existing.ID == board.ID {
+			return Board{}, ErrBoardExists
+		}
+	}
+	s.boards = append(s.boards, board)
+	return board, nil
+}
+
+// UpdateBoard replaces a live board's name and description.
+func (s *Store) UpdateBoard(boardID, name, description string) (Board, error) {
+	board, err := normalizeBoard(boardID, name, description)
+	if err != nil {
+		return Board{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, existing := range s.boards {
+		if existing.ID != board.ID || existing.DeletedAt != "" {
+			continue
+		}
+		s.boards[idx] = board
+		return board, nil
+	}
+	return Board{}, ErrNotFound
+}
+
+// DeleteBoard soft-deletes a board. Unless force is set it refuses with
+// ErrBoardNotEmpty while the board still holds posts, drafts included.
+func (s *Store) DeleteBoard(boardID string, force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, board := range s.boards {
+		if board.ID != boardID || board.DeletedAt != "" {
+			continue
+		}
+		if !force {
+			for _, post := range s.posts {
+				if post.BoardID == boardID && post.DeletedAt == "" {
+					return ErrBoardNotEmpty
+				}
+			}
+		}
+		s.boards[idx].DeletedAt = now()
+		return nil
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+)
+
+// CachedStoreConfig sets how long each cached read stays fresh and how many
+// users and post scores are kept. Zero fields use the defaults.
+type CachedStoreConfig struct {
+	BoardsTTL     time.Duration
+	UserTTL       time.Duration
+	PostScoreTTL  time.Duration
+	MaxUsers      int
+	MaxPostScores int
+}
+
+const (
+	defaultBoardsCacheTTL    = time.Minute
+	defaultUserCacheTTL      = 30 * time.Second
+	defaultPostScoreCacheTTL = 5 * time.Second
+	defaultMaxCachedUsers    = 10000
+	defaultMaxCachedScores   = 10000
+)
+
+// CachedStore decorates an API with short-lived caches for the hottest reads:
+// Boards, GetUser and PostScore. Writes made through the CachedStore drop the
+// entries they affect, including deletes and report removals that clear a
+// post's votes; every other method goes straight to the wrapped store.
+// It is safe for concurrent use.
+type CachedStore struct {
+	API
+
+	boards *ttlCache[struct{}, []Board]
+	users  *ttlCache[string, User]
+	scores *ttlCache[string, int]
+}
+
+// NewCachedStore wraps inner with the caches described by cfg.
+func NewCachedStore(inner API, cfg CachedStoreConfig) *CachedStore {
+	return &CachedStore{
+		API:    inner,
+		boards: newTTLCache[struct{}, []Board]("boards", orDuration(cfg.BoardsTTL, defaultBoardsCacheTTL), 1),
+		users:  newTTLCache[string, User]("users", orDuration(cfg.UserTTL, defaultUserCacheTTL), orInt(cfg.MaxUsers, defaultMaxCachedUsers)),
+		scores: newTTLCache[string, int]("post_scores", orDuration(cfg.PostScoreTTL, defaultPostScoreCacheTTL), orInt(cfg.MaxPostScores, defaultMaxCachedScores)),
+	}
+}
+
+var _ API = (*CachedStore)(nil)
+
+// Close closes the wrapped store if it can be closed. API has no Close, so
+// embedding alone would hide the inner store's from callers that look for it.
+func (s *CachedStore) Close() error {
+	if closer, ok := s.API.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func orDuration(value, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func orInt(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func (s *CachedStore) Boards() []Board {
+	boards, ok, gen := s.boards.get(struct{}{})
+	if !ok {
+		boards = s.API.Boards()
+		s.boards.set(struct{}{}, boards, gen)
+	}
+	// Callers may modify the slice they get back; the cached one stays intact.
+	return append([]Board(nil), boards...)
+}
+
+func (s *CachedStore) CreateBoard(boardID, name, description string) (Board, error) {
+	defer s.boards.purge()
+	return s.API.CreateBoard(boardID, name, description)
+}
+
+func (s *CachedStore) UpdateBoard(boardID, name, description string) (Board, error) {
+	defer s.boards.purge()
+	return s.API.UpdateBoard(boardID, name, description)
+}
+
+func (s *CachedStore) DeleteBoard(boardID string, force bool) error {
+	defer s.boards.purge()
+	return s.API.DeleteBoard(boardID, force)
+}
+
+// GetUser caches found users only, so a freshly registered account is
+// visible immediately.
+func (s *CachedStore) GetUser(userID string) (User, bool) {
+	user, ok, gen := s.users.get(userID)
+	if ok {
+		return user, true
+	}
+	user, ok = s.API.GetUser(userID)
+	if ok {
+		s.users.set(userID, user, gen)
+	}
+	return user, ok
+}
+
+func (s *CachedStore) UpdateUser(userID, nickname, bio, avatar, cover string) (User, error) {
+	defer s.users.remove(userID)
+	return s.API.UpdateUser(userID, nickname, bio, avatar, cover)
+}
+
+func (s *CachedStore) AddExp(userID string, delta int) (int, error) {
+	defer s.users.remove(userID)
+	return s.API.AddExp(userID, delta)
+}
+
+func (s *CachedStore) AwardExp(userID, reason string) (ExpAward, error) {
+	defer s.users.remove(userID)
+	return s.API.AwardExp(userID, reason)
+}
+
+func (s *CachedStore) CheckIn(userID string) (int, bool, error) {
+	defer s.users.remove(userID)
+	return s.API.CheckIn(userID)
+}
+
+func (s *CachedStore) DeactivateAccount(userID string) error {
+	defer s.users.remove(userID)
+	return s.API.DeactivateAccount(userID)
+}
+
+func (s *CachedStore) PostScore(postID string) int {
+	score, ok, gen := s.scores.get(postID)
+	if ok {
+		return score
+	}
+	score = s.API.PostScore(postID)
+	s.scores.set(postID, score, gen)
+	return score
+}
+
+func (s *CachedStore) VotePost(postID, userID string, value int) (int, int, error) {
+	defer s.scores.remove(postID)
+	return s.API.VotePost(postID, userID, value)
+}
+
+func (s *CachedStore) ClearPostVote(postID, userID string) (int, int, error) {
+	defer s.scores.remove(postID)
+	return s.API.ClearPostVote(postID, userID)
+}
+
+func (s *CachedStore) SetPostVote(postID, userID string, value int) (int, int, error) {
+	defer s.scores.remove(postID)
+	return s.API.SetPostVote(postID, userID, value)
+}
+
+// SoftDeletePost drops the post's cached score along with its votes.
+func (s *CachedStore) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
+	defer s.scores.remove(postID)
+	return s.API.SoftDeletePost(postID, actorUserID, isAdmin)
+}
+
+// UpdateReport may remove the reported post, which clears its votes. The
+// target isn't known without another lookup and moderation is rare, so every
+// cached score is dropped.
+func (s *CachedStore) UpdateReport(reportID, status, action, note, handledBy string) (Report, error) {
+	defer s.scores.purge()
+	return s.API.UpdateReport(reportID, status, action, note, handledBy)
+}
+
+func (s *CachedStore) UpdateReports(reportIDs []string, status, action, note, handledBy string) (int, error) {
+	defer s.scores.purge()
+	return s.API.UpdateReports(reportIDs, status, action, note, handledBy)
+}
+
+// ttlCache is a size-bounded LRU whose entries also expire after ttl. Each
+// invalidation bumps a generation counter; set ignores values loaded under
+// an older generation, so a read racing a write cannot re-cache stale data.
+type ttlCache[K comparable, V any] struct {
+	name string
+	ttl  time.Duration
+	max  int
+
+	mu      sync.Mutex
+	gen     uint64
+	order   *list.List // front is most recently used
+	entries map[K]*list.Element
+}
+
+type ttlEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[K comparable, V any](name string, ttl time.Duration, max int) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		name:    name,
+		ttl:     ttl,
+		max:     max,
+		order:   list.New(),
+		entries: map[K]*list.Element{},
+	}
+}
+
+// get returns the live value for key and the generation to pass to set on a miss.
+func (c *ttlCache[K, V]) get(key K) (V, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*ttlEntry[K, V])
+		if time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(elem)
+			metrics.StoreCacheLookups.WithLabelValues(c.name, "hit").Inc()
+			return entry.value, true, c.gen
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	metrics.StoreCacheLookups.WithLabelValues(c.name, "miss").Inc()
+	var zero V
+	return zero, false, c.gen
+}
+
+func (c *ttlCache[K, V]) set(key K, value V, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	entry := &ttlEntry[K, V]{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*ttlEntry[K, V]).key)
+	}
+}
+
+func (c *ttlCache[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *ttlCache[K, V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.order.Init()
+	c.entries = map[K]*list.Element{}
+}
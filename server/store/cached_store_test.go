@@ -0,0 +1,118 @@
+package store
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCachedStoreInvalidatesOnWrites(t *testing.T) {
+	inner := NewStore()
+	s := NewCachedStore(inner, CachedStoreConfig{MaxUsers: 1})
+	reg, err := s.Register("alice@example.com", "password123", "alice")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	userID := reg.User.ID
+
+	if user, ok := s.GetUser(userID); !ok || user.Nickname != "alice" {
+		t.Fatalf("GetUser = %+v, %v", user, ok)
+	}
+	// A write that bypasses the decorator is not seen until the entry expires...
+	if _, err := inner.UpdateUser(userID, "direct", "", "", ""); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if user, _ := s.GetUser(userID); user.Nickname != "alice" {
+		t.Fatalf("cached nickname = %q, want alice", user.Nickname)
+	}
+	// ...while writes through it drop the entry.
+	if _, err := s.UpdateUser(userID, "alicia", "", "", ""); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if user, _ := s.GetUser(userID); user.Nickname != "alicia" {
+		t.Fatalf("nickname after update = %q, want alicia", user.Nickname)
+	}
+
+	post := s.CreatePost(s.Boards()[0].ID, userID, "title", "content", "", "", nil, nil)
+	if score := s.PostScore(post.ID); score != 0 {
+		t.Fatalf("initial score = %d", score)
+	}
+	if _, _, err := s.VotePost(post.ID, "u_voter", 1); err != nil {
+		t.Fatalf("VotePost: %v", err)
+	}
+	if score := s.PostScore(post.ID); score != 1 {
+		t.Fatalf("score after vote = %d, want 1", score)
+	}
+
+	// Deleting a post clears its votes, so the cached score must go too,
+	// whether the author deletes it or a report removes it.
+	if err := s.SoftDeletePost(post.ID, userID, false); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+	if score := s.PostScore(post.ID); score != 0 {
+		t.Fatalf("score after delete = %d, want 0", score)
+	}
+	reported := s.CreatePost(s.Boards()[0].ID, userID, "reported", "content", "", "", nil, nil)
+	if _, _, err := s.VotePost(reported.ID, "u_voter", 1); err != nil {
+		t.Fatalf("VotePost: %v", err)
+	}
+	if score := s.PostScore(reported.ID); score != 1 {
+		t.Fatalf("score before report = %d, want 1", score)
+	}
+	report, err := s.CreateReport("u_reporter", ReportTargetPost, reported.ID, "spam", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if _, err := s.UpdateReport(report.ID, ReportStatusResolved, ReportActionRemove, "", "u_admin"); err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+	if score := s.PostScore(reported.ID); score != 0 {
+		t.Fatalf("score after report removal = %d, want 0", score)
+	}
+
+	before := len(s.Boards())
+	if _, err := s.CreateBoard("b_new", "new", ""); err != nil {
+		t.Fatalf("CreateBoard: %v", err)
+	}
+	if after := len(s.Boards()); after != before+1 {
+		t.Fatalf("boards after create = %d, want %d", after, before+1)
+	}
+
+	// MaxUsers is 1, so caching a second user evicts the first.
+	other, err := s.Register("bob@example.com", "password123", "bob")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s.GetUser(other.User.ID)
+	if _, err := inner.UpdateUser(userID, "evicted", "", "", ""); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if user, _ := s.GetUser(userID); user.Nickname != "evicted" {
+		t.Fatalf("nickname after eviction = %q, want evicted", user.Nickname)
+	}
+}
+
+func TestCachedStoreConcurrentAccess(t *testing.T) {
+	s := NewCachedStore(NewStore(), CachedStoreConfig{})
+	post := s.CreatePost(s.Boards()[0].ID, "u_author", "title", "content", "", "", nil, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(voter string) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				s.PostScore(post.ID)
+				s.Boards()
+				if j%10 == 0 {
+					if _, _, err := s.SetPostVote(post.ID, voter, 1); err != nil {
+						t.Errorf("SetPostVote: %v", err)
+					}
+				}
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+	if score := s.PostScore(post.ID); score != 8 {
+		t.Fatalf("score = %d, want 8", score)
+	}
+}
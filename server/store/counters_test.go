@@ -0,0 +1,65 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteIDsContinueAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.db")
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	boardID := s.Boards()[0].ID
+	first := s.CreatePost(boardID, "u_author", "one", "c", "", "", nil, nil)
+	second := s.CreatePost(boardID, "u_author", "two", "c", "", "", nil, nil)
+	comment := s.CreateComment(first.ID, "u_author", "reply", "", "", nil, nil)
+	if first.ID != "p_1" || second.ID != "p_2" || comment.ID != "c_1" {
+		t.Fatalf("IDs = %s, %s, %s; want p_1, p_2, c_1", first.ID, second.ID, comment.ID)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if third := s.CreatePost(boardID, "u_author", "three", "c", "", "", nil, nil); third.ID != "p_3" {
+		t.Fatalf("post after reopen = %s, want p_3", third.ID)
+	}
+}
+
+// The insert benchmarks use an in-memory database so they measure statement
+// work rather than fsync latency, which swamps it on a file.
+
+func BenchmarkCreatePost(b *testing.B) {
+	s, err := OpenSQLite(SQLiteMemoryPath)
+	if err != nil {
+		b.Fatalf("OpenSQLite: %v", err)
+	}
+	b.Cleanup(func() { _ = s.Close() })
+	boardID := s.Boards()[0].ID
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if post := s.CreatePost(boardID, "u_author", "title", "content", "", "", nil, nil); post.ID == "" {
+			b.Fatal("CreatePost failed")
+		}
+	}
+}
+
+func BenchmarkSaveFile(b *testing.B) {
+	s, err := OpenSQLite(SQLiteMemoryPath)
+	if err != nil {
+		b.Fatalf("OpenSQLite: %v", err)
+	}
+	b.Cleanup(func() { _ = s.Close() })
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if file := s.SaveFile("u_author", "a.png", "key", "path", 1, 1); file.ID == "" {
+			b.Fatal("SaveFile failed")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import "testing"
+
+func TestSoftDeleteDropsVotes(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, "u_author", "t", "c", "", "", nil, nil)
+	comment := s.CreateComment(post.ID, "u_author", "c", "", "", nil, nil)
+	reported := s.CreateComment(post.ID, "u_author", "spam", "", "", nil, nil)
+	for _, voter := range []string{"u_a", "u_b"} {
+		if _, _, err := s.VotePost(post.ID, voter, 1); err != nil {
+			t.Fatalf("VotePost: %v", err)
+		}
+		if _, _, err := s.VoteComment(post.ID, comment.ID, voter, 1); err != nil {
+			t.Fatalf("VoteComment: %v", err)
+		}
+		if _, _, err := s.VoteComment(post.ID, reported.ID, voter, -1); err != nil {
+			t.Fatalf("VoteComment: %v", err)
+		}
+	}
+
+	if err := s.SoftDeleteComment(post.ID, comment.ID, "u_author", false); err != nil {
+		t.Fatalf("SoftDeleteComment: %v", err)
+	}
+	report, err := s.CreateReport("u_reporter", ReportTargetComment, reported.ID, "spam", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if _, err := s.UpdateReport(report.ID, ReportStatusResolved, ReportActionRemove, "", "u_admin"); err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+	if err := s.SoftDeletePost(post.ID, "u_author", false); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+
+	s.mu.Lock()
+	postVotes, commentVotes, reportedVotes := len(s.postVotes[post.ID]), len(s.commentVotes[comment.ID]), len(s.commentVotes[reported.ID])
+	s.mu.Unlock()
+	if postVotes != 0 || commentVotes != 0 || reportedVotes != 0 {
+		t.Fatalf("votes left after deletion: post %d, comment %d, removed comment %d", postVotes, commentVotes, reportedVotes)
+	}
+	if _, total := s.PostVoters(post.ID, 1, 0, 10); total != 0 {
+		t.Fatalf("PostVoters total = %d, want 0", total)
+	}
+	if problems, _ := s.VerifyIntegrity(); len(problems) != 0 {
+		t.Fatalf("VerifyIntegrity: %q", problems)
+	}
+}
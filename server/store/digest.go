@@ -0,0 +1,84 @@
+package store
+
+// NotificationPrefs holds a user's notification delivery settings.
+// DigestSeq is the sequence number of the newest notification the last email
+// digest covered, 0 if none has gone out.
+type NotificationPrefs struct {
+	EmailDigest bool
+	DigestSeq   int
+}
+
+// DigestRecipient is an opted-in user with unread notifications that arrived
+// after their last digest, newest first. Seq is the sequence number of
+// Items[0], to hand back to MarkDigestSent.
+type DigestRecipient struct {
+	UserID string
+	Email  string
+	Items  []Notification
+	Seq    int
+}
+
+// NotificationPrefs returns userID's settings; users who never set any get
+// the zero value (digest off).
+func (s *Store) NotificationPrefs(userID string) NotificationPrefs {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.notificationPrefs[userID]
+}
+
+// SetEmailDigest turns the daily notification email on or off for userID.
+func (s *Store) SetEmailDigest(userID string, enabled bool) error {
+	if userID == "" {
+		return ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return ErrNotFound
+	}
+	prefs := s.notificationPrefs[userID]
+	prefs.EmailDigest = enabled
+	s.notificationPrefs[userID] = prefs
+	return nil
+}
+
+// DigestRecipients lists verified, opted-in users who have unread
+// notifications newer than their last digest.
+func (s *Store) DigestRecipients() []DigestRecipient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]DigestRecipient, 0)
+	for account, userID := range s.accounts {
+		prefs := s.notificationPrefs[userID]
+		if !prefs.EmailDigest || s.accountVerification[account].VerifiedAt == "" {
+			continue
+		}
+		var items []Notification
+		for i := len(s.notifications) - 1; i >= 0; i-- {
+			n := s.notifications[i]
+			if n.RecipientID == userID && n.ReadAt == "" && exportSeq(n.ID) > prefs.DigestSeq {
+				items = append(items, n)
+			}
+		}
+		if len(items) > 0 {
+			out = append(out, DigestRecipient{UserID: userID, Email: account, Items: items, Seq: exportSeq(items[0].ID)})
+		}
+	}
+	return out
+}
+
+// MarkDigestSent records that userID's digest covered notifications up to
+// seq, so the next one only covers newer ones.
+func (s *Store) MarkDigestSent(userID string, seq int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := s.notificationPrefs[userID]
+	prefs.DigestSeq = seq
+	s.notificationPrefs[userID] = prefs
+	return nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDigestCursorSkipsOnlyDigestedNotifications(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "digest.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			reg, err := s.Register("reader@example.com", "password123", "reader")
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+				t.Fatalf("VerifyEmail: %v", err)
+			}
+			if err := s.SetEmailDigest(reg.User.ID, true); err != nil {
+				t.Fatalf("SetEmailDigest: %v", err)
+			}
+			notify := func() Notification {
+				t.Helper()
+				n, err := s.CreateNotification(reg.User.ID, "u_actor", "follow", "user", reg.User.ID)
+				if err != nil {
+					t.Fatalf("CreateNotification: %v", err)
+				}
+				return n
+			}
+
+			notify()
+			second := notify()
+			recipients := s.DigestRecipients()
+			if len(recipients) != 1 || len(recipients[0].Items) != 2 || recipients[0].Items[0].ID != second.ID {
+				t.Fatalf("first digest = %+v, want both notifications, newest first", recipients)
+			}
+			if err := s.MarkDigestSent(reg.User.ID, recipients[0].Seq); err != nil {
+				t.Fatalf("MarkDigestSent: %v", err)
+			}
+			if recipients := s.DigestRecipients(); len(recipients) != 0 {
+				t.Fatalf("after marking: %+v, want no recipients", recipients)
+			}
+
+			// Created within the same second as the digested ones, which a
+			// timestamp cursor would skip.
+			third := notify()
+			recipients = s.DigestRecipients()
+			if len(recipients) != 1 || len(recipients[0].Items) != 1 || recipients[0].Items[0].ID != third.ID {
+				t.Fatalf("second digest = %+v, want only %s", recipients, third.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dmRoomPrefix marks chat rooms that belong to exactly two users.
+const dmRoomPrefix = "dm_"
+
+// Conversation is a direct-message room as seen by one of its two members.
+// LastMessage is zero when nothing has been sent yet. Unread counts the peer's
+// messages after the member's read position.
+type Conversation struct {
+	RoomID      string
+	PeerID      string
+	CreatedAt   string
+	LastMessage ChatMessage
+	Unread      int
+}
+
+// UpdatedAt is when the conversation last saw activity.
+func (c Conversation) UpdatedAt() string {
+	if c.LastMessage.ID != "" {
+		return c.LastMessage.CreatedAt
+	}
+	return c.CreatedAt
+}
+
+// IsDMRoom reports whether roomID names a direct-message room.
+func IsDMRoom(roomID string) bool {
+	return strings.HasPrefix(roomID, dmRoomPrefix)
+}
+
+// dmRoomID is the canonical room for a pair of users, independent of order.
+func dmRoomID(userA, userB string) string {
+	if userB < userA {
+		userA, userB = userB, userA
+	}
+	return dmRoomPrefix + userA + "_" + userB
+}
+
+// messageSeq extracts the sequence number from a message ID ("m_42" or
+// "42"). Message IDs are allocated from one global counter, so within a room
+// a larger seq is always a later message.
+func messageSeq(id string) (int64, bool) {
+	id = strings.TrimPrefix(strings.TrimSpace(id), "m_")
+	seq, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+// dmRoom records the two members of a direct-message room.
+type dmRoom struct {
+	UserA     string
+	UserB     string
+	CreatedAt string
+}
+
+// sortConversations orders conversations by latest activity, newest first.
+func sortConversations(items []Conversation) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].UpdatedAt() > items[j].UpdatedAt()
+	})
+}
+
+// OpenDMRoom returns the direct-message room between userID and peerID,
+// recording it on first use.
+func (s *Store) OpenDMRoom(userID, peerID string) (string, error) {
+	if userID == "" || peerID == "" || userID == peerID {
+		return "", ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roomID := dmRoomID(userID, peerID)
+	if _, ok := s.dmRooms[roomID]; !ok {
+		userA, userB := userID, peerID
+		if userB < userA {
+			userA, userB = userB, userA
+		}
+		s.dmRooms[roomID] = dmRoom{UserA: userA, UserB: userB, CreatedAt: now()}
+	}
+	return roomID, nil
+}
+
+// DMRoomMembers returns the two members of a direct-message room. ok is false
+// if the room was never opened.
+func (s *Store) DMRoomMembers(roomID string) (userA, userB string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.dmRooms[roomID]
+	return room.UserA, room.UserB, ok
+}
+
+// Conversations lists userID's direct-message rooms, most recently active first.
+func (s *Store) Conversations(userID string) []Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Conversation, 0)
+	for roomID, room := range s.dmRooms {
+		var peerID string
+		switch userID {
+		case room.UserA:
+			peerID = room.UserB
+		case room.UserB:
+			peerID = room.UserA
+		default:
+			continue
+		}
+		conv := Conversation{RoomID: roomID, PeerID: peerID, CreatedAt: room.CreatedAt}
+		messages := liveMessages(s.messages[roomID])
+		if len(messages) > 0 {
+			conv.LastMessage = messages[len(messages)-1]
+		}
+		lastRead := s.chatReads[userID][roomID]
+		for _, message := range messages {
+			if seq, _ := messageSeq(message.ID); seq > lastRead && message.SenderID != userID {
+				conv.Unread++
+			}
+		}
+		out = append(out, conv)
+	}
+	sortConversations(out)
+	return out
+}
+
+// UnreadMessageCount totals the unread counts Conversations would report
+// across all of userID's rooms.
+func (s *Store) UnreadMessageCount(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for roomID, room := range s.dmRooms {
+		if userID != room.UserA && userID != room.UserB {
+			continue
+		}
+		lastRead := s.chatReads[userID][roomID]
+		for _, message := range liveMessages(s.messages[roomID]) {
+			if seq, _ := messageSeq(message.ID); seq > lastRead && message.SenderID != userID {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// MarkRoomRead records that userID has read roomID up to and including the
+// message with the given seq. The read position never moves backwards.
+func (s *Store) MarkRoomRead(userID, roomID string, seq string) error {
+	value, ok := messageSeq(seq)
+	if userID == "" || roomID == "" || !ok {
+		return ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reads := s.chatReads[userID]
+	if reads == nil {
+		reads = map[string]int64{}
+		s.chatReads[userID] = reads
+	}
+	if value > reads[roomID] {
+		reads[roomID] = value
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// emailChange is a requested but unconfirmed switch of a user's login email.
+// The account keeps its current email until the new address is verified.
+type emailChange struct {
+	NewEmail  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// accountOf returns the account (login email) owned by userID. Callers hold s.mu.
+func (s *Store) accountOf(userID string) (string, bool) {
+	for account, id := range s.accounts {
+		if id == userID {
+			return account, true
+		}
+	}
+	return "", false
+}
+
+// AccountEmail returns userID's login email and the address of a pending,
+// unexpired change, which is empty when there is none.
+func (s *Store) AccountEmail(userID string) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountOf(userID)
+	if !ok {
+		return "", "", ErrNotFound
+	}
+	pending := ""
+	if change, ok := s.emailChanges[userID]; ok && time.Now().UTC().Before(change.ExpiresAt) {
+		pending = change.NewEmail
+	}
+	return account, pending, nil
+}
+
+// AccountStatus returns userID's login email and whether it is verified.
+// Accounts without a verification record, such as seeded ones, count as
+// verified, as they do at login.
+func (s *Store) AccountStatus(userID string) (AccountStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountOf(userID)
+	if !ok {
+		return AccountStatus{}, ErrNotFound
+	}
+	verification, hasVerification := s.accountVerification[account]
+	return AccountStatus{
+		Email:    account,
+		Verified: !hasVerification || verification.VerifiedAt != "",
+	}, nil
+}
+
+// RequestEmailChange records newEmail as userID's pending login email and
+// returns the token that confirms it. password must be the current one. A
+// new request replaces any earlier pending change.
+func (s *Store) RequestEmailChange(userID, newEmail, password string) (string, error) {
+	normalizedEmail := normalizeEmail(newEmail)
+	trimmedPassword := strings.TrimSpace(password)
+	if normalizedEmail == "" || trimmedPassword == "" {
+		return "", ErrInvalidInput
+	}
+	if !validateEmail(normalizedEmail) {
+		return "", ErrInvalidEmail
+	}
+
+	verificationToken, err := newVerificationToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accountOf(userID)
+	if !ok {
+		return "", ErrNotFound
+	}
+	if !verifyPassword(s.passwords[account], trimmedPassword) {
+		return "", ErrInvalidCredentials
+	}
+	if _, taken := s.accounts[normalizedEmail]; taken {
+		return "", ErrAccountExists
+	}
+
+	s.emailChanges[userID] = emailChange{
+		NewEmail:  normalizedEmail,
+		TokenHash: hashVerificationToken(verificationToken),
+		ExpiresAt: verificationTokenExpiry(),
+	}
+	return verificationToken, nil
+}
+
+// ConfirmEmailChange applies the pending change that token belongs to,
+// making the new address the account's login email. The address must still
+// be free.
+func (s *Store) ConfirmEmailChange(token string) error {
+	trimmedToken := strings.TrimSpace(token)
+	if trimmedToken == "" {
+		return ErrInvalidInput
+	}
+	verificationHash := hashVerificationToken(trimmedToken)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, change := range s.emailChanges {
+		if change.TokenHash != verificationHash {
+			continue
+		}
+		if time.Now().UTC().After(change.ExpiresAt) {
+			return ErrVerificationTokenExpired
+		}
+		if _, taken := s.accounts[change.NewEmail]; taken {
+			return ErrAccountExists
+		}
+		account, ok := s.accountOf(userID)
+		if !ok {
+			delete(s.emailChanges, userID)
+			return ErrVerificationTokenInvalid
+		}
+
+		s.accounts[change.NewEmail] = userID
+		s.passwords[change.NewEmail] = s.passwords[account]
+		s.accountVerification[change.NewEmail] = s.accountVerification[account]
+		delete(s.accounts, account)
+		delete(s.passwords, account)
+		delete(s.accountVerification, account)
+		delete(s.emailChanges, userID)
+		return nil
+	}
+	return ErrVerificationTokenInvalid
+}
@@ -0,0 +1,59 @@
+package store
+
+import "testing"
+
+func TestEmailChangeAppliesOnlyAfterConfirmation(t *testing.T) {
+	s := NewStore()
+	register := func(account string) User {
+		t.Helper()
+		reg, err := s.Register(account, "password123", account)
+		if err != nil {
+			t.Fatalf("Register(%s): %v", account, err)
+		}
+		if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+			t.Fatalf("VerifyEmail(%s): %v", account, err)
+		}
+		return reg.User
+	}
+	user := register("old@example.com")
+	register("taken@example.com")
+
+	if _, err := s.RequestEmailChange(user.ID, "new@example.com", "wrong-pass1"); err != ErrInvalidCredentials {
+		t.Fatalf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.RequestEmailChange(user.ID, "not-an-email", "password123"); err != ErrInvalidEmail {
+		t.Fatalf("invalid email: got %v, want ErrInvalidEmail", err)
+	}
+	if _, err := s.RequestEmailChange(user.ID, "Taken@example.com", "password123"); err != ErrAccountExists {
+		t.Fatalf("taken email: got %v, want ErrAccountExists", err)
+	}
+
+	token, err := s.RequestEmailChange(user.ID, "New@example.com", "password123")
+	if err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	email, pending, err := s.AccountEmail(user.ID)
+	if err != nil || email != "old@example.com" || pending != "new@example.com" {
+		t.Fatalf("AccountEmail before confirm = %q, %q, %v", email, pending, err)
+	}
+	if _, _, err := s.Login("new@example.com", "password123", ""); err != ErrInvalidCredentials {
+		t.Fatalf("login with unconfirmed email: got %v, want ErrInvalidCredentials", err)
+	}
+
+	if err := s.ConfirmEmailChange(token); err != nil {
+		t.Fatalf("ConfirmEmailChange: %v", err)
+	}
+	email, pending, err = s.AccountEmail(user.ID)
+	if err != nil || email != "new@example.com" || pending != "" {
+		t.Fatalf("AccountEmail after confirm = %q, %q, %v", email, pending, err)
+	}
+	if _, _, err := s.Login("new@example.com", "password123", ""); err != nil {
+		t.Fatalf("login with new email: %v", err)
+	}
+	if _, _, err := s.Login("old@example.com", "password123", ""); err != ErrInvalidCredentials {
+		t.Fatalf("login with old email: got %v, want ErrInvalidCredentials", err)
+	}
+	if err := s.ConfirmEmailChange(token); err != ErrVerificationTokenInvalid {
+		t.Fatalf("reused token: got %v, want ErrVerificationTokenInvalid", err)
+	}
+}
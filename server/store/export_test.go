@@ -0,0 +1,127 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportUserDataKeepsToOwnRecords(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			testExportUserData(t, s)
+		})
+	}
+}
+
+func testExportUserData(t *testing.T, s API) {
+	register := func(account string) User {
+		t.Helper()
+		reg, err := s.Register(account, "password123", account)
+		if err != nil {
+			t.Fatalf("Register(%s): %v", account, err)
+		}
+		return reg.User
+	}
+	alice := register("alice@example.com")
+	bob := register("bob@example.com")
+	boardID := s.Boards()[0].ID
+
+	kept := s.CreatePost(boardID, alice.ID, "kept", "content", "", "", nil, nil)
+	removed := s.CreatePost(boardID, alice.ID, "removed", "content", "", "", nil, nil)
+	if err := s.SoftDeletePost(removed.ID, alice.ID, false); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+	s.CreatePost(boardID, alice.ID, "draft", "content", "", PostStatusDraft, nil, nil)
+	s.CreatePost(boardID, bob.ID, "bob's", "content", "", "", nil, nil)
+	s.CreateComment(kept.ID, bob.ID, "reply", "", "", nil, nil)
+	s.CreateComment(kept.ID, alice.ID, "mine", "", "", nil, nil)
+	s.SaveFile(alice.ID, "a.png", "k", "p", 1, 1)
+	if err := s.FollowUser(alice.ID, bob.ID); err != nil {
+		t.Fatalf("FollowUser: %v", err)
+	}
+	if err := s.FollowUser(bob.ID, alice.ID); err != nil {
+		t.Fatalf("FollowUser: %v", err)
+	}
+	if _, err := s.CreateReport(alice.ID, "post", "p_99", "spam", ""); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if _, err := s.CreateReport(bob.ID, "post", kept.ID, "spam", ""); err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+
+	var profile ExportedProfile
+	var posts []Post
+	var comments []Comment
+	var files []FileMeta
+	var following []User
+	var reports []Report
+	var sections []string
+	err := s.ExportUserData(alice.ID, func(section string, item any) error {
+		if len(sections) == 0 || sections[len(sections)-1] != section {
+			sections = append(sections, section)
+		}
+		switch v := item.(type) {
+		case ExportedProfile:
+			profile = v
+		case Post:
+			posts = append(posts, v)
+		case Comment:
+			comments = append(comments, v)
+		case FileMeta:
+			files = append(files, v)
+		case User:
+			following = append(following, v)
+		case Report:
+			reports = append(reports, v)
+		default:
+			t.Fatalf("unexpected %s item %T", section, item)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+	want := []string{ExportProfile, ExportPosts, ExportComments, ExportFiles, ExportFollowing, ExportReports}
+	if len(sections) != len(want) {
+		t.Fatalf("sections = %v, want %v", sections, want)
+	}
+	for i := range want {
+		if sections[i] != want[i] {
+			t.Fatalf("sections = %v, want %v", sections, want)
+		}
+	}
+	if profile.User.ID != alice.ID || profile.Email != "alice@example.com" {
+		t.Fatalf("profile = %+v", profile)
+	}
+	if len(posts) != 2 || posts[0].Title != "kept" || posts[1].Title != "draft" {
+		t.Fatalf("Posts = %+v, want kept and draft", posts)
+	}
+	if len(comments) != 1 || comments[0].Content != "mine" {
+		t.Fatalf("Comments = %+v, want only alice's", comments)
+	}
+	if len(files) != 1 || len(reports) != 1 {
+		t.Fatalf("Files = %d, Reports = %d, want 1 and 1", len(files), len(reports))
+	}
+	if len(following) != 1 || following[0].ID != bob.ID {
+		t.Fatalf("Following = %+v, want bob", following)
+	}
+	emitted := 0
+	if err := s.ExportUserData(alice.ID, func(string, any) error {
+		emitted++
+		return errExportStop
+	}); err != errExportStop || emitted != 1 {
+		t.Fatalf("stopping emit: got %v after %d items, want errExportStop after 1", err, emitted)
+	}
+	if err := s.ExportUserData("u_missing", func(string, any) error { return nil }); err != ErrNotFound {
+		t.Fatalf("missing user: got %v, want ErrNotFound", err)
+	}
+}
+
+var errExportStop = errors.New("stop")
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// integrityCounters are the ID sequences RepairCounters keeps ahead of the
+// rows they number, with the SQL table holding those rows.
+var integrityCounters = []struct {
+	name  string
+	table string
+}{
+	{"user", "users"},
+	{"post", "posts"},
+	{"comment", "comments"},
+	{"file", "files"},
+}
+
+// VerifyIntegrity checks the references the store does not enforce itself:
+// comments whose post or parent is gone, votes on posts or comments that are
+// missing or deleted (deletion drops them), votes other than +1/-1, and
+// attachments on live posts and comments naming files that were never
+// stored. It returns one line per problem, sorted, and changes nothing.
+func (s *Store) VerifyIntegrity() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	posts := make(map[string]bool, len(s.posts)) // post ID -> deleted
+	for _, post := range s.posts {
+		posts[post.ID] = post.DeletedAt != ""
+	}
+	comments := make(map[string]Comment, len(s.comments))
+	for _, comment := range s.comments {
+		comments[comment.ID] = comment
+	}
+
+	problems := []string{}
+	checkAttachments := func(owner string, fileIDs []string) {
+		for _, fileID := range fileIDs {
+			if _, ok := s.files[fileID]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: attachment %s does not exist", owner, fileID))
+			}
+		}
+	}
+	for _, post := range s.posts {
+		if post.DeletedAt == "" {
+			checkAttachments("post "+post.ID, post.Attachments)
+		}
+	}
+	for _, comment := range s.comments {
+		if _, ok := posts[comment.PostID]; !ok {
+			problems = append(problems, fmt.Sprintf("comment %s: post %s does not exist", comment.ID, comment.PostID))
+		}
+		if comment.ParentID != "" && comments[comment.ParentID].PostID != comment.PostID {
+			problems = append(problems, fmt.Sprintf("comment %s: parent %s does not exist in post %s", comment.ID, comment.ParentID, comment.PostID))
+		}
+		if comment.DeletedAt == "" {
+			checkAttachments("comment "+comment.ID, comment.Attachments)
+		}
+	}
+	for postID, votes := range s.postVotes {
+		if deleted, ok := posts[postID]; (!ok || deleted) && len(votes) > 0 {
+			problems = append(problems, fmt.Sprintf("post %s: %d votes on a %s post", postID, len(votes), missingOrDeleted(ok)))
+		}
+		problems = append(problems, badVoteValues("post "+postID, votes)...)
+	}
+	for commentID, votes := range s.commentVotes {
+		if comment, ok := comments[commentID]; (!ok || comment.DeletedAt != "") && len(votes) > 0 {
+			problems = append(problems, fmt.Sprintf("comment %s: %d votes on a %s comment", commentID, len(votes), missingOrDeleted(ok)))
+		}
+		problems = append(problems, badVoteValues("comment "+commentID, votes)...)
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+func missingOrDeleted(exists bool) string {
+	if exists {
+		return "deleted"
+	}
+	return "missing"
+}
+
+// badVoteValues reports every vote in votes (user ID -> value) that is not
+// +1 or -1, which would skew the target's score.
+func badVoteValues(target string, votes map[string]int) []string {
+	var problems []string
+	for userID, value := range votes {
+		if value != 1 && value != -1 {
+			problems = append(problems, fmt.Sprintf("%s: vote by %s has value %d", target, userID, value))
+		}
+	}
+	return problems
+}
+
+// RepairCounters raises any ID sequence that has fallen behind the highest
+// ID in use, so the next insert cannot reuse an existing ID. It returns one
+// line per counter it moved.
+func (s *Store) RepairCounters() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	highest := map[string]int{}
+	for userID := range s.users {
+		highest["user"] = max(highest["user"], exportSeq(userID))
+	}
+	for _, post := range s.posts {
+		highest["post"] = max(highest["post"], exportSeq(post.ID))
+	}
+	for _, comment := range s.comments {
+		highest["comment"] = max(highest["comment"], exportSeq(comment.ID))
+	}
+	for fileID := range s.files {
+		highest["file"] = max(highest["file"], exportSeq(fileID))
+	}
+	counters := map[string]*int{
+		"user":    &s.nextUserID,
+		"post":    &s.nextPostID,
+		"comment": &s.nextComment,
+		"file":    &s.nextFileID,
+	}
+
+	repaired := []string{}
+	for _, counter := range integrityCounters {
+		current := counters[counter.name]
+		if *current < highest[counter.name] {
+			repaired = append(repaired, counterRepair(counter.name, *current, highest[counter.name]))
+			*current = highest[counter.name]
+		}
+	}
+	return repaired, nil
+}
+
+func counterRepair(name string, from, to int) string {
+	return fmt.Sprintf("counter %s: raised from %d to %d", name, from, to)
+}
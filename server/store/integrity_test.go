@@ -0,0 +1,72 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	file := s.SaveFile("u_1", "a.png", "k", "p", 0, 0)
+	post := s.CreatePost(boardID, "u_1", "t", "c", "", "", nil, []string{file.ID, "f_missing"})
+	comment := s.CreateComment(post.ID, "u_1", "c", "", "", nil, nil)
+	if _, _, err := s.VotePost(post.ID, "u_2", 1); err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+
+	problems, err := s.VerifyIntegrity()
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	want := []string{"post " + post.ID + ": attachment f_missing does not exist"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Fatalf("got %q, want %q", problems, want)
+	}
+
+	s.mu.Lock()
+	s.comments = append(s.comments, Comment{ID: "c_99", PostID: "p_gone", ParentID: comment.ID})
+	s.postVotes["p_gone"] = map[string]int{"u_2": 1}
+	s.commentVotes[comment.ID] = map[string]int{"u_2": 3}
+	s.mu.Unlock()
+
+	problems, err = s.VerifyIntegrity()
+	if err != nil {
+		t.Fatalf("VerifyIntegrity: %v", err)
+	}
+	want = []string{
+		"comment " + comment.ID + ": vote by u_2 has value 3",
+		"comment c_99: parent " + comment.ID + " does not exist in post p_gone",
+		"comment c_99: post p_gone does not exist",
+		"post " + post.ID + ": attachment f_missing does not exist",
+		"post p_gone: 1 votes on a missing post",
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Fatalf("got %q, want %q", problems, want)
+	}
+}
+
+func TestRepairCounters(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	s.CreatePost(boardID, "u_1", "t", "c", "", "", nil, nil)
+	s.CreatePost(boardID, "u_1", "t", "c", "", "", nil, nil)
+
+	s.mu.Lock()
+	s.nextPostID = 0
+	s.mu.Unlock()
+
+	repaired, err := s.RepairCounters()
+	if err != nil {
+		t.Fatalf("RepairCounters: %v", err)
+	}
+	if want := []string{"counter post: raised from 0 to 2"}; !reflect.DeepEqual(repaired, want) {
+		t.Fatalf("got %q, want %q", repaired, want)
+	}
+	if post := s.CreatePost(boardID, "u_1", "t", "c", "", "", nil, nil); post.ID != "p_3" {
+		t.Fatalf("next post ID = %s, want p_3", post.ID)
+	}
+	if repaired, _ := s.RepairCounters(); len(repaired) != 0 {
+		t.Fatalf("second repair changed %q", repaired)
+	}
+}
@@ -1,10 +1,49 @@
 package store
 
+import "time"
+
+// DailyCheckInExp is the exp granted by the first check-in of each UTC day.
+const DailyCheckInExp = 10
+
+// Reasons accepted by AwardExp.
+const (
+	ExpReasonPost           = "post"
+	ExpReasonComment        = "comment"
+	ExpReasonUpvoteReceived = "upvote_received"
+)
+
+// expRewards maps an AwardExp reason to the exp it grants.
+var expRewards = map[string]int{
+	ExpReasonPost:           5,
+	ExpReasonComment:        2,
+	ExpReasonUpvoteReceived: 1,
+}
+
+// ExpForReason returns the exp granted for reason and whether the reason is known.
+func ExpForReason(reason string) (int, bool) {
+	amount, ok := expRewards[reason]
+	return amount, ok
+}
+
 type LevelInfo struct {
 	Level int
 	Title string
 }
 
+// ExpAward describes the outcome of AwardExp.
+type ExpAward struct {
+	Reason string
+	Delta  int
+	Exp    int
+	Before LevelInfo
+	After  LevelInfo
+}
+
+// LeveledUp reports whether the award moved the user across a level threshold.
+func (a ExpAward) LeveledUp() bool {
+	return a.After.Level > a.Before.Level
+}
+
 func LevelForExp(exp int) LevelInfo {
 	if exp < 0 {
 		exp = 0
@@ -20,3 +59,27 @@ func LevelForExp(exp int) LevelInfo {
 	}
 	return LevelInfo{Level: 4, Title: "大佬"}
 }
+
+// awardExp looks up the amount for reason and applies it through addExp.
+func awardExp(addExp func(userID string, delta int) (int, error), userID, reason string) (ExpAward, error) {
+	amount, ok := ExpForReason(reason)
+	if !ok {
+		return ExpAward{}, ErrInvalidInput
+	}
+	exp, err := addExp(userID, amount)
+	if err != nil {
+		return ExpAward{}, err
+	}
+	return ExpAward{
+		Reason: reason,
+		Delta:  amount,
+		Exp:    exp,
+		Before: LevelForExp(exp - amount),
+		After:  LevelForExp(exp),
+	}, nil
+}
+
+// checkInDate returns today's date in UTC, the granularity of daily check-ins.
+func checkInDate() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
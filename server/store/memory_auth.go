@@ -2,6 +2,7 @@ package store
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -74,18 +75,18 @@ func (s *Store) Register(account, password, nickname string) (RegisterResult, er
 	}, nil
 }
 
-func (s *Store) Login(account, password string) (string, User, error) {
+func (s *Store) Login(account, password, userAgent string) (Session, User, error) {
 	normalizedAccount := normalizeEmail(account)
 	trimmedPassword := strings.TrimSpace(password)
 	if normalizedAccount == "" || trimmedPassword == "" {
-		return "", User{}, ErrInvalidInput
+		return Session{}, User{}, ErrInvalidInput
 	}
 
 	s.mu.Lock()
 	userID, ok := s.accounts[normalizedAccount]
 	if !ok {
 		s.mu.Unlock()
-		return "", User{}, ErrInvalidCredentials
+		return Session{}, User{}, ErrInvalidCredentials
 	}
 	passwordHash := s.passwords[normalizedAccount]
 	user := s.users[userID]
@@ -93,27 +94,120 @@ func (s *Store) Login(account, password string) (string, User, error) {
 	s.mu.Unlock()
 
 	if !verifyPassword(passwordHash, trimmedPassword) {
-		return "", User{}, ErrInvalidCredentials
+		return Session{}, User{}, ErrInvalidCredentials
 	}
 	if hasVerification && verification.VerifiedAt == "" {
-		return "", User{}, ErrAccountUnverified
+		return Session{}, User{}, ErrAccountUnverified
 	}
+	rehashed := rehashPassword(passwordHash, trimmedPassword)
 
 	token, err := newToken()
 	if err != nil {
-		return "", User{}, err
+		return Session{}, User{}, err
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if old := s.userTokens[userID]; old != "" {
-		delete(s.tokens, old)
+	// Skip the upgrade if the password changed while we were hashing.
+	if rehashed != "" && s.passwords[normalizedAccount] == passwordHash {
+		s.passwords[normalizedAccount] = rehashed
 	}
-	s.tokens[token] = userID
-	s.userTokens[userID] = token
 
-	return token, user, nil
+	for existing, session := range s.sessions {
+		if session.UserID == userID && sessionExpired(session.ExpiresAt) {
+			delete(s.sessions, existing)
+		}
+	}
+	s.nextSessionID++
+	created := now()
+	session := &Session{
+		ID:        fmt.Sprintf("s_%d", s.nextSessionID),
+		UserID:    userID,
+		Token:     token,
+		UserAgent: normalizeUserAgent(userAgent),
+		CreatedAt: created,
+		LastSeen:  created,
+		ExpiresAt: sessionTokenExpiry().Format(time.RFC3339),
+	}
+	s.sessions[token] = session
+
+	return *session, user, nil
+}
+
+// RefreshSession extends a still-valid token's expiry, or gives the session a
+// fresh token when rotate is set. The session keeps its ID either way.
+func (s *Store) RefreshSession(token string, rotate bool) (Session, error) {
+	trimmedToken := strings.TrimSpace(token)
+	if trimmedToken == "" {
+		return Session{}, ErrInvalidToken
+	}
+
+	var replacement string
+	if rotate {
+		var err error
+		if replacement, err = newToken(); err != nil {
+			return Session{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[trimmedToken]
+	if !ok {
+		return Session{}, ErrInvalidToken
+	}
+	if sessionExpired(session.ExpiresAt) {
+		delete(s.sessions, trimmedToken)
+		return Session{}, ErrInvalidToken
+	}
+
+	session.LastSeen = now()
+	session.ExpiresAt = sessionTokenExpiry().Format(time.RFC3339)
+	if rotate {
+		delete(s.sessions, trimmedToken)
+		session.Token = replacement
+		s.sessions[replacement] = session
+	}
+	return *session, nil
+}
+
+// Sessions lists userID's unexpired sessions, most recently used first.
+func (s *Store) Sessions(userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := []Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && !sessionExpired(session.ExpiresAt) {
+			sessions = append(sessions, *session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].LastSeen != sessions[j].LastSeen {
+			return sessions[i].LastSeen > sessions[j].LastSeen
+		}
+		return sessions[i].CreatedAt > sessions[j].CreatedAt
+	})
+	return sessions, nil
+}
+
+// RevokeSession deletes one of userID's sessions. Sessions of other users
+// report ErrNotFound.
+func (s *Store) RevokeSession(userID, sessionID string) error {
+	trimmedID := strings.TrimSpace(sessionID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, session := range s.sessions {
+		if session.ID == trimmedID && session.UserID == userID {
+			delete(s.sessions, token)
+			return nil
+		}
+	}
+	return ErrNotFound
 }
 
 func (s *Store) VerifyEmail(token string) error {
@@ -140,7 +234,9 @@ func (s *Store) VerifyEmail(token string) error {
 		if nowTime.After(verification.ExpiresAt) {
 			return ErrVerificationTokenExpired
 		}
+		// The link is single-use: forget its hash so a replay cannot match.
 		verification.VerifiedAt = now()
+		verification.TokenHash = ""
 		s.accountVerification[account] = verification
 		return nil
 	}
@@ -179,6 +275,30 @@ func (s *Store) ResendVerification(account string) (string, error) {
 	return verificationToken, nil
 }
 
+// CheckPassword reports ErrInvalidCredentials unless password matches the
+// account that owns userID.
+func (s *Store) CheckPassword(userID, password string) error {
+	trimmedPassword := strings.TrimSpace(password)
+	if trimmedPassword == "" {
+		return ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	passwordHash := ""
+	for account, id := range s.accounts {
+		if id == userID {
+			passwordHash = s.passwords[account]
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if !verifyPassword(passwordHash, trimmedPassword) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
 func (s *Store) DeactivateAccount(userID string) error {
 	trimmedID := strings.TrimSpace(userID)
 	if trimmedID == "" {
@@ -205,12 +325,14 @@ func (s *Store) DeactivateAccount(userID string) error {
 		delete(s.passwords, accountKey)
 		delete(s.accountVerification, accountKey)
 	}
-	if token := s.userTokens[trimmedID]; token != "" {
-		delete(s.tokens, token)
+	delete(s.emailChanges, trimmedID)
+	for token, session := range s.sessions {
+		if session.UserID == trimmedID {
+			delete(s.sessions, token)
+		}
 	}
-	delete(s.userTokens, trimmedID)
 
-	user.Nickname = "已注销用户"
+	user.Nickname = DeletedUserNickname
 	user.Avatar = ""
 	user.Cover = ""
 	user.Bio = ""
@@ -223,6 +345,56 @@ func (s *Store) DeactivateAccount(userID string) error {
 		}
 		delete(followees, trimmedID)
 	}
+	delete(s.boardSubs, trimmedID)
+	delete(s.blocks, trimmedID)
+	delete(s.admins, trimmedID)
+	for _, blocked := range s.blocks {
+		delete(blocked, trimmedID)
+	}
+
+	return nil
+}
+
+// SetAdmin grants or revokes the admin role.
+func (s *Store) SetAdmin(userID string, admin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	if _, ok := s.users[userID]; !ok {
+		return ErrNotFound
+	}
+	if admin {
+		s.admins[userID] = true
+	} else {
+		delete(s.admins, userID)
+	}
 	return nil
 }
+
+func (s *Store) IsAdmin(userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.admins[userID]
+}
+
+// SeedAdmins grants the admin role to the given account emails, but only while
+// no admin exists yet. It returns how many users were promoted.
+func (s *Store) SeedAdmins(accounts []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.admins) > 0 {
+		return 0, nil
+	}
+	promoted := 0
+	for _, account := range accounts {
+		userID, ok := s.accounts[normalizeEmail(account)]
+		if !ok || s.admins[userID] {
+			continue
+		}
+		s.admins[userID] = true
+		promoted++
+	}
+	return promoted, nil
+}
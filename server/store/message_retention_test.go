@@ -0,0 +1,73 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneMessagesKeepsNewestAndClampsReads(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "prune.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+	memory := NewStore()
+
+	readCursor := map[string]func(roomID string) int64{
+		"memory": func(roomID string) int64 {
+			memory.mu.Lock()
+			defer memory.mu.Unlock()
+			return memory.chatReads["u_b"][roomID]
+		},
+		"sqlite": func(roomID string) int64 {
+			var seq int64
+			if err := sqlite.db.QueryRow(`SELECT last_read_seq FROM chat_reads WHERE user_id = 'u_b' AND room_id = ?;`, roomID).Scan(&seq); err != nil {
+				t.Fatalf("read cursor: %v", err)
+			}
+			return seq
+		},
+	}
+	for name, s := range map[string]API{"memory": memory, "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			roomID, err := s.OpenDMRoom("u_a", "u_b")
+			if err != nil {
+				t.Fatalf("OpenDMRoom: %v", err)
+			}
+			var sent []ChatMessage
+			for _, content := range []string{"one", "two", "three", "four", "five"} {
+				sent = append(sent, s.AddMessage(roomID, "u_a", content))
+			}
+			if _, err := s.DeleteMessage(sent[3].ID, "u_a"); err != nil {
+				t.Fatalf("DeleteMessage: %v", err)
+			}
+			if err := s.MarkRoomRead("u_b", roomID, sent[0].ID); err != nil {
+				t.Fatalf("MarkRoomRead: %v", err)
+			}
+
+			if _, err := s.PruneMessages(roomID, 0); err != ErrInvalidInput {
+				t.Fatalf("PruneMessages(0) = %v, want ErrInvalidInput", err)
+			}
+			if rooms := s.MessageRooms(); len(rooms) != 1 || rooms[0] != roomID {
+				t.Fatalf("MessageRooms = %v, want [%s]", rooms, roomID)
+			}
+			// Keeping the two newest live messages drops "one" and "two"; the
+			// deleted "four" sits between the survivors and stays.
+			if pruned, err := s.PruneMessages(roomID, 2); err != nil || pruned != 2 {
+				t.Fatalf("PruneMessages = %d, %v; want 2", pruned, err)
+			}
+			history := s.Messages(roomID, 0)
+			if len(history) != 2 || history[0].ID != sent[2].ID || history[1].ID != sent[4].ID {
+				t.Fatalf("history after prune = %v", history)
+			}
+			if want, _ := messageSeq(sent[1].ID); readCursor[name](roomID) != want {
+				t.Fatalf("read cursor = %d, want clamped to %d", readCursor[name](roomID), want)
+			}
+			if unread := s.UnreadMessageCount("u_b"); unread != 2 {
+				t.Fatalf("unread after prune = %d, want 2", unread)
+			}
+			if pruned, err := s.PruneMessages(roomID, 2); err != nil || pruned != 0 {
+				t.Fatalf("second prune = %d, %v; want 0", pruned, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MessageEditWindow is how long after sending a chat message its sender may
+// still edit it. Deleting is not time-limited.
+const MessageEditWindow = 15 * time.Minute
+
+// editWindowPassed reports whether a message sent at createdAt can no longer
+// be edited. Unparseable timestamps count as expired.
+func editWindowPassed(createdAt string) bool {
+	parsed, err := time.Parse(time.RFC3339, createdAt)
+	return err != nil || time.Since(parsed) > MessageEditWindow
+}
+
+// liveMessages returns messages without the deleted ones.
+func liveMessages(messages []ChatMessage) []ChatMessage {
+	out := make([]ChatMessage, 0, len(messages))
+	for _, message := range messages {
+		if message.DeletedAt == "" {
+			out = append(out, message)
+		}
+	}
+	return out
+}
+
+// findMessage locates a message by ID across all rooms. Callers hold s.mu.
+func (s *Store) findMessage(messageID string) (string, int, bool) {
+	for roomID, messages := range s.messages {
+		for i, message := range messages {
+			if message.ID == messageID {
+				return roomID, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// EditMessage replaces the content of senderID's message, as long as it is
+// still within MessageEditWindow.
+func (s *Store) EditMessage(messageID, senderID, content string) (ChatMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ChatMessage{}, ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roomID, i, ok := s.findMessage(messageID)
+	if !ok || s.messages[roomID][i].DeletedAt != "" {
+		return ChatMessage{}, ErrNotFound
+	}
+	message := s.messages[roomID][i]
+	if message.SenderID != senderID {
+		return ChatMessage{}, ErrForbidden
+	}
+	if editWindowPassed(message.CreatedAt) {
+		return ChatMessage{}, ErrEditWindowExpired
+	}
+	message.Content = content
+	message.EditedAt = now()
+	s.messages[roomID][i] = message
+	return message, nil
+}
+
+// DeleteMessage soft-deletes senderID's message and returns it with DeletedAt
+// set.
+func (s *Store) DeleteMessage(messageID, senderID string) (ChatMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roomID, i, ok := s.findMessage(messageID)
+	if !ok || s.messages[roomID][i].DeletedAt != "" {
+		return ChatMessage{}, ErrNotFound
+	}
+	message := s.messages[roomID][i]
+	if message.SenderID != senderID {
+		return ChatMessage{}, ErrForbidden
+	}
+	message.DeletedAt = now()
+	s.messages[roomID][i] = message
+	return message, nil
+}
+
+// MessageRooms lists every room that has stored messages, deleted ones
+// included.
+func (s *Store) MessageRooms() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms := make([]string, 0, len(s.messages))
+	for roomID, messages := range s.messages {
+		if len(messages) > 0 {
+			rooms = append(rooms, roomID)
+		}
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// PruneMessages permanently removes roomID's history older than its newest
+// keepLast live messages, deleted messages in that range included, and
+// returns how many it removed. Read cursors left behind the pruned range are
+// moved up to its last message so they never point below the history.
+func (s *Store) PruneMessages(roomID string, keepLast int) (int, error) {
+	if keepLast < 1 {
+		return 0, ErrInvalidInput
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.messages[roomID]
+	cut, live := 0, 0
+	for i := len(messages) - 1; i >= 0 && live < keepLast; i-- {
+		if messages[i].DeletedAt == "" {
+			live++
+			cut = i
+		}
+	}
+	if live < keepLast || cut == 0 {
+		return 0, nil
+	}
+
+	boundary, _ := messageSeq(messages[cut-1].ID)
+	s.messages[roomID] = append([]ChatMessage(nil), messages[cut:]...)
+	for _, reads := range s.chatReads {
+		if last, ok := reads[roomID]; ok && last < boundary {
+			reads[roomID] = boundary
+		}
+	}
+	return cut, nil
+}
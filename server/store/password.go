@@ -0,0 +1,206 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms selectable with PASSWORD_HASH.
+const (
+	PasswordHashBcrypt = "bcrypt"
+	PasswordHashArgon2 = "argon2"
+)
+
+// argon2id parameters (OWASP's 19 MiB, two passes, one lane profile). They
+// are written into every hash, so changing them only affects new hashes and
+// marks older ones for rehashing on login.
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+	argon2Prefix  = "$argon2id$"
+)
+
+// PasswordPolicy chooses how new password hashes are made. Existing hashes of
+// either algorithm keep verifying whatever the policy.
+type PasswordPolicy struct {
+	Algorithm  string // PasswordHashBcrypt or PasswordHashArgon2
+	BcryptCost int    // bcrypt only
+}
+
+var (
+	passwordPolicyMu sync.RWMutex
+	passwordPolicy   = PasswordPolicy{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.DefaultCost}
+)
+
+// PasswordPolicyFromEnv reads PASSWORD_HASH (bcrypt or argon2, default bcrypt)
+// and PASSWORD_COST (bcrypt cost, default 10).
+func PasswordPolicyFromEnv() (PasswordPolicy, error) {
+	policy := PasswordPolicy{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.DefaultCost}
+	if algorithm := strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASH"))); algorithm != "" {
+		policy.Algorithm = algorithm
+	}
+	if raw := strings.TrimSpace(os.Getenv("PASSWORD_COST")); raw != "" {
+		cost, err := strconv.Atoi(raw)
+		if err != nil {
+			return PasswordPolicy{}, fmt.Errorf("invalid PASSWORD_COST: %w", err)
+		}
+		policy.BcryptCost = cost
+	}
+	if err := policy.validate(); err != nil {
+		return PasswordPolicy{}, err
+	}
+	return policy, nil
+}
+
+func (p PasswordPolicy) validate() error {
+	switch p.Algorithm {
+	case PasswordHashBcrypt, PasswordHashArgon2:
+	default:
+		return fmt.Errorf("unsupported PASSWORD_HASH %q (want bcrypt or argon2)", p.Algorithm)
+	}
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("PASSWORD_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+// SetPasswordPolicy replaces the policy used for new hashes. Call it once at
+// startup, before serving requests.
+func SetPasswordPolicy(policy PasswordPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = policy
+	return nil
+}
+
+func currentPasswordPolicy() PasswordPolicy {
+	passwordPolicyMu.RLock()
+	defer passwordPolicyMu.RUnlock()
+	return passwordPolicy
+}
+
+// hashPassword hashes with the current policy. Hashes are self-describing:
+// bcrypt's "$2a$" form, or the PHC string "$argon2id$v=19$m=..,t=..,p=..$salt$key".
+func hashPassword(password string) (string, error) {
+	policy := currentPasswordPolicy()
+	if policy.Algorithm == PasswordHashArgon2 {
+		return hashArgon2(password)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), policy.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// verifyPassword checks password against a hash of either algorithm.
+func verifyPassword(passwordHash string, password string) bool {
+	if passwordHash == "" || password == "" {
+		return false
+	}
+	if strings.HasPrefix(passwordHash, argon2Prefix) {
+		params, salt, key, ok := parseArgon2(passwordHash)
+		if !ok {
+			return false
+		}
+		got := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(got, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+// passwordNeedsRehash reports whether a hash that just verified was made with
+// another algorithm or other parameters than the current policy asks for.
+func passwordNeedsRehash(passwordHash string) bool {
+	policy := currentPasswordPolicy()
+	if strings.HasPrefix(passwordHash, argon2Prefix) {
+		if policy.Algorithm != PasswordHashArgon2 {
+			return true
+		}
+		params, _, key, ok := parseArgon2(passwordHash)
+		return !ok || params != defaultArgon2Params() || len(key) != argon2KeyLen
+	}
+	if policy.Algorithm != PasswordHashBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(passwordHash))
+	return err != nil || cost != policy.BcryptCost
+}
+
+// rehashPassword returns a hash of password under the current policy when
+// passwordHash is outdated, or "" when it can stay as it is.
+func rehashPassword(passwordHash, password string) string {
+	if !passwordNeedsRehash(passwordHash) {
+		return ""
+	}
+	rehashed, err := hashPassword(password)
+	if err != nil {
+		return ""
+	}
+	return rehashed
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func defaultArgon2Params() argon2Params {
+	return argon2Params{memory: argon2Memory, time: argon2Time, threads: argon2Threads}
+}
+
+func hashArgon2(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	params := defaultArgon2Params()
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func parseArgon2(passwordHash string) (argon2Params, []byte, []byte, bool) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(passwordHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2Params{}, nil, nil, false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, false
+	}
+	var params argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil ||
+		params.memory == 0 || params.time == 0 || params.threads == 0 {
+		return argon2Params{}, nil, nil, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Params{}, nil, nil, false
+	}
+	return params, salt, key, true
+}
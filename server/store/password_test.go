@@ -0,0 +1,80 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func usePasswordPolicy(t *testing.T, policy PasswordPolicy) {
+	t.Helper()
+	previous := currentPasswordPolicy()
+	if err := SetPasswordPolicy(policy); err != nil {
+		t.Fatalf("SetPasswordPolicy: %v", err)
+	}
+	t.Cleanup(func() { _ = SetPasswordPolicy(previous) })
+}
+
+func TestVerifyPasswordAcrossAlgorithms(t *testing.T) {
+	usePasswordPolicy(t, PasswordPolicy{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost})
+	bcryptHash, err := hashPassword("password123")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+
+	usePasswordPolicy(t, PasswordPolicy{Algorithm: PasswordHashArgon2, BcryptCost: bcrypt.MinCost})
+	argonHash, err := hashPassword("password123")
+	if err != nil {
+		t.Fatalf("hashPassword: %v", err)
+	}
+	if !strings.HasPrefix(argonHash, "$argon2id$v=19$m=") {
+		t.Fatalf("argon2 hash = %q, want PHC format", argonHash)
+	}
+
+	for _, hash := range []string{bcryptHash, argonHash} {
+		if !verifyPassword(hash, "password123") {
+			t.Fatalf("verifyPassword(%.12s...) rejected the right password", hash)
+		}
+		if verifyPassword(hash, "password124") {
+			t.Fatalf("verifyPassword(%.12s...) accepted a wrong password", hash)
+		}
+	}
+	if !passwordNeedsRehash(bcryptHash) || passwordNeedsRehash(argonHash) {
+		t.Fatalf("under argon2 only the bcrypt hash should need a rehash")
+	}
+
+	usePasswordPolicy(t, PasswordPolicy{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	if !passwordNeedsRehash(bcryptHash) || !passwordNeedsRehash(argonHash) {
+		t.Fatalf("a cost change and an algorithm change should both need a rehash")
+	}
+	if verifyPassword("$argon2id$v=19$m=0,t=0,p=0$AAAA$AAAA", "password123") {
+		t.Fatalf("malformed argon2 hash verified")
+	}
+}
+
+func TestLoginUpgradesOutdatedHash(t *testing.T) {
+	usePasswordPolicy(t, PasswordPolicy{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost})
+	s := NewStore()
+	reg, err := s.Register("alice@example.com", "password123", "alice")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+
+	usePasswordPolicy(t, PasswordPolicy{Algorithm: PasswordHashArgon2, BcryptCost: bcrypt.MinCost})
+	if _, _, err := s.Login("alice@example.com", "password123", ""); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if hash := s.passwords["alice@example.com"]; !strings.HasPrefix(hash, argon2Prefix) {
+		t.Fatalf("stored hash = %.12s..., want argon2id after login", hash)
+	}
+	if _, _, err := s.Login("alice@example.com", "password123", ""); err != nil {
+		t.Fatalf("Login with upgraded hash: %v", err)
+	}
+	if _, _, err := s.Login("alice@example.com", "wrongpass1", ""); err != ErrInvalidCredentials {
+		t.Fatalf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+}
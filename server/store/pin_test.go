@@ -0,0 +1,46 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPinnedPostsLeadBoardFeed(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	ids := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		post := s.CreatePost(boardID, "u_author", fmt.Sprintf("post %d", i), "content", "", "", nil, nil)
+		ids = append(ids, post.ID)
+	}
+
+	if err := s.SetPostPinned(ids[1], true, false); err != ErrForbidden {
+		t.Fatalf("non-admin pin: got %v, want ErrForbidden", err)
+	}
+	if err := s.SetPostPinned(ids[1], true, true); err != nil {
+		t.Fatalf("SetPostPinned: %v", err)
+	}
+
+	board, _, _ := s.Posts(PostListFilter{BoardIDs: []string{boardID}})
+	if board[0].ID != ids[1] || !board[0].Pinned {
+		t.Fatalf("board feed starts with %s, want pinned %s", board[0].ID, ids[1])
+	}
+	if all, _, _ := s.Posts(PostListFilter{}); all[0].ID == ids[1] {
+		t.Fatalf("global feed should ignore pins, got %s first", all[0].ID)
+	}
+
+	// Walk the board feed one post per page: pinned first, then the rest
+	// newest first, with nothing repeated or skipped.
+	want := []string{ids[1], ids[3], ids[2], ids[0]}
+	cursor := ""
+	for i, id := range want {
+		page, next := s.PostsAfterCursor(boardID, cursor, 1)
+		if len(page) != 1 || page[0].ID != id {
+			t.Fatalf("page %d = %v, want %s", i, page, id)
+		}
+		cursor = next
+	}
+	if cursor != "" {
+		t.Fatalf("cursor after last page = %q, want empty", cursor)
+	}
+}
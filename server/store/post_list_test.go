@@ -0,0 +1,70 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPostsFiltersAndPages(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "posts.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			viewer, err := s.Register("viewer@example.com", "password123", "viewer")
+			if err != nil {
+				t.Fatalf("Register viewer: %v", err)
+			}
+			author, err := s.Register("author@example.com", "password123", "author")
+			if err != nil {
+				t.Fatalf("Register author: %v", err)
+			}
+			blocked, err := s.Register("blocked@example.com", "password123", "blocked")
+			if err != nil {
+				t.Fatalf("Register blocked: %v", err)
+			}
+			if err := s.BlockUser(viewer.User.ID, blocked.User.ID); err != nil {
+				t.Fatalf("BlockUser: %v", err)
+			}
+			boards := s.Boards()
+			for i := 0; i < 3; i++ {
+				s.CreatePost(boards[0].ID, author.User.ID, "mine", "c", "", "", nil, nil)
+			}
+			s.CreatePost(boards[1].ID, blocked.User.ID, "hidden", "c", "", "", nil, nil)
+			s.CreatePost(boards[2].ID, author.User.ID, "elsewhere", "c", "", "", nil, nil)
+
+			filter := PostListFilter{
+				BoardIDs: []string{boards[0].ID, boards[1].ID},
+				ViewerID: viewer.User.ID,
+				Limit:    2,
+			}
+			seen := map[string]bool{}
+			for _, offset := range []int{0, 2} {
+				filter.Offset = offset
+				page, total, err := s.Posts(filter)
+				if err != nil {
+					t.Fatalf("Posts: %v", err)
+				}
+				if total != 3 {
+					t.Fatalf("offset %d: total = %d, want 3", offset, total)
+				}
+				for _, post := range page {
+					if post.AuthorID != author.User.ID || post.BoardID != boards[0].ID || seen[post.ID] {
+						t.Fatalf("offset %d: unexpected post %+v", offset, post)
+					}
+					seen[post.ID] = true
+				}
+			}
+			if len(seen) != 3 {
+				t.Fatalf("pages returned %d posts, want 3", len(seen))
+			}
+
+			if page, total, err := s.Posts(PostListFilter{AuthorID: blocked.User.ID}); err != nil || total != 1 || len(page) != 1 {
+				t.Fatalf("author filter = %d posts of %d (%v), want 1", len(page), total, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// benchPageSize matches the default feed page size.
+const benchPageSize = 20
+
+// seedFeed creates a page of posts on a SQLite store, each with a few votes
+// and comments, and returns the store and the post IDs.
+func seedFeed(b *testing.B) (*SQLiteStore, []string) {
+	b.Helper()
+
+	s, err := OpenSQLite(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("OpenSQLite: %v", err)
+	}
+	b.Cleanup(func() { _ = s.Close() })
+
+	boardID := s.Boards()[0].ID
+	ids := make([]string, 0, benchPageSize)
+	for i := 0; i < benchPageSize; i++ {
+		post := s.CreatePost(boardID, "u_author", fmt.Sprintf("post %d", i), "content", "", "", nil, nil)
+		for v := 0; v < 5; v++ {
+			if _, _, err := s.VotePost(post.ID, fmt.Sprintf("u_voter_%d", v), 1); err != nil {
+				b.Fatalf("VotePost: %v", err)
+			}
+			s.CreateComment(post.ID, "u_author", "reply", "", "", nil, nil)
+		}
+		ids = append(ids, post.ID)
+	}
+	return s, ids
+}
+
+// BenchmarkPostStatsPerPost is the old feed path: three queries per post.
+func BenchmarkPostStatsPerPost(b *testing.B) {
+	s, ids := seedFeed(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			_ = s.PostScore(id)
+			_ = s.CommentCount(id)
+			_ = s.PostVote(id, "u_voter_0")
+		}
+	}
+}
+
+// BenchmarkPostStatsBatch is the current feed path: three queries per page.
+func BenchmarkPostStatsBatch(b *testing.B) {
+	s, ids := seedFeed(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.PostScores(ids)
+		_ = s.CommentCounts(ids)
+		_ = s.PostVotes(ids, "u_voter_0")
+	}
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPostVoteTimeFollowsTheVote(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "votes.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			post := s.CreatePost(s.Boards()[0].ID, "u_author", "t", "c", "", "", nil, nil)
+			if got := s.PostVoteTime(post.ID, "u_voter"); got != "" {
+				t.Fatalf("before voting = %q, want empty", got)
+			}
+			if _, _, err := s.SetPostVote(post.ID, "u_voter", 1); err != nil {
+				t.Fatalf("SetPostVote: %v", err)
+			}
+			if got := s.PostVoteTime(post.ID, "u_voter"); got == "" {
+				t.Fatal("vote time missing after voting")
+			}
+			if _, _, err := s.SetPostVote(post.ID, "u_voter", 0); err != nil {
+				t.Fatalf("SetPostVote: %v", err)
+			}
+			if got := s.PostVoteTime(post.ID, "u_voter"); got != "" {
+				t.Fatalf("after clearing = %q, want empty", got)
+			}
+		})
+	}
+}
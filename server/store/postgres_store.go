@@ -0,0 +1,48 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// PostgresStore is a PostgreSQL-backed implementation of API for deployments
+// beyond the single-file SQLite demo.
+//
+// It shares queries and schema with SQLiteStore; placeholders are rebound to
+// Postgres' $N style and counters are incremented with an atomic upsert.
+type PostgresStore struct {
+	sqlStore
+}
+
+// OpenPostgres connects to PostgreSQL using a pgx DSN/URL and runs migrations.
+func OpenPostgres(dsn string) (*PostgresStore, error) {
+	dsn = strings.TrimSpace(dsn)
+	if dsn == "" {
+		return nil, errors.New("postgres dsn is required")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s := &PostgresStore{sqlStore{db: &sqlDB{DB: db, dialect: dialectPostgres}}}
+	if err := s.migrate(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err := s.seedBoards(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+var _ API = (*PostgresStore)(nil)
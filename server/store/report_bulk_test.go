@@ -0,0 +1,44 @@
+package store
+
+import "testing"
+
+func TestUpdateReportsSkipsMissingAndTerminal(t *testing.T) {
+	s := NewStore()
+	post := s.CreatePost(s.Boards()[0].ID, "u_author", "title", "content", "", "", nil, nil)
+	ids := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		report, err := s.CreateReport("u_reporter", "post", post.ID, "spam", "")
+		if err != nil {
+			t.Fatalf("CreateReport: %v", err)
+		}
+		ids = append(ids, report.ID)
+	}
+	if _, err := s.UpdateReport(ids[2], ReportStatusRejected, "", "", "u_admin"); err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+
+	if _, err := s.UpdateReports(ids, "closed", "", "", "u_admin"); err != ErrInvalidInput {
+		t.Fatalf("unknown status: got %v, want ErrInvalidInput", err)
+	}
+	updated, err := s.UpdateReports(append(ids, "r_missing", ids[0]), ReportStatusResolved, ReportActionRemove, "bulk", "u_admin")
+	if err != nil {
+		t.Fatalf("UpdateReports: %v", err)
+	}
+	if updated != 2 {
+		t.Fatalf("updated = %d, want 2", updated)
+	}
+
+	reports, _, err := s.Reports("", 1, 10)
+	if err != nil {
+		t.Fatalf("Reports: %v", err)
+	}
+	want := map[string]string{ids[0]: ReportStatusResolved, ids[1]: ReportStatusResolved, ids[2]: ReportStatusRejected}
+	for _, report := range reports {
+		if report.Status != want[report.ID] {
+			t.Fatalf("report %s status = %s, want %s", report.ID, report.Status, want[report.ID])
+		}
+	}
+	if _, ok := s.GetPost(post.ID); ok {
+		t.Fatalf("reported post should be removed by the bulk resolution")
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// ReportActionRemove, applied when a report is resolved, soft-deletes the target.
+	ReportActionRemove = "remove"
+
+	ReportStatusOpen      = "open"
+	ReportStatusReviewing = "reviewing"
+	ReportStatusResolved  = "resolved"
+	ReportStatusRejected  = "rejected"
+
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+	ReportTargetFile    = "file"
+
+	// NotificationContentRemoved tells an author a moderator removed their
+	// post or comment; the reason is on the post's tombstone.
+	NotificationContentRemoved = "content_removed"
+	// ModeratorRemovalReason is the deleted reason when an admin deletes
+	// someone else's post or comment directly rather than through a report.
+	ModeratorRemovalReason = "removed by a moderator"
+)
+
+// removalReason is the deleted reason for content removed over a report: the
+// report's reason, followed by the moderator's note when there is one.
+func removalReason(reportReason, note string) string {
+	reportReason, note = strings.TrimSpace(reportReason), strings.TrimSpace(note)
+	switch {
+	case reportReason == "":
+		return note
+	case note == "":
+		return reportReason
+	}
+	return reportReason + ": " + note
+}
+
+// reportTransitions lists the statuses reachable from each status. Resolved and
+// rejected are terminal; staying in the same status (e.g. to edit the note) is
+// always allowed.
+var reportTransitions = map[string][]string{
+	ReportStatusOpen:      {ReportStatusReviewing, ReportStatusResolved, ReportStatusRejected},
+	ReportStatusReviewing: {ReportStatusOpen, ReportStatusResolved, ReportStatusRejected},
+	ReportStatusResolved:  nil,
+	ReportStatusRejected:  nil,
+}
+
+// ReportEvent is one entry in a report's status history.
+type ReportEvent struct {
+	ReportID   string
+	FromStatus string
+	ToStatus   string
+	Action     string
+	Note       string
+	HandledBy  string
+	CreatedAt  string
+}
+
+// ValidReportTarget reports whether targetType is one of the ReportTarget
+// constants accepted by CreateReport.
+func ValidReportTarget(targetType string) bool {
+	switch targetType {
+	case ReportTargetPost, ReportTargetComment, ReportTargetUser, ReportTargetFile:
+		return true
+	}
+	return false
+}
+
+// marshalReportSnapshot encodes the fields kept in Report.TargetSnapshot.
+func marshalReportSnapshot(fields map[string]string) string {
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+func validReportStatus(status string) bool {
+	_, ok := reportTransitions[status]
+	return ok
+}
+
+// checkReportTransition reports whether a report may move from one status to another.
+func checkReportTransition(from, to string) error {
+	if from == to {
+		return nil
+	}
+	for _, next := range reportTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return ErrInvalidTransition
+}
+
+// removesTarget reports whether an update moving a report from previous to
+// status with the given action should soft-delete the reported content.
+func removesTarget(previous, status, action string) bool {
+	return action == ReportActionRemove && status == ReportStatusResolved && previous != ReportStatusResolved
+}
+
+// annotateReportNote appends a system remark to the moderator's note.
+func annotateReportNote(note, remark string) string {
+	remark = "[" + remark + "]"
+	if note == "" {
+		return remark
+	}
+	return note + " " + remark
+}
+
+// Keys in the map returned by ReportStats. Per-status and per-reason counts use
+// the ReportStatsStatusPrefix and ReportStatsReasonPrefix prefixes.
+const (
+	ReportStatsTotal        = "total"
+	ReportStatsOpenStale    = "open_older_than_24h"
+	ReportStatsStatusPrefix = "status:"
+	ReportStatsReasonPrefix = "reason:"
+)
+
+// reportStaleAfter is how long an open report waits before it counts as backlog.
+const reportStaleAfter = 24 * time.Hour
+
+func reportStaleCutoff() string {
+	return time.Now().UTC().Add(-reportStaleAfter).Format(time.RFC3339)
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateReportRejectsUnknownTargetType(t *testing.T) {
+	s := NewStore()
+	if _, err := s.CreateReport("u_reporter", "chat", "m_1", "spam", ""); err != ErrInvalidInput {
+		t.Fatalf("unknown target_type: got %v, want ErrInvalidInput", err)
+	}
+	for _, targetType := range []string{ReportTargetPost, ReportTargetComment, ReportTargetUser, ReportTargetFile} {
+		if _, err := s.CreateReport("u_reporter", targetType, "x_1", "spam", ""); err != nil {
+			t.Fatalf("CreateReport(%s): %v", targetType, err)
+		}
+	}
+}
+
+func TestRemoveActionSoftDeletesReportedFile(t *testing.T) {
+	s := NewStore()
+	file := s.SaveFile("u_uploader", "abuse.png", "key", "/tmp/abuse.png", 10, 10)
+	report, err := s.CreateReport("u_reporter", ReportTargetFile, file.ID, "abuse", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+
+	updated, err := s.UpdateReport(report.ID, ReportStatusResolved, ReportActionRemove, "", "u_admin")
+	if err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+	if updated.Note != "" {
+		t.Fatalf("note = %q, want no skip remark", updated.Note)
+	}
+	if _, ok := s.GetFile(file.ID); ok {
+		t.Fatalf("reported file should be soft-deleted")
+	}
+}
+
+func TestCreateReportSnapshotsReportedUser(t *testing.T) {
+	s := NewStore()
+	reg, err := s.Register("spammer@example.com", "password123", "spammer")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	user := reg.User
+	if _, err := s.UpdateUser(user.ID, "spammer", "buy now", "", ""); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	report, err := s.CreateReport("u_reporter", ReportTargetUser, user.ID, "spam", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if _, err := s.UpdateUser(user.ID, "innocent", "", "", ""); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	reports, _, err := s.Reports("", 1, 10)
+	if err != nil || len(reports) != 1 || reports[0].ID != report.ID {
+		t.Fatalf("Reports = %v, %v", reports, err)
+	}
+	if want := `{"bio":"buy now","nickname":"spammer"}`; reports[0].TargetSnapshot != want {
+		t.Fatalf("snapshot = %s, want %s", reports[0].TargetSnapshot, want)
+	}
+
+	missing, err := s.CreateReport("u_reporter", ReportTargetPost, "p_missing", "spam", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if missing.TargetSnapshot != "" {
+		t.Fatalf("missing target snapshot = %q, want empty", missing.TargetSnapshot)
+	}
+}
+
+func TestRemoveActionRecordsReasonAndNotifiesAuthor(t *testing.T) {
+	s := NewStore()
+	post := s.CreatePost(s.Boards()[0].ID, "u_author", "t", "c", "", "", nil, nil)
+	report, err := s.CreateReport("u_reporter", ReportTargetPost, post.ID, "垃圾广告", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	if _, err := s.UpdateReport(report.ID, ReportStatusResolved, ReportActionRemove, "重复发布", "u_admin"); err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+
+	removed, ok := s.GetPostIncludingDeleted(post.ID)
+	if !ok || removed.DeletedAt == "" {
+		t.Fatalf("post should be soft-deleted")
+	}
+	if removed.DeletedReason != "垃圾广告: 重复发布" {
+		t.Fatalf("DeletedReason = %q", removed.DeletedReason)
+	}
+	items, total := s.Notifications("u_author", 0, 10)
+	if total != 1 || items[0].Type != NotificationContentRemoved || items[0].ActorID != "u_admin" || items[0].TargetID != post.ID {
+		t.Fatalf("notifications = %+v", items)
+	}
+}
+
+func TestSelfDeleteLeavesReasonBlank(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	own := s.CreatePost(boardID, "u_author", "t", "c", "", "", nil, nil)
+	other := s.CreatePost(boardID, "u_author", "t", "c", "", "", nil, nil)
+	if err := s.SoftDeletePost(own.ID, "u_author", false); err != nil {
+		t.Fatalf("SoftDeletePost: %v", err)
+	}
+	if err := s.SoftDeletePost(other.ID, "u_admin", true); err != nil {
+		t.Fatalf("SoftDeletePost as admin: %v", err)
+	}
+
+	if post, _ := s.GetPostIncludingDeleted(own.ID); post.DeletedReason != "" {
+		t.Fatalf("self-deleted post has reason %q", post.DeletedReason)
+	}
+	if post, _ := s.GetPostIncludingDeleted(other.ID); post.DeletedReason != ModeratorRemovalReason {
+		t.Fatalf("admin-deleted post has reason %q", post.DeletedReason)
+	}
+	if _, total := s.Notifications("u_author", 0, 10); total != 1 {
+		t.Fatalf("author got %d notifications, want 1", total)
+	}
+}
+
+func TestGetCommentIncludingDeletedKeepsRemovalReason(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "comments.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			post := s.CreatePost(s.Boards()[0].ID, "u_author", "t", "c", "", "", nil, nil)
+			comment := s.CreateComment(post.ID, "u_author", "reply", "", "", nil, nil)
+			if err := s.SoftDeleteComment(post.ID, comment.ID, "u_admin", true); err != nil {
+				t.Fatalf("SoftDeleteComment: %v", err)
+			}
+
+			if _, ok := s.GetCommentByID(comment.ID); ok {
+				t.Fatal("GetCommentByID should skip the deleted comment")
+			}
+			got, ok := s.GetCommentIncludingDeleted(comment.ID)
+			if !ok || got.DeletedAt == "" || got.DeletedReason != ModeratorRemovalReason {
+				t.Fatalf("GetCommentIncludingDeleted = %+v, %v; want deleted with reason %q", got, ok, ModeratorRemovalReason)
+			}
+		})
+	}
+}
+
+func TestRemoveActionRemarkSurvivesGuardedUpdate(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "reports.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	report, err := sqlite.CreateReport("u_reporter", ReportTargetPost, "p_missing", "spam", "")
+	if err != nil {
+		t.Fatalf("CreateReport: %v", err)
+	}
+	updated, err := sqlite.UpdateReport(report.ID, ReportStatusResolved, ReportActionRemove, "checked", "u_admin")
+	if err != nil {
+		t.Fatalf("UpdateReport: %v", err)
+	}
+	want := "checked [remove skipped: target not found or already deleted]"
+	if updated.Note != want {
+		t.Fatalf("note = %q, want %q", updated.Note, want)
+	}
+}
@@ -0,0 +1,37 @@
+package store
+
+import "testing"
+
+func TestSearchPostsResolvesAuthorAndCounters(t *testing.T) {
+	s := NewStore()
+	reg, err := s.Register("alice@example.com", "password123", "alice")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	boardID := s.Boards()[0].ID
+	post := s.CreatePost(boardID, reg.User.ID, "Hello campus", "content", "", "", nil, nil)
+	orphan := s.CreatePost(boardID, "u_gone", "hello again", "content", "", "", nil, nil)
+	if _, _, err := s.VotePost(post.ID, "u_voter", 1); err != nil {
+		t.Fatalf("VotePost: %v", err)
+	}
+	s.CreateComment(post.ID, "u_voter", "first", "", "", nil, nil)
+	deleted := s.CreateComment(post.ID, "u_voter", "second", "", "", nil, nil)
+	if err := s.SoftDeleteComment(post.ID, deleted.ID, "u_voter", false); err != nil {
+		t.Fatalf("SoftDeleteComment: %v", err)
+	}
+
+	results, total := s.SearchPosts("HELLO", 0, 10)
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("SearchPosts = %d results, total %d; want 2", len(results), total)
+	}
+	byID := map[string]PostSearchResult{}
+	for _, r := range results {
+		byID[r.ID] = r
+	}
+	if got := byID[post.ID]; got.Author.Nickname != "alice" || got.Score != 1 || got.CommentCount != 1 {
+		t.Fatalf("result = author %q, score %d, comments %d; want alice, 1, 1", got.Author.Nickname, got.Score, got.CommentCount)
+	}
+	if got := byID[orphan.ID]; got.Author != DeletedUser() {
+		t.Fatalf("orphan author = %+v, want the deleted placeholder", got.Author)
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/Versifine/Cumt-cumpus-hub/server/internal/metrics"
+)
+
+type sqlDialect int
+
+const (
+	dialectSQLite sqlDialect = iota
+	dialectPostgres
+)
+
+// sqlDB wraps *sql.DB so queries can be written once with '?' placeholders
+// and rebound to the driver's native style.
+type sqlDB struct {
+	*sql.DB
+	dialect sqlDialect
+}
+
+func (db *sqlDB) Exec(query string, args ...any) (sql.Result, error) {
+	res, err := db.DB.Exec(db.dialect.rebind(query), args...)
+	countDBError("exec", err)
+	return res, err
+}
+
+func (db *sqlDB) Query(query string, args ...any) (*sql.Rows, error) {
+	rows, err := db.DB.Query(db.dialect.rebind(query), args...)
+	countDBError("query", err)
+	return rows, err
+}
+
+func (db *sqlDB) QueryRow(query string, args ...any) *sql.Row {
+	return db.DB.QueryRow(db.dialect.rebind(query), args...)
+}
+
+func (db *sqlDB) Begin() (*sqlTx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		countDBError("begin", err)
+		return nil, err
+	}
+	return &sqlTx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// sqlTx is the transaction counterpart of sqlDB.
+type sqlTx struct {
+	*sql.Tx
+	dialect sqlDialect
+}
+
+func (tx *sqlTx) Exec(query string, args ...any) (sql.Result, error) {
+	res, err := tx.Tx.Exec(tx.dialect.rebind(query), args...)
+	countDBError("exec", err)
+	return res, err
+}
+
+func (tx *sqlTx) Query(query string, args ...any) (*sql.Rows, error) {
+	rows, err := tx.Tx.Query(tx.dialect.rebind(query), args...)
+	countDBError("query", err)
+	return rows, err
+}
+
+func (tx *sqlTx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.rebind(query), args...)
+}
+
+// countDBError records failed calls. QueryRow errors surface at Scan time and
+// are mostly sql.ErrNoRows, so they are not counted; neither are the expected
+// duplicate-column errors from idempotent migrations.
+func countDBError(op string, err error) {
+	if err != nil && !isSQLiteDuplicateColumnError(err) {
+		metrics.DBErrors.WithLabelValues(op).Inc()
+	}
+}
+
+// rebind rewrites '?' placeholders to $1, $2, ... for Postgres.
+// Question marks inside single-quoted literals are left untouched.
+func (d sqlDialect) rebind(query string) string {
+	if d != dialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(query) + 8)
+	inQuote := false
+	n := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+			builder.WriteByte(ch)
+		case ch == '?' && !inQuote:
+			n++
+			builder.WriteByte('$')
+			builder.WriteString(strconv.Itoa(n))
+		default:
+			builder.WriteByte(ch)
+		}
+	}
+	return builder.String()
+}
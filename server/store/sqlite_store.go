@@ -1,25 +1,41 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
-// SQLiteStore is a demo database-backed implementation of API.
+// sqlStore implements API on top of database/sql.
 //
 // It keeps the existing ID format (u_1, p_1, ...) so that the REST/WS payloads
-// stay stable while we switch persistence from memory to SQLite.
+// stay stable across backends. Queries are written with '?' placeholders and
+// rebound per dialect by sqlDB.
+type sqlStore struct {
+	db *sqlDB
+	// counterStmt is nextCounter's upsert, prepared once. SQLite would
+	// otherwise re-parse it on every insert; pgx already caches statements
+	// per connection, so Postgres leaves it nil.
+	counterStmt *sql.Stmt
+}
+
+// SQLiteStore is a demo database-backed implementation of API.
 type SQLiteStore struct {
-	db *sql.DB
+	sqlStore
 }
 
+// SQLiteMemoryPath opens a private in-memory database that lives as long as
+// the store, for tests and throwaway runs.
+const SQLiteMemoryPath = ":memory:"
+
 // OpenSQLite opens (or creates) a SQLite database at the given path and runs migrations.
 func OpenSQLite(path string) (*SQLiteStore, error) {
 	path = strings.TrimSpace(path)
@@ -27,11 +43,17 @@ func OpenSQLite(path string) (*SQLiteStore, error) {
 		return nil, errors.New("sqlite path is required")
 	}
 
-	normalized := filepath.ToSlash(path)
-	dsn := "file:" + normalized + "?cache=shared" +
-		"&_pragma=busy_timeout(5000)" +
-		"&_pragma=journal_mode(WAL)" +
-		"&_pragma=foreign_keys(ON)"
+	var dsn string
+	if path == SQLiteMemoryPath {
+		// No shared cache: each store gets its own database. The single pooled
+		// connection below keeps it alive until Close.
+		dsn = "file::memory:?_pragma=foreign_keys(ON)"
+	} else {
+		dsn = "file:" + filepath.ToSlash(path) + "?cache=shared" +
+			"&_pragma=busy_timeout(5000)" +
+			"&_pragma=journal_mode(WAL)" +
+			"&_pragma=foreign_keys(ON)"
+	}
 
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
@@ -45,7 +67,7 @@ func OpenSQLite(path string) (*SQLiteStore, error) {
 		return nil, err
 	}
 
-	s := &SQLiteStore{db: db}
+	s := &SQLiteStore{sqlStore{db: &sqlDB{DB: db, dialect: dialectSQLite}}}
 	if err := s.migrate(); err != nil {
 		_ = db.Close()
 		return nil, err
@@ -54,14 +76,41 @@ func OpenSQLite(path string) (*SQLiteStore, error) {
 		_ = db.Close()
 		return nil, err
 	}
+	if s.counterStmt, err = db.Prepare(nextCounterQuery); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	return s, nil
 }
 
-func (s *SQLiteStore) Close() error {
+// pingTimeout bounds Ping, so a wedged database fails a health probe instead
+// of hanging it.
+const pingTimeout = 2 * time.Second
+
+// Ping checks that the database still answers within pingTimeout.
+func (s *sqlStore) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return s.db.PingContext(ctx)
+}
+
+func (s *sqlStore) Close() error {
+	if s.counterStmt != nil {
+		_ = s.counterStmt.Close()
+	}
 	return s.db.Close()
 }
 
-func (s *SQLiteStore) migrate() error {
+// Close checkpoints the WAL into the main database file before closing, so a
+// clean shutdown leaves a self-contained dev.db behind.
+func (s *SQLiteStore) Close() error {
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE);`); err != nil {
+		log.Printf("sqlite: wal checkpoint failed: %v", err)
+	}
+	return s.sqlStore.Close()
+}
+
+func (s *sqlStore) migrate() error {
 	stmts := []string{
 		`CREATE TABLE IF NOT EXISTS counters (
 			name TEXT PRIMARY KEY,
@@ -86,16 +135,32 @@ func (s *SQLiteStore) migrate() error {
 			verify_token_hash TEXT,
 			verify_token_expires_at TEXT
 		);`,
-		`CREATE TABLE IF NOT EXISTS tokens (
-			token TEXT PRIMARY KEY,
-			user_id TEXT NOT NULL UNIQUE
+		// email_changes holds at most one unconfirmed login email change per
+		// user; accounts.account is only rewritten once it is confirmed.
+		`CREATE TABLE IF NOT EXISTS email_changes (
+			user_id TEXT PRIMARY KEY,
+			new_account TEXT NOT NULL,
+			token_hash TEXT NOT NULL,
+			expires_at TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			token TEXT NOT NULL UNIQUE,
+			user_id TEXT NOT NULL,
+			user_agent TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			last_seen TEXT NOT NULL,
+			expires_at TEXT NOT NULL
 		);`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);`,
 
 		`CREATE TABLE IF NOT EXISTS boards (
 			seq INTEGER NOT NULL,
 			id TEXT PRIMARY KEY,
 			name TEXT NOT NULL,
-			description TEXT NOT NULL
+			description TEXT NOT NULL,
+			deleted_at TEXT
 		);`,
 		`CREATE TABLE IF NOT EXISTS posts (
 			seq INTEGER NOT NULL,
@@ -108,6 +173,10 @@ func (s *SQLiteStore) migrate() error {
 			tags TEXT NOT NULL,
 			attachments TEXT NOT NULL,
 			view_count INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL DEFAULT 'published',
+			pinned BOOLEAN NOT NULL DEFAULT FALSE,
+			pinned_at TEXT NOT NULL DEFAULT '',
+			version INTEGER NOT NULL DEFAULT 1,
 			created_at TEXT NOT NULL,
 			deleted_at TEXT
 		);`,
@@ -154,7 +223,8 @@ func (s *SQLiteStore) migrate() error {
 			storage_path TEXT NOT NULL,
 			width INTEGER NOT NULL DEFAULT 0,
 			height INTEGER NOT NULL DEFAULT 0,
-			created_at TEXT NOT NULL
+			created_at TEXT NOT NULL,
+			deleted_at TEXT
 		);`,
 
 		`CREATE TABLE IF NOT EXISTS messages (
@@ -163,10 +233,28 @@ func (s *SQLiteStore) migrate() error {
 			room_id TEXT NOT NULL,
 			sender_id TEXT NOT NULL,
 			content TEXT NOT NULL,
-			created_at TEXT NOT NULL
+			created_at TEXT NOT NULL,
+			edited_at TEXT,
+			deleted_at TEXT
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_messages_room_seq ON messages(room_id, seq);`,
 
+		`CREATE TABLE IF NOT EXISTS dm_rooms (
+			room_id TEXT PRIMARY KEY,
+			user_a TEXT NOT NULL,
+			user_b TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_dm_rooms_user_a ON dm_rooms(user_a);`,
+		`CREATE INDEX IF NOT EXISTS idx_dm_rooms_user_b ON dm_rooms(user_b);`,
+
+		`CREATE TABLE IF NOT EXISTS chat_reads (
+			user_id TEXT NOT NULL,
+			room_id TEXT NOT NULL,
+			last_read_seq INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY(user_id, room_id)
+		);`,
+
 		`CREATE TABLE IF NOT EXISTS reports (
 			seq INTEGER NOT NULL,
 			id TEXT PRIMARY KEY,
@@ -183,6 +271,17 @@ func (s *SQLiteStore) migrate() error {
 			updated_at TEXT NOT NULL
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_reports_status_seq ON reports(status, seq);`,
+		`CREATE TABLE IF NOT EXISTS report_events (
+			seq INTEGER PRIMARY KEY,
+			report_id TEXT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			action TEXT NOT NULL,
+			note TEXT NOT NULL,
+			handled_by TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_report_events_report ON report_events(report_id, seq);`,
 
 		`CREATE TABLE IF NOT EXISTS follows (
 			follower_id TEXT NOT NULL,
@@ -192,6 +291,34 @@ func (s *SQLiteStore) migrate() error {
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id);`,
 
+		`CREATE TABLE IF NOT EXISTS board_subscriptions (
+			user_id TEXT NOT NULL,
+			board_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, board_id)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS blocks (
+			blocker_id TEXT NOT NULL,
+			blocked_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (blocker_id, blocked_id)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS checkins (
+			user_id TEXT NOT NULL,
+			date TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (user_id, date)
+		);`,
+		// One row per (post, voter) whose upvote has earned the author exp.
+		`CREATE TABLE IF NOT EXISTS upvote_awards (
+			post_id TEXT NOT NULL,
+			voter_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (post_id, voter_id)
+		);`,
+
 		// Notifications table for in-app notifications
 		`CREATE TABLE IF NOT EXISTS notifications (
 			seq INTEGER NOT NULL,
@@ -205,6 +332,25 @@ func (s *SQLiteStore) migrate() error {
 			created_at TEXT NOT NULL
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_notifications_recipient ON notifications(recipient_id, created_at DESC);`,
+		`CREATE TABLE IF NOT EXISTS notification_prefs (
+			user_id TEXT PRIMARY KEY,
+			email_digest BOOLEAN NOT NULL DEFAULT FALSE,
+			digest_seq INTEGER NOT NULL DEFAULT 0
+		);`,
+
+		// post_tags mirrors posts.tags (normalized) for live published posts only, so tag
+		// filtering and counting don't have to decode every post's JSON.
+		`CREATE TABLE IF NOT EXISTS post_tags (
+			post_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (post_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags(tag);`,
+
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			name TEXT PRIMARY KEY,
+			applied_at TEXT NOT NULL
+		);`,
 	}
 
 	for _, stmt := range stmts {
@@ -234,6 +380,11 @@ func (s *SQLiteStore) migrate() error {
 			return err
 		}
 	}
+	if _, err := s.db.Exec(`ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
 
 	// Backward compatible migration for databases created before password auth.
 	if _, err := s.db.Exec(`ALTER TABLE accounts ADD COLUMN password_hash TEXT;`); err != nil {
@@ -281,22 +432,30 @@ func (s *SQLiteStore) migrate() error {
 			return err
 		}
 	}
+	if _, err := s.db.Exec(`ALTER TABLE files ADD COLUMN deleted_at TEXT;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
 
-	// Legacy databases may contain demo tokens for accounts without passwords.
-	// Drop those tokens so users must register (set a password) before using the API.
-	_, _ = s.db.Exec(
-		`DELETE FROM tokens
-		 WHERE user_id IN (
-			SELECT user_id
-			FROM accounts
-			WHERE password_hash IS NULL OR TRIM(password_hash) = ''
-		 );`,
-	)
+	// Backward compatible migration for messages table: edit and soft delete.
+	if _, err := s.db.Exec(`ALTER TABLE messages ADD COLUMN edited_at TEXT;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE messages ADD COLUMN deleted_at TEXT;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
 
-	// Session tokens are intentionally not retained across server restarts for the demo.
-	// Clearing tokens on startup forces users to login again after restart, while keeping
-	// all other persisted data (posts/comments/files/etc.) intact.
-	_, _ = s.db.Exec(`DELETE FROM tokens;`)
+	// The single-token-per-user tokens table was replaced by sessions; its tokens
+	// carry no session metadata, so they are dropped and those users log in again.
+	if _, err := s.db.Exec(`DROP TABLE IF EXISTS tokens;`); err != nil {
+		return err
+	}
+	_, _ = s.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?;`, nowRFC3339())
 
 	// Backward compatible migrations for databases created before soft delete support.
 	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN deleted_at TEXT;`); err != nil {
@@ -354,6 +513,47 @@ func (s *SQLiteStore) migrate() error {
 			return err
 		}
 	}
+	// Posts written before drafts existed were all published.
+	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published';`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN pinned_at TEXT NOT NULL DEFAULT '';`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE boards ADD COLUMN deleted_at TEXT;`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE reports ADD COLUMN target_snapshot TEXT NOT NULL DEFAULT '';`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE posts ADD COLUMN deleted_reason TEXT NOT NULL DEFAULT '';`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
+	if _, err := s.db.Exec(`ALTER TABLE comments ADD COLUMN deleted_reason TEXT NOT NULL DEFAULT '';`); err != nil {
+		if !isSQLiteDuplicateColumnError(err) {
+			return err
+		}
+	}
 	if _, err := s.db.Exec(
 		`UPDATE comments
 		 SET floor = 0
@@ -375,6 +575,13 @@ func (s *SQLiteStore) migrate() error {
 	); err != nil {
 		return err
 	}
+	if err := s.runOnce("backfill_post_tags", s.backfillPostTags); err != nil {
+		return err
+	}
+	if err := s.runOnce("purge_votes_on_deleted", purgeVotesOnDeleted); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -401,7 +608,101 @@ func nullStringOrValue(value string) any {
 	return value
 }
 
-func (s *SQLiteStore) seedBoards() error {
+// runOnce applies a data migration in its own transaction unless
+// schema_migrations records it as already applied.
+func (s *sqlStore) runOnce(name string, apply func(tx *sqlTx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var applied string
+	err = tx.QueryRow(`SELECT name FROM schema_migrations WHERE name = ?;`, name).Scan(&applied)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := apply(tx); err != nil {
+		return fmt.Errorf("migration %s: %w", name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations(name, applied_at) VALUES(?, ?);`, name, nowRFC3339()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// backfillPostTags populates post_tags from the JSON tags of existing live posts.
+func (s *sqlStore) backfillPostTags(tx *sqlTx) error {
+	rows, err := tx.Query(`SELECT id, tags FROM posts WHERE (deleted_at IS NULL OR TRIM(deleted_at) = '') AND status = 'published';`)
+	if err != nil {
+		return err
+	}
+	tagged := map[string][]string{}
+	for rows.Next() {
+		var (
+			id   string
+			tags sql.NullString
+		)
+		if err := rows.Scan(&id, &tags); err != nil {
+			rows.Close()
+			return err
+		}
+		if decoded := decodeTags(tags.String); len(decoded) > 0 {
+			tagged[id] = decoded
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for id, tags := range tagged {
+		if err := setPostTags(tx, id, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeVotesOnDeleted drops votes left on posts and comments deleted before
+// deletion started removing them.
+func purgeVotesOnDeleted(tx *sqlTx) error {
+	if _, err := tx.Exec(
+		`DELETE FROM post_votes WHERE post_id IN (
+			SELECT id FROM posts WHERE deleted_at IS NOT NULL AND TRIM(deleted_at) <> ''
+		 );`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`DELETE FROM comment_votes WHERE comment_id IN (
+			SELECT id FROM comments WHERE deleted_at IS NOT NULL AND TRIM(deleted_at) <> ''
+		 );`,
+	)
+	return err
+}
+
+// setPostTags replaces postID's post_tags rows with the normalized tags.
+func setPostTags(tx *sqlTx, postID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM post_tags WHERE post_id = ?;`, postID); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		normalized := normalizeTag(tag)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if _, err := tx.Exec(`INSERT INTO post_tags(post_id, tag) VALUES(?, ?);`, postID, normalized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlStore) seedBoards() error {
 	var count int
 	if err := s.db.QueryRow(`SELECT COUNT(*) FROM boards;`).Scan(&count); err != nil {
 		return err
@@ -435,15 +736,21 @@ func (s *SQLiteStore) seedBoards() error {
 	return nil
 }
 
-func (s *SQLiteStore) nextCounter(tx *sql.Tx, name string) (int, error) {
-	if _, err := tx.Exec(`INSERT OR IGNORE INTO counters(name, value) VALUES(?, 0);`, name); err != nil {
-		return 0, err
-	}
-	if _, err := tx.Exec(`UPDATE counters SET value = value + 1 WHERE name = ?;`, name); err != nil {
-		return 0, err
+// nextCounterQuery bumps a named counter and returns its new value in one
+// statement, so taking an ID costs a single round trip.
+const nextCounterQuery = `INSERT INTO counters(name, value) VALUES(?, 1)
+	ON CONFLICT(name) DO UPDATE SET value = counters.value + 1
+	RETURNING value;`
+
+func (s *sqlStore) nextCounter(tx *sqlTx, name string) (int, error) {
+	var row *sql.Row
+	if s.counterStmt != nil {
+		row = tx.Stmt(s.counterStmt).QueryRow(name)
+	} else {
+		row = tx.QueryRow(nextCounterQuery, name)
 	}
 	var value int
-	if err := tx.QueryRow(`SELECT value FROM counters WHERE name = ?;`, name).Scan(&value); err != nil {
+	if err := row.Scan(&value); err != nil {
 		return 0, err
 	}
 	return value, nil
@@ -453,33 +760,49 @@ func nowRFC3339() string {
 	return time.Now().UTC().Format(time.RFC3339)
 }
 
-func (s *SQLiteStore) rotateToken(tx *sql.Tx, userID string) (string, error) {
-	if _, err := tx.Exec(`DELETE FROM tokens WHERE user_id = ?;`, userID); err != nil {
-		return "", err
+// insertSession issues a new session for userID alongside any existing ones.
+func (s *sqlStore) insertSession(tx *sqlTx, userID, userAgent string) (Session, error) {
+	seq, err := s.nextCounter(tx, "session")
+	if err != nil {
+		return Session{}, err
+	}
+	now := nowRFC3339()
+	session := Session{
+		ID:        fmt.Sprintf("s_%d", seq),
+		UserID:    userID,
+		UserAgent: normalizeUserAgent(userAgent),
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: sessionTokenExpiry().Format(time.RFC3339),
 	}
 
 	var lastErr error
 	for i := 0; i < 3; i++ {
 		token, err := newToken()
 		if err != nil {
-			return "", err
+			return Session{}, err
 		}
-		if _, err := tx.Exec(`INSERT INTO tokens(token, user_id) VALUES(?, ?);`, token, userID); err != nil {
+		if _, err := tx.Exec(
+			`INSERT INTO sessions(id, token, user_id, user_agent, created_at, last_seen, expires_at)
+			 VALUES(?, ?, ?, ?, ?, ?, ?);`,
+			session.ID, token, userID, session.UserAgent, session.CreatedAt, session.LastSeen, session.ExpiresAt,
+		); err != nil {
 			lastErr = err
 			if isSQLiteConstraintError(err) {
 				continue
 			}
-			return "", err
+			return Session{}, err
 		}
-		return token, nil
+		session.Token = token
+		return session, nil
 	}
 	if lastErr == nil {
 		lastErr = errors.New("failed to generate token")
 	}
-	return "", lastErr
+	return Session{}, lastErr
 }
 
-func (s *SQLiteStore) Register(account, password, nickname string) (RegisterResult, error) {
+func (s *sqlStore) Register(account, password, nickname string) (RegisterResult, error) {
 	normalizedAccount := normalizeEmail(account)
 	trimmedPassword := strings.TrimSpace(password)
 	trimmedNickname := strings.TrimSpace(nickname)
@@ -589,16 +912,16 @@ func (s *SQLiteStore) Register(account, password, nickname string) (RegisterResu
 	}, nil
 }
 
-func (s *SQLiteStore) Login(account, password string) (string, User, error) {
+func (s *sqlStore) Login(account, password, userAgent string) (Session, User, error) {
 	normalizedAccount := normalizeEmail(account)
 	trimmedPassword := strings.TrimSpace(password)
 	if normalizedAccount == "" || trimmedPassword == "" {
-		return "", User{}, ErrInvalidInput
+		return Session{}, User{}, ErrInvalidInput
 	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
-		return "", User{}, err
+		return Session{}, User{}, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
@@ -615,31 +938,137 @@ func (s *SQLiteStore) Login(account, password string) (string, User, error) {
 		normalizedAccount,
 	).Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp, &passwordHash, &verifiedAt)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", User{}, ErrInvalidCredentials
+		return Session{}, User{}, ErrInvalidCredentials
 	}
 	if err != nil {
-		return "", User{}, err
+		return Session{}, User{}, err
 	}
 
 	if !verifyPassword(strings.TrimSpace(passwordHash.String), trimmedPassword) {
-		return "", User{}, ErrInvalidCredentials
+		return Session{}, User{}, ErrInvalidCredentials
 	}
 	if strings.TrimSpace(verifiedAt.String) == "" {
-		return "", User{}, ErrAccountUnverified
+		return Session{}, User{}, ErrAccountUnverified
+	}
+	if rehashed := rehashPassword(strings.TrimSpace(passwordHash.String), trimmedPassword); rehashed != "" {
+		// Conditional on the old hash so a concurrent password change wins.
+		if _, err := tx.Exec(
+			`UPDATE accounts SET password_hash = ? WHERE account = ? AND password_hash = ?;`,
+			rehashed, normalizedAccount, passwordHash.String,
+		); err != nil {
+			return Session{}, User{}, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE user_id = ? AND expires_at <= ?;`, user.ID, nowRFC3339()); err != nil {
+		return Session{}, User{}, err
+	}
+	session, err := s.insertSession(tx, user.ID, userAgent)
+	if err != nil {
+		return Session{}, User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Session{}, User{}, err
+	}
+	return session, user, nil
+}
+
+// RefreshSession extends a still-valid token's expiry, or gives the session a
+// fresh token when rotate is set. The session keeps its ID either way.
+func (s *sqlStore) RefreshSession(token string, rotate bool) (Session, error) {
+	trimmedToken := strings.TrimSpace(token)
+	if trimmedToken == "" {
+		return Session{}, ErrInvalidToken
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Session{}, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	token, err := s.rotateToken(tx, user.ID)
+	var session Session
+	err = tx.QueryRow(
+		`SELECT id, user_id, user_agent, created_at, last_seen, expires_at FROM sessions WHERE token = ?;`,
+		trimmedToken,
+	).Scan(&session.ID, &session.UserID, &session.UserAgent, &session.CreatedAt, &session.LastSeen, &session.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrInvalidToken
+	}
 	if err != nil {
-		return "", User{}, err
+		return Session{}, err
+	}
+	if sessionExpired(session.ExpiresAt) {
+		if _, err := tx.Exec(`DELETE FROM sessions WHERE id = ?;`, session.ID); err != nil {
+			return Session{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return Session{}, err
+		}
+		return Session{}, ErrInvalidToken
+	}
+
+	session.Token = trimmedToken
+	session.LastSeen = nowRFC3339()
+	session.ExpiresAt = sessionTokenExpiry().Format(time.RFC3339)
+	if rotate {
+		if session.Token, err = newToken(); err != nil {
+			return Session{}, err
+		}
+	}
+	if _, err := tx.Exec(
+		`UPDATE sessions SET token = ?, last_seen = ?, expires_at = ? WHERE id = ?;`,
+		session.Token, session.LastSeen, session.ExpiresAt, session.ID,
+	); err != nil {
+		return Session{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return "", User{}, err
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// Sessions lists userID's unexpired sessions, most recently used first.
+func (s *sqlStore) Sessions(userID string) ([]Session, error) {
+	rows, err := s.db.Query(
+		`SELECT id, token, user_id, user_agent, created_at, last_seen, expires_at
+		 FROM sessions
+		 WHERE user_id = ? AND expires_at > ?
+		 ORDER BY last_seen DESC, id DESC;`,
+		userID, nowRFC3339(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.Token, &session.UserID, &session.UserAgent, &session.CreatedAt, &session.LastSeen, &session.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession deletes one of userID's sessions. Sessions of other users
+// report ErrNotFound.
+func (s *sqlStore) RevokeSession(userID, sessionID string) error {
+	res, err := s.db.Exec(`DELETE FROM sessions WHERE id = ? AND user_id = ?;`, strings.TrimSpace(sessionID), userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
 	}
-	return token, user, nil
+	return nil
 }
 
-func (s *SQLiteStore) VerifyEmail(token string) error {
+func (s *sqlStore) VerifyEmail(token string) error {
 	trimmedToken := strings.TrimSpace(token)
 	if trimmedToken == "" {
 		return ErrInvalidInput
@@ -680,9 +1109,10 @@ func (s *SQLiteStore) VerifyEmail(token string) error {
 	if time.Now().UTC().After(parsedExpiry) {
 		return ErrVerificationTokenExpired
 	}
+	// The link is single-use: forget its hash so a replay cannot match.
 	if _, err := tx.Exec(
 		`UPDATE accounts
-		 SET verified_at = ?
+		 SET verified_at = ?, verify_token_hash = NULL
 		 WHERE account = ?;`,
 		nowRFC3339(),
 		account,
@@ -695,7 +1125,7 @@ func (s *SQLiteStore) VerifyEmail(token string) error {
 	return nil
 }
 
-func (s *SQLiteStore) ResendVerification(account string) (string, error) {
+func (s *sqlStore) ResendVerification(account string) (string, error) {
 	normalizedAccount := normalizeEmail(account)
 	if normalizedAccount == "" {
 		return "", ErrInvalidInput
@@ -745,39 +1175,71 @@ func (s *SQLiteStore) ResendVerification(account string) (string, error) {
 	return verificationToken, nil
 }
 
-func (s *SQLiteStore) DeactivateAccount(userID string) error {
-	trimmedID := strings.TrimSpace(userID)
-	if trimmedID == "" {
+// CheckPassword reports ErrInvalidCredentials unless password matches the
+// account that owns userID.
+func (s *sqlStore) CheckPassword(userID, password string) error {
+	trimmedPassword := strings.TrimSpace(password)
+	if trimmedPassword == "" {
 		return ErrInvalidInput
 	}
 
-	tx, err := s.db.Begin()
+	var passwordHash sql.NullString
+	err := s.db.QueryRow(`SELECT password_hash FROM accounts WHERE user_id = ?;`, userID).Scan(&passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidCredentials
+	}
 	if err != nil {
 		return err
 	}
-	defer func() { _ = tx.Rollback() }()
+	if !verifyPassword(strings.TrimSpace(passwordHash.String), trimmedPassword) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
 
-	var existing string
-	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ?;`, trimmedID).Scan(&existing); err != nil {
+func (s *sqlStore) DeactivateAccount(userID string) error {
+	trimmedID := strings.TrimSpace(userID)
+	if trimmedID == "" {
+		return ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var existing string
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ?;`, trimmedID).Scan(&existing); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrNotFound
 		}
 		return err
 	}
-	if _, err := tx.Exec(`DELETE FROM tokens WHERE user_id = ?;`, trimmedID); err != nil {
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE user_id = ?;`, trimmedID); err != nil {
 		return err
 	}
 	if _, err := tx.Exec(`DELETE FROM accounts WHERE user_id = ?;`, trimmedID); err != nil {
 		return err
 	}
+	if _, err := tx.Exec(`DELETE FROM email_changes WHERE user_id = ?;`, trimmedID); err != nil {
+		return err
+	}
 	if _, err := tx.Exec(`DELETE FROM follows WHERE follower_id = ? OR followee_id = ?;`, trimmedID, trimmedID); err != nil {
 		return err
 	}
+	if _, err := tx.Exec(`DELETE FROM board_subscriptions WHERE user_id = ?;`, trimmedID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM blocks WHERE blocker_id = ? OR blocked_id = ?;`, trimmedID, trimmedID); err != nil {
+		return err
+	}
 	if _, err := tx.Exec(
 		`UPDATE users
-		 SET nickname = ?, avatar = '', cover = '', bio = ''
+		 SET nickname = ?, avatar = '', cover = '', bio = '', is_admin = ?
 		 WHERE id = ?;`,
-		"已注销用户",
+		DeletedUserNickname,
+		false,
 		trimmedID,
 	); err != nil {
 		return err
@@ -788,22 +1250,188 @@ func (s *SQLiteStore) DeactivateAccount(userID string) error {
 	return nil
 }
 
-func (s *SQLiteStore) UserByToken(token string) (User, bool) {
-	var user User
+// AccountEmail returns userID's login email and the address of a pending,
+// unexpired change, which is empty when there is none.
+func (s *sqlStore) AccountEmail(userID string) (string, string, error) {
+	var account string
+	err := s.db.QueryRow(`SELECT account FROM accounts WHERE user_id = ?;`, userID).Scan(&account)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	var pending string
+	err = s.db.QueryRow(
+		`SELECT new_account FROM email_changes WHERE user_id = ? AND expires_at > ?;`,
+		userID, nowRFC3339(),
+	).Scan(&pending)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", "", err
+	}
+	return account, pending, nil
+}
+
+// AccountStatus returns userID's login email and whether it is verified.
+func (s *sqlStore) AccountStatus(userID string) (AccountStatus, error) {
+	var status AccountStatus
+	var verifiedAt sql.NullString
 	err := s.db.QueryRow(
-		`SELECT u.id, u.nickname, u.created_at, u.avatar, u.cover, u.bio, u.exp
+		`SELECT account, verified_at FROM accounts WHERE user_id = ?;`,
+		userID,
+	).Scan(&status.Email, &verifiedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AccountStatus{}, ErrNotFound
+	}
+	if err != nil {
+		return AccountStatus{}, err
+	}
+	status.Verified = strings.TrimSpace(verifiedAt.String) != ""
+	return status, nil
+}
+
+// RequestEmailChange records newEmail as userID's pending login email and
+// returns the token that confirms it. password must be the current one. A
+// new request replaces any earlier pending change.
+func (s *sqlStore) RequestEmailChange(userID, newEmail, password string) (string, error) {
+	normalizedEmail := normalizeEmail(newEmail)
+	trimmedPassword := strings.TrimSpace(password)
+	if normalizedEmail == "" || trimmedPassword == "" {
+		return "", ErrInvalidInput
+	}
+	if !validateEmail(normalizedEmail) {
+		return "", ErrInvalidEmail
+	}
+
+	verificationToken, err := newVerificationToken()
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var passwordHash sql.NullString
+	err = tx.QueryRow(`SELECT password_hash FROM accounts WHERE user_id = ?;`, userID).Scan(&passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if !verifyPassword(strings.TrimSpace(passwordHash.String), trimmedPassword) {
+		return "", ErrInvalidCredentials
+	}
+	var taken int
+	err = tx.QueryRow(`SELECT 1 FROM accounts WHERE account = ?;`, normalizedEmail).Scan(&taken)
+	if err == nil {
+		return "", ErrAccountExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO email_changes(user_id, new_account, token_hash, expires_at, created_at)
+		 VALUES(?, ?, ?, ?, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+			new_account = excluded.new_account,
+			token_hash = excluded.token_hash,
+			expires_at = excluded.expires_at,
+			created_at = excluded.created_at;`,
+		userID,
+		normalizedEmail,
+		hashVerificationToken(verificationToken),
+		verificationTokenExpiry().Format(time.RFC3339),
+		nowRFC3339(),
+	); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return verificationToken, nil
+}
+
+// ConfirmEmailChange applies the pending change that token belongs to,
+// making the new address the account's login email. The address must still
+// be free.
+func (s *sqlStore) ConfirmEmailChange(token string) error {
+	trimmedToken := strings.TrimSpace(token)
+	if trimmedToken == "" {
+		return ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var userID, newAccount, expiresAt string
+	err = tx.QueryRow(
+		`SELECT user_id, new_account, expires_at FROM email_changes WHERE token_hash = ?;`,
+		hashVerificationToken(trimmedToken),
+	).Scan(&userID, &newAccount, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVerificationTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if sessionExpired(expiresAt) {
+		return ErrVerificationTokenExpired
+	}
+
+	res, err := tx.Exec(`UPDATE accounts SET account = ? WHERE user_id = ?;`, newAccount, userID)
+	if err != nil {
+		if isSQLiteConstraintError(err) {
+			return ErrAccountExists
+		}
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrVerificationTokenInvalid
+	}
+	if _, err := tx.Exec(`DELETE FROM email_changes WHERE user_id = ?;`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) UserByToken(token string) (User, bool) {
+	var (
+		user      User
+		sessionID string
+		lastSeen  string
+		expiresAt string
+	)
+	err := s.db.QueryRow(
+		`SELECT u.id, u.nickname, u.created_at, u.avatar, u.cover, u.bio, u.exp, t.id, t.last_seen, t.expires_at
 		 FROM users u
-		 JOIN tokens t ON t.user_id = u.id
+		 JOIN sessions t ON t.user_id = u.id
 		 WHERE t.token = ?;`,
 		token,
-	).Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp)
+	).Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp, &sessionID, &lastSeen, &expiresAt)
 	if err != nil {
 		return User{}, false
 	}
+	if sessionExpired(expiresAt) {
+		// Expired sessions are removed lazily, the first time they are presented.
+		_, _ = s.db.Exec(`DELETE FROM sessions WHERE id = ?;`, sessionID)
+		return User{}, false
+	}
+	if sessionStale(lastSeen) {
+		_, _ = s.db.Exec(`UPDATE sessions SET last_seen = ? WHERE id = ?;`, nowRFC3339(), sessionID)
+	}
 	return user, true
 }
 
-func (s *SQLiteStore) GetUser(userID string) (User, bool) {
+func (s *sqlStore) GetUser(userID string) (User, bool) {
 	var user User
 	if err := s.db.QueryRow(`SELECT id, nickname, created_at, avatar, cover, bio, exp FROM users WHERE id = ?;`, userID).
 		Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp); err != nil {
@@ -812,11 +1440,139 @@ func (s *SQLiteStore) GetUser(userID string) (User, bool) {
 	return user, true
 }
 
-func (s *SQLiteStore) UpdateUser(userID, nickname, bio, avatar, cover string) (User, error) {
+// UserByNickname resolves an exact nickname to an active account. Nicknames
+// are not unique, so it reports false when none or several users match.
+func (s *sqlStore) UserByNickname(nickname string) (User, bool) {
+	trimmed := strings.TrimSpace(nickname)
+	if trimmed == "" {
+		return User{}, false
+	}
+	rows, err := s.db.Query(
+		`SELECT u.id, u.nickname, u.created_at, u.avatar, u.cover, u.bio, u.exp
+		 FROM users u
+		 JOIN accounts a ON a.user_id = u.id
+		 WHERE u.nickname = ?
+		 LIMIT 2;`,
+		trimmed,
+	)
+	if err != nil {
+		return User{}, false
+	}
+	defer rows.Close()
+
+	var matches []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp); err != nil {
+			return User{}, false
+		}
+		matches = append(matches, user)
+	}
+	if len(matches) != 1 {
+		return User{}, false
+	}
+	return matches[0], true
+}
+
+func (s *sqlStore) GetUsers(userIDs []string) map[string]User {
+	out := make(map[string]User, len(userIDs))
+	ids := make([]any, 0, len(userIDs))
+	seen := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return out
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := s.db.Query(
+		`SELECT id, nickname, created_at, avatar, cover, bio, exp FROM users WHERE id IN (`+placeholders+`);`,
+		ids...,
+	)
+	if err != nil {
+		return out
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp); err != nil {
+			return out
+		}
+		out[user.ID] = user
+	}
+	return out
+}
+
+func (s *sqlStore) SetAdmin(userID string, admin bool) error {
+	res, err := s.db.Exec(`UPDATE users SET is_admin = ? WHERE id = ?;`, admin, userID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqlStore) IsAdmin(userID string) bool {
+	var admin bool
+	if err := s.db.QueryRow(`SELECT is_admin FROM users WHERE id = ?;`, userID).Scan(&admin); err != nil {
+		return false
+	}
+	return admin
+}
+
+func (s *sqlStore) SeedAdmins(accounts []string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM users WHERE is_admin = ?;`, true).Scan(&existing); err != nil {
+		return 0, err
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+
+	promoted := 0
+	for _, account := range accounts {
+		res, err := tx.Exec(
+			`UPDATE users SET is_admin = ?
+			 WHERE id = (SELECT user_id FROM accounts WHERE account = ?) AND is_admin = ?;`,
+			true, normalizeEmail(account), false,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			promoted += int(affected)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return promoted, nil
+}
+
+// UpdateUser replaces the user's profile fields. The nickname must pass
+// validateNickname; bio, avatar and cover may be empty to clear them.
+func (s *sqlStore) UpdateUser(userID, nickname, bio, avatar, cover string) (User, error) {
 	trimmedID := strings.TrimSpace(userID)
 	if trimmedID == "" {
 		return User{}, ErrInvalidInput
 	}
+	if !validateNickname(nickname) {
+		return User{}, ErrInvalidNickname
+	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -824,14 +1580,6 @@ func (s *SQLiteStore) UpdateUser(userID, nickname, bio, avatar, cover string) (U
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	// If fields are empty, we might want to keep existing values or allow clearing them.
-	// For simplicity, let's assume the caller sends the full desired state,
-	// OR we only update non-empty fields?
-	// Usually standard UPDATE API expects full replacement or PATCH semantics.
-	// Let's implement partial update logic: only update fields that are provided (or if we explicitly want to support clearing, we need a better protocol).
-	// For MVP: The frontend will likely send the current values if not changed.
-	// But to be safe and robust, let's fetch current first.
-
 	var user User
 	if err := tx.QueryRow(`SELECT id, nickname, created_at, avatar, cover, bio, exp FROM users WHERE id = ?;`, trimmedID).
 		Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp); err != nil {
@@ -841,19 +1589,8 @@ func (s *SQLiteStore) UpdateUser(userID, nickname, bio, avatar, cover string) (U
 		return User{}, err
 	}
 
-	newNickname := strings.TrimSpace(nickname)
-	if newNickname != "" {
-		user.Nickname = newNickname
-	}
-	// Bio, Avatar, Cover can be empty strings (clearing them) or new values.
-	// However, if the caller sends empty string, does it mean "no change" or "clear"?
-	// A common pattern in Go/REST is to treat empty string as "no change" unless a special flag is set,
-	// OR, for a simple MVP, we can assume the frontend sends the *complete* new state.
-	// Let's assume the frontend sends the NEW state for everything.
-	// If the frontend wants to keep the old value, it sends the old value.
-	// We only protect Nickname from being empty.
-
-	user.Bio = bio // Allow empty
+	user.Nickname = strings.TrimSpace(nickname)
+	user.Bio = bio
 	user.Avatar = avatar
 	user.Cover = cover
 
@@ -870,32 +1607,91 @@ func (s *SQLiteStore) UpdateUser(userID, nickname, bio, avatar, cover string) (U
 	return user, nil
 }
 
-func (s *SQLiteStore) AddUserExp(userID string, delta int) error {
+func (s *sqlStore) AddExp(userID string, delta int) (int, error) {
 	trimmedID := strings.TrimSpace(userID)
 	if trimmedID == "" {
-		return ErrInvalidInput
+		return 0, ErrInvalidInput
 	}
 
-	res, err := s.db.Exec(
+	var exp int
+	err := s.db.QueryRow(
 		`UPDATE users
 		 SET exp = CASE WHEN exp + ? < 0 THEN 0 ELSE exp + ? END
-		 WHERE id = ?;`,
+		 WHERE id = ?
+		 RETURNING exp;`,
 		delta,
 		delta,
 		trimmedID,
+	).Scan(&exp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return exp, nil
+}
+
+func (s *sqlStore) AwardExp(userID, reason string) (ExpAward, error) {
+	return awardExp(s.AddExp, userID, reason)
+}
+
+func (s *sqlStore) CheckIn(userID string) (int, bool, error) {
+	trimmedID := strings.TrimSpace(userID)
+	if trimmedID == "" {
+		return 0, false, ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exp int
+	if err := tx.QueryRow(`SELECT exp FROM users WHERE id = ?;`, trimmedID).Scan(&exp); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, ErrNotFound
+		}
+		return 0, false, err
+	}
+
+	res, err := tx.Exec(
+		`INSERT INTO checkins(user_id, date, created_at) VALUES(?, ?, ?)
+		 ON CONFLICT(user_id, date) DO NOTHING;`,
+		trimmedID,
+		checkInDate(),
+		nowRFC3339(),
 	)
 	if err != nil {
-		return err
+		return 0, false, err
 	}
-	affected, err := res.RowsAffected()
-	if err == nil && affected == 0 {
-		return ErrNotFound
+	if affected, err := res.RowsAffected(); err != nil {
+		return 0, false, err
+	} else if affected == 0 {
+		return exp, true, nil
 	}
-	return err
+
+	if err := tx.QueryRow(
+		`UPDATE users SET exp = exp + ? WHERE id = ? RETURNING exp;`,
+		DailyCheckInExp,
+		trimmedID,
+	).Scan(&exp); err != nil {
+		return 0, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return exp, false, nil
 }
 
-func (s *SQLiteStore) Boards() []Board {
-	rows, err := s.db.Query(`SELECT id, name, description FROM boards ORDER BY seq ASC;`)
+func (s *sqlStore) Boards() []Board {
+	rows, err := s.db.Query(
+		`SELECT id, name, description
+		 FROM boards
+		 WHERE deleted_at IS NULL OR TRIM(deleted_at) = ''
+		 ORDER BY seq ASC;`,
+	)
 	if err != nil {
 		return nil
 	}
@@ -912,40 +1708,200 @@ func (s *SQLiteStore) Boards() []Board {
 	return out
 }
 
-func (s *SQLiteStore) GetBoard(boardID string) (Board, bool) {
-	var board Board
-	err := s.db.QueryRow(`SELECT id, name, description FROM boards WHERE id = ?;`, boardID).
-		Scan(&board.ID, &board.Name, &board.Description)
+func (s *sqlStore) BoardsWithStats() []BoardStats {
+	rows, err := s.db.Query(
+		`SELECT b.id, b.name, b.description, COUNT(p.id), MAX(p.created_at)
+		 FROM boards b
+		 LEFT JOIN posts p
+		   ON p.board_id = b.id
+		  AND (p.deleted_at IS NULL OR TRIM(p.deleted_at) = '')
+		  AND p.status = 'published'
+		 WHERE b.deleted_at IS NULL OR TRIM(b.deleted_at) = ''
+		 GROUP BY b.seq, b.id, b.name, b.description
+		 ORDER BY b.seq ASC;`,
+	)
 	if err != nil {
-		return Board{}, false
+		return nil
 	}
-	return board, true
+	defer rows.Close()
+
+	var out []BoardStats
+	for rows.Next() {
+		var b BoardStats
+		var lastPostAt sql.NullString
+		if err := rows.Scan(&b.ID, &b.Name, &b.Description, &b.PostCount, &lastPostAt); err != nil {
+			return nil
+		}
+		b.LastPostAt = strings.TrimSpace(lastPostAt.String)
+		out = append(out, b)
+	}
+	return out
 }
 
-func (s *SQLiteStore) Posts(boardID string) []Post {
-	var (
-		rows *sql.Rows
-		err  error
-	)
-	if boardID == "" {
-		rows, err = s.db.Query(
-			`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, created_at
-		 FROM posts
-		 WHERE deleted_at IS NULL OR TRIM(deleted_at) = ''
-		 ORDER BY created_at DESC, seq DESC;`,
-		)
-	} else {
-		rows, err = s.db.Query(
-			`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, created_at
-			 FROM posts
-			 WHERE board_id = ?
-			   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
-			 ORDER BY created_at DESC, seq DESC;`,
+func (s *sqlStore) GetBoard(boardID string) (Board, bool) {
+	var board Board
+	err := s.db.QueryRow(
+		`SELECT id, name, description
+		 FROM boards
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		boardID,
+	).Scan(&board.ID, &board.Name, &board.Description)
+	if err != nil {
+		return Board{}, false
+	}
+	return board, true
+}
+
+// CreateBoard appends a board after the existing ones. IDs of deleted boards
+// stay taken, since their posts still point at them.
+func (s *sqlStore) CreateBoard(boardID, name, description string) (Board, error) {
+	board, err := normalizeBoard(boardID, name, description)
+	if err != nil {
+		return Board{}, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Board{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM boards WHERE id = ?;`, board.ID).Scan(&exists); err != nil {
+		return Board{}, err
+	}
+	if exists > 0 {
+		return Board{}, ErrBoardExists
+	}
+	var seq int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) + 1 FROM boards;`).Scan(&seq); err != nil {
+		return Board{}, err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO boards(seq, id, name, description, deleted_at) VALUES(?, ?, ?, ?, NULL);`,
+		seq,
+		board.ID,
+		board.Name,
+		board.Description,
+	); err != nil {
+		return Board{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Board{}, err
+	}
+	return board, nil
+}
+
+// UpdateBoard replaces a live board's name and description.
+func (s *sqlStore) UpdateBoard(boardID, name, description string) (Board, error) {
+	board, err := normalizeBoard(boardID, name, description)
+	if err != nil {
+		return Board{}, err
+	}
+
+	res, err := s.db.Exec(
+		`UPDATE boards
+		 SET name = ?, description = ?
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		board.Name,
+		board.Description,
+		board.ID,
+	)
+	if err != nil {
+		return Board{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return Board{}, err
+	}
+	if affected == 0 {
+		return Board{}, ErrNotFound
+	}
+	return board, nil
+}
+
+// DeleteBoard soft-deletes a board. Unless force is set it refuses with
+// ErrBoardNotEmpty while the board still holds posts, drafts included.
+func (s *sqlStore) DeleteBoard(boardID string, force bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var live int
+	if err := tx.QueryRow(
+		`SELECT COUNT(1) FROM boards WHERE id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		boardID,
+	).Scan(&live); err != nil {
+		return err
+	}
+	if live == 0 {
+		return ErrNotFound
+	}
+	if !force {
+		var posts int
+		if err := tx.QueryRow(
+			`SELECT COUNT(1) FROM posts WHERE board_id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
 			boardID,
-		)
+		).Scan(&posts); err != nil {
+			return err
+		}
+		if posts > 0 {
+			return ErrBoardNotEmpty
+		}
+	}
+	if _, err := tx.Exec(`UPDATE boards SET deleted_at = ? WHERE id = ?;`, nowRFC3339(), boardID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Posts returns the filter's page of live posts, newest first, and the total
+// number of matches. A single-board list leads with its pinned posts. Paging
+// happens in SQL, so a page costs one COUNT and one bounded SELECT.
+func (s *sqlStore) Posts(filter PostListFilter) ([]Post, int, error) {
+	filter = filter.normalized()
+	where := []string{"(deleted_at IS NULL OR TRIM(deleted_at) = '')", "status = 'published'"}
+	args := make([]any, 0, len(filter.BoardIDs)+2)
+	order := `created_at DESC, seq DESC`
+	if len(filter.BoardIDs) > 0 {
+		where = append(where, `board_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(filter.BoardIDs)), ",")+`)`)
+		for _, id := range filter.BoardIDs {
+			args = append(args, id)
+		}
+		if len(filter.BoardIDs) == 1 {
+			order = `pinned DESC, pinned_at DESC, ` + order
+		}
+	}
+	if filter.AuthorID != "" {
+		where = append(where, "author_id = ?")
+		args = append(args, filter.AuthorID)
+	}
+	if filter.ViewerID != "" {
+		where = append(where, "author_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)")
+		args = append(args, filter.ViewerID)
+	}
+	clause := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM posts WHERE `+clause+`;`, args...).Scan(&total); err != nil {
+		return nil, 0, err
 	}
+
+	query := `SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, pinned, pinned_at, created_at
+		 FROM posts
+		 WHERE ` + clause + `
+		 ORDER BY ` + order
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := s.db.Query(query+`;`, args...)
 	if err != nil {
-		return nil
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -955,30 +1911,127 @@ func (s *SQLiteStore) Posts(boardID string) []Post {
 		var contentJSON sql.NullString
 		var tags sql.NullString
 		var attachments sql.NullString
-		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.CreatedAt); err != nil {
-			return nil
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Pinned, &p.PinnedAt, &p.CreatedAt); err != nil {
+			return nil, 0, err
 		}
 		p.ContentJSON = strings.TrimSpace(contentJSON.String)
 		p.Tags = decodeTags(tags.String)
 		p.Attachments = decodeAttachmentIDs(attachments.String)
 		out = append(out, p)
 	}
-	return out
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// PostsAfterCursor pages the feed by seq: it returns up to limit live posts
+// whose seq is below the cursor post's (newest first; an empty cursor starts
+// from the newest post) and the cursor for the next page, empty at the end.
+// Unlike offset paging, posts inserted mid-scroll can't shift the window.
+// Board feeds lead with their pinned posts, most recently pinned first.
+func (s *sqlStore) PostsAfterCursor(boardID, cursor string, limit int) ([]Post, string) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, pinned, pinned_at, created_at
+		 FROM posts
+		 WHERE (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published'`
+	args := []any{}
+	if cursor = strings.TrimSpace(cursor); cursor != "" {
+		var (
+			seq      int64
+			pinned   bool
+			pinnedAt string
+		)
+		err := s.db.QueryRow(`SELECT seq, pinned, pinned_at FROM posts WHERE id = ?;`, cursor).Scan(&seq, &pinned, &pinnedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return []Post{}, ""
+		}
+		if err != nil {
+			return nil, ""
+		}
+		switch {
+		case boardID == "":
+			query += ` AND seq < ?`
+			args = append(args, seq)
+		case pinned:
+			// Still inside the pinned block: the rest of it, then every unpinned post.
+			query += ` AND ((pinned = ? AND (pinned_at < ? OR (pinned_at = ? AND seq < ?))) OR pinned = ?)`
+			args = append(args, true, pinnedAt, pinnedAt, seq, false)
+		default:
+			query += ` AND pinned = ? AND seq < ?`
+			args = append(args, false, seq)
+		}
+	}
+	order := `seq DESC`
+	if boardID != "" {
+		query += ` AND board_id = ?`
+		args = append(args, boardID)
+		order = `pinned DESC, pinned_at DESC, seq DESC`
+	}
+	// Fetch one extra row to learn whether another page exists.
+	query += ` ORDER BY ` + order + ` LIMIT ?;`
+	args = append(args, limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, ""
+	}
+	defer rows.Close()
+
+	out := make([]Post, 0, limit+1)
+	for rows.Next() {
+		var p Post
+		var contentJSON sql.NullString
+		var tags sql.NullString
+		var attachments sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Pinned, &p.PinnedAt, &p.CreatedAt); err != nil {
+			return nil, ""
+		}
+		p.ContentJSON = strings.TrimSpace(contentJSON.String)
+		p.Tags = decodeTags(tags.String)
+		p.Attachments = decodeAttachmentIDs(attachments.String)
+		out = append(out, p)
+	}
+	if len(out) <= limit {
+		return out, ""
+	}
+	out = out[:limit]
+	return out, out[limit-1].ID
+}
+
+// GetPost returns a live, published post by ID.
+func (s *sqlStore) GetPost(postID string) (Post, bool) {
+	return s.getPost(postID, false)
+}
+
+// GetPostIncludingDeleted returns a post by ID even if it has been soft-deleted
+// or is still a draft.
+func (s *sqlStore) GetPostIncludingDeleted(postID string) (Post, bool) {
+	return s.getPost(postID, true)
 }
 
-func (s *SQLiteStore) GetPost(postID string) (Post, bool) {
+func (s *sqlStore) getPost(postID string, includeDeleted bool) (Post, bool) {
+	query := `SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, pinned, pinned_at, version, created_at, deleted_at, deleted_reason
+		 FROM posts
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published';`
+	if includeDeleted {
+		query = `SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, pinned, pinned_at, version, created_at, deleted_at, deleted_reason
+		 FROM posts
+		 WHERE id = ?;`
+	}
+
 	var post Post
 	var deletedAt sql.NullString
 	var contentJSON sql.NullString
 	var tags sql.NullString
 	var attachments sql.NullString
-	err := s.db.QueryRow(
-		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, created_at, deleted_at
-		 FROM posts
-		 WHERE id = ?
-		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
-		postID,
-	).Scan(&post.ID, &post.BoardID, &post.AuthorID, &post.Title, &post.Content, &contentJSON, &tags, &attachments, &post.ViewCount, &post.CreatedAt, &deletedAt)
+	err := s.db.QueryRow(query, postID).Scan(&post.ID, &post.BoardID, &post.AuthorID, &post.Title, &post.Content, &contentJSON, &tags, &attachments, &post.ViewCount, &post.Status, &post.Pinned, &post.PinnedAt, &post.Version, &post.CreatedAt, &deletedAt, &post.DeletedReason)
 	if err != nil {
 		return Post{}, false
 	}
@@ -989,7 +2042,7 @@ func (s *SQLiteStore) GetPost(postID string) (Post, bool) {
 	return post, true
 }
 
-func (s *SQLiteStore) IncrementPostViewCount(postID string) error {
+func (s *sqlStore) IncrementPostViewCount(postID string) error {
 	trimmed := strings.TrimSpace(postID)
 	if trimmed == "" {
 		return ErrInvalidInput
@@ -1009,7 +2062,7 @@ func (s *SQLiteStore) IncrementPostViewCount(postID string) error {
 	return err
 }
 
-func (s *SQLiteStore) CreatePost(boardID, authorID, title, content, contentJSON string, tags, attachments []string) Post {
+func (s *sqlStore) CreatePost(boardID, authorID, title, content, contentJSON, status string, tags, attachments []string) Post {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return Post{}
@@ -1031,12 +2084,14 @@ func (s *SQLiteStore) CreatePost(boardID, authorID, title, content, contentJSON
 		Tags:        tags,
 		Attachments: attachments,
 		ViewCount:   0,
+		Status:      normalizePostStatus(status),
+		Version:     1,
 		CreatedAt:   nowRFC3339(),
 	}
 
 	if _, err := tx.Exec(
-		`INSERT INTO posts(seq, id, board_id, author_id, title, content, content_json, tags, attachments, view_count, created_at, deleted_at)
-		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL);`,
+		`INSERT INTO posts(seq, id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, created_at, deleted_at)
+		 VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL);`,
 		seq,
 		post.ID,
 		post.BoardID,
@@ -1047,10 +2102,16 @@ func (s *SQLiteStore) CreatePost(boardID, authorID, title, content, contentJSON
 		encodeTags(post.Tags),
 		encodeAttachmentIDs(post.Attachments),
 		0,
+		post.Status,
 		post.CreatedAt,
 	); err != nil {
 		return Post{}
 	}
+	if !post.IsDraft() {
+		if err := setPostTags(tx, post.ID, post.Tags); err != nil {
+			return Post{}
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		return Post{}
@@ -1058,7 +2119,100 @@ func (s *SQLiteStore) CreatePost(boardID, authorID, title, content, contentJSON
 	return post
 }
 
-func (s *SQLiteStore) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
+// DraftPosts returns authorID's live drafts, newest first.
+func (s *sqlStore) DraftPosts(authorID string) []Post {
+	rows, err := s.db.Query(
+		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, created_at
+		 FROM posts
+		 WHERE author_id = ?
+		   AND status = 'draft'
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq DESC;`,
+		authorID,
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := make([]Post, 0)
+	for rows.Next() {
+		var p Post
+		var contentJSON sql.NullString
+		var tags sql.NullString
+		var attachments sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Status, &p.CreatedAt); err != nil {
+			return nil
+		}
+		p.ContentJSON = strings.TrimSpace(contentJSON.String)
+		p.Tags = decodeTags(tags.String)
+		p.Attachments = decodeAttachmentIDs(attachments.String)
+		out = append(out, p)
+	}
+	return out
+}
+
+// PublishPost makes authorID's draft live. It takes a fresh seq and
+// created_at so the post lands at the head of the feed, and indexes its tags.
+func (s *sqlStore) PublishPost(postID, authorID string) (Post, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Post{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		currentAuthor string
+		status        string
+		deletedAt     sql.NullString
+	)
+	err = tx.QueryRow(`SELECT author_id, status, deleted_at FROM posts WHERE id = ?;`, postID).
+		Scan(&currentAuthor, &status, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Post{}, ErrNotFound
+	}
+	if err != nil {
+		return Post{}, err
+	}
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return Post{}, ErrNotFound
+	}
+	if currentAuthor != authorID {
+		return Post{}, ErrForbidden
+	}
+	if status != PostStatusDraft {
+		return Post{}, ErrInvalidTransition
+	}
+
+	seq, err := s.nextCounter(tx, "post")
+	if err != nil {
+		return Post{}, err
+	}
+	if _, err := tx.Exec(
+		`UPDATE posts SET status = ?, seq = ?, created_at = ? WHERE id = ?;`,
+		PostStatusPublished, seq, nowRFC3339(), postID,
+	); err != nil {
+		return Post{}, err
+	}
+	var tags sql.NullString
+	if err := tx.QueryRow(`SELECT tags FROM posts WHERE id = ?;`, postID).Scan(&tags); err != nil {
+		return Post{}, err
+	}
+	if err := setPostTags(tx, postID, decodeTags(tags.String)); err != nil {
+		return Post{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Post{}, err
+	}
+
+	post, ok := s.getPost(postID, false)
+	if !ok {
+		return Post{}, ErrNotFound
+	}
+	return post, nil
+}
+
+func (s *sqlStore) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -1074,53 +2228,153 @@ func (s *SQLiteStore) SoftDeletePost(postID, actorUserID string, isAdmin bool) e
 	if err != nil {
 		return err
 	}
-	if strings.TrimSpace(deletedAt.String) != "" {
-		return ErrNotFound
-	}
 	if !isAdmin && authorID != actorUserID {
 		return ErrForbidden
 	}
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return ErrAlreadyDeleted
+	}
 
-	if _, err := tx.Exec(`UPDATE posts SET deleted_at = ? WHERE id = ?;`, nowRFC3339(), postID); err != nil {
+	reason := ""
+	if authorID != actorUserID {
+		reason = ModeratorRemovalReason
+		if _, err := s.insertNotification(tx, authorID, actorUserID, NotificationContentRemoved, ReportTargetPost, postID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE posts SET deleted_at = ?, deleted_reason = ? WHERE id = ?;`, nowRFC3339(), reason, postID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM post_tags WHERE post_id = ?;`, postID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM post_votes WHERE post_id = ?;`, postID); err != nil {
 		return err
 	}
 	return tx.Commit()
 }
 
-func (s *SQLiteStore) Comments(postID string) []Comment {
-	rows, err := s.db.Query(
-		`SELECT id, post_id, parent_id, author_id, content, content_json, tags, attachments, floor, created_at
-		 FROM comments
-		 WHERE post_id = ?
-		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
-		 ORDER BY seq DESC;`,
-		postID,
+// UpdatePost replaces a live post's title, content and tags. Only the author
+// or an admin may edit, and only while the post is still at version; a stale
+// version yields ErrConflict so concurrent edits can't overwrite each other.
+func (s *sqlStore) UpdatePost(postID, actorUserID string, isAdmin bool, version int, title, content, contentJSON string, tags []string) (Post, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Post{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		authorID  string
+		status    string
+		deletedAt sql.NullString
 	)
+	err = tx.QueryRow(`SELECT author_id, status, deleted_at FROM posts WHERE id = ?;`, postID).
+		Scan(&authorID, &status, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Post{}, ErrNotFound
+	}
 	if err != nil {
-		return nil
+		return Post{}, err
+	}
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return Post{}, ErrNotFound
+	}
+	if !isAdmin && authorID != actorUserID {
+		return Post{}, ErrForbidden
 	}
-	defer rows.Close()
 
-	var out []Comment
-	for rows.Next() {
-		var c Comment
-		var parentID sql.NullString
-		var contentJSON sql.NullString
-		var tags sql.NullString
-		var attachments sql.NullString
-		if err := rows.Scan(&c.ID, &c.PostID, &parentID, &c.AuthorID, &c.Content, &contentJSON, &tags, &attachments, &c.Floor, &c.CreatedAt); err != nil {
-			return nil
+	res, err := tx.Exec(
+		`UPDATE posts
+		 SET title = ?, content = ?, content_json = ?, tags = ?, version = version + 1
+		 WHERE id = ? AND version = ?;`,
+		title, content, contentJSON, encodeTags(tags), postID, version,
+	)
+	if err != nil {
+		return Post{}, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return Post{}, err
+	} else if affected == 0 {
+		return Post{}, ErrConflict
+	}
+	if status != PostStatusDraft {
+		if err := setPostTags(tx, postID, tags); err != nil {
+			return Post{}, err
 		}
-		c.ParentID = strings.TrimSpace(parentID.String)
-		c.ContentJSON = strings.TrimSpace(contentJSON.String)
-		c.Tags = decodeTags(tags.String)
-		c.Attachments = decodeAttachmentIDs(attachments.String)
-		out = append(out, c)
 	}
-	return out
+	if err := tx.Commit(); err != nil {
+		return Post{}, err
+	}
+
+	post, ok := s.getPost(postID, true)
+	if !ok {
+		return Post{}, ErrNotFound
+	}
+	return post, nil
 }
 
-func (s *SQLiteStore) CommentCount(postID string) int {
+// SetPostPinned pins or unpins a live post at the top of its board. Only
+// admins may pin.
+func (s *sqlStore) SetPostPinned(postID string, pinned bool, isAdmin bool) error {
+	if !isAdmin {
+		return ErrForbidden
+	}
+	pinnedAt := ""
+	if pinned {
+		pinnedAt = nowRFC3339()
+	}
+	res, err := s.db.Exec(
+		`UPDATE posts SET pinned = ?, pinned_at = ?
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published';`,
+		pinned, pinnedAt, postID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *sqlStore) Comments(postID string) []Comment {
+	rows, err := s.db.Query(
+		`SELECT id, post_id, parent_id, author_id, content, content_json, tags, attachments, floor, created_at
+		 FROM comments
+		 WHERE post_id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq DESC;`,
+		postID,
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var out []Comment
+	for rows.Next() {
+		var c Comment
+		var parentID sql.NullString
+		var contentJSON sql.NullString
+		var tags sql.NullString
+		var attachments sql.NullString
+		if err := rows.Scan(&c.ID, &c.PostID, &parentID, &c.AuthorID, &c.Content, &contentJSON, &tags, &attachments, &c.Floor, &c.CreatedAt); err != nil {
+			return nil
+		}
+		c.ParentID = strings.TrimSpace(parentID.String)
+		c.ContentJSON = strings.TrimSpace(contentJSON.String)
+		c.Tags = decodeTags(tags.String)
+		c.Attachments = decodeAttachmentIDs(attachments.String)
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *sqlStore) CommentCount(postID string) int {
 	var count int
 	err := s.db.QueryRow(
 		`SELECT COUNT(1)
@@ -1135,7 +2389,103 @@ func (s *SQLiteStore) CommentCount(postID string) int {
 	return count
 }
 
-func (s *SQLiteStore) UserStats(userID string) (int, int, error) {
+func (s *sqlStore) CommentCounts(postIDs []string) map[string]int {
+	return s.countsByPostID(
+		`SELECT post_id, COUNT(1)
+		 FROM comments
+		 WHERE post_id IN (%s)
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 GROUP BY post_id;`,
+		nil, postIDs,
+	)
+}
+
+func (s *sqlStore) PostScores(postIDs []string) map[string]int {
+	return s.countsByPostID(
+		`SELECT post_id, COALESCE(SUM(value), 0)
+		 FROM post_votes
+		 WHERE post_id IN (%s)
+		 GROUP BY post_id;`,
+		nil, postIDs,
+	)
+}
+
+func (s *sqlStore) PostVoteBreakdown(postID string) (up, down int) {
+	breakdown := s.PostVoteBreakdowns([]string{postID})[postID]
+	return breakdown.Up, breakdown.Down
+}
+
+// PostVoteBreakdowns counts upvotes and downvotes separately, one query per
+// direction.
+func (s *sqlStore) PostVoteBreakdowns(postIDs []string) map[string]VoteBreakdown {
+	const query = `SELECT post_id, COUNT(1)
+		 FROM post_votes
+		 WHERE value = ? AND post_id IN (%s)
+		 GROUP BY post_id;`
+	out := map[string]VoteBreakdown{}
+	for postID, up := range s.countsByPostID(query, []any{1}, postIDs) {
+		out[postID] = VoteBreakdown{Up: up}
+	}
+	for postID, down := range s.countsByPostID(query, []any{-1}, postIDs) {
+		breakdown := out[postID]
+		breakdown.Down = down
+		out[postID] = breakdown
+	}
+	return out
+}
+
+func (s *sqlStore) PostVotes(postIDs []string, userID string) map[string]int {
+	if strings.TrimSpace(userID) == "" {
+		return map[string]int{}
+	}
+	return s.countsByPostID(
+		`SELECT post_id, value
+		 FROM post_votes
+		 WHERE user_id = ? AND post_id IN (%s);`,
+		[]any{userID}, postIDs,
+	)
+}
+
+// maxInClauseIDs bounds the IN (...) list per query, well under the bind
+// parameter limits of SQLite and PostgreSQL.
+const maxInClauseIDs = 500
+
+// countsByPostID runs query, whose %s is replaced by the IN placeholders, over
+// postIDs in chunks and collects (post_id, n) rows. args precede the IDs.
+func (s *sqlStore) countsByPostID(query string, args []any, postIDs []string) map[string]int {
+	out := map[string]int{}
+	seen := make(map[string]struct{}, len(postIDs))
+	ids := make([]any, 0, len(postIDs))
+	for _, id := range postIDs {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	for start := 0; start < len(ids); start += maxInClauseIDs {
+		chunk := ids[start:min(start+maxInClauseIDs, len(ids))]
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		rows, err := s.db.Query(fmt.Sprintf(query, placeholders), append(append([]any{}, args...), chunk...)...)
+		if err != nil {
+			return out
+		}
+		for rows.Next() {
+			var id string
+			var n int
+			if err := rows.Scan(&id, &n); err != nil {
+				rows.Close()
+				return out
+			}
+			out[id] = n
+		}
+		rows.Close()
+	}
+	return out
+}
+
+func (s *sqlStore) UserStats(userID string) (int, int, error) {
 	trimmed := strings.TrimSpace(userID)
 	if trimmed == "" {
 		return 0, 0, ErrInvalidInput
@@ -1146,7 +2496,8 @@ func (s *SQLiteStore) UserStats(userID string) (int, int, error) {
 		`SELECT COUNT(1)
 		 FROM posts
 		 WHERE author_id = ?
-		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published';`,
 		trimmed,
 	).Scan(&postsCount); err != nil {
 		return 0, 0, err
@@ -1168,7 +2519,7 @@ func (s *SQLiteStore) UserStats(userID string) (int, int, error) {
 	return postsCount, commentsCount, nil
 }
 
-func (s *SQLiteStore) GetComment(postID, commentID string) (Comment, bool) {
+func (s *sqlStore) GetComment(postID, commentID string) (Comment, bool) {
 	var comment Comment
 	var deletedAt sql.NullString
 	var parentID sql.NullString
@@ -1195,7 +2546,47 @@ func (s *SQLiteStore) GetComment(postID, commentID string) (Comment, bool) {
 	return comment, true
 }
 
-func (s *SQLiteStore) CreateComment(postID, authorID, content, contentJSON, parentID string, tags, attachments []string) Comment {
+// GetCommentByID returns a live comment by ID whatever post it is under.
+func (s *sqlStore) GetCommentByID(commentID string) (Comment, bool) {
+	return s.getCommentByID(commentID, false)
+}
+
+// GetCommentIncludingDeleted returns a comment by ID even if it has been
+// soft-deleted, with its DeletedAt and DeletedReason.
+func (s *sqlStore) GetCommentIncludingDeleted(commentID string) (Comment, bool) {
+	return s.getCommentByID(commentID, true)
+}
+
+func (s *sqlStore) getCommentByID(commentID string, includeDeleted bool) (Comment, bool) {
+	query := `SELECT id, post_id, parent_id, author_id, content, content_json, tags, attachments, floor, created_at, deleted_at, deleted_reason
+		 FROM comments
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`
+	if includeDeleted {
+		query = `SELECT id, post_id, parent_id, author_id, content, content_json, tags, attachments, floor, created_at, deleted_at, deleted_reason
+		 FROM comments
+		 WHERE id = ?;`
+	}
+
+	var comment Comment
+	var deletedAt sql.NullString
+	var parentID sql.NullString
+	var contentJSON sql.NullString
+	var tags sql.NullString
+	var attachments sql.NullString
+	err := s.db.QueryRow(query, commentID).Scan(&comment.ID, &comment.PostID, &parentID, &comment.AuthorID, &comment.Content, &contentJSON, &tags, &attachments, &comment.Floor, &comment.CreatedAt, &deletedAt, &comment.DeletedReason)
+	if err != nil {
+		return Comment{}, false
+	}
+	comment.ParentID = strings.TrimSpace(parentID.String)
+	comment.ContentJSON = strings.TrimSpace(contentJSON.String)
+	comment.Tags = decodeTags(tags.String)
+	comment.Attachments = decodeAttachmentIDs(attachments.String)
+	comment.DeletedAt = strings.TrimSpace(deletedAt.String)
+	return comment, true
+}
+
+func (s *sqlStore) CreateComment(postID, authorID, content, contentJSON, parentID string, tags, attachments []string) Comment {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return Comment{}
@@ -1260,7 +2651,7 @@ func (s *SQLiteStore) CreateComment(postID, authorID, content, contentJSON, pare
 	return comment
 }
 
-func (s *SQLiteStore) SoftDeleteComment(postID, commentID, actorUserID string, isAdmin bool) error {
+func (s *sqlStore) SoftDeleteComment(postID, commentID, actorUserID string, isAdmin bool) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -1282,25 +2673,36 @@ func (s *SQLiteStore) SoftDeleteComment(postID, commentID, actorUserID string, i
 	if err != nil {
 		return err
 	}
-	if strings.TrimSpace(deletedAt.String) != "" {
-		return ErrNotFound
-	}
 	if !isAdmin && authorID != actorUserID {
 		return ErrForbidden
 	}
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return ErrAlreadyDeleted
+	}
 
+	reason := ""
+	if authorID != actorUserID {
+		reason = ModeratorRemovalReason
+		if _, err := s.insertNotification(tx, authorID, actorUserID, NotificationContentRemoved, ReportTargetComment, commentID); err != nil {
+			return err
+		}
+	}
 	if _, err := tx.Exec(
-		`UPDATE comments SET deleted_at = ? WHERE post_id = ? AND id = ?;`,
+		`UPDATE comments SET deleted_at = ?, deleted_reason = ? WHERE post_id = ? AND id = ?;`,
 		nowRFC3339(),
+		reason,
 		postID,
 		commentID,
 	); err != nil {
 		return err
 	}
+	if _, err := tx.Exec(`DELETE FROM comment_votes WHERE comment_id = ?;`, commentID); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
-func (s *SQLiteStore) PostScore(postID string) int {
+func (s *sqlStore) PostScore(postID string) int {
 	var score int
 	err := s.db.QueryRow(
 		`SELECT COALESCE(SUM(value), 0)
@@ -1314,7 +2716,7 @@ func (s *SQLiteStore) PostScore(postID string) int {
 	return score
 }
 
-func (s *SQLiteStore) PostVote(postID, userID string) int {
+func (s *sqlStore) PostVote(postID, userID string) int {
 	if strings.TrimSpace(userID) == "" {
 		return 0
 	}
@@ -1335,55 +2737,161 @@ func (s *SQLiteStore) PostVote(postID, userID string) int {
 	return value
 }
 
-func (s *SQLiteStore) VotePost(postID, userID string, value int) (int, int, error) {
+// PostVoteTime returns when userID last set their vote on postID, or "" if
+// they haven't voted.
+func (s *sqlStore) PostVoteTime(postID, userID string) string {
+	if strings.TrimSpace(userID) == "" {
+		return ""
+	}
+	var createdAt string
+	if err := s.db.QueryRow(
+		`SELECT created_at
+		 FROM post_votes
+		 WHERE post_id = ? AND user_id = ?;`,
+		postID,
+		userID,
+	).Scan(&createdAt); err != nil {
+		return ""
+	}
+	return createdAt
+}
+
+func (s *sqlStore) VotePost(postID, userID string, value int) (int, int, error) {
 	if value != 1 && value != -1 {
 		return 0, 0, ErrInvalidInput
 	}
+	return s.SetPostVote(postID, userID, value)
+}
+
+func (s *sqlStore) ClearPostVote(postID, userID string) (int, int, error) {
+	return s.SetPostVote(postID, userID, 0)
+}
+
+func (s *sqlStore) SetPostVote(postID, userID string, value int) (int, int, error) {
+	if value < -1 || value > 1 {
+		return 0, 0, ErrInvalidInput
+	}
 	if strings.TrimSpace(userID) == "" {
 		return 0, 0, ErrInvalidInput
 	}
-	if _, ok := s.GetPost(postID); !ok {
-		return 0, 0, ErrNotFound
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	if _, err := s.db.Exec(
-		`INSERT INTO post_votes (post_id, user_id, value, created_at)
-		 VALUES (?, ?, ?, ?)
-		 ON CONFLICT(post_id, user_id)
-		 DO UPDATE SET value = excluded.value, created_at = excluded.created_at;`,
+	var authorID string
+	err = tx.QueryRow(
+		`SELECT author_id FROM posts WHERE id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '') AND status = 'published';`,
 		postID,
-		userID,
-		value,
-		nowRFC3339(),
-	); err != nil {
+	).Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrNotFound
+	}
+	if err != nil {
 		return 0, 0, err
 	}
 
-	score := s.PostScore(postID)
+	if value == 0 {
+		_, err = tx.Exec(`DELETE FROM post_votes WHERE post_id = ? AND user_id = ?;`, postID, userID)
+	} else {
+		_, err = tx.Exec(
+			`INSERT INTO post_votes (post_id, user_id, value, created_at)
+			 VALUES (?, ?, ?, ?)
+			 ON CONFLICT(post_id, user_id)
+			 DO UPDATE SET value = excluded.value, created_at = excluded.created_at;`,
+			postID,
+			userID,
+			value,
+			nowRFC3339(),
+		)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The author earns exp for the first upvote from each voter only, so
+	// clearing and re-voting can't farm it.
+	if value == 1 && authorID != userID {
+		res, err := tx.Exec(
+			`INSERT INTO upvote_awards(post_id, voter_id, created_at) VALUES(?, ?, ?)
+			 ON CONFLICT(post_id, voter_id) DO NOTHING;`,
+			postID, userID, nowRFC3339(),
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return 0, 0, err
+		} else if affected == 1 {
+			amount, _ := ExpForReason(ExpReasonUpvoteReceived)
+			if _, err := tx.Exec(`UPDATE users SET exp = exp + ? WHERE id = ?;`, amount, authorID); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	var score int
+	if err := tx.QueryRow(
+		`SELECT COALESCE(SUM(value), 0) FROM post_votes WHERE post_id = ?;`,
+		postID,
+	).Scan(&score); err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
 	return score, value, nil
 }
 
-func (s *SQLiteStore) ClearPostVote(postID, userID string) (int, int, error) {
-	if strings.TrimSpace(userID) == "" {
-		return 0, 0, ErrInvalidInput
+// PostVoters returns the users whose vote on postID equals value, most recent
+// vote first, along with the total count.
+func (s *sqlStore) PostVoters(postID string, value, offset, limit int) ([]User, int) {
+	if offset < 0 {
+		offset = 0
 	}
-	if _, ok := s.GetPost(postID); !ok {
-		return 0, 0, ErrNotFound
+	if limit <= 0 {
+		limit = 20
 	}
 
-	if _, err := s.db.Exec(
-		`DELETE FROM post_votes WHERE post_id = ? AND user_id = ?;`,
-		postID,
-		userID,
-	); err != nil {
-		return 0, 0, err
+	var total int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(1)
+		 FROM post_votes v
+		 JOIN users u ON u.id = v.user_id
+		 WHERE v.post_id = ? AND v.value = ?;`,
+		postID, value,
+	).Scan(&total); err != nil {
+		return nil, 0
+	}
+
+	rows, err := s.db.Query(
+		`SELECT u.id, u.nickname, u.created_at, u.avatar, u.cover, u.bio, u.exp
+		 FROM post_votes v
+		 JOIN users u ON u.id = v.user_id
+		 WHERE v.post_id = ? AND v.value = ?
+		 ORDER BY v.created_at DESC, u.id
+		 LIMIT ? OFFSET ?;`,
+		postID, value, limit, offset,
+	)
+	if err != nil {
+		return nil, 0
 	}
+	defer rows.Close()
 
-	score := s.PostScore(postID)
-	return score, 0, nil
+	out := make([]User, 0, limit)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Nickname, &user.CreatedAt, &user.Avatar, &user.Cover, &user.Bio, &user.Exp); err != nil {
+			return nil, 0
+		}
+		out = append(out, user)
+	}
+	return out, total
 }
 
-func (s *SQLiteStore) CommentScore(postID, commentID string) int {
+func (s *sqlStore) CommentScore(postID, commentID string) int {
 	var score int
 	err := s.db.QueryRow(
 		`SELECT COALESCE(SUM(value), 0)
@@ -1398,7 +2906,7 @@ func (s *SQLiteStore) CommentScore(postID, commentID string) int {
 	return score
 }
 
-func (s *SQLiteStore) CommentVote(postID, commentID, userID string) int {
+func (s *sqlStore) CommentVote(postID, commentID, userID string) int {
 	if strings.TrimSpace(userID) == "" {
 		return 0
 	}
@@ -1420,57 +2928,87 @@ func (s *SQLiteStore) CommentVote(postID, commentID, userID string) int {
 	return value
 }
 
-func (s *SQLiteStore) VoteComment(postID, commentID, userID string, value int) (int, int, error) {
+func (s *sqlStore) VoteComment(postID, commentID, userID string, value int) (int, int, error) {
 	if value != 1 && value != -1 {
 		return 0, 0, ErrInvalidInput
 	}
+	return s.SetCommentVote(postID, commentID, userID, value)
+}
+
+func (s *sqlStore) ClearCommentVote(postID, commentID, userID string) (int, int, error) {
+	return s.SetCommentVote(postID, commentID, userID, 0)
+}
+
+func (s *sqlStore) SetCommentVote(postID, commentID, userID string, value int) (int, int, error) {
+	if value < -1 || value > 1 {
+		return 0, 0, ErrInvalidInput
+	}
 	if strings.TrimSpace(userID) == "" {
 		return 0, 0, ErrInvalidInput
 	}
-	if _, ok := s.GetComment(postID, commentID); !ok {
-		return 0, 0, ErrNotFound
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	if _, err := s.db.Exec(
-		`INSERT INTO comment_votes (comment_id, post_id, user_id, value, created_at)
-		 VALUES (?, ?, ?, ?, ?)
-		 ON CONFLICT(comment_id, user_id)
-		 DO UPDATE SET value = excluded.value, post_id = excluded.post_id, created_at = excluded.created_at;`,
-		commentID,
+	var exists int
+	err = tx.QueryRow(
+		`SELECT 1
+		 FROM comments
+		 WHERE post_id = ?
+		   AND id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
 		postID,
-		userID,
-		value,
-		nowRFC3339(),
-	); err != nil {
+		commentID,
+	).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, ErrNotFound
+	}
+	if err != nil {
 		return 0, 0, err
 	}
 
-	score := s.CommentScore(postID, commentID)
-	return score, value, nil
-}
-
-func (s *SQLiteStore) ClearCommentVote(postID, commentID, userID string) (int, int, error) {
-	if strings.TrimSpace(userID) == "" {
-		return 0, 0, ErrInvalidInput
+	if value == 0 {
+		_, err = tx.Exec(
+			`DELETE FROM comment_votes WHERE post_id = ? AND comment_id = ? AND user_id = ?;`,
+			postID,
+			commentID,
+			userID,
+		)
+	} else {
+		_, err = tx.Exec(
+			`INSERT INTO comment_votes (comment_id, post_id, user_id, value, created_at)
+			 VALUES (?, ?, ?, ?, ?)
+			 ON CONFLICT(comment_id, user_id)
+			 DO UPDATE SET value = excluded.value, post_id = excluded.post_id, created_at = excluded.created_at;`,
+			commentID,
+			postID,
+			userID,
+			value,
+			nowRFC3339(),
+		)
 	}
-	if _, ok := s.GetComment(postID, commentID); !ok {
-		return 0, 0, ErrNotFound
+	if err != nil {
+		return 0, 0, err
 	}
 
-	if _, err := s.db.Exec(
-		`DELETE FROM comment_votes WHERE post_id = ? AND comment_id = ? AND user_id = ?;`,
+	var score int
+	if err := tx.QueryRow(
+		`SELECT COALESCE(SUM(value), 0) FROM comment_votes WHERE post_id = ? AND comment_id = ?;`,
 		postID,
 		commentID,
-		userID,
-	); err != nil {
+	).Scan(&score); err != nil {
 		return 0, 0, err
 	}
-
-	score := s.CommentScore(postID, commentID)
-	return score, 0, nil
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return score, value, nil
 }
 
-func (s *SQLiteStore) SaveFile(uploaderID, filename, storageKey, storagePath string, width, height int) FileMeta {
+func (s *sqlStore) SaveFile(uploaderID, filename, storageKey, storagePath string, width, height int) FileMeta {
 	tx, err := s.db.Begin()
 	if err != nil {
 		log.Printf("[SaveFile] failed to begin transaction: %v", err)
@@ -1512,121 +3050,438 @@ func (s *SQLiteStore) SaveFile(uploaderID, filename, storageKey, storagePath str
 		return FileMeta{}
 	}
 
-	if err := tx.Commit(); err != nil {
-		log.Printf("[SaveFile] failed to commit: %v", err)
-		return FileMeta{}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[SaveFile] failed to commit: %v", err)
+		return FileMeta{}
+	}
+	log.Printf("[SaveFile] saved file: id=%s, path=%s", file.ID, file.StoragePath)
+	return file
+}
+
+func (s *sqlStore) GetFile(fileID string) (FileMeta, bool) {
+	var file FileMeta
+	err := s.db.QueryRow(
+		`SELECT id, uploader_id, filename, storage_key, storage_path, width, height, created_at
+		 FROM files
+		 WHERE id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		fileID,
+	).Scan(&file.ID, &file.UploaderID, &file.Filename, &file.StorageKey, &file.StoragePath, &file.Width, &file.Height, &file.CreatedAt)
+	if err != nil {
+		return FileMeta{}, false
+	}
+	return file, true
+}
+
+func (s *sqlStore) IsFileDeleted(fileID string) bool {
+	var deletedAt sql.NullString
+	err := s.db.QueryRow(`SELECT deleted_at FROM files WHERE id = ?;`, fileID).Scan(&deletedAt)
+	return err == nil && strings.TrimSpace(deletedAt.String) != ""
+}
+
+func (s *sqlStore) SoftDeleteFile(fileID, actorUserID string, isAdmin bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var uploaderID string
+	var deletedAt sql.NullString
+	err = tx.QueryRow(`SELECT uploader_id, deleted_at FROM files WHERE id = ?;`, fileID).Scan(&uploaderID, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return ErrNotFound
+	}
+	if !isAdmin && uploaderID != actorUserID {
+		return ErrForbidden
+	}
+
+	if _, err := tx.Exec(`UPDATE files SET deleted_at = ? WHERE id = ?;`, nowRFC3339(), fileID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) AddMessage(roomID, senderID, content string) ChatMessage {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ChatMessage{}
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	seq, err := s.nextCounter(tx, "message")
+	if err != nil {
+		return ChatMessage{}
+	}
+
+	message := ChatMessage{
+		ID:        fmt.Sprintf("m_%d", seq),
+		RoomID:    roomID,
+		SenderID:  senderID,
+		Content:   content,
+		CreatedAt: nowRFC3339(),
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO messages(seq, id, room_id, sender_id, content, created_at)
+		 VALUES(?, ?, ?, ?, ?, ?);`,
+		seq,
+		message.ID,
+		message.RoomID,
+		message.SenderID,
+		message.Content,
+		message.CreatedAt,
+	); err != nil {
+		return ChatMessage{}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ChatMessage{}
+	}
+	return message
+}
+
+func (s *sqlStore) Messages(roomID string, limit int) []ChatMessage {
+	if strings.TrimSpace(roomID) == "" {
+		return nil
+	}
+
+	query := `SELECT id, room_id, sender_id, content, created_at, edited_at
+			  FROM messages
+			  WHERE room_id = ?
+			    AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+			  ORDER BY seq ASC;`
+	args := []any{roomID}
+
+	reverse := false
+	if limit > 0 {
+		query = `SELECT id, room_id, sender_id, content, created_at, edited_at
+				 FROM messages
+				 WHERE room_id = ?
+				   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+				 ORDER BY seq DESC
+				 LIMIT ?;`
+		args = []any{roomID, limit}
+		reverse = true
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := make([]ChatMessage, 0, max(limit, 0))
+	for rows.Next() {
+		var m ChatMessage
+		var editedAt sql.NullString
+		if err := rows.Scan(&m.ID, &m.RoomID, &m.SenderID, &m.Content, &m.CreatedAt, &editedAt); err != nil {
+			return nil
+		}
+		m.EditedAt = editedAt.String
+		out = append(out, m)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	if reverse {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out
+}
+
+func (s *sqlStore) OpenDMRoom(userID, peerID string) (string, error) {
+	if userID == "" || peerID == "" || userID == peerID {
+		return "", ErrInvalidInput
+	}
+	userA, userB := userID, peerID
+	if userB < userA {
+		userA, userB = userB, userA
+	}
+	roomID := dmRoomID(userA, userB)
+	if _, err := s.db.Exec(
+		`INSERT INTO dm_rooms(room_id, user_a, user_b, created_at)
+		 VALUES(?, ?, ?, ?)
+		 ON CONFLICT(room_id) DO NOTHING;`,
+		roomID, userA, userB, nowRFC3339(),
+	); err != nil {
+		return "", err
+	}
+	return roomID, nil
+}
+
+func (s *sqlStore) DMRoomMembers(roomID string) (string, string, bool) {
+	var userA, userB string
+	err := s.db.QueryRow(`SELECT user_a, user_b FROM dm_rooms WHERE room_id = ?;`, roomID).Scan(&userA, &userB)
+	if err != nil {
+		return "", "", false
+	}
+	return userA, userB, true
+}
+
+func (s *sqlStore) Conversations(userID string) []Conversation {
+	rows, err := s.db.Query(
+		`SELECT d.room_id, d.user_a, d.user_b, d.created_at, m.id, m.sender_id, m.content, m.created_at, m.edited_at,
+		        (SELECT COUNT(*)
+		           FROM messages u
+		          WHERE u.room_id = d.room_id
+		            AND u.sender_id <> ?
+		            AND (u.deleted_at IS NULL OR TRIM(u.deleted_at) = '')
+		            AND u.seq > COALESCE(
+		                (SELECT r.last_read_seq FROM chat_reads r WHERE r.user_id = ? AND r.room_id = d.room_id), 0))
+		 FROM dm_rooms d
+		 LEFT JOIN messages m
+		   ON m.room_id = d.room_id
+		  AND m.seq = (SELECT MAX(seq) FROM messages
+		                WHERE room_id = d.room_id
+		                  AND (deleted_at IS NULL OR TRIM(deleted_at) = ''))
+		 WHERE d.user_a = ? OR d.user_b = ?;`,
+		userID, userID, userID, userID,
+	)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := make([]Conversation, 0)
+	for rows.Next() {
+		var conv Conversation
+		var userA, userB string
+		var msgID, senderID, content, sentAt, editedAt sql.NullString
+		if err := rows.Scan(&conv.RoomID, &userA, &userB, &conv.CreatedAt, &msgID, &senderID, &content, &sentAt, &editedAt, &conv.Unread); err != nil {
+			return nil
+		}
+		conv.PeerID = userA
+		if userA == userID {
+			conv.PeerID = userB
+		}
+		if msgID.Valid {
+			conv.LastMessage = ChatMessage{
+				ID:        msgID.String,
+				RoomID:    conv.RoomID,
+				SenderID:  senderID.String,
+				Content:   content.String,
+				CreatedAt: sentAt.String,
+				EditedAt:  editedAt.String,
+			}
+		}
+		out = append(out, conv)
+	}
+	sortConversations(out)
+	return out
+}
+
+// UnreadMessageCount counts, in one query, the messages Conversations would
+// report as unread across all of userID's rooms.
+func (s *sqlStore) UnreadMessageCount(userID string) int {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*)
+		 FROM messages u
+		 JOIN dm_rooms d ON d.room_id = u.room_id
+		 LEFT JOIN chat_reads r ON r.user_id = ? AND r.room_id = u.room_id
+		 WHERE (d.user_a = ? OR d.user_b = ?)
+		   AND u.sender_id <> ?
+		   AND (u.deleted_at IS NULL OR TRIM(u.deleted_at) = '')
+		   AND u.seq > COALESCE(r.last_read_seq, 0);`,
+		userID, userID, userID, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0
 	}
-	log.Printf("[SaveFile] saved file: id=%s, path=%s", file.ID, file.StoragePath)
-	return file
+	return count
 }
 
-func (s *SQLiteStore) GetFile(fileID string) (FileMeta, bool) {
-	var file FileMeta
-	err := s.db.QueryRow(
-		`SELECT id, uploader_id, filename, storage_key, storage_path, width, height, created_at
-		 FROM files
-		 WHERE id = ?;`,
-		fileID,
-	).Scan(&file.ID, &file.UploaderID, &file.Filename, &file.StorageKey, &file.StoragePath, &file.Width, &file.Height, &file.CreatedAt)
+func (s *sqlStore) MarkRoomRead(userID, roomID string, seq string) error {
+	value, ok := messageSeq(seq)
+	if userID == "" || roomID == "" || !ok {
+		return ErrInvalidInput
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO chat_reads(user_id, room_id, last_read_seq)
+		 VALUES(?, ?, ?)
+		 ON CONFLICT(user_id, room_id) DO UPDATE SET last_read_seq =
+		   CASE WHEN excluded.last_read_seq > chat_reads.last_read_seq
+		        THEN excluded.last_read_seq ELSE chat_reads.last_read_seq END;`,
+		userID, roomID, value,
+	)
+	return err
+}
+
+// messageForUpdate loads a live message inside tx and checks that senderID
+// sent it.
+func messageForUpdate(tx *sqlTx, messageID, senderID string) (ChatMessage, error) {
+	var m ChatMessage
+	var editedAt, deletedAt sql.NullString
+	err := tx.QueryRow(
+		`SELECT id, room_id, sender_id, content, created_at, edited_at, deleted_at
+		 FROM messages WHERE id = ?;`,
+		messageID,
+	).Scan(&m.ID, &m.RoomID, &m.SenderID, &m.Content, &m.CreatedAt, &editedAt, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ChatMessage{}, ErrNotFound
+	}
 	if err != nil {
-		return FileMeta{}, false
+		return ChatMessage{}, err
 	}
-	return file, true
+	if strings.TrimSpace(deletedAt.String) != "" {
+		return ChatMessage{}, ErrNotFound
+	}
+	if m.SenderID != senderID {
+		return ChatMessage{}, ErrForbidden
+	}
+	m.EditedAt = editedAt.String
+	return m, nil
 }
 
-func (s *SQLiteStore) AddMessage(roomID, senderID, content string) ChatMessage {
+func (s *sqlStore) EditMessage(messageID, senderID, content string) (ChatMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ChatMessage{}, ErrInvalidInput
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
-		return ChatMessage{}
+		return ChatMessage{}, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	seq, err := s.nextCounter(tx, "message")
+	message, err := messageForUpdate(tx, messageID, senderID)
 	if err != nil {
-		return ChatMessage{}
+		return ChatMessage{}, err
 	}
-
-	message := ChatMessage{
-		ID:        fmt.Sprintf("m_%d", seq),
-		RoomID:    roomID,
-		SenderID:  senderID,
-		Content:   content,
-		CreatedAt: nowRFC3339(),
+	if editWindowPassed(message.CreatedAt) {
+		return ChatMessage{}, ErrEditWindowExpired
 	}
-
+	message.Content = content
+	message.EditedAt = nowRFC3339()
 	if _, err := tx.Exec(
-		`INSERT INTO messages(seq, id, room_id, sender_id, content, created_at)
-		 VALUES(?, ?, ?, ?, ?, ?);`,
-		seq,
-		message.ID,
-		message.RoomID,
-		message.SenderID,
-		message.Content,
-		message.CreatedAt,
+		`UPDATE messages SET content = ?, edited_at = ? WHERE id = ?;`,
+		message.Content, message.EditedAt, message.ID,
 	); err != nil {
-		return ChatMessage{}
+		return ChatMessage{}, err
 	}
-
 	if err := tx.Commit(); err != nil {
-		return ChatMessage{}
+		return ChatMessage{}, err
 	}
-	return message
+	return message, nil
 }
 
-func (s *SQLiteStore) Messages(roomID string, limit int) []ChatMessage {
-	if strings.TrimSpace(roomID) == "" {
-		return nil
+func (s *sqlStore) DeleteMessage(messageID, senderID string) (ChatMessage, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return ChatMessage{}, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	query := `SELECT id, room_id, sender_id, content, created_at
-			  FROM messages
-			  WHERE room_id = ?
-			  ORDER BY seq ASC;`
-	args := []any{roomID}
-
-	reverse := false
-	if limit > 0 {
-		query = `SELECT id, room_id, sender_id, content, created_at
-				 FROM messages
-				 WHERE room_id = ?
-				 ORDER BY seq DESC
-				 LIMIT ?;`
-		args = []any{roomID, limit}
-		reverse = true
+	message, err := messageForUpdate(tx, messageID, senderID)
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	message.DeletedAt = nowRFC3339()
+	if _, err := tx.Exec(`UPDATE messages SET deleted_at = ? WHERE id = ?;`, message.DeletedAt, message.ID); err != nil {
+		return ChatMessage{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return ChatMessage{}, err
 	}
+	return message, nil
+}
 
-	rows, err := s.db.Query(query, args...)
+// MessageRooms lists every room that has stored messages, deleted ones
+// included.
+func (s *sqlStore) MessageRooms() []string {
+	rows, err := s.db.Query(`SELECT DISTINCT room_id FROM messages ORDER BY room_id;`)
 	if err != nil {
 		return nil
 	}
 	defer rows.Close()
 
-	out := make([]ChatMessage, 0, max(limit, 0))
+	var rooms []string
 	for rows.Next() {
-		var m ChatMessage
-		if err := rows.Scan(&m.ID, &m.RoomID, &m.SenderID, &m.Content, &m.CreatedAt); err != nil {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
 			return nil
 		}
-		out = append(out, m)
+		rooms = append(rooms, roomID)
 	}
-	if len(out) == 0 {
-		return nil
+	return rooms
+}
+
+// PruneMessages permanently removes roomID's history older than its newest
+// keepLast live messages, deleted messages in that range included, and
+// returns how many it removed. Read cursors left behind the pruned range are
+// moved up to its last message so they never point below the history.
+func (s *sqlStore) PruneMessages(roomID string, keepLast int) (int, error) {
+	if keepLast < 1 {
+		return 0, ErrInvalidInput
 	}
 
-	if reverse {
-		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
-			out[i], out[j] = out[j], out[i]
-		}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
 	}
-	return out
+	defer func() { _ = tx.Rollback() }()
+
+	// The oldest message to keep, then the newest one before it.
+	var keepFrom int64
+	err = tx.QueryRow(
+		`SELECT seq FROM messages
+		 WHERE room_id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq DESC
+		 LIMIT 1 OFFSET ?;`,
+		roomID, keepLast-1,
+	).Scan(&keepFrom)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var boundary sql.NullInt64
+	if err := tx.QueryRow(`SELECT MAX(seq) FROM messages WHERE room_id = ? AND seq < ?;`, roomID, keepFrom).Scan(&boundary); err != nil {
+		return 0, err
+	}
+	if !boundary.Valid {
+		return 0, nil
+	}
+
+	result, err := tx.Exec(`DELETE FROM messages WHERE room_id = ? AND seq <= ?;`, roomID, boundary.Int64)
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(
+		`UPDATE chat_reads SET last_read_seq = ? WHERE room_id = ? AND last_read_seq < ?;`,
+		boundary.Int64, roomID, boundary.Int64,
+	); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(pruned), nil
 }
 
-func (s *SQLiteStore) CreateReport(reporterID, targetType, targetID, reason, detail string) (Report, error) {
+func (s *sqlStore) CreateReport(reporterID, targetType, targetID, reason, detail string) (Report, error) {
 	trimmedType := strings.TrimSpace(targetType)
 	trimmedID := strings.TrimSpace(targetID)
 	trimmedReason := strings.TrimSpace(reason)
 	trimmedDetail := strings.TrimSpace(detail)
-	if trimmedType == "" || trimmedID == "" || trimmedReason == "" {
+	if !ValidReportTarget(trimmedType) || trimmedID == "" || trimmedReason == "" {
 		return Report{}, ErrInvalidInput
 	}
 
@@ -1640,32 +3495,38 @@ func (s *SQLiteStore) CreateReport(reporterID, targetType, targetID, reason, det
 	if err != nil {
 		return Report{}, err
 	}
+	snapshot, err := reportSnapshotTx(tx, trimmedType, trimmedID)
+	if err != nil {
+		return Report{}, err
+	}
 
 	now := nowRFC3339()
 	report := Report{
-		ID:         fmt.Sprintf("r_%d", seq),
-		TargetType: trimmedType,
-		TargetID:   trimmedID,
-		ReporterID: reporterID,
-		Reason:     trimmedReason,
-		Detail:     trimmedDetail,
-		Status:     "open",
-		Action:     "",
-		Note:       "",
-		HandledBy:  "",
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:             fmt.Sprintf("r_%d", seq),
+		TargetType:     trimmedType,
+		TargetID:       trimmedID,
+		TargetSnapshot: snapshot,
+		ReporterID:     reporterID,
+		Reason:         trimmedReason,
+		Detail:         trimmedDetail,
+		Status:         ReportStatusOpen,
+		Action:         "",
+		Note:           "",
+		HandledBy:      "",
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
 	if _, err := tx.Exec(
 		`INSERT INTO reports(
-			seq, id, target_type, target_id, reporter_id, reason, detail,
+			seq, id, target_type, target_id, target_snapshot, reporter_id, reason, detail,
 			status, action, note, handled_by, created_at, updated_at
-		) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		seq,
 		report.ID,
 		report.TargetType,
 		report.TargetID,
+		report.TargetSnapshot,
 		report.ReporterID,
 		report.Reason,
 		report.Detail,
@@ -1685,7 +3546,7 @@ func (s *SQLiteStore) CreateReport(reporterID, targetType, targetID, reason, det
 	return report, nil
 }
 
-func (s *SQLiteStore) Reports(status string, page, pageSize int) ([]Report, int, error) {
+func (s *sqlStore) Reports(status string, page, pageSize int) ([]Report, int, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -1712,7 +3573,7 @@ func (s *SQLiteStore) Reports(status string, page, pageSize int) ([]Report, int,
 	var rows *sql.Rows
 	if trimmed == "" {
 		rows, err = s.db.Query(
-			`SELECT id, target_type, target_id, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
+			`SELECT id, target_type, target_id, target_snapshot, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
 			 FROM reports
 			 ORDER BY seq DESC
 			 LIMIT ? OFFSET ?;`,
@@ -1721,7 +3582,7 @@ func (s *SQLiteStore) Reports(status string, page, pageSize int) ([]Report, int,
 		)
 	} else {
 		rows, err = s.db.Query(
-			`SELECT id, target_type, target_id, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
+			`SELECT id, target_type, target_id, target_snapshot, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
 			 FROM reports
 			 WHERE status = ?
 			 ORDER BY seq DESC
@@ -1743,6 +3604,7 @@ func (s *SQLiteStore) Reports(status string, page, pageSize int) ([]Report, int,
 			&r.ID,
 			&r.TargetType,
 			&r.TargetID,
+			&r.TargetSnapshot,
 			&r.ReporterID,
 			&r.Reason,
 			&r.Detail,
@@ -1760,10 +3622,10 @@ func (s *SQLiteStore) Reports(status string, page, pageSize int) ([]Report, int,
 	return out, total, nil
 }
 
-func (s *SQLiteStore) UpdateReport(reportID, status, action, note, handledBy string) (Report, error) {
+func (s *sqlStore) UpdateReport(reportID, status, action, note, handledBy string) (Report, error) {
 	trimmedID := strings.TrimSpace(reportID)
 	trimmedStatus := strings.TrimSpace(status)
-	if trimmedID == "" || trimmedStatus == "" {
+	if trimmedID == "" || !validReportStatus(trimmedStatus) {
 		return Report{}, ErrInvalidInput
 	}
 
@@ -1773,29 +3635,13 @@ func (s *SQLiteStore) UpdateReport(reportID, status, action, note, handledBy str
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	now := nowRFC3339()
-	res, err := tx.Exec(
-		`UPDATE reports
-		 SET status = ?, action = ?, note = ?, handled_by = ?, updated_at = ?
-		 WHERE id = ?;`,
-		trimmedStatus,
-		strings.TrimSpace(action),
-		strings.TrimSpace(note),
-		strings.TrimSpace(handledBy),
-		now,
-		trimmedID,
-	)
-	if err != nil {
+	if err := s.updateReportTx(tx, trimmedID, trimmedStatus, action, note, handledBy); err != nil {
 		return Report{}, err
 	}
-	affected, err := res.RowsAffected()
-	if err == nil && affected == 0 {
-		return Report{}, ErrNotFound
-	}
 
 	var r Report
 	if err := tx.QueryRow(
-		`SELECT id, target_type, target_id, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
+		`SELECT id, target_type, target_id, target_snapshot, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
 		 FROM reports
 		 WHERE id = ?;`,
 		trimmedID,
@@ -1803,6 +3649,7 @@ func (s *SQLiteStore) UpdateReport(reportID, status, action, note, handledBy str
 		&r.ID,
 		&r.TargetType,
 		&r.TargetID,
+		&r.TargetSnapshot,
 		&r.ReporterID,
 		&r.Reason,
 		&r.Detail,
@@ -1825,19 +3672,308 @@ func (s *SQLiteStore) UpdateReport(reportID, status, action, note, handledBy str
 	return r, nil
 }
 
-func (s *SQLiteStore) FollowUser(followerID, followeeID string) error {
+// UpdateReports applies one status change to several reports in a single
+// transaction and returns how many were updated. Unknown IDs and reports the
+// transition is not allowed from are skipped.
+func (s *sqlStore) UpdateReports(reportIDs []string, status, action, note, handledBy string) (int, error) {
+	trimmedStatus := strings.TrimSpace(status)
+	if !validReportStatus(trimmedStatus) {
+		return 0, ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	updated := 0
+	seen := make(map[string]bool, len(reportIDs))
+	for _, reportID := range reportIDs {
+		trimmedID := strings.TrimSpace(reportID)
+		if trimmedID == "" || seen[trimmedID] {
+			continue
+		}
+		seen[trimmedID] = true
+		err := s.updateReportTx(tx, trimmedID, trimmedStatus, action, note, handledBy)
+		switch err {
+		case nil:
+			updated++
+		case ErrNotFound, ErrInvalidTransition:
+		default:
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// updateReportTx moves one report to status, applies its action and records
+// the history event.
+func (s *sqlStore) updateReportTx(tx *sqlTx, reportID, status, action, note, handledBy string) error {
+	var previous, targetType, targetID, reportReason string
+	if err := tx.QueryRow(`SELECT status, target_type, target_id, reason FROM reports WHERE id = ?;`, reportID).
+		Scan(&previous, &targetType, &targetID, &reportReason); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := checkReportTransition(previous, status); err != nil {
+		return err
+	}
+
+	event := ReportEvent{
+		ReportID:   reportID,
+		FromStatus: previous,
+		ToStatus:   status,
+		Action:     strings.TrimSpace(action),
+		Note:       strings.TrimSpace(note),
+		HandledBy:  strings.TrimSpace(handledBy),
+		CreatedAt:  nowRFC3339(),
+	}
+	// The status guard turns a concurrent transition into a conflict instead
+	// of a lost update. It runs first so a transition that loses the race
+	// never removes the target.
+	res, err := tx.Exec(
+		`UPDATE reports
+		 SET status = ?, action = ?, note = ?, handled_by = ?, updated_at = ?
+		 WHERE id = ? AND status = ?;`,
+		event.ToStatus,
+		event.Action,
+		event.Note,
+		event.HandledBy,
+		event.CreatedAt,
+		reportID,
+		previous,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrInvalidTransition
+	}
+	if removesTarget(event.FromStatus, event.ToStatus, event.Action) {
+		reason := removalReason(reportReason, event.Note)
+		remark, err := s.removeReportTarget(tx, targetType, targetID, event.CreatedAt, reason, event.HandledBy)
+		if err != nil {
+			return err
+		}
+		if remark != "" {
+			event.Note = annotateReportNote(event.Note, remark)
+			if _, err := tx.Exec(`UPDATE reports SET note = ? WHERE id = ?;`, event.Note, reportID); err != nil {
+				return err
+			}
+		}
+	}
+
+	seq, err := s.nextCounter(tx, "report_event")
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO report_events(
+			seq, report_id, from_status, to_status, action, note, handled_by, created_at
+		) VALUES(?, ?, ?, ?, ?, ?, ?, ?);`,
+		seq,
+		event.ReportID,
+		event.FromStatus,
+		event.ToStatus,
+		event.Action,
+		event.Note,
+		event.HandledBy,
+		event.CreatedAt,
+	)
+	return err
+}
+
+func (s *sqlStore) ReportStats() (map[string]int, error) {
+	stats := map[string]int{
+		ReportStatsTotal:     0,
+		ReportStatsOpenStale: 0,
+	}
+
+	for _, group := range []struct {
+		column string
+		prefix string
+	}{
+		{column: "status", prefix: ReportStatsStatusPrefix},
+		{column: "reason", prefix: ReportStatsReasonPrefix},
+	} {
+		rows, err := s.db.Query(`SELECT ` + group.column + `, COUNT(1) FROM reports GROUP BY ` + group.column + `;`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			stats[group.prefix+key] = count
+			if group.column == "status" {
+				stats[ReportStatsTotal] += count
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	var stale int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(1) FROM reports WHERE status = ? AND created_at < ?;`,
+		ReportStatusOpen,
+		reportStaleCutoff(),
+	).Scan(&stale); err != nil {
+		return nil, err
+	}
+	stats[ReportStatsOpenStale] = stale
+	return stats, nil
+}
+
+// removeReportTarget soft-deletes a reported post, comment or file inside the
+// moderation transaction, bypassing the author check. Removed posts and
+// comments record reason and their author is notified on behalf of
+// moderatorID. It returns a remark for the report note when the target could
+// not be removed.
+func (s *sqlStore) removeReportTarget(tx *sqlTx, targetType, targetID, deletedAt, reason, moderatorID string) (string, error) {
+	var query string
+	args := []any{deletedAt, reason, targetID}
+	switch targetType {
+	case ReportTargetPost:
+		query = `UPDATE posts SET deleted_at = ?, deleted_reason = ? WHERE id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`
+	case ReportTargetComment:
+		query = `UPDATE comments SET deleted_at = ?, deleted_reason = ? WHERE id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`
+	case ReportTargetFile:
+		query = `UPDATE files SET deleted_at = ? WHERE id = ? AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`
+		args = []any{deletedAt, targetID}
+	default:
+		return "remove skipped: unsupported target_type " + targetType, nil
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return "", err
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return "remove skipped: target not found or already deleted", nil
+	}
+	switch targetType {
+	case ReportTargetPost:
+		if _, err := tx.Exec(`DELETE FROM post_tags WHERE post_id = ?;`, targetID); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(`DELETE FROM post_votes WHERE post_id = ?;`, targetID); err != nil {
+			return "", err
+		}
+	case ReportTargetComment:
+		if _, err := tx.Exec(`DELETE FROM comment_votes WHERE comment_id = ?;`, targetID); err != nil {
+			return "", err
+		}
+	default:
+		return "", nil
+	}
+	var authorID string
+	if err := tx.QueryRow(`SELECT author_id FROM `+targetType+`s WHERE id = ?;`, targetID).Scan(&authorID); err != nil {
+		return "", err
+	}
+	if _, err := s.insertNotification(tx, authorID, moderatorID, NotificationContentRemoved, targetType, targetID); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// reportSnapshotTx captures the reported user, post or comment as it looks
+// now. A missing target or another target type yields an empty snapshot.
+func reportSnapshotTx(tx *sqlTx, targetType, targetID string) (string, error) {
+	var (
+		fields map[string]string
+		err    error
+	)
+	switch targetType {
+	case ReportTargetUser:
+		var nickname, bio string
+		err = tx.QueryRow(`SELECT nickname, bio FROM users WHERE id = ?;`, targetID).Scan(&nickname, &bio)
+		fields = map[string]string{"nickname": nickname, "bio": bio}
+	case ReportTargetPost:
+		var authorID, title, content string
+		err = tx.QueryRow(`SELECT author_id, title, content FROM posts WHERE id = ?;`, targetID).Scan(&authorID, &title, &content)
+		fields = map[string]string{"author_id": authorID, "title": title, "content": content}
+	case ReportTargetComment:
+		var authorID, content string
+		err = tx.QueryRow(`SELECT author_id, content FROM comments WHERE id = ?;`, targetID).Scan(&authorID, &content)
+		fields = map[string]string{"author_id": authorID, "content": content}
+	default:
+		return "", nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return marshalReportSnapshot(fields), nil
+}
+
+// ReportEvents returns a report's status history, oldest first.
+func (s *sqlStore) ReportEvents(reportID string) ([]ReportEvent, error) {
+	trimmedID := strings.TrimSpace(reportID)
+	var existing string
+	if err := s.db.QueryRow(`SELECT id FROM reports WHERE id = ?;`, trimmedID).Scan(&existing); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := s.db.Query(
+		`SELECT report_id, from_status, to_status, action, note, handled_by, created_at
+		 FROM report_events
+		 WHERE report_id = ?
+		 ORDER BY seq ASC;`,
+		trimmedID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ReportEvent, 0)
+	for rows.Next() {
+		var e ReportEvent
+		if err := rows.Scan(&e.ReportID, &e.FromStatus, &e.ToStatus, &e.Action, &e.Note, &e.HandledBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+func (s *sqlStore) FollowUser(followerID, followeeID string) error {
 	if followerID == followeeID {
 		return ErrInvalidInput
 	}
+	if s.IsBlocked(followeeID, followerID) {
+		return ErrForbidden
+	}
 
 	_, err := s.db.Exec(
-		`INSERT OR IGNORE INTO follows (follower_id, followee_id, created_at) VALUES (?, ?, ?);`,
+		`INSERT INTO follows (follower_id, followee_id, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT(follower_id, followee_id) DO NOTHING;`,
 		followerID, followeeID, nowRFC3339(),
 	)
 	return err
 }
 
-func (s *SQLiteStore) UnfollowUser(followerID, followeeID string) error {
+func (s *sqlStore) UnfollowUser(followerID, followeeID string) error {
 	_, err := s.db.Exec(
 		`DELETE FROM follows WHERE follower_id = ? AND followee_id = ?;`,
 		followerID, followeeID,
@@ -1845,7 +3981,73 @@ func (s *SQLiteStore) UnfollowUser(followerID, followeeID string) error {
 	return err
 }
 
-func (s *SQLiteStore) IsFollowing(followerID, followeeID string) bool {
+func (s *sqlStore) BlockUser(blockerID, blockedID string) error {
+	if blockerID == blockedID {
+		return ErrInvalidInput
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM users WHERE id IN (?, ?);`, blockerID, blockedID).Scan(&count); err != nil {
+		return err
+	}
+	if count != 2 {
+		return ErrNotFound
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO blocks (blocker_id, blocked_id, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT(blocker_id, blocked_id) DO NOTHING;`,
+		blockerID, blockedID, nowRFC3339(),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM follows WHERE follower_id = ? AND followee_id = ?;`, blockedID, blockerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) UnblockUser(blockerID, blockedID string) error {
+	_, err := s.db.Exec(
+		`DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?;`,
+		blockerID, blockedID,
+	)
+	return err
+}
+
+func (s *sqlStore) IsBlocked(blockerID, blockedID string) bool {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(1) FROM blocks WHERE blocker_id = ? AND blocked_id = ?;`,
+		blockerID, blockedID,
+	).Scan(&count)
+	return err == nil && count > 0
+}
+
+func (s *sqlStore) BlockedIDs(userID string) []string {
+	rows, err := s.db.Query(`SELECT blocked_id FROM blocks WHERE blocker_id = ? ORDER BY blocked_id;`, userID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return ids
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *sqlStore) IsFollowing(followerID, followeeID string) bool {
 	var count int
 	err := s.db.QueryRow(
 		`SELECT COUNT(1) FROM follows WHERE follower_id = ? AND followee_id = ?;`,
@@ -1854,7 +4056,41 @@ func (s *SQLiteStore) IsFollowing(followerID, followeeID string) bool {
 	return err == nil && count > 0
 }
 
-func (s *SQLiteStore) GetFollowCounts(userID string) (int, int) {
+// FollowingAmong reports which of userIDs followerID follows; only followed
+// IDs are present in the result.
+func (s *sqlStore) FollowingAmong(followerID string, userIDs []string) map[string]bool {
+	out := map[string]bool{}
+	args := []any{followerID}
+	for _, id := range userIDs {
+		if id != "" {
+			args = append(args, id)
+		}
+	}
+	if followerID == "" || len(args) == 1 {
+		return out
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(args)-1), ",")
+	rows, err := s.db.Query(
+		`SELECT followee_id FROM follows WHERE follower_id = ? AND followee_id IN (`+placeholders+`);`,
+		args...,
+	)
+	if err != nil {
+		return out
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return out
+		}
+		out[id] = true
+	}
+	return out
+}
+
+func (s *sqlStore) GetFollowCounts(userID string) (int, int) {
 	var followers int
 	_ = s.db.QueryRow(`SELECT COUNT(1) FROM follows WHERE followee_id = ?;`, userID).Scan(&followers)
 
@@ -1864,7 +4100,7 @@ func (s *SQLiteStore) GetFollowCounts(userID string) (int, int) {
 	return followers, following
 }
 
-func (s *SQLiteStore) Followers(userID string, offset, limit int) ([]User, int) {
+func (s *sqlStore) Followers(userID string, offset, limit int) ([]User, int) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -1902,7 +4138,7 @@ func (s *SQLiteStore) Followers(userID string, offset, limit int) ([]User, int)
 	return out, total
 }
 
-func (s *SQLiteStore) Following(userID string, offset, limit int) ([]User, int) {
+func (s *sqlStore) Following(userID string, offset, limit int) ([]User, int) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -1940,7 +4176,7 @@ func (s *SQLiteStore) Following(userID string, offset, limit int) ([]User, int)
 	return out, total
 }
 
-func (s *SQLiteStore) UserComments(userID string, offset, limit int) ([]Comment, int) {
+func (s *sqlStore) UserComments(userID string, offset, limit int) ([]Comment, int) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -2007,6 +4243,138 @@ func (s *SQLiteStore) UserComments(userID string, offset, limit int) ([]Comment,
 	return out, total
 }
 
+// ExportUserData hands userID's data to emit section by section, each row as
+// it is scanned. Soft-deleted posts, comments and files are left out. An
+// error from emit stops the export.
+func (s *sqlStore) ExportUserData(userID string, emit func(section string, item any) error) error {
+	user, ok := s.GetUser(userID)
+	if !ok {
+		return ErrNotFound
+	}
+	profile := ExportedProfile{User: user}
+	err := s.db.QueryRow(`SELECT account FROM accounts WHERE user_id = ?;`, userID).Scan(&profile.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := emit(ExportProfile, profile); err != nil {
+		return err
+	}
+
+	if err := s.exportRows(
+		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, pinned, pinned_at, version, created_at
+		 FROM posts
+		 WHERE author_id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq ASC;`,
+		userID,
+		func(rows *sql.Rows) error {
+			var p Post
+			var contentJSON, tags, attachments sql.NullString
+			if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Status, &p.Pinned, &p.PinnedAt, &p.Version, &p.CreatedAt); err != nil {
+				return err
+			}
+			p.ContentJSON = strings.TrimSpace(contentJSON.String)
+			p.Tags = decodeTags(tags.String)
+			p.Attachments = decodeAttachmentIDs(attachments.String)
+			return emit(ExportPosts, p)
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := s.exportRows(
+		`SELECT id, post_id, parent_id, author_id, content, content_json, tags, attachments, floor, created_at
+		 FROM comments
+		 WHERE author_id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq ASC;`,
+		userID,
+		func(rows *sql.Rows) error {
+			var c Comment
+			var parentID, contentJSON, tags, attachments sql.NullString
+			if err := rows.Scan(&c.ID, &c.PostID, &parentID, &c.AuthorID, &c.Content, &contentJSON, &tags, &attachments, &c.Floor, &c.CreatedAt); err != nil {
+				return err
+			}
+			c.ParentID = strings.TrimSpace(parentID.String)
+			c.ContentJSON = strings.TrimSpace(contentJSON.String)
+			c.Tags = decodeTags(tags.String)
+			c.Attachments = decodeAttachmentIDs(attachments.String)
+			return emit(ExportComments, c)
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := s.exportRows(
+		`SELECT id, uploader_id, filename, storage_key, storage_path, width, height, created_at
+		 FROM files
+		 WHERE uploader_id = ?
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 ORDER BY seq ASC;`,
+		userID,
+		func(rows *sql.Rows) error {
+			var f FileMeta
+			if err := rows.Scan(&f.ID, &f.UploaderID, &f.Filename, &f.StorageKey, &f.StoragePath, &f.Width, &f.Height, &f.CreatedAt); err != nil {
+				return err
+			}
+			return emit(ExportFiles, f)
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := s.exportRows(
+		`SELECT u.id, u.nickname, u.created_at, u.avatar, u.cover, u.bio, u.exp
+		 FROM follows f
+		 JOIN users u ON u.id = f.followee_id
+		 WHERE f.follower_id = ?
+		 ORDER BY u.seq ASC;`,
+		userID,
+		func(rows *sql.Rows) error {
+			var u User
+			if err := rows.Scan(&u.ID, &u.Nickname, &u.CreatedAt, &u.Avatar, &u.Cover, &u.Bio, &u.Exp); err != nil {
+				return err
+			}
+			return emit(ExportFollowing, u)
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := s.exportRows(
+		`SELECT id, target_type, target_id, reporter_id, reason, detail, status, action, note, handled_by, created_at, updated_at
+		 FROM reports
+		 WHERE reporter_id = ?
+		 ORDER BY seq ASC;`,
+		userID,
+		func(rows *sql.Rows) error {
+			var r Report
+			if err := rows.Scan(&r.ID, &r.TargetType, &r.TargetID, &r.ReporterID, &r.Reason, &r.Detail, &r.Status, &r.Action, &r.Note, &r.HandledBy, &r.CreatedAt, &r.UpdatedAt); err != nil {
+				return err
+			}
+			return emit(ExportReports, r)
+		},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// exportRows runs a per-user export query and hands each row to scan.
+func (s *sqlStore) exportRows(query, userID string, scan func(*sql.Rows) error) error {
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -2014,55 +4382,282 @@ func max(a, b int) int {
 	return b
 }
 
-// SearchPosts searches posts by title or content using LIKE.
-func (s *SQLiteStore) SearchPosts(keyword string, offset, limit int) ([]Post, int) {
-	keyword = strings.TrimSpace(keyword)
-	if keyword == "" {
-		return nil, 0
-	}
-	if offset < 0 {
-		offset = 0
+// SearchPosts searches posts by title or content using LIKE.
+func (s *sqlStore) SearchPosts(keyword string, offset, limit int) ([]PostSearchResult, int) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	pattern := "%" + keyword + "%"
+
+	// Get total count
+	var total int
+	if err := s.db.QueryRow(
+		`SELECT COUNT(1)
+		 FROM posts
+		 WHERE (LOWER(title) LIKE LOWER(?) OR LOWER(content) LIKE LOWER(?))
+		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published';`,
+		pattern, pattern,
+	).Scan(&total); err != nil {
+		return nil, 0
+	}
+
+	// Get paginated results with author, score and comment count in one query
+	rows, err := s.db.Query(
+		`SELECT p.id, p.board_id, p.author_id, p.title, p.content, p.content_json, p.tags, p.attachments, p.view_count, p.pinned, p.pinned_at, p.created_at,
+		        u.id, u.nickname, u.avatar, u.exp,
+		        COALESCE((SELECT SUM(v.value) FROM post_votes v WHERE v.post_id = p.id), 0),
+		        (SELECT COUNT(1) FROM comments c
+		          WHERE c.post_id = p.id
+		            AND (c.deleted_at IS NULL OR TRIM(c.deleted_at) = ''))
+		 FROM posts p
+		 LEFT JOIN users u ON u.id = p.author_id
+		 WHERE (LOWER(p.title) LIKE LOWER(?) OR LOWER(p.content) LIKE LOWER(?))
+		   AND (p.deleted_at IS NULL OR TRIM(p.deleted_at) = '')
+		   AND p.status = 'published'
+		 ORDER BY p.created_at DESC, p.seq DESC
+		 LIMIT ? OFFSET ?;`,
+		pattern, pattern, limit, offset,
+	)
+	if err != nil {
+		return nil, 0
+	}
+	defer rows.Close()
+
+	out := make([]PostSearchResult, 0, limit)
+	for rows.Next() {
+		var r PostSearchResult
+		var contentJSON sql.NullString
+		var tags sql.NullString
+		var attachments sql.NullString
+		var authorID, nickname, avatar sql.NullString
+		var exp sql.NullInt64
+		if err := rows.Scan(
+			&r.ID, &r.BoardID, &r.AuthorID, &r.Title, &r.Content, &contentJSON, &tags, &attachments, &r.ViewCount, &r.Pinned, &r.PinnedAt, &r.CreatedAt,
+			&authorID, &nickname, &avatar, &exp,
+			&r.Score, &r.CommentCount,
+		); err != nil {
+			return nil, 0
+		}
+		r.ContentJSON = strings.TrimSpace(contentJSON.String)
+		r.Tags = decodeTags(tags.String)
+		r.Attachments = decodeAttachmentIDs(attachments.String)
+		if authorID.Valid {
+			r.Author = User{ID: authorID.String, Nickname: nickname.String, Avatar: avatar.String, Exp: int(exp.Int64)}
+		} else {
+			r.Author = DeletedUser()
+		}
+		out = append(out, r)
+	}
+	return out, total
+}
+
+// PostsByTag pages through live posts carrying tag, matched case-insensitively.
+func (s *sqlStore) PostsByTag(tag string, offset, limit int) ([]Post, int) {
+	normalized := normalizeTag(tag)
+	if normalized == "" {
+		return nil, 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM post_tags WHERE tag = ?;`, normalized).Scan(&total); err != nil {
+		return nil, 0
+	}
+
+	rows, err := s.db.Query(
+		`SELECT p.id, p.board_id, p.author_id, p.title, p.content, p.content_json, p.tags, p.attachments, p.view_count, p.pinned, p.pinned_at, p.created_at
+		 FROM post_tags t
+		 JOIN posts p ON p.id = t.post_id
+		 WHERE t.tag = ?
+		 ORDER BY p.created_at DESC, p.seq DESC
+		 LIMIT ? OFFSET ?;`,
+		normalized, limit, offset,
+	)
+	if err != nil {
+		return nil, 0
+	}
+	defer rows.Close()
+
+	out := make([]Post, 0, limit)
+	for rows.Next() {
+		var p Post
+		var contentJSON sql.NullString
+		var tags sql.NullString
+		var attachments sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Pinned, &p.PinnedAt, &p.CreatedAt); err != nil {
+			return nil, 0
+		}
+		p.ContentJSON = strings.TrimSpace(contentJSON.String)
+		p.Tags = decodeTags(tags.String)
+		p.Attachments = decodeAttachmentIDs(attachments.String)
+		out = append(out, p)
+	}
+	return out, total
+}
+
+// AdminPosts pages through published posts, deleted ones included, with the
+// filtering and paging done in SQL.
+func (s *sqlStore) AdminPosts(filter AdminPostFilter) ([]Post, int) {
+	filter = filter.normalized()
+	where := []string{"status = 'published'"}
+	args := []any{}
+	if filter.BoardID != "" {
+		where = append(where, "board_id = ?")
+		args = append(args, filter.BoardID)
+	}
+	if filter.AuthorID != "" {
+		where = append(where, "author_id = ?")
+		args = append(args, filter.AuthorID)
+	}
+	if filter.DeletedOnly {
+		where = append(where, "deleted_at IS NOT NULL AND TRIM(deleted_at) <> ''")
+	}
+	clause := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM posts WHERE `+clause+`;`, args...).Scan(&total); err != nil {
+		return nil, 0
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, pinned, pinned_at, version, created_at, deleted_at, deleted_reason
+		 FROM posts
+		 WHERE `+clause+`
+		 ORDER BY created_at DESC, seq DESC
+		 LIMIT ? OFFSET ?;`,
+		append(args, filter.Limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0
+	}
+	defer rows.Close()
+
+	out := make([]Post, 0, filter.Limit)
+	for rows.Next() {
+		var p Post
+		var contentJSON, tags, attachments, deletedAt sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Status, &p.Pinned, &p.PinnedAt, &p.Version, &p.CreatedAt, &deletedAt, &p.DeletedReason); err != nil {
+			return nil, 0
+		}
+		p.ContentJSON = strings.TrimSpace(contentJSON.String)
+		p.Tags = decodeTags(tags.String)
+		p.Attachments = decodeAttachmentIDs(attachments.String)
+		p.DeletedAt = strings.TrimSpace(deletedAt.String)
+		out = append(out, p)
+	}
+	return out, total
+}
+
+// SubscribeBoard adds a live board to the user's feed. Subscribing twice is
+// not an error.
+func (s *sqlStore) SubscribeBoard(userID, boardID string) error {
+	if _, ok := s.GetUser(userID); !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.GetBoard(boardID); !ok {
+		return ErrNotFound
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO board_subscriptions (user_id, board_id, created_at) VALUES (?, ?, ?)
+		 ON CONFLICT(user_id, board_id) DO NOTHING;`,
+		userID, boardID, nowRFC3339(),
+	)
+	return err
+}
+
+func (s *sqlStore) UnsubscribeBoard(userID, boardID string) error {
+	_, err := s.db.Exec(
+		`DELETE FROM board_subscriptions WHERE user_id = ? AND board_id = ?;`,
+		userID, boardID,
+	)
+	return err
+}
+
+// SubscribedBoards returns the IDs of the live boards the user subscribes to,
+// in board order.
+func (s *sqlStore) SubscribedBoards(userID string) []string {
+	rows, err := s.db.Query(
+		`SELECT b.id
+		 FROM board_subscriptions bs
+		 JOIN boards b ON b.id = bs.board_id
+		 WHERE bs.user_id = ?
+		   AND (b.deleted_at IS NULL OR TRIM(b.deleted_at) = '')
+		 ORDER BY b.seq ASC;`,
+		userID,
+	)
+	if err != nil {
+		return nil
 	}
-	if limit <= 0 {
-		limit = 20
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil
+		}
+		ids = append(ids, id)
 	}
+	return ids
+}
 
-	pattern := "%" + keyword + "%"
+// FeedPosts pages through published posts by users the viewer follows and
+// in boards they subscribe to, newest first, leaving out blocked authors.
+func (s *sqlStore) FeedPosts(userID string, filter FeedFilter) ([]Post, int) {
+	filter = filter.normalized()
+	sources := []string{}
+	args := []any{}
+	if filter.Source != FeedBoards {
+		sources = append(sources, "author_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)")
+		args = append(args, userID)
+	}
+	if filter.Source != FeedFollowing {
+		sources = append(sources, "board_id IN (SELECT board_id FROM board_subscriptions WHERE user_id = ?)")
+		args = append(args, userID)
+	}
+	clause := `(deleted_at IS NULL OR TRIM(deleted_at) = '')
+		   AND status = 'published'
+		   AND author_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)
+		   AND (` + strings.Join(sources, " OR ") + `)`
+	args = append([]any{userID}, args...)
 
-	// Get total count
 	var total int
-	if err := s.db.QueryRow(
-		`SELECT COUNT(1)
-		 FROM posts
-		 WHERE (title LIKE ? OR content LIKE ?)
-		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
-		pattern, pattern,
-	).Scan(&total); err != nil {
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM posts WHERE `+clause+`;`, args...).Scan(&total); err != nil {
 		return nil, 0
 	}
 
-	// Get paginated results
 	rows, err := s.db.Query(
-		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, created_at
+		`SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, pinned, pinned_at, created_at
 		 FROM posts
-		 WHERE (title LIKE ? OR content LIKE ?)
-		   AND (deleted_at IS NULL OR TRIM(deleted_at) = '')
+		 WHERE `+clause+`
 		 ORDER BY created_at DESC, seq DESC
 		 LIMIT ? OFFSET ?;`,
-		pattern, pattern, limit, offset,
+		append(args, filter.Limit, filter.Offset)...,
 	)
 	if err != nil {
 		return nil, 0
 	}
 	defer rows.Close()
 
-	out := make([]Post, 0, limit)
+	out := make([]Post, 0, filter.Limit)
 	for rows.Next() {
 		var p Post
-		var contentJSON sql.NullString
-		var tags sql.NullString
-		var attachments sql.NullString
-		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.CreatedAt); err != nil {
+		var contentJSON, tags, attachments sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Pinned, &p.PinnedAt, &p.CreatedAt); err != nil {
 			return nil, 0
 		}
 		p.ContentJSON = strings.TrimSpace(contentJSON.String)
@@ -2073,8 +4668,108 @@ func (s *SQLiteStore) SearchPosts(keyword string, offset, limit int) ([]Post, in
 	return out, total
 }
 
+// PopularTags returns the most used tags across live posts; ties are broken
+// alphabetically. limit <= 0 returns every tag.
+func (s *sqlStore) PopularTags(limit int) []TagCount {
+	query := `SELECT tag, COUNT(1) AS uses FROM post_tags GROUP BY tag ORDER BY uses DESC, tag ASC`
+	args := []any{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query+`;`, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil
+		}
+		out = append(out, tc)
+	}
+	return out
+}
+
+// TrendingPosts ranks live posts created since the cutoff by their votes,
+// comments and views; ties go to the newer post. limit <= 0 returns every
+// candidate.
+func (s *sqlStore) TrendingPosts(since time.Time, limit int) []Post {
+	query := `SELECT id, board_id, author_id, title, content, content_json, tags, attachments, view_count, status, pinned, pinned_at, version, created_at
+		 FROM (
+			SELECT p.*,
+			       COALESCE((SELECT SUM(v.value) FROM post_votes v WHERE v.post_id = p.id), 0) AS votes,
+			       (SELECT COUNT(1) FROM comments c
+			         WHERE c.post_id = p.id
+			           AND (c.deleted_at IS NULL OR TRIM(c.deleted_at) = '')) AS comment_count
+			FROM posts p
+			WHERE (p.deleted_at IS NULL OR TRIM(p.deleted_at) = '')
+			  AND p.status = 'published'
+			  AND p.created_at >= ?
+		 ) t
+		 ORDER BY t.votes * ? + t.comment_count * ? + t.view_count * ? DESC, t.seq DESC`
+	args := []any{trendingCutoff(since), trendingVoteWeight, trendingCommentWeight, trendingViewWeight}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query+`;`, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := make([]Post, 0)
+	for rows.Next() {
+		var p Post
+		var contentJSON, tags, attachments sql.NullString
+		if err := rows.Scan(&p.ID, &p.BoardID, &p.AuthorID, &p.Title, &p.Content, &contentJSON, &tags, &attachments, &p.ViewCount, &p.Status, &p.Pinned, &p.PinnedAt, &p.Version, &p.CreatedAt); err != nil {
+			return nil
+		}
+		p.ContentJSON = strings.TrimSpace(contentJSON.String)
+		p.Tags = decodeTags(tags.String)
+		p.Attachments = decodeAttachmentIDs(attachments.String)
+		out = append(out, p)
+	}
+	return out
+}
+
+// TrendingTags counts tags over live posts created since the cutoff, most
+// used first with ties broken alphabetically.
+func (s *sqlStore) TrendingTags(since time.Time, limit int) []TagCount {
+	query := `SELECT t.tag, COUNT(1) AS uses
+		 FROM post_tags t
+		 JOIN posts p ON p.id = t.post_id
+		 WHERE p.created_at >= ?
+		 GROUP BY t.tag
+		 ORDER BY uses DESC, t.tag ASC`
+	args := []any{trendingCutoff(since)}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query+`;`, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	out := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil
+		}
+		out = append(out, tc)
+	}
+	return out
+}
+
 // SearchUsers searches users by nickname using LIKE.
-func (s *SQLiteStore) SearchUsers(keyword string, offset, limit int) ([]User, int) {
+func (s *sqlStore) SearchUsers(keyword string, offset, limit int) ([]User, int) {
 	keyword = strings.TrimSpace(keyword)
 	if keyword == "" {
 		return nil, 0
@@ -2091,7 +4786,7 @@ func (s *SQLiteStore) SearchUsers(keyword string, offset, limit int) ([]User, in
 	// Get total count
 	var total int
 	if err := s.db.QueryRow(
-		`SELECT COUNT(1) FROM users WHERE nickname LIKE ?;`,
+		`SELECT COUNT(1) FROM users WHERE LOWER(nickname) LIKE LOWER(?);`,
 		pattern,
 	).Scan(&total); err != nil {
 		return nil, 0
@@ -2101,7 +4796,7 @@ func (s *SQLiteStore) SearchUsers(keyword string, offset, limit int) ([]User, in
 	rows, err := s.db.Query(
 		`SELECT id, nickname, created_at, avatar, cover, bio, exp
 		 FROM users
-		 WHERE nickname LIKE ?
+		 WHERE LOWER(nickname) LIKE LOWER(?)
 		 ORDER BY created_at DESC
 		 LIMIT ? OFFSET ?;`,
 		pattern, limit, offset,
@@ -2123,7 +4818,7 @@ func (s *SQLiteStore) SearchUsers(keyword string, offset, limit int) ([]User, in
 }
 
 // CreateNotification creates a new notification.
-func (s *SQLiteStore) CreateNotification(recipientID, actorID, notifType, targetType, targetID string) (Notification, error) {
+func (s *sqlStore) CreateNotification(recipientID, actorID, notifType, targetType, targetID string) (Notification, error) {
 	if recipientID == "" || actorID == "" || notifType == "" {
 		return Notification{}, ErrInvalidInput
 	}
@@ -2138,6 +4833,22 @@ func (s *SQLiteStore) CreateNotification(recipientID, actorID, notifType, target
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	notif, err := s.insertNotification(tx, recipientID, actorID, notifType, targetType, targetID)
+	if err != nil {
+		return Notification{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Notification{}, err
+	}
+	return notif, nil
+}
+
+// insertNotification adds a notification inside tx unless it would go to its
+// own actor or to nobody.
+func (s *sqlStore) insertNotification(tx *sqlTx, recipientID, actorID, notifType, targetType, targetID string) (Notification, error) {
+	if recipientID == "" || recipientID == actorID {
+		return Notification{}, nil
+	}
 	seq, err := s.nextCounter(tx, "notification")
 	if err != nil {
 		return Notification{}, err
@@ -2167,15 +4878,17 @@ func (s *SQLiteStore) CreateNotification(recipientID, actorID, notifType, target
 	); err != nil {
 		return Notification{}, err
 	}
-
-	if err := tx.Commit(); err != nil {
-		return Notification{}, err
-	}
 	return notif, nil
 }
 
 // Notifications returns notifications for a user with pagination.
-func (s *SQLiteStore) Notifications(recipientID string, offset, limit int) ([]Notification, int) {
+func (s *sqlStore) Notifications(recipientID string, offset, limit int) ([]Notification, int) {
+	return s.NotificationsByType(recipientID, nil, offset, limit)
+}
+
+// NotificationsByType is Notifications limited to the given types; an empty
+// types list matches every type.
+func (s *sqlStore) NotificationsByType(recipientID string, types []string, offset, limit int) ([]Notification, int) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -2183,10 +4896,19 @@ func (s *SQLiteStore) Notifications(recipientID string, offset, limit int) ([]No
 		limit = 20
 	}
 
+	where := `recipient_id = ?`
+	args := []any{recipientID}
+	if len(types) > 0 {
+		where += ` AND type IN (` + strings.TrimSuffix(strings.Repeat("?,", len(types)), ",") + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+
 	var total int
 	if err := s.db.QueryRow(
-		`SELECT COUNT(1) FROM notifications WHERE recipient_id = ?;`,
-		recipientID,
+		`SELECT COUNT(1) FROM notifications WHERE `+where+`;`,
+		args...,
 	).Scan(&total); err != nil {
 		return nil, 0
 	}
@@ -2194,10 +4916,10 @@ func (s *SQLiteStore) Notifications(recipientID string, offset, limit int) ([]No
 	rows, err := s.db.Query(
 		`SELECT id, recipient_id, actor_id, type, target_type, target_id, read_at, created_at
 		 FROM notifications
-		 WHERE recipient_id = ?
+		 WHERE `+where+`
 		 ORDER BY seq DESC
 		 LIMIT ? OFFSET ?;`,
-		recipientID, limit, offset,
+		append(args, limit, offset)...,
 	)
 	if err != nil {
 		return nil, 0
@@ -2222,7 +4944,7 @@ func (s *SQLiteStore) Notifications(recipientID string, offset, limit int) ([]No
 }
 
 // UnreadNotificationCount returns the count of unread notifications.
-func (s *SQLiteStore) UnreadNotificationCount(recipientID string) int {
+func (s *sqlStore) UnreadNotificationCount(recipientID string) int {
 	var count int
 	if err := s.db.QueryRow(
 		`SELECT COUNT(1) FROM notifications WHERE recipient_id = ? AND (read_at IS NULL OR TRIM(read_at) = '');`,
@@ -2234,7 +4956,7 @@ func (s *SQLiteStore) UnreadNotificationCount(recipientID string) int {
 }
 
 // MarkNotificationRead marks a single notification as read.
-func (s *SQLiteStore) MarkNotificationRead(notificationID, recipientID string) error {
+func (s *sqlStore) MarkNotificationRead(notificationID, recipientID string) error {
 	res, err := s.db.Exec(
 		`UPDATE notifications SET read_at = ? WHERE id = ? AND recipient_id = ?;`,
 		nowRFC3339(),
@@ -2251,8 +4973,74 @@ func (s *SQLiteStore) MarkNotificationRead(notificationID, recipientID string) e
 	return nil
 }
 
+// MarkNotificationsRead marks the given unread notifications as read and
+// returns how many changed.
+func (s *sqlStore) MarkNotificationsRead(recipientID string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	timestamp := nowRFC3339()
+	updated := 0
+	for start := 0; start < len(ids); start += maxInClauseIDs {
+		chunk := ids[start:min(start+maxInClauseIDs, len(ids))]
+		args := make([]any, 0, len(chunk)+2)
+		args = append(args, timestamp)
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		args = append(args, recipientID)
+		res, err := s.db.Exec(
+			`UPDATE notifications SET read_at = ?
+			 WHERE id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")+`)
+			   AND recipient_id = ?
+			   AND (read_at IS NULL OR TRIM(read_at) = '');`,
+			args...,
+		)
+		if err != nil {
+			return updated, err
+		}
+		affected, _ := res.RowsAffected()
+		updated += int(affected)
+	}
+	return updated, nil
+}
+
+// MarkNotificationUnread clears a notification's read time.
+func (s *sqlStore) MarkNotificationUnread(notificationID, recipientID string) error {
+	res, err := s.db.Exec(
+		`UPDATE notifications SET read_at = NULL WHERE id = ? AND recipient_id = ?;`,
+		notificationID,
+		recipientID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteNotification removes a notification permanently.
+func (s *sqlStore) DeleteNotification(notificationID, recipientID string) error {
+	res, err := s.db.Exec(
+		`DELETE FROM notifications WHERE id = ? AND recipient_id = ?;`,
+		notificationID,
+		recipientID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // MarkAllNotificationsRead marks all notifications for a user as read.
-func (s *SQLiteStore) MarkAllNotificationsRead(recipientID string) error {
+func (s *sqlStore) MarkAllNotificationsRead(recipientID string) error {
 	_, err := s.db.Exec(
 		`UPDATE notifications SET read_at = ? WHERE recipient_id = ? AND (read_at IS NULL OR TRIM(read_at) = '');`,
 		nowRFC3339(),
@@ -2261,4 +5049,301 @@ func (s *SQLiteStore) MarkAllNotificationsRead(recipientID string) error {
 	return err
 }
 
+// NotificationPrefs returns a user's notification settings (digest off if unset).
+func (s *sqlStore) NotificationPrefs(userID string) NotificationPrefs {
+	var prefs NotificationPrefs
+	_ = s.db.QueryRow(
+		`SELECT email_digest, digest_seq FROM notification_prefs WHERE user_id = ?;`,
+		userID,
+	).Scan(&prefs.EmailDigest, &prefs.DigestSeq)
+	return prefs
+}
+
+// SetEmailDigest turns the daily notification email on or off.
+func (s *sqlStore) SetEmailDigest(userID string, enabled bool) error {
+	if strings.TrimSpace(userID) == "" {
+		return ErrInvalidInput
+	}
+	var existing string
+	if err := s.db.QueryRow(`SELECT id FROM users WHERE id = ?;`, userID).Scan(&existing); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO notification_prefs(user_id, email_digest)
+		 VALUES(?, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET email_digest = excluded.email_digest;`,
+		userID, enabled,
+	)
+	return err
+}
+
+// DigestRecipients lists verified, opted-in users with unread notifications
+// newer than their last digest.
+func (s *sqlStore) DigestRecipients() []DigestRecipient {
+	rows, err := s.db.Query(
+		`SELECT p.user_id, a.account, p.digest_seq
+		 FROM notification_prefs p
+		 JOIN accounts a ON a.user_id = p.user_id
+		 WHERE p.email_digest = ?
+		   AND a.verified_at IS NOT NULL AND TRIM(a.verified_at) != '';`,
+		true,
+	)
+	if err != nil {
+		return nil
+	}
+	type candidate struct {
+		userID, email string
+		seq           int
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.userID, &c.email, &c.seq); err != nil {
+			rows.Close()
+			return nil
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+
+	out := make([]DigestRecipient, 0)
+	for _, c := range candidates {
+		items, newest, err := s.unreadNotificationsAfter(c.userID, c.seq)
+		if err != nil {
+			return nil
+		}
+		if len(items) > 0 {
+			out = append(out, DigestRecipient{UserID: c.userID, Email: c.email, Items: items, Seq: newest})
+		}
+	}
+	return out
+}
+
+// unreadNotificationsAfter returns recipientID's unread notifications with a
+// seq above afterSeq, newest first, along with the newest one's seq.
+func (s *sqlStore) unreadNotificationsAfter(recipientID string, afterSeq int) ([]Notification, int, error) {
+	rows, err := s.db.Query(
+		`SELECT seq, id, recipient_id, actor_id, type, target_type, target_id, created_at
+		 FROM notifications
+		 WHERE recipient_id = ?
+		   AND (read_at IS NULL OR TRIM(read_at) = '')
+		   AND seq > ?
+		 ORDER BY seq DESC;`,
+		recipientID, afterSeq,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var out []Notification
+	newest := 0
+	for rows.Next() {
+		var n Notification
+		var seq int
+		var targetType, targetID sql.NullString
+		if err := rows.Scan(&seq, &n.ID, &n.RecipientID, &n.ActorID, &n.Type, &targetType, &targetID, &n.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		n.TargetType = strings.TrimSpace(targetType.String)
+		n.TargetID = strings.TrimSpace(targetID.String)
+		newest = max(newest, seq)
+		out = append(out, n)
+	}
+	return out, newest, rows.Err()
+}
+
+// MarkDigestSent records the seq of the newest notification a user's digest
+// covered.
+func (s *sqlStore) MarkDigestSent(userID string, seq int) error {
+	_, err := s.db.Exec(
+		`UPDATE notification_prefs SET digest_seq = ? WHERE user_id = ?;`,
+		seq, userID,
+	)
+	return err
+}
+
+// VerifyIntegrity runs the Store.VerifyIntegrity checks against the tables.
+func (s *sqlStore) VerifyIntegrity() ([]string, error) {
+	problems := []string{}
+	collect := func(query string, format func(values []string) string) error {
+		rows, err := s.db.Query(query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			raw := make([]sql.NullString, len(columns))
+			dest := make([]any, len(columns))
+			for i := range raw {
+				dest[i] = &raw[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+			values := make([]string, len(raw))
+			for i, value := range raw {
+				values[i] = value.String
+			}
+			problems = append(problems, format(values))
+		}
+		return rows.Err()
+	}
+
+	checks := []struct {
+		query  string
+		format func(values []string) string
+	}{
+		{
+			`SELECT c.id, c.post_id FROM comments c
+			 LEFT JOIN posts p ON p.id = c.post_id
+			 WHERE p.id IS NULL;`,
+			func(v []string) string { return fmt.Sprintf("comment %s: post %s does not exist", v[0], v[1]) },
+		},
+		{
+			`SELECT c.id, c.parent_id, c.post_id FROM comments c
+			 LEFT JOIN comments parent ON parent.id = c.parent_id AND parent.post_id = c.post_id
+			 WHERE c.parent_id IS NOT NULL AND TRIM(c.parent_id) <> '' AND parent.id IS NULL;`,
+			func(v []string) string {
+				return fmt.Sprintf("comment %s: parent %s does not exist in post %s", v[0], v[1], v[2])
+			},
+		},
+		{
+			`SELECT v.post_id, COUNT(*),
+			        CASE WHEN p.id IS NULL THEN 'missing' ELSE 'deleted' END
+			 FROM post_votes v
+			 LEFT JOIN posts p ON p.id = v.post_id
+			 WHERE p.id IS NULL OR (p.deleted_at IS NOT NULL AND TRIM(p.deleted_at) <> '')
+			 GROUP BY v.post_id, p.id;`,
+			func(v []string) string { return fmt.Sprintf("post %s: %s votes on a %s post", v[0], v[1], v[2]) },
+		},
+		{
+			`SELECT v.comment_id, COUNT(*),
+			        CASE WHEN c.id IS NULL THEN 'missing' ELSE 'deleted' END
+			 FROM comment_votes v
+			 LEFT JOIN comments c ON c.id = v.comment_id
+			 WHERE c.id IS NULL OR (c.deleted_at IS NOT NULL AND TRIM(c.deleted_at) <> '')
+			 GROUP BY v.comment_id, c.id;`,
+			func(v []string) string { return fmt.Sprintf("comment %s: %s votes on a %s comment", v[0], v[1], v[2]) },
+		},
+		{
+			`SELECT post_id, user_id, value FROM post_votes WHERE value NOT IN (1, -1);`,
+			func(v []string) string { return fmt.Sprintf("post %s: vote by %s has value %s", v[0], v[1], v[2]) },
+		},
+		{
+			`SELECT comment_id, user_id, value FROM comment_votes WHERE value NOT IN (1, -1);`,
+			func(v []string) string { return fmt.Sprintf("comment %s: vote by %s has value %s", v[0], v[1], v[2]) },
+		},
+	}
+	for _, check := range checks {
+		if err := collect(check.query, check.format); err != nil {
+			return nil, err
+		}
+	}
+
+	attachmentProblems, err := s.missingAttachments()
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, attachmentProblems...)
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// missingAttachments lists attachments of live posts and comments that name
+// a file with no row in files.
+func (s *sqlStore) missingAttachments() ([]string, error) {
+	files := map[string]bool{}
+	rows, err := s.db.Query(`SELECT id FROM files;`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		files[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, table := range []struct{ name, kind string }{{"posts", "post"}, {"comments", "comment"}} {
+		rows, err := s.db.Query(
+			`SELECT id, attachments FROM ` + table.name + `
+			 WHERE attachments IS NOT NULL AND TRIM(attachments) <> ''
+			   AND (deleted_at IS NULL OR TRIM(deleted_at) = '');`,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			var attachments sql.NullString
+			if err := rows.Scan(&id, &attachments); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			for _, fileID := range decodeAttachmentIDs(attachments.String) {
+				if !files[fileID] {
+					problems = append(problems, fmt.Sprintf("%s %s: attachment %s does not exist", table.kind, id, fileID))
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
+
+// RepairCounters raises each ID counter to at least the highest seq in its
+// table, in one transaction.
+func (s *sqlStore) RepairCounters() ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	repaired := []string{}
+	for _, counter := range integrityCounters {
+		var highest, current int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM ` + counter.table + `;`).Scan(&highest); err != nil {
+			return nil, err
+		}
+		err := tx.QueryRow(`SELECT value FROM counters WHERE name = ?;`, counter.name).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if current >= highest {
+			continue
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO counters(name, value) VALUES(?, ?)
+			 ON CONFLICT(name) DO UPDATE SET value = excluded.value;`,
+			counter.name, highest,
+		); err != nil {
+			return nil, err
+		}
+		repaired = append(repaired, counterRepair(counter.name, current, highest))
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repaired, nil
+}
+
 var _ API = (*SQLiteStore)(nil)
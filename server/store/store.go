@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -8,6 +9,19 @@ import (
 	"time"
 )
 
+const (
+	// DeletedUserNickname replaces the nickname of deactivated accounts.
+	DeletedUserNickname = "已注销用户"
+	// DeletedUserID is the ID of the placeholder author used when content
+	// points at a user row that no longer exists.
+	DeletedUserID = "u_deleted"
+)
+
+// DeletedUser returns the placeholder for a missing author.
+func DeletedUser() User {
+	return User{ID: DeletedUserID, Nickname: DeletedUserNickname}
+}
+
 type User struct {
 	ID        string
 	Nickname  string
@@ -25,72 +39,155 @@ type RegisterResult struct {
 
 // API defines the data operations the handlers need.
 //
-// The default implementation in this repo is an in-memory store (*Store).
-// Database-backed implementations can satisfy this interface to swap storage
-// without changing handler logic.
+// Two implementations live in this package: the in-memory *Store and the
+// database-backed *SQLiteStore. Handlers depend only on this interface so the
+// storage can be swapped without changing handler logic.
 type API interface {
 	Register(account, password, nickname string) (RegisterResult, error)
-	Login(account, password string) (string, User, error)
+	Login(account, password, userAgent string) (Session, User, error)
+	RefreshSession(token string, rotate bool) (Session, error)
+	Sessions(userID string) ([]Session, error)
+	RevokeSession(userID, sessionID string) error
 	VerifyEmail(token string) error
 	ResendVerification(account string) (string, error)
+	CheckPassword(userID, password string) error
 	DeactivateAccount(userID string) error
+	AccountEmail(userID string) (email string, pending string, err error)
+	AccountStatus(userID string) (AccountStatus, error)
+	RequestEmailChange(userID, newEmail, password string) (string, error)
+	ConfirmEmailChange(token string) error
 	UserByToken(token string) (User, bool)
 	GetUser(userID string) (User, bool)
+	GetUsers(userIDs []string) map[string]User
+	UserByNickname(nickname string) (User, bool)
 	UpdateUser(userID, nickname, bio, avatar, cover string) (User, error)
-	AddUserExp(userID string, delta int) error
+	AddExp(userID string, delta int) (int, error)
+	AwardExp(userID, reason string) (ExpAward, error)
+	CheckIn(userID string) (exp int, alreadyClaimed bool, err error)
+	SetAdmin(userID string, admin bool) error
+	IsAdmin(userID string) bool
+	SeedAdmins(accounts []string) (int, error)
 
 	FollowUser(followerID, followeeID string) error
 	UnfollowUser(followerID, followeeID string) error
 	IsFollowing(followerID, followeeID string) bool
+	FollowingAmong(followerID string, userIDs []string) map[string]bool
 	GetFollowCounts(userID string) (followers int, following int)
 	Followers(userID string, offset, limit int) ([]User, int)
 	Following(userID string, offset, limit int) ([]User, int)
+
+	BlockUser(blockerID, blockedID string) error
+	UnblockUser(blockerID, blockedID string) error
+	IsBlocked(blockerID, blockedID string) bool
+	BlockedIDs(userID string) []string
 	UserComments(userID string, offset, limit int) ([]Comment, int)
+	UserStats(userID string) (posts int, comments int, err error)
+	ExportUserData(userID string, emit func(section string, item any) error) error
 
 	Boards() []Board
+	BoardsWithStats() []BoardStats
 	GetBoard(boardID string) (Board, bool)
-
-	Posts(boardID string) []Post
+	CreateBoard(boardID, name, description string) (Board, error)
+	UpdateBoard(boardID, name, description string) (Board, error)
+	DeleteBoard(boardID string, force bool) error
+	SubscribeBoard(userID, boardID string) error
+	UnsubscribeBoard(userID, boardID string) error
+	SubscribedBoards(userID string) []string
+	FeedPosts(userID string, filter FeedFilter) ([]Post, int)
+
+	Posts(filter PostListFilter) ([]Post, int, error)
+	PostsAfterCursor(boardID, cursor string, limit int) ([]Post, string)
 	GetPost(postID string) (Post, bool)
+	GetPostIncludingDeleted(postID string) (Post, bool)
 	IncrementPostViewCount(postID string) error
-	CreatePost(boardID, authorID, title, content, contentJSON string, tags, attachments []string) Post
+	CreatePost(boardID, authorID, title, content, contentJSON, status string, tags, attachments []string) Post
+	DraftPosts(authorID string) []Post
+	PublishPost(postID, authorID string) (Post, error)
+	UpdatePost(postID, actorUserID string, isAdmin bool, version int, title, content, contentJSON string, tags []string) (Post, error)
 	SoftDeletePost(postID, actorUserID string, isAdmin bool) error
+	AdminPosts(filter AdminPostFilter) ([]Post, int)
+	SetPostPinned(postID string, pinned bool, isAdmin bool) error
 
 	Comments(postID string) []Comment
 	GetComment(postID, commentID string) (Comment, bool)
+	GetCommentByID(commentID string) (Comment, bool)
+	GetCommentIncludingDeleted(commentID string) (Comment, bool)
 	CreateComment(postID, authorID, content, contentJSON, parentID string, tags, attachments []string) Comment
 	SoftDeleteComment(postID, commentID, actorUserID string, isAdmin bool) error
 	CommentCount(postID string) int
+	CommentCounts(postIDs []string) map[string]int
 
 	PostScore(postID string) int
 	PostVote(postID, userID string) int
+	PostVoteTime(postID, userID string) string
+	PostScores(postIDs []string) map[string]int
+	PostVoteBreakdown(postID string) (up, down int)
+	PostVoteBreakdowns(postIDs []string) map[string]VoteBreakdown
+	PostVotes(postIDs []string, userID string) map[string]int
 	VotePost(postID, userID string, value int) (int, int, error)
 	ClearPostVote(postID, userID string) (int, int, error)
+	SetPostVote(postID, userID string, value int) (int, int, error)
+	PostVoters(postID string, value, offset, limit int) ([]User, int)
 	CommentScore(postID, commentID string) int
 	CommentVote(postID, commentID, userID string) int
 	VoteComment(postID, commentID, userID string, value int) (int, int, error)
 	ClearCommentVote(postID, commentID, userID string) (int, int, error)
+	SetCommentVote(postID, commentID, userID string, value int) (int, int, error)
 
 	SaveFile(uploaderID, filename, storageKey, storagePath string, width, height int) FileMeta
 	GetFile(fileID string) (FileMeta, bool)
+	IsFileDeleted(fileID string) bool
+	SoftDeleteFile(fileID, actorUserID string, isAdmin bool) error
 
 	AddMessage(roomID, senderID, content string) ChatMessage
 	Messages(roomID string, limit int) []ChatMessage
+	OpenDMRoom(userID, peerID string) (string, error)
+	DMRoomMembers(roomID string) (userA, userB string, ok bool)
+	Conversations(userID string) []Conversation
+	UnreadMessageCount(userID string) int
+	MarkRoomRead(userID, roomID string, seq string) error
+	EditMessage(messageID, senderID, content string) (ChatMessage, error)
+	DeleteMessage(messageID, senderID string) (ChatMessage, error)
+	MessageRooms() []string
+	PruneMessages(roomID string, keepLast int) (int, error)
 
 	CreateReport(reporterID, targetType, targetID, reason, detail string) (Report, error)
 	Reports(status string, page, pageSize int) ([]Report, int, error)
 	UpdateReport(reportID, status, action, note, handledBy string) (Report, error)
+	UpdateReports(reportIDs []string, status, action, note, handledBy string) (int, error)
+	ReportEvents(reportID string) ([]ReportEvent, error)
+	ReportStats() (map[string]int, error)
 
 	// Search
-	SearchPosts(keyword string, offset, limit int) ([]Post, int)
+	SearchPosts(keyword string, offset, limit int) ([]PostSearchResult, int)
+	PostsByTag(tag string, offset, limit int) ([]Post, int)
+	PopularTags(limit int) []TagCount
+	TrendingPosts(since time.Time, limit int) []Post
+	TrendingTags(since time.Time, limit int) []TagCount
 	SearchUsers(keyword string, offset, limit int) ([]User, int)
 
 	// Notifications
 	CreateNotification(recipientID, actorID, notifType, targetType, targetID string) (Notification, error)
 	Notifications(recipientID string, offset, limit int) ([]Notification, int)
+	NotificationsByType(recipientID string, types []string, offset, limit int) ([]Notification, int)
 	UnreadNotificationCount(recipientID string) int
 	MarkNotificationRead(notificationID, recipientID string) error
+	MarkNotificationsRead(recipientID string, ids []string) (int, error)
+	MarkNotificationUnread(notificationID, recipientID string) error
+	DeleteNotification(notificationID, recipientID string) error
 	MarkAllNotificationsRead(recipientID string) error
+	NotificationPrefs(userID string) NotificationPrefs
+	SetEmailDigest(userID string, enabled bool) error
+	DigestRecipients() []DigestRecipient
+	MarkDigestSent(userID string, seq int) error
+
+	// Maintenance
+	VerifyIntegrity() ([]string, error)
+	RepairCounters() ([]string, error)
+
+	// Ping reports whether the backing storage is reachable, giving up when
+	// ctx is done.
+	Ping(ctx context.Context) error
 }
 
 // Board is a simple forum category in the demo community module.
@@ -98,6 +195,25 @@ type Board struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	DeletedAt   string `json:"-"`
+}
+
+// PostSearchResult is a post matched by SearchPosts together with what a
+// result row shows, resolved in the same pass so callers need no per-row
+// lookups. Author is DeletedUser() when the author's row is missing.
+type PostSearchResult struct {
+	Post
+	Author       User
+	Score        int
+	CommentCount int
+}
+
+// BoardStats is a board with activity computed over its non-deleted posts.
+// LastPostAt is empty when the board has no posts.
+type BoardStats struct {
+	Board
+	PostCount  int
+	LastPostAt string
 }
 
 // Post is a forum post stored in memory for the demo.
@@ -111,8 +227,46 @@ type Post struct {
 	Tags        []string
 	Attachments []string
 	ViewCount   int
+	Status      string // PostStatusDraft or PostStatusPublished; empty means published
+	Pinned      bool
+	PinnedAt    string
+	Version     int // bumped by every UpdatePost; starts at 1
 	CreatedAt   string
 	DeletedAt   string
+	// DeletedReason says why a moderator removed the post; blank when the
+	// author deleted it.
+	DeletedReason string
+}
+
+// Post statuses. Drafts are hidden from feeds, search, tags and GetPost;
+// only their author sees them, through DraftPosts and
+// GetPostIncludingDeleted.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusPublished = "published"
+)
+
+// IsDraft reports whether the post is an unpublished draft.
+func (p Post) IsDraft() bool {
+	return p.Status == PostStatusDraft
+}
+
+// PinnedBefore reports whether a sorts ahead of b in a board feed: pinned
+// posts come first, most recently pinned first. It is meant for a stable
+// sort over an already ordered feed, which it leaves alone otherwise.
+func PinnedBefore(a, b Post) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	return a.Pinned && a.PinnedAt > b.PinnedAt
+}
+
+// normalizePostStatus maps anything but "draft" to published.
+func normalizePostStatus(status string) string {
+	if status == PostStatusDraft {
+		return PostStatusDraft
+	}
+	return PostStatusPublished
 }
 
 // Comment is a reply under a post.
@@ -128,6 +282,8 @@ type Comment struct {
 	Floor       int
 	CreatedAt   string
 	DeletedAt   string
+	// DeletedReason is set as for Post.DeletedReason.
+	DeletedReason string
 }
 
 // ChatMessage is a message stored per room for history queries.
@@ -137,6 +293,8 @@ type ChatMessage struct {
 	SenderID  string
 	Content   string
 	CreatedAt string
+	EditedAt  string
+	DeletedAt string
 }
 
 // FileMeta tracks uploaded files and where they are stored on disk.
@@ -149,11 +307,12 @@ type FileMeta struct {
 	Width       int
 	Height      int
 	CreatedAt   string
+	DeletedAt   string
 }
 
 type Report struct {
 	ID         string
-	TargetType string
+	TargetType string // one of the ReportTarget constants
 	TargetID   string
 	ReporterID string
 	Reason     string
@@ -164,6 +323,9 @@ type Report struct {
 	HandledBy  string
 	CreatedAt  string
 	UpdatedAt  string
+	// TargetSnapshot is a JSON object with the reported user, post or comment
+	// as it was when reported; empty for other targets or a missing target.
+	TargetSnapshot string
 }
 
 // Notification represents an in-app notification.
@@ -171,7 +333,7 @@ type Notification struct {
 	ID          string
 	RecipientID string // User who receives the notification
 	ActorID     string // User who triggered the notification
-	Type        string // "comment", "reply", "follow", "like"
+	Type        string // "comment", "reply", "follow", "like", "mention"
 	TargetType  string // "post", "comment"
 	TargetID    string // ID of the post or comment
 	ReadAt      string // When the notification was read
@@ -185,18 +347,29 @@ type Store struct {
 	accounts            map[string]string
 	passwords           map[string]string
 	accountVerification map[string]AccountVerification
-	tokens              map[string]string
-	userTokens          map[string]string
+	sessions            map[string]*Session // map[token]*Session
 	boards              []Board
 	posts               []Post
 	comments            []Comment
 	postVotes           map[string]map[string]int
 	commentVotes        map[string]map[string]int
+	postVoteSeq         map[string]map[string]int    // map[postID]map[userID]seq, latest vote wins
+	postVoteAt          map[string]map[string]string // map[postID]map[userID]RFC3339 time of the vote
 	files               map[string]FileMeta
 	messages            map[string][]ChatMessage
+	dmRooms             map[string]dmRoom
+	chatReads           map[string]map[string]int64
 	reports             []Report
+	reportEvents        []ReportEvent
 	follows             map[string]map[string]bool // map[followerID]map[followeeID]bool
+	boardSubs           map[string]map[string]bool // map[userID]map[boardID]bool
+	blocks              map[string]map[string]bool // map[blockerID]map[blockedID]bool
+	admins              map[string]bool
+	checkins            map[string]map[string]bool // map[userID]map[date]bool
+	upvoteAwards        map[string]map[string]bool // map[postID]map[voterID]bool, upvotes that earned exp
 	notifications       []Notification
+	notificationPrefs   map[string]NotificationPrefs
+	emailChanges        map[string]emailChange // map[userID]pending change
 	nextUserID          int
 	nextPostID          int
 	nextComment         int
@@ -204,6 +377,8 @@ type Store struct {
 	nextMsgID           int
 	nextReport          int
 	nextNotifID         int
+	nextSessionID       int
+	nextVoteSeq         int
 }
 
 type AccountVerification struct {
@@ -212,6 +387,11 @@ type AccountVerification struct {
 	ExpiresAt  time.Time
 }
 
+// Ping always succeeds: the in-memory store has nothing to reach.
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
 // NewStore creates a demo store with a few built-in boards.
 func NewStore() *Store {
 	return &Store{
@@ -219,16 +399,26 @@ func NewStore() *Store {
 		accounts:            map[string]string{},
 		passwords:           map[string]string{},
 		accountVerification: map[string]AccountVerification{},
-		tokens:              map[string]string{},
-		userTokens:          map[string]string{},
+		sessions:            map[string]*Session{},
 		boards:              defaultBoards(),
 		posts:               []Post{},
 		comments:            []Comment{},
 		postVotes:           map[string]map[string]int{},
 		commentVotes:        map[string]map[string]int{},
+		postVoteSeq:         map[string]map[string]int{},
+		postVoteAt:          map[string]map[string]string{},
 		files:               map[string]FileMeta{},
 		messages:            map[string][]ChatMessage{},
+		dmRooms:             map[string]dmRoom{},
+		chatReads:           map[string]map[string]int64{},
 		follows:             map[string]map[string]bool{},
+		boardSubs:           map[string]map[string]bool{},
+		blocks:              map[string]map[string]bool{},
+		admins:              map[string]bool{},
+		checkins:            map[string]map[string]bool{},
+		upvoteAwards:        map[string]map[string]bool{},
+		notificationPrefs:   map[string]NotificationPrefs{},
+		emailChanges:        map[string]emailChange{},
 	}
 }
 
@@ -245,11 +435,18 @@ func (s *Store) UserByToken(token string) (User, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	userID, ok := s.tokens[token]
+	session, ok := s.sessions[token]
 	if !ok {
 		return User{}, false
 	}
-	user, ok := s.users[userID]
+	if sessionExpired(session.ExpiresAt) {
+		delete(s.sessions, token)
+		return User{}, false
+	}
+	if sessionStale(session.LastSeen) {
+		session.LastSeen = now()
+	}
+	user, ok := s.users[session.UserID]
 	if !ok {
 		return User{}, false
 	}
@@ -265,8 +462,55 @@ func (s *Store) GetUser(userID string) (User, bool) {
 	return user, ok
 }
 
-// UpdateUser updates user profile fields.
+// GetUsers resolves a batch of user IDs; unknown IDs are absent from the result.
+func (s *Store) GetUsers(userIDs []string) map[string]User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]User, len(userIDs))
+	for _, id := range userIDs {
+		if user, ok := s.users[id]; ok {
+			out[id] = user
+		}
+	}
+	return out
+}
+
+// UserByNickname resolves an exact nickname to an active account. Nicknames
+// are not unique, so it reports false when none or several users match.
+func (s *Store) UserByNickname(nickname string) (User, bool) {
+	trimmed := strings.TrimSpace(nickname)
+	if trimmed == "" {
+		return User{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		match User
+		found bool
+	)
+	for _, userID := range s.accounts {
+		user, ok := s.users[userID]
+		if !ok || user.Nickname != trimmed {
+			continue
+		}
+		if found {
+			return User{}, false
+		}
+		match, found = user, true
+	}
+	return match, found
+}
+
+// UpdateUser replaces the user's profile fields. The nickname must pass
+// validateNickname; bio, avatar and cover may be empty to clear them.
 func (s *Store) UpdateUser(userID, nickname, bio, avatar, cover string) (User, error) {
+	if !validateNickname(nickname) {
+		return User{}, ErrInvalidNickname
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -275,9 +519,7 @@ func (s *Store) UpdateUser(userID, nickname, bio, avatar, cover string) (User, e
 		return User{}, ErrNotFound
 	}
 
-	if strings.TrimSpace(nickname) != "" {
-		user.Nickname = nickname
-	}
+	user.Nickname = strings.TrimSpace(nickname)
 	user.Bio = bio
 	user.Avatar = avatar
 	user.Cover = cover
@@ -286,20 +528,52 @@ func (s *Store) UpdateUser(userID, nickname, bio, avatar, cover string) (User, e
 	return user, nil
 }
 
-func (s *Store) AddUserExp(userID string, delta int) error {
+// AddExp adjusts a user's exp (never below zero) and returns the new total.
+func (s *Store) AddExp(userID string, delta int) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.addExpLocked(userID, delta)
+}
+
+// AwardExp grants the exp configured for reason (see ExpForReason).
+func (s *Store) AwardExp(userID, reason string) (ExpAward, error) {
+	return awardExp(s.AddExp, userID, reason)
+}
+
+func (s *Store) addExpLocked(userID string, delta int) (int, error) {
 	user, ok := s.users[userID]
 	if !ok {
-		return ErrNotFound
+		return 0, ErrNotFound
 	}
 	user.Exp += delta
 	if user.Exp < 0 {
 		user.Exp = 0
 	}
 	s.users[userID] = user
-	return nil
+	return user.Exp, nil
+}
+
+// CheckIn records today's (UTC) check-in and awards DailyCheckInExp once per day.
+func (s *Store) CheckIn(userID string) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return 0, false, ErrNotFound
+	}
+	today := checkInDate()
+	if s.checkins[userID][today] {
+		return user.Exp, true, nil
+	}
+	if s.checkins[userID] == nil {
+		s.checkins[userID] = map[string]bool{}
+	}
+	s.checkins[userID][today] = true
+
+	exp, err := s.addExpLocked(userID, DailyCheckInExp)
+	return exp, false, err
 }
 
 func (s *Store) FollowUser(followerID, followeeID string) error {
@@ -315,6 +589,9 @@ func (s *Store) FollowUser(followerID, followeeID string) error {
 	if _, ok := s.users[followeeID]; !ok {
 		return ErrNotFound
 	}
+	if s.blocks[followeeID][followerID] {
+		return ErrForbidden
+	}
 
 	if s.follows[followerID] == nil {
 		s.follows[followerID] = make(map[string]bool)
@@ -333,6 +610,62 @@ func (s *Store) UnfollowUser(followerID, followeeID string) error {
 	return nil
 }
 
+// BlockUser hides blockedID's content from blockerID and stops blockedID from
+// following blockerID. An existing follow in that direction is removed.
+func (s *Store) BlockUser(blockerID, blockedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if blockerID == blockedID {
+		return ErrInvalidInput
+	}
+	if _, ok := s.users[blockerID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.users[blockedID]; !ok {
+		return ErrNotFound
+	}
+
+	if s.blocks[blockerID] == nil {
+		s.blocks[blockerID] = make(map[string]bool)
+	}
+	s.blocks[blockerID][blockedID] = true
+	if s.follows[blockedID] != nil {
+		delete(s.follows[blockedID], blockerID)
+	}
+	return nil
+}
+
+func (s *Store) UnblockUser(blockerID, blockedID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.blocks[blockerID] != nil {
+		delete(s.blocks[blockerID], blockedID)
+	}
+	return nil
+}
+
+func (s *Store) IsBlocked(blockerID, blockedID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.blocks[blockerID][blockedID]
+}
+
+// BlockedIDs lists the users blocked by userID.
+func (s *Store) BlockedIDs(userID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.blocks[userID]))
+	for id := range s.blocks[userID] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (s *Store) IsFollowing(followerID, followeeID string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -343,6 +676,21 @@ func (s *Store) IsFollowing(followerID, followeeID string) bool {
 	return s.follows[followerID][followeeID]
 }
 
+// FollowingAmong reports which of userIDs followerID follows; only followed
+// IDs are present in the result.
+func (s *Store) FollowingAmong(followerID string, userIDs []string) map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]bool{}
+	for _, id := range userIDs {
+		if s.follows[followerID][id] {
+			out[id] = true
+		}
+	}
+	return out
+}
+
 func (s *Store) GetFollowCounts(userID string) (int, int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -455,35 +803,101 @@ func (s *Store) Boards() []Board {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	boards := make([]Board, len(s.boards))
-	copy(boards, s.boards)
+	boards := make([]Board, 0, len(s.boards))
+	for _, board := range s.boards {
+		if board.DeletedAt == "" {
+			boards = append(boards, board)
+		}
+	}
 	return boards
 }
 
-// GetBoard returns a board by ID.
+// BoardsWithStats returns all boards with their post count and latest post time.
+func (s *Store) BoardsWithStats() []BoardStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]BoardStats, 0, len(s.boards))
+	index := make(map[string]int, len(s.boards))
+	for _, board := range s.boards {
+		if board.DeletedAt != "" {
+			continue
+		}
+		index[board.ID] = len(out)
+		out = append(out, BoardStats{Board: board})
+	}
+	for _, post := range s.posts {
+		if post.DeletedAt != "" || post.IsDraft() {
+			continue
+		}
+		idx, ok := index[post.BoardID]
+		if !ok {
+			continue
+		}
+		out[idx].PostCount++
+		if post.CreatedAt > out[idx].LastPostAt {
+			out[idx].LastPostAt = post.CreatedAt
+		}
+	}
+	return out
+}
+
+// GetBoard returns a live board by ID.
 func (s *Store) GetBoard(boardID string) (Board, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for _, board := range s.boards {
-		if board.ID == boardID {
+		if board.ID == boardID && board.DeletedAt == "" {
 			return board, true
 		}
 	}
 	return Board{}, false
 }
 
-// Posts returns posts for a board. If boardID is empty, it returns all posts.
-func (s *Store) Posts(boardID string) []Post {
+// PostListFilter selects a page of the public post list.
+type PostListFilter struct {
+	// BoardIDs restricts the list to these boards; empty means every board.
+	BoardIDs []string
+	AuthorID string
+	// ViewerID hides posts by authors the viewer has blocked.
+	ViewerID string
+	Offset   int
+	Limit    int // <= 0 returns every match
+}
+
+func (f PostListFilter) normalized() PostListFilter {
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return f
+}
+
+// Posts returns the filter's page of live posts, newest first, and the total
+// number of matches. A single-board list leads with its pinned posts.
+func (s *Store) Posts(filter PostListFilter) ([]Post, int, error) {
+	filter = filter.normalized()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	boards := make(map[string]struct{}, len(filter.BoardIDs))
+	for _, id := range filter.BoardIDs {
+		boards[id] = struct{}{}
+	}
+	blocked := s.blocks[filter.ViewerID]
 	filtered := make([]Post, 0, len(s.posts))
 	for _, post := range s.posts {
-		if post.DeletedAt != "" {
+		if post.DeletedAt != "" || post.IsDraft() {
+			continue
+		}
+		if _, ok := boards[post.BoardID]; len(boards) > 0 && !ok {
+			continue
+		}
+		if filter.AuthorID != "" && post.AuthorID != filter.AuthorID {
 			continue
 		}
-		if boardID != "" && post.BoardID != boardID {
+		if blocked[post.AuthorID] {
 			continue
 		}
 		filtered = append(filtered, post)
@@ -492,7 +906,69 @@ func (s *Store) Posts(boardID string) []Post {
 	sort.SliceStable(filtered, func(i, j int) bool {
 		return filtered[i].CreatedAt > filtered[j].CreatedAt
 	})
-	return filtered
+	if len(boards) == 1 {
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return PinnedBefore(filtered[i], filtered[j])
+		})
+	}
+	total := len(filtered)
+	if filter.Limit <= 0 {
+		return filtered, total, nil
+	}
+	start := min(filter.Offset, total)
+	end := min(start+filter.Limit, total)
+	return filtered[start:end], total, nil
+}
+
+// PostsAfterCursor returns up to limit live posts created before the cursor
+// post (newest first; an empty cursor starts from the newest post) and the
+// cursor for the next page, which is empty once the feed is exhausted. An
+// unknown cursor yields an empty page. Board feeds lead with their pinned
+// posts.
+func (s *Store) PostsAfterCursor(boardID, cursor string, limit int) ([]Post, string) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// s.posts is in creation order, so reversing it gives seq DESC. Deleted
+	// posts stay in the ordering so they still work as cursors.
+	ordered := make([]Post, 0, len(s.posts))
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		if boardID == "" || s.posts[i].BoardID == boardID {
+			ordered = append(ordered, s.posts[i])
+		}
+	}
+	if boardID != "" {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return PinnedBefore(ordered[i], ordered[j])
+		})
+	}
+
+	start := 0
+	if cursor = strings.TrimSpace(cursor); cursor != "" {
+		start = len(ordered)
+		for i, post := range ordered {
+			if post.ID == cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	out := make([]Post, 0, limit)
+	for _, post := range ordered[start:] {
+		if post.DeletedAt != "" || post.IsDraft() {
+			continue
+		}
+		if len(out) == limit {
+			return out, out[len(out)-1].ID
+		}
+		out = append(out, post)
+	}
+	return out, ""
 }
 
 // GetPost returns a post by ID.
@@ -501,7 +977,20 @@ func (s *Store) GetPost(postID string) (Post, bool) {
 	defer s.mu.Unlock()
 
 	for _, post := range s.posts {
-		if post.ID == postID && post.DeletedAt == "" {
+		if post.ID == postID && post.DeletedAt == "" && !post.IsDraft() {
+			return post, true
+		}
+	}
+	return Post{}, false
+}
+
+// GetPostIncludingDeleted returns a post by ID even if it has been soft-deleted.
+func (s *Store) GetPostIncludingDeleted(postID string) (Post, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, post := range s.posts {
+		if post.ID == postID {
 			return post, true
 		}
 	}
@@ -522,8 +1011,9 @@ func (s *Store) IncrementPostViewCount(postID string) error {
 	return ErrNotFound
 }
 
-// CreatePost appends a post to the store and returns it.
-func (s *Store) CreatePost(boardID, authorID, title, content, contentJSON string, tags, attachments []string) Post {
+// CreatePost appends a post to the store and returns it. status is
+// PostStatusDraft or, for anything else, PostStatusPublished.
+func (s *Store) CreatePost(boardID, authorID, title, content, contentJSON, status string, tags, attachments []string) Post {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -542,14 +1032,32 @@ func (s *Store) CreatePost(boardID, authorID, title, content, contentJSON string
 		Tags:        storedTags,
 		Attachments: storedAttachments,
 		ViewCount:   0,
+		Status:      normalizePostStatus(status),
+		Version:     1,
 		CreatedAt:   now(),
 	}
 	s.posts = append(s.posts, post)
 	return post
 }
 
-// SoftDeletePost marks a post as deleted. Only the post author can delete it in the demo.
-func (s *Store) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
+// DraftPosts returns authorID's live drafts, newest first.
+func (s *Store) DraftPosts(authorID string) []Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Post, 0)
+	for i := len(s.posts) - 1; i >= 0; i-- {
+		post := s.posts[i]
+		if post.AuthorID == authorID && post.IsDraft() && post.DeletedAt == "" {
+			out = append(out, post)
+		}
+	}
+	return out
+}
+
+// PublishPost makes authorID's draft live. The post is stamped with the
+// current time and moved to the head of the feed, as if created now.
+func (s *Store) PublishPost(postID, authorID string) (Post, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -558,13 +1066,98 @@ func (s *Store) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
 			continue
 		}
 		if post.DeletedAt != "" {
-			return ErrNotFound
+			return Post{}, ErrNotFound
+		}
+		if post.AuthorID != authorID {
+			return Post{}, ErrForbidden
+		}
+		if !post.IsDraft() {
+			return Post{}, ErrInvalidTransition
+		}
+		post.Status = PostStatusPublished
+		post.CreatedAt = now()
+		// s.posts order stands in for seq in PostsAfterCursor.
+		s.posts = append(append(s.posts[:idx:idx], s.posts[idx+1:]...), post)
+		return post, nil
+	}
+	return Post{}, ErrNotFound
+}
+
+// UpdatePost replaces a live post's title, content and tags. Only the author
+// or an admin may edit, and only while the post is still at version; a stale
+// version yields ErrConflict so concurrent edits can't overwrite each other.
+func (s *Store) UpdatePost(postID, actorUserID string, isAdmin bool, version int, title, content, contentJSON string, tags []string) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, post := range s.posts {
+		if post.ID != postID || post.DeletedAt != "" {
+			continue
+		}
+		if !isAdmin && post.AuthorID != actorUserID {
+			return Post{}, ErrForbidden
+		}
+		if post.Version != version {
+			return Post{}, ErrConflict
+		}
+		post.Title = title
+		post.Content = content
+		post.ContentJSON = contentJSON
+		post.Tags = append([]string(nil), tags...)
+		post.Version++
+		s.posts[idx] = post
+		return post, nil
+	}
+	return Post{}, ErrNotFound
+}
+
+// SetPostPinned pins or unpins a live post at the top of its board. Only
+// admins may pin.
+func (s *Store) SetPostPinned(postID string, pinned bool, isAdmin bool) error {
+	if !isAdmin {
+		return ErrForbidden
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, post := range s.posts {
+		if post.ID != postID || post.DeletedAt != "" || post.IsDraft() {
+			continue
+		}
+		s.posts[idx].Pinned = pinned
+		s.posts[idx].PinnedAt = ""
+		if pinned {
+			s.posts[idx].PinnedAt = now()
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+// SoftDeletePost marks a post as deleted. Only the post author can delete it in the demo.
+// Deleting it again yields ErrAlreadyDeleted rather than ErrNotFound.
+func (s *Store) SoftDeletePost(postID, actorUserID string, isAdmin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, post := range s.posts {
+		if post.ID != postID {
+			continue
 		}
 		if !isAdmin && post.AuthorID != actorUserID {
 			return ErrForbidden
 		}
+		if post.DeletedAt != "" {
+			return ErrAlreadyDeleted
+		}
 		post.DeletedAt = now()
+		if post.AuthorID != actorUserID {
+			post.DeletedReason = ModeratorRemovalReason
+			s.addNotificationLocked(post.AuthorID, actorUserID, NotificationContentRemoved, ReportTargetPost, post.ID)
+		}
 		s.posts[idx] = post
+		s.dropPostVotesLocked(postID)
 		return nil
 	}
 	return ErrNotFound
@@ -598,6 +1191,33 @@ func (s *Store) GetComment(postID, commentID string) (Comment, bool) {
 	return Comment{}, false
 }
 
+// GetCommentByID returns a live comment by ID whatever post it is under.
+func (s *Store) GetCommentByID(commentID string) (Comment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, comment := range s.comments {
+		if comment.ID == commentID && comment.DeletedAt == "" {
+			return comment, true
+		}
+	}
+	return Comment{}, false
+}
+
+// GetCommentIncludingDeleted returns a comment by ID even if it has been
+// soft-deleted, with its DeletedAt and DeletedReason.
+func (s *Store) GetCommentIncludingDeleted(commentID string) (Comment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, comment := range s.comments {
+		if comment.ID == commentID {
+			return comment, true
+		}
+	}
+	return Comment{}, false
+}
+
 // CreateComment appends a comment to the store and returns it.
 func (s *Store) CreateComment(postID, authorID, content, contentJSON, parentID string, tags, attachments []string) Comment {
 	s.mu.Lock()
@@ -637,6 +1257,7 @@ func (s *Store) CreateComment(postID, authorID, content, contentJSON, parentID s
 }
 
 // SoftDeleteComment marks a comment as deleted. Only the comment author can delete it in the demo.
+// Deleting it again yields ErrAlreadyDeleted rather than ErrNotFound.
 func (s *Store) SoftDeleteComment(postID, commentID, actorUserID string, isAdmin bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -645,14 +1266,19 @@ func (s *Store) SoftDeleteComment(postID, commentID, actorUserID string, isAdmin
 		if comment.PostID != postID || comment.ID != commentID {
 			continue
 		}
-		if comment.DeletedAt != "" {
-			return ErrNotFound
-		}
 		if !isAdmin && comment.AuthorID != actorUserID {
 			return ErrForbidden
 		}
+		if comment.DeletedAt != "" {
+			return ErrAlreadyDeleted
+		}
 		comment.DeletedAt = now()
+		if comment.AuthorID != actorUserID {
+			comment.DeletedReason = ModeratorRemovalReason
+			s.addNotificationLocked(comment.AuthorID, actorUserID, NotificationContentRemoved, ReportTargetComment, comment.ID)
+		}
 		s.comments[idx] = comment
+		delete(s.commentVotes, commentID)
 		return nil
 	}
 	return ErrNotFound
@@ -684,7 +1310,7 @@ func (s *Store) UserStats(userID string) (int, int, error) {
 	activePosts := make(map[string]struct{}, len(s.posts))
 	postsCount := 0
 	for _, post := range s.posts {
-		if post.DeletedAt != "" {
+		if post.DeletedAt != "" || post.IsDraft() {
 			continue
 		}
 		activePosts[post.ID] = struct{}{}
@@ -705,6 +1331,94 @@ func (s *Store) UserStats(userID string) (int, int, error) {
 	return postsCount, commentsCount, nil
 }
 
+// CommentCounts returns the non-deleted comment count for each of postIDs.
+// Posts without comments are absent from the result.
+func (s *Store) CommentCounts(postIDs []string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]struct{}, len(postIDs))
+	for _, id := range postIDs {
+		wanted[id] = struct{}{}
+	}
+	out := map[string]int{}
+	for _, comment := range s.comments {
+		if _, ok := wanted[comment.PostID]; ok && comment.DeletedAt == "" {
+			out[comment.PostID]++
+		}
+	}
+	return out
+}
+
+// PostScores returns the aggregated vote score for each of postIDs. Posts
+// without votes are absent from the result.
+func (s *Store) PostScores(postIDs []string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]int{}
+	for _, id := range postIDs {
+		if votes := s.postVotes[id]; len(votes) > 0 {
+			out[id] = sumVotes(votes)
+		}
+	}
+	return out
+}
+
+// VoteBreakdown splits a score into its upvotes and downvotes.
+type VoteBreakdown struct {
+	Up   int
+	Down int
+}
+
+// PostVoteBreakdown returns how many users up- and downvoted postID.
+func (s *Store) PostVoteBreakdown(postID string) (up, down int) {
+	breakdown := s.PostVoteBreakdowns([]string{postID})[postID]
+	return breakdown.Up, breakdown.Down
+}
+
+// PostVoteBreakdowns returns the up and down counts for each of postIDs.
+// Posts without votes are absent from the result.
+func (s *Store) PostVoteBreakdowns(postIDs []string) map[string]VoteBreakdown {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]VoteBreakdown{}
+	for _, id := range postIDs {
+		var breakdown VoteBreakdown
+		for _, value := range s.postVotes[id] {
+			switch {
+			case value > 0:
+				breakdown.Up++
+			case value < 0:
+				breakdown.Down++
+			}
+		}
+		if breakdown != (VoteBreakdown{}) {
+			out[id] = breakdown
+		}
+	}
+	return out
+}
+
+// PostVotes returns userID's vote on each of postIDs. Posts the user hasn't
+// voted on are absent from the result.
+func (s *Store) PostVotes(postIDs []string, userID string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := map[string]int{}
+	if userID == "" {
+		return out
+	}
+	for _, id := range postIDs {
+		if value := s.postVotes[id][userID]; value != 0 {
+			out[id] = value
+		}
+	}
+	return out
+}
+
 // PostScore returns the aggregated vote score for a post.
 func (s *Store) PostScore(postID string) int {
 	s.mu.Lock()
@@ -731,11 +1445,34 @@ func (s *Store) PostVote(postID, userID string) int {
 	return votes[userID]
 }
 
+// PostVoteTime returns when userID last set their vote on postID, or "" if
+// they haven't voted.
+func (s *Store) PostVoteTime(postID, userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.postVoteAt[postID][userID]
+}
+
 // VotePost upserts a user's vote on a post and returns the new score and my_vote.
 func (s *Store) VotePost(postID, userID string, value int) (int, int, error) {
 	if value != 1 && value != -1 {
 		return 0, 0, ErrInvalidInput
 	}
+	return s.SetPostVote(postID, userID, value)
+}
+
+// ClearPostVote removes a user's vote and returns the new score and my_vote.
+func (s *Store) ClearPostVote(postID, userID string) (int, int, error) {
+	return s.SetPostVote(postID, userID, 0)
+}
+
+// SetPostVote sets a user's vote on a post to 1 or -1, or clears it for 0,
+// and returns the new score and my_vote.
+func (s *Store) SetPostVote(postID, userID string, value int) (int, int, error) {
+	if value < -1 || value > 1 {
+		return 0, 0, ErrInvalidInput
+	}
 	if strings.TrimSpace(userID) == "" {
 		return 0, 0, ErrInvalidInput
 	}
@@ -743,36 +1480,79 @@ func (s *Store) VotePost(postID, userID string, value int) (int, int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.postExists(postID) {
+	post, ok := s.livePostLocked(postID)
+	if !ok {
 		return 0, 0, ErrNotFound
 	}
 
-	if s.postVotes[postID] == nil {
-		s.postVotes[postID] = map[string]int{}
+	setVote(s.postVotes, postID, userID, value)
+	seq := 0
+	if value != 0 {
+		s.nextVoteSeq++
+		seq = s.nextVoteSeq
+	}
+	setVote(s.postVoteSeq, postID, userID, seq)
+	if value == 0 {
+		delete(s.postVoteAt[postID], userID)
+	} else {
+		if s.postVoteAt[postID] == nil {
+			s.postVoteAt[postID] = map[string]string{}
+		}
+		s.postVoteAt[postID][userID] = now()
 	}
-	s.postVotes[postID][userID] = value
-	score := sumVotes(s.postVotes[postID])
-	return score, value, nil
-}
 
-// ClearPostVote removes a user's vote and returns the new score and my_vote.
-func (s *Store) ClearPostVote(postID, userID string) (int, int, error) {
-	if strings.TrimSpace(userID) == "" {
-		return 0, 0, ErrInvalidInput
+	// The author earns exp for the first upvote from each voter only, so
+	// clearing and re-voting can't farm it.
+	if value == 1 && post.AuthorID != userID && !s.upvoteAwards[postID][userID] {
+		if s.upvoteAwards[postID] == nil {
+			s.upvoteAwards[postID] = map[string]bool{}
+		}
+		s.upvoteAwards[postID][userID] = true
+		amount, _ := ExpForReason(ExpReasonUpvoteReceived)
+		_, _ = s.addExpLocked(post.AuthorID, amount)
 	}
+	return sumVotes(s.postVotes[postID]), value, nil
+}
 
+// PostVoters returns the users whose vote on postID equals value, most recent
+// vote first, along with the total count.
+func (s *Store) PostVoters(postID string, value, offset, limit int) ([]User, int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.postExists(postID) {
-		return 0, 0, ErrNotFound
+	voterIDs := make([]string, 0)
+	for userID, v := range s.postVotes[postID] {
+		if v == value {
+			voterIDs = append(voterIDs, userID)
+		}
 	}
+	seq := s.postVoteSeq[postID]
+	sort.Slice(voterIDs, func(i, j int) bool {
+		return seq[voterIDs[i]] > seq[voterIDs[j]]
+	})
 
-	if votes := s.postVotes[postID]; votes != nil {
-		delete(votes, userID)
+	voters := make([]User, 0, len(voterIDs))
+	for _, userID := range voterIDs {
+		if user, ok := s.users[userID]; ok {
+			voters = append(voters, user)
+		}
+	}
+
+	total := len(voters)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	if offset > end {
+		offset = end
 	}
-	score := sumVotes(s.postVotes[postID])
-	return score, 0, nil
+	return voters[offset:end], total
 }
 
 // CommentScore returns the aggregated vote score for a comment.
@@ -803,29 +1583,23 @@ func (s *Store) CommentVote(postID, commentID, userID string) int {
 
 // VoteComment upserts a user's vote on a comment and returns the new score and my_vote.
 func (s *Store) VoteComment(postID, commentID, userID string, value int) (int, int, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if value != 1 && value != -1 {
 		return 0, 0, ErrInvalidInput
 	}
-	if strings.TrimSpace(userID) == "" {
-		return 0, 0, ErrInvalidInput
-	}
-	if !s.commentExists(postID, commentID) {
-		return 0, 0, ErrNotFound
-	}
-
-	if s.commentVotes[commentID] == nil {
-		s.commentVotes[commentID] = map[string]int{}
-	}
-	s.commentVotes[commentID][userID] = value
-	score := sumVotes(s.commentVotes[commentID])
-	return score, value, nil
+	return s.SetCommentVote(postID, commentID, userID, value)
 }
 
 // ClearCommentVote removes a user's vote and returns the new score and my_vote.
 func (s *Store) ClearCommentVote(postID, commentID, userID string) (int, int, error) {
+	return s.SetCommentVote(postID, commentID, userID, 0)
+}
+
+// SetCommentVote sets a user's vote on a comment to 1 or -1, or clears it for
+// 0, and returns the new score and my_vote.
+func (s *Store) SetCommentVote(postID, commentID, userID string, value int) (int, int, error) {
+	if value < -1 || value > 1 {
+		return 0, 0, ErrInvalidInput
+	}
 	if strings.TrimSpace(userID) == "" {
 		return 0, 0, ErrInvalidInput
 	}
@@ -837,11 +1611,28 @@ func (s *Store) ClearCommentVote(postID, commentID, userID string) (int, int, er
 		return 0, 0, ErrNotFound
 	}
 
-	if votes := s.commentVotes[commentID]; votes != nil {
-		delete(votes, userID)
+	setVote(s.commentVotes, commentID, userID, value)
+	return sumVotes(s.commentVotes[commentID]), value, nil
+}
+
+// setVote records userID's vote on targetID, deleting it when value is 0.
+func setVote(votes map[string]map[string]int, targetID, userID string, value int) {
+	if value == 0 {
+		delete(votes[targetID], userID)
+		return
+	}
+	if votes[targetID] == nil {
+		votes[targetID] = map[string]int{}
 	}
-	score := sumVotes(s.commentVotes[commentID])
-	return score, 0, nil
+	votes[targetID][userID] = value
+}
+
+// dropPostVotesLocked forgets every vote on a post being deleted, so voter
+// lists and recomputed scores never count it again. Callers hold s.mu.
+func (s *Store) dropPostVotesLocked(postID string) {
+	delete(s.postVotes, postID)
+	delete(s.postVoteSeq, postID)
+	delete(s.postVoteAt, postID)
 }
 
 // SaveFile stores file metadata and returns it.
@@ -864,13 +1655,42 @@ func (s *Store) SaveFile(uploaderID, filename, storageKey, storagePath string, w
 	return file
 }
 
-// GetFile looks up file metadata by ID.
+// GetFile looks up file metadata by ID. Soft-deleted files are not returned.
 func (s *Store) GetFile(fileID string) (FileMeta, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	file, ok := s.files[fileID]
-	return file, ok
+	if !ok || file.DeletedAt != "" {
+		return FileMeta{}, false
+	}
+	return file, true
+}
+
+// IsFileDeleted reports whether fileID exists but was soft-deleted.
+func (s *Store) IsFileDeleted(fileID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.files[fileID]
+	return ok && file.DeletedAt != ""
+}
+
+// SoftDeleteFile marks a file deleted. Only its uploader or an admin may do so.
+func (s *Store) SoftDeleteFile(fileID, actorUserID string, isAdmin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.files[fileID]
+	if !ok || file.DeletedAt != "" {
+		return ErrNotFound
+	}
+	if !isAdmin && file.UploaderID != actorUserID {
+		return ErrForbidden
+	}
+	file.DeletedAt = now()
+	s.files[fileID] = file
+	return nil
 }
 
 // AddMessage appends a message to a room history and returns it.
@@ -895,7 +1715,7 @@ func (s *Store) Messages(roomID string, limit int) []ChatMessage {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	messages := s.messages[roomID]
+	messages := liveMessages(s.messages[roomID])
 	if len(messages) == 0 {
 		return nil
 	}
@@ -921,13 +1741,14 @@ func (s *Store) CreateReport(reporterID, targetType, targetID, reason, detail st
 		ReporterID: reporterID,
 		Reason:     strings.TrimSpace(reason),
 		Detail:     strings.TrimSpace(detail),
-		Status:     "open",
+		Status:     ReportStatusOpen,
 		CreatedAt:  now(),
 		UpdatedAt:  now(),
 	}
-	if report.TargetType == "" || report.TargetID == "" || report.Reason == "" {
+	if !ValidReportTarget(report.TargetType) || report.TargetID == "" || report.Reason == "" {
 		return Report{}, ErrInvalidInput
 	}
+	report.TargetSnapshot = s.reportSnapshotLocked(report.TargetType, report.TargetID)
 	s.reports = append(s.reports, report)
 	return report, nil
 }
@@ -969,25 +1790,186 @@ func (s *Store) UpdateReport(reportID, status, action, note, handledBy string) (
 
 	trimmedID := strings.TrimSpace(reportID)
 	trimmedStatus := strings.TrimSpace(status)
-	if trimmedID == "" || trimmedStatus == "" {
+	if trimmedID == "" || !validReportStatus(trimmedStatus) {
 		return Report{}, ErrInvalidInput
 	}
+	return s.updateReportLocked(trimmedID, trimmedStatus, action, note, handledBy)
+}
+
+// UpdateReports applies one status change to several reports and returns how
+// many were updated. Unknown IDs and reports the transition is not allowed
+// from are skipped.
+func (s *Store) UpdateReports(reportIDs []string, status, action, note, handledBy string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	trimmedStatus := strings.TrimSpace(status)
+	if !validReportStatus(trimmedStatus) {
+		return 0, ErrInvalidInput
+	}
+
+	updated := 0
+	seen := make(map[string]bool, len(reportIDs))
+	for _, reportID := range reportIDs {
+		trimmedID := strings.TrimSpace(reportID)
+		if trimmedID == "" || seen[trimmedID] {
+			continue
+		}
+		seen[trimmedID] = true
+		if _, err := s.updateReportLocked(trimmedID, trimmedStatus, action, note, handledBy); err == nil {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// updateReportLocked moves one report to status and records the history
+// event. Callers hold s.mu.
+func (s *Store) updateReportLocked(reportID, status, action, note, handledBy string) (Report, error) {
 	for idx, report := range s.reports {
-		if report.ID != trimmedID {
+		if report.ID != reportID {
 			continue
 		}
-		report.Status = trimmedStatus
-		report.Action = strings.TrimSpace(action)
-		report.Note = strings.TrimSpace(note)
-		report.HandledBy = strings.TrimSpace(handledBy)
-		report.UpdatedAt = now()
+		if err := checkReportTransition(report.Status, status); err != nil {
+			return Report{}, err
+		}
+		event := ReportEvent{
+			ReportID:   report.ID,
+			FromStatus: report.Status,
+			ToStatus:   status,
+			Action:     strings.TrimSpace(action),
+			Note:       strings.TrimSpace(note),
+			HandledBy:  strings.TrimSpace(handledBy),
+			CreatedAt:  now(),
+		}
+		if removesTarget(event.FromStatus, event.ToStatus, event.Action) {
+			reason := removalReason(report.Reason, event.Note)
+			if remark := s.removeReportTargetLocked(report.TargetType, report.TargetID, event.CreatedAt, reason, event.HandledBy); remark != "" {
+				event.Note = annotateReportNote(event.Note, remark)
+			}
+		}
+		report.Status = event.ToStatus
+		report.Action = event.Action
+		report.Note = event.Note
+		report.HandledBy = event.HandledBy
+		report.UpdatedAt = event.CreatedAt
 		s.reports[idx] = report
+		s.reportEvents = append(s.reportEvents, event)
 		return report, nil
 	}
 	return Report{}, ErrNotFound
 }
 
+// ReportStats counts reports per status and per reason for the moderation dashboard.
+func (s *Store) ReportStats() (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := reportStaleCutoff()
+	stats := map[string]int{
+		ReportStatsTotal:     len(s.reports),
+		ReportStatsOpenStale: 0,
+	}
+	for _, report := range s.reports {
+		stats[ReportStatsStatusPrefix+report.Status]++
+		stats[ReportStatsReasonPrefix+report.Reason]++
+		if report.Status == ReportStatusOpen && report.CreatedAt < cutoff {
+			stats[ReportStatsOpenStale]++
+		}
+	}
+	return stats, nil
+}
+
+// reportSnapshotLocked captures the reported user, post or comment as it
+// looks now. Callers hold s.mu.
+func (s *Store) reportSnapshotLocked(targetType, targetID string) string {
+	switch targetType {
+	case ReportTargetUser:
+		if user, ok := s.users[targetID]; ok {
+			return marshalReportSnapshot(map[string]string{"nickname": user.Nickname, "bio": user.Bio})
+		}
+	case ReportTargetPost:
+		for _, post := range s.posts {
+			if post.ID == targetID {
+				return marshalReportSnapshot(map[string]string{"author_id": post.AuthorID, "title": post.Title, "content": post.Content})
+			}
+		}
+	case ReportTargetComment:
+		for _, comment := range s.comments {
+			if comment.ID == targetID {
+				return marshalReportSnapshot(map[string]string{"author_id": comment.AuthorID, "content": comment.Content})
+			}
+		}
+	}
+	return ""
+}
+
+// removeReportTargetLocked soft-deletes a reported post, comment or file.
+// Removed posts and comments record reason and their author is notified on
+// behalf of moderatorID. It returns a remark for the report note when the
+// target could not be removed.
+func (s *Store) removeReportTargetLocked(targetType, targetID, deletedAt, reason, moderatorID string) string {
+	switch targetType {
+	case ReportTargetPost:
+		for idx, post := range s.posts {
+			if post.ID == targetID && post.DeletedAt == "" {
+				post.DeletedAt = deletedAt
+				post.DeletedReason = reason
+				s.posts[idx] = post
+				s.dropPostVotesLocked(targetID)
+				s.addNotificationLocked(post.AuthorID, moderatorID, NotificationContentRemoved, ReportTargetPost, post.ID)
+				return ""
+			}
+		}
+	case ReportTargetComment:
+		for idx, comment := range s.comments {
+			if comment.ID == targetID && comment.DeletedAt == "" {
+				comment.DeletedAt = deletedAt
+				comment.DeletedReason = reason
+				s.comments[idx] = comment
+				delete(s.commentVotes, targetID)
+				s.addNotificationLocked(comment.AuthorID, moderatorID, NotificationContentRemoved, ReportTargetComment, comment.ID)
+				return ""
+			}
+		}
+	case ReportTargetFile:
+		if file, ok := s.files[targetID]; ok && file.DeletedAt == "" {
+			file.DeletedAt = deletedAt
+			s.files[targetID] = file
+			return ""
+		}
+	default:
+		return "remove skipped: unsupported target_type " + targetType
+	}
+	return "remove skipped: target not found or already deleted"
+}
+
+// ReportEvents returns a report's status history, oldest first.
+func (s *Store) ReportEvents(reportID string) ([]ReportEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trimmedID := strings.TrimSpace(reportID)
+	found := false
+	for _, report := range s.reports {
+		if report.ID == trimmedID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	out := make([]ReportEvent, 0)
+	for _, event := range s.reportEvents {
+		if event.ReportID == trimmedID {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
+
 // now returns the current time in UTC RFC3339 format.
 func now() string {
 	return time.Now().UTC().Format(time.RFC3339)
@@ -995,13 +1977,19 @@ func now() string {
 
 var _ API = (*Store)(nil)
 
-func (s *Store) postExists(postID string) bool {
+// livePostLocked returns a published, undeleted post. Callers hold s.mu.
+func (s *Store) livePostLocked(postID string) (Post, bool) {
 	for _, post := range s.posts {
-		if post.ID == postID && post.DeletedAt == "" {
-			return true
+		if post.ID == postID && post.DeletedAt == "" && !post.IsDraft() {
+			return post, true
 		}
 	}
-	return false
+	return Post{}, false
+}
+
+func (s *Store) postExists(postID string) bool {
+	_, ok := s.livePostLocked(postID)
+	return ok
 }
 
 func (s *Store) commentExists(postID, commentID string) bool {
@@ -1021,8 +2009,20 @@ func sumVotes(votes map[string]int) int {
 	return score
 }
 
+// PostsByTag pages through live posts carrying tag, matched case-insensitively.
+func (s *Store) PostsByTag(tag string, offset, limit int) ([]Post, int) {
+	posts, _, _ := s.Posts(PostListFilter{})
+	return pagePostsByTag(posts, tag, offset, limit)
+}
+
+// PopularTags returns the most used tags across live posts.
+func (s *Store) PopularTags(limit int) []TagCount {
+	posts, _, _ := s.Posts(PostListFilter{})
+	return countTags(posts, limit)
+}
+
 // SearchPosts searches posts by title or content.
-func (s *Store) SearchPosts(keyword string, offset, limit int) ([]Post, int) {
+func (s *Store) SearchPosts(keyword string, offset, limit int) ([]PostSearchResult, int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1033,7 +2033,7 @@ func (s *Store) SearchPosts(keyword string, offset, limit int) ([]Post, int) {
 
 	matched := make([]Post, 0)
 	for _, post := range s.posts {
-		if post.DeletedAt != "" {
+		if post.DeletedAt != "" || post.IsDraft() {
 			continue
 		}
 		titleLower := strings.ToLower(post.Title)
@@ -1061,7 +2061,28 @@ func (s *Store) SearchPosts(keyword string, offset, limit int) ([]Post, int) {
 	if offset > end {
 		offset = end
 	}
-	return matched[offset:end], total
+
+	page := matched[offset:end]
+	out := make([]PostSearchResult, 0, len(page))
+	for _, post := range page {
+		author, ok := s.users[post.AuthorID]
+		if !ok {
+			author = DeletedUser()
+		}
+		comments := 0
+		for _, comment := range s.comments {
+			if comment.PostID == post.ID && comment.DeletedAt == "" {
+				comments++
+			}
+		}
+		out = append(out, PostSearchResult{
+			Post:         post,
+			Author:       author,
+			Score:        sumVotes(s.postVotes[post.ID]),
+			CommentCount: comments,
+		})
+	}
+	return out, total
 }
 
 // SearchUsers searches users by nickname.
@@ -1116,6 +2137,15 @@ func (s *Store) CreateNotification(recipientID, actorID, notifType, targetType,
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.addNotificationLocked(recipientID, actorID, notifType, targetType, targetID), nil
+}
+
+// addNotificationLocked appends a notification unless it would go to its own
+// actor or to nobody. Callers hold s.mu.
+func (s *Store) addNotificationLocked(recipientID, actorID, notifType, targetType, targetID string) Notification {
+	if recipientID == "" || recipientID == actorID {
+		return Notification{}
+	}
 	s.nextNotifID++
 	notif := Notification{
 		ID:          fmt.Sprintf("n_%d", s.nextNotifID),
@@ -1127,18 +2157,29 @@ func (s *Store) CreateNotification(recipientID, actorID, notifType, targetType,
 		CreatedAt:   now(),
 	}
 	s.notifications = append(s.notifications, notif)
-	return notif, nil
+	return notif
 }
 
 // Notifications returns notifications for a user with pagination.
 func (s *Store) Notifications(recipientID string, offset, limit int) ([]Notification, int) {
+	return s.NotificationsByType(recipientID, nil, offset, limit)
+}
+
+// NotificationsByType is Notifications limited to the given types; an empty
+// types list matches every type.
+func (s *Store) NotificationsByType(recipientID string, types []string, offset, limit int) ([]Notification, int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
 	filtered := make([]Notification, 0)
 	for i := len(s.notifications) - 1; i >= 0; i-- {
-		if s.notifications[i].RecipientID == recipientID {
-			filtered = append(filtered, s.notifications[i])
+		n := s.notifications[i]
+		if n.RecipientID == recipientID && (len(wanted) == 0 || wanted[n.Type]) {
+			filtered = append(filtered, n)
 		}
 	}
 
@@ -1187,6 +2228,56 @@ func (s *Store) MarkNotificationRead(notificationID, recipientID string) error {
 	return ErrNotFound
 }
 
+// MarkNotificationsRead marks the given unread notifications of recipientID as
+// read and returns how many changed. Unknown IDs and other users'
+// notifications are ignored.
+func (s *Store) MarkNotificationsRead(recipientID string, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	timestamp := now()
+	updated := 0
+	for i, n := range s.notifications {
+		if wanted[n.ID] && n.RecipientID == recipientID && n.ReadAt == "" {
+			s.notifications[i].ReadAt = timestamp
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// MarkNotificationUnread clears a notification's read time.
+func (s *Store) MarkNotificationUnread(notificationID, recipientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, n := range s.notifications {
+		if n.ID == notificationID && n.RecipientID == recipientID {
+			s.notifications[i].ReadAt = ""
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteNotification removes a notification permanently.
+func (s *Store) DeleteNotification(notificationID, recipientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, n := range s.notifications {
+		if n.ID == notificationID && n.RecipientID == recipientID {
+			s.notifications = append(s.notifications[:i], s.notifications[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
 // MarkAllNotificationsRead marks all notifications for a user as read.
 func (s *Store) MarkAllNotificationsRead(recipientID string) error {
 	s.mu.Lock()
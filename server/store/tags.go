@@ -1,5 +1,16 @@
 package store
 
+import (
+	"sort"
+	"strings"
+)
+
+// TagCount is a tag and the number of live posts carrying it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
 func encodeTags(tags []string) string {
 	return encodeAttachmentIDs(tags)
 }
@@ -7,3 +18,77 @@ func encodeTags(tags []string) string {
 func decodeTags(raw string) []string {
 	return decodeAttachmentIDs(raw)
 }
+
+// normalizeTag is the case-insensitive form tags are matched and counted by.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func hasTag(tags []string, normalized string) bool {
+	for _, tag := range tags {
+		if normalizeTag(tag) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// pagePostsByTag filters posts (already newest first) to those tagged tag and
+// returns one page of them with the total match count.
+func pagePostsByTag(posts []Post, tag string, offset, limit int) ([]Post, int) {
+	normalized := normalizeTag(tag)
+	if normalized == "" {
+		return nil, 0
+	}
+	matched := make([]Post, 0)
+	for _, post := range posts {
+		if hasTag(post.Tags, normalized) {
+			matched = append(matched, post)
+		}
+	}
+
+	total := len(matched)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset >= total {
+		return []Post{}, total
+	}
+	end := min(offset+limit, total)
+	return matched[offset:end], total
+}
+
+// countTags tallies normalized tags across posts, most used first; ties are
+// broken alphabetically. limit <= 0 returns every tag.
+func countTags(posts []Post, limit int) []TagCount {
+	counts := map[string]int{}
+	for _, post := range posts {
+		seen := map[string]bool{}
+		for _, tag := range post.Tags {
+			normalized := normalizeTag(tag)
+			if normalized == "" || seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+			counts[normalized]++
+		}
+	}
+
+	out := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		out = append(out, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
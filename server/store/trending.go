@@ -0,0 +1,80 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Trending weights, in tenths of a point: a net upvote counts 2, a comment
+// 3 and a view 0.1, so discussion outranks drive-by reads.
+const (
+	trendingVoteWeight    = 20
+	trendingCommentWeight = 30
+	trendingViewWeight    = 1
+)
+
+func trendingRank(votes, comments, views int) int {
+	return votes*trendingVoteWeight + comments*trendingCommentWeight + views*trendingViewWeight
+}
+
+func trendingCutoff(since time.Time) string {
+	return since.UTC().Format(time.RFC3339)
+}
+
+// livePostsSinceLocked returns published, undeleted posts created at or after
+// cutoff in creation order. Callers hold s.mu.
+func (s *Store) livePostsSinceLocked(cutoff string) []Post {
+	out := make([]Post, 0)
+	for _, post := range s.posts {
+		if post.DeletedAt != "" || post.IsDraft() || post.CreatedAt < cutoff {
+			continue
+		}
+		out = append(out, post)
+	}
+	return out
+}
+
+// TrendingPosts ranks live posts created since the cutoff by their votes,
+// comments and views; ties go to the newer post. limit <= 0 returns every
+// candidate.
+func (s *Store) TrendingPosts(since time.Time, limit int) []Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	posts := s.livePostsSinceLocked(trendingCutoff(since))
+	comments := map[string]int{}
+	for _, comment := range s.comments {
+		if comment.DeletedAt == "" {
+			comments[comment.PostID]++
+		}
+	}
+	rank := make(map[string]int, len(posts))
+	for _, post := range posts {
+		votes := 0
+		for _, value := range s.postVotes[post.ID] {
+			votes += value
+		}
+		rank[post.ID] = trendingRank(votes, comments[post.ID], post.ViewCount)
+	}
+
+	// posts is oldest first, so reversing before the stable sort makes ties newest first.
+	for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+		posts[i], posts[j] = posts[j], posts[i]
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return rank[posts[i].ID] > rank[posts[j].ID]
+	})
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts
+}
+
+// TrendingTags counts tags over live posts created since the cutoff, most
+// used first with ties broken alphabetically.
+func (s *Store) TrendingTags(since time.Time, limit int) []TagCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return countTags(s.livePostsSinceLocked(trendingCutoff(since)), limit)
+}
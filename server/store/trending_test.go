@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrendingRanksRecentActivity(t *testing.T) {
+	s := NewStore()
+	boardID := s.Boards()[0].ID
+	quiet := s.CreatePost(boardID, "u_1", "quiet", "content", "", "", []string{"Go"}, nil)
+	voted := s.CreatePost(boardID, "u_1", "voted", "content", "", "", []string{"go", "food"}, nil)
+	discussed := s.CreatePost(boardID, "u_1", "discussed", "content", "", "", nil, nil)
+	s.CreatePost(boardID, "u_1", "draft", "content", "", PostStatusDraft, []string{"food"}, nil)
+	if _, _, err := s.VotePost(voted.ID, "u_2", 1); err != nil {
+		t.Fatalf("VotePost: %v", err)
+	}
+	s.CreateComment(discussed.ID, "u_2", "first", "", "", nil, nil)
+
+	posts := s.TrendingPosts(time.Now().Add(-24*time.Hour), 10)
+	if len(posts) != 3 {
+		t.Fatalf("got %d posts, want 3 published", len(posts))
+	}
+	if posts[0].ID != discussed.ID || posts[1].ID != voted.ID || posts[2].ID != quiet.ID {
+		t.Fatalf("order = %s, %s, %s; want discussed, voted, quiet", posts[0].Title, posts[1].Title, posts[2].Title)
+	}
+	if got := s.TrendingPosts(time.Now().Add(time.Hour), 10); len(got) != 0 {
+		t.Fatalf("posts before the window should be excluded, got %d", len(got))
+	}
+
+	tags := s.TrendingTags(time.Now().Add(-24*time.Hour), 10)
+	if len(tags) != 2 || tags[0] != (TagCount{Tag: "go", Count: 2}) || tags[1] != (TagCount{Tag: "food", Count: 1}) {
+		t.Fatalf("tags = %+v, want go:2 then food:1", tags)
+	}
+}
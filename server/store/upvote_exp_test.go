@@ -0,0 +1,48 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUpvoteExpIsAwardedOncePerVoter(t *testing.T) {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "votes.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlite.Close()
+
+	for name, s := range map[string]API{"memory": NewStore(), "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			author, err := s.Register("author@example.com", "password123", "author")
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			post := s.CreatePost(s.Boards()[0].ID, author.User.ID, "t", "c", "", "", nil, nil)
+			expOf := func() int {
+				user, _ := s.GetUser(author.User.ID)
+				return user.Exp
+			}
+			before := expOf()
+
+			// Vote, clear, re-vote and flip: only the first upvote counts.
+			for _, value := range []int{1, 0, 1, -1, 1} {
+				if _, _, err := s.SetPostVote(post.ID, "u_voter", value); err != nil {
+					t.Fatalf("SetPostVote(%d): %v", value, err)
+				}
+			}
+			if _, _, err := s.SetPostVote(post.ID, author.User.ID, 1); err != nil {
+				t.Fatalf("self vote: %v", err)
+			}
+			if got := expOf() - before; got != 1 {
+				t.Fatalf("author gained %d exp, want 1", got)
+			}
+			if _, _, err := s.SetPostVote(post.ID, "u_second", 1); err != nil {
+				t.Fatalf("SetPostVote: %v", err)
+			}
+			if got := expOf() - before; got != 2 {
+				t.Fatalf("author gained %d exp after a second voter, want 2", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerificationTokenExpires(t *testing.T) {
+	if err := SetVerificationTokenTTL(time.Millisecond); err != nil {
+		t.Fatalf("SetVerificationTokenTTL: %v", err)
+	}
+	t.Cleanup(func() { _ = SetVerificationTokenTTL(defaultVerificationTokenTTL) })
+	if err := SetVerificationTokenTTL(0); err != ErrInvalidInput {
+		t.Fatalf("SetVerificationTokenTTL(0) = %v, want ErrInvalidInput", err)
+	}
+
+	s := NewStore()
+	reg, err := s.Register("late@example.com", "password123", "late")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := s.VerifyEmail(reg.VerificationToken); err != ErrVerificationTokenExpired {
+		t.Fatalf("VerifyEmail after TTL = %v, want ErrVerificationTokenExpired", err)
+	}
+}
+
+func TestVerificationTokenIsSingleUse(t *testing.T) {
+	s := NewStore()
+	first, err := s.Register("first@example.com", "password123", "first")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := s.Register("second@example.com", "password123", "second"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := s.VerifyEmail(first.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if err := s.VerifyEmail(first.VerificationToken); err != ErrVerificationTokenInvalid {
+		t.Fatalf("replayed VerifyEmail = %v, want ErrVerificationTokenInvalid", err)
+	}
+	if _, _, err := s.Login("second@example.com", "password123", ""); err != ErrAccountUnverified {
+		t.Fatalf("second account login = %v, want ErrAccountUnverified", err)
+	}
+	if _, _, err := s.Login("first@example.com", "password123", ""); err != nil {
+		t.Fatalf("first account login: %v", err)
+	}
+}
+
+func TestAccountStatusTracksVerification(t *testing.T) {
+	s := NewStore()
+	reg, err := s.Register("Status@example.com", "password123", "status")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if got, err := s.AccountStatus(reg.User.ID); err != nil || got != (AccountStatus{Email: "status@example.com"}) {
+		t.Fatalf("AccountStatus before verify = %+v, %v", got, err)
+	}
+	if err := s.VerifyEmail(reg.VerificationToken); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if got, err := s.AccountStatus(reg.User.ID); err != nil || !got.Verified {
+		t.Fatalf("AccountStatus after verify = %+v, %v", got, err)
+	}
+	if _, err := s.AccountStatus("u_missing"); err != ErrNotFound {
+		t.Fatalf("AccountStatus(missing) = %v, want ErrNotFound", err)
+	}
+}